@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"net/http"
+	"net/url"
+	"strings"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,3 +17,14 @@ type SessionCreds struct {
 func BcryptCompare(password string, hash []byte) error {
 	return bcrypt.CompareHashAndPassword(hash, []byte(password))
 }
+
+// ExtractLoginCreds extracts login credentials from request cookies
+func ExtractLoginCreds(r *http.Request) (creds SessionCreds) {
+	if c, err := r.Cookie("session"); err == nil {
+		creds.Session = c.Value
+	}
+	if c, err := r.Cookie("loginID"); err == nil {
+		creds.UserID, _ = url.QueryUnescape(strings.TrimSpace(c.Value))
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Scopes of operations an API token can be permitted to perform
+const (
+	// Read JSON endpoints, exports and connect to the websocket API
+	ScopeRead = "read"
+
+	// Create posts and upload images
+	ScopePost = "post"
+
+	// Perform moderation actions with the staff positions of the token owner
+	ScopeModerate = "moderate"
+)
+
+type apiTokenKey struct{}
+
+// APIToken grants a bot access to the HTTP and websocket APIs
+type APIToken struct {
+	ID     uint64   `json:"id"`
+	Name   string   `json:"name"`
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+
+	// Rate limit of requests authenticated with the token, independent of
+	// any per-IP limits. Allows bursts of up to RateLimit requests and
+	// refills at a rate of RateLimit per RateInterval seconds.
+	RateLimit    uint `json:"rateLimit"`
+	RateInterval uint `json:"rateInterval"`
+
+	Created time.Time `json:"created"`
+
+	// Usage metrics
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	Requests uint64     `json:"requests"`
+	Limited  uint64     `json:"limited"`
+}
+
+// HasScope returns, if the token is permitted to perform operations of scope
+func (t APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScope returns, if s is a valid API token scope
+func IsScope(s string) bool {
+	switch s {
+	case ScopeRead, ScopePost, ScopeModerate:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewAPIToken generates a new random API token
+func NewAPIToken() (string, error) {
+	return RandomID(32)
+}
+
+// HashAPIToken returns the hash an API token is stored in the database as
+func HashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// ExtractAPIToken extracts an API token from the Authorization header of a
+// request, if any
+func ExtractAPIToken(r *http.Request) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
+
+// WithAPIToken returns a copy of ctx carrying the API token a request was
+// authenticated with
+func WithAPIToken(ctx context.Context, t APIToken) context.Context {
+	return context.WithValue(ctx, apiTokenKey{}, t)
+}
+
+// APITokenFromContext returns the API token a request was authenticated with,
+// if any
+func APITokenFromContext(ctx context.Context) (t APIToken, ok bool) {
+	t, ok = ctx.Value(apiTokenKey{}).(APIToken)
+	return
+}
@@ -118,3 +118,53 @@ func TestRandomID(t *testing.T) {
 		t.Fatalf("unexpected hash string length: %d", l)
 	}
 }
+
+func TestExtractAPIToken(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name, header, token string
+	}{
+		{"no header", "", ""},
+		{"other scheme", "Basic foo", ""},
+		{"bearer token", "Bearer foo", "foo"},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/", nil)
+			if c.header != "" {
+				r.Header.Set("Authorization", c.header)
+			}
+			AssertDeepEquals(t, ExtractAPIToken(r), c.token)
+		})
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	t.Parallel()
+
+	tok := APIToken{Scopes: []string{ScopeRead, ScopeModerate}}
+	for s, has := range map[string]bool{
+		ScopeRead:     true,
+		ScopePost:     false,
+		ScopeModerate: true,
+	} {
+		if tok.HasScope(s) != has {
+			t.Fatalf("unexpected scope result: %s", s)
+		}
+	}
+	if IsScope("admin") {
+		t.Fatal("invalid scope accepted")
+	}
+}
+
+func TestHashAPIToken(t *testing.T) {
+	t.Parallel()
+
+	AssertDeepEquals(t, HashAPIToken("foo"),
+		"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+}
@@ -52,6 +52,7 @@ type Ban struct {
 // BanRecord stores information about a specific ban
 type BanRecord struct {
 	Ban
+	Shadow     bool
 	ForPost    uint64
 	Reason, By string
 	Expires    time.Time
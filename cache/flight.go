@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"sync"
+)
+
+// Coalesces concurrent fetches of fresh data for the same key, so that a
+// traffic spike on a hot resource results in a single database fetch and
+// encode. Unlike the store lock, this also covers requests racing a store's
+// eviction or deletion, which would otherwise each create a new empty store
+// and fetch the data independently.
+var flights = flightGroup{
+	calls: make(map[Key]*flight),
+}
+
+// Result of fetching fresh data for a key
+type flightResult struct {
+	data interface{}
+	json []byte
+	ctr  uint64
+	err  error
+}
+
+// In-progress or completed fetch
+type flight struct {
+	wg sync.WaitGroup
+	flightResult
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[Key]*flight
+}
+
+// Execute fn for key k, unless an execution for the same key is already in
+// progress. In that case wait for it to complete and share its result.
+func (g *flightGroup) do(k Key, fn func() flightResult) flightResult {
+	g.mu.Lock()
+	if f, ok := g.calls[k]; ok {
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.flightResult
+	}
+	f := new(flight)
+	f.wg.Add(1)
+	g.calls[k] = f
+	g.mu.Unlock()
+
+	f.flightResult = fn()
+	f.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, k)
+	g.mu.Unlock()
+
+	return f.flightResult
+}
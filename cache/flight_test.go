@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var (
+		calls   uint32
+		wg      sync.WaitGroup
+		release = make(chan struct{})
+		k       = ThreadKey(1, 0)
+	)
+	fetch := func() flightResult {
+		atomic.AddUint32(&calls, 1)
+		<-release
+		return flightResult{
+			data: "foo",
+			ctr:  1,
+		}
+	}
+
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			res := flights.do(k, fetch)
+			if res.data.(string) != "foo" || res.ctr != 1 {
+				t.Errorf("unexpected result: %v", res)
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond * 100) // Wait for all requests to join
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadUint32(&calls); n != 1 {
+		t.Fatalf("data fetched %d times", n)
+	}
+	if len(flights.calls) != 0 {
+		t.Fatal("flight not cleaned up")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
 )
 
@@ -30,9 +31,9 @@ var ThreadFE = FrontEnd{
 		return db.GetThread(k.ID, int(k.LastN))
 	},
 
-	RenderHTML: func(data interface{}, json []byte) []byte {
+	RenderHTML: func(data interface{}, json []byte, ln lang.Pack) []byte {
 		var b bytes.Buffer
-		templates.WriteThreadPosts(&b, data.(common.Thread), json)
+		templates.WriteThreadPosts(&b, data.(common.Thread), json, ln)
 		return b.Bytes()
 	},
 }
@@ -53,9 +54,10 @@ var CatalogFE = FrontEnd{
 		return db.GetBoardCatalog(k.Board)
 	},
 
-	RenderHTML: func(data interface{}, json []byte) []byte {
+	RenderHTML: func(data interface{}, json []byte, ln lang.Pack) []byte {
 		var b bytes.Buffer
-		templates.WriteCatalogThreads(&b, data.(common.Board).Threads, json)
+		templates.WriteCatalogThreads(&b, data.(common.Board).Threads, json,
+			ln)
 		return b.Bytes()
 	},
 }
@@ -172,7 +174,7 @@ var BoardFE = FrontEnd{
 		return pages, nil
 	},
 
-	Size: func(data interface{}, _, _ []byte) (s int) {
+	Size: func(data interface{}, _ []byte, _ int) (s int) {
 		for _, p := range data.([]PageStore) {
 			s += len(p.JSON) * 2
 		}
@@ -208,15 +210,16 @@ var BoardPageFE = FrontEnd{
 		return data.(PageStore).JSON, nil
 	},
 
-	RenderHTML: func(data interface{}, json []byte) []byte {
+	RenderHTML: func(data interface{}, json []byte, ln lang.Pack) []byte {
 		var b bytes.Buffer
-		templates.WriteIndexThreads(&b, data.(PageStore).Data.Threads, json)
+		templates.WriteIndexThreads(&b, data.(PageStore).Data.Threads, json,
+			ln)
 		return b.Bytes()
 	},
 
-	Size: func(_ interface{}, _, html []byte) int {
+	Size: func(_ interface{}, _ []byte, html int) int {
 		// Only the HTML is owned by this store. All other data is just
 		// borrowed from board
-		return len(html)
+		return html
 	},
 }
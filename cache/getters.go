@@ -3,6 +3,8 @@ package cache
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/bakape/meguca/lang"
 )
 
 // FrontEnd provides functions for fetching, validating and generating the
@@ -18,12 +20,13 @@ type FrontEnd struct {
 	// Encode data into JSON. If null, default encoder is used.
 	EncodeJSON func(data interface{}) ([]byte, error)
 
-	// RenderHTML produces HTML from the passed in data and JSON
-	RenderHTML func(interface{}, []byte) []byte
+	// RenderHTML produces HTML from the passed in data and JSON in the
+	// language of the passed language pack
+	RenderHTML func(interface{}, []byte, lang.Pack) []byte
 
-	// Calculates the size taken by the store.
-	// If nil, the default function is used.
-	Size func(data interface{}, json, html []byte) int
+	// Calculates the size taken by the store. html is the combined length of
+	// HTML rendered in all languages. If nil, the default function is used.
+	Size func(data interface{}, json []byte, html int) int
 }
 
 // GetJSONAndData GetJSON retrieves JSON from the cache along with unencoded post data,
@@ -38,7 +41,7 @@ func GetJSONAndData(k Key, f FrontEnd) ([]byte, interface{}, uint64, error) {
 		return nil, nil, 0, err
 	}
 	if fresh {
-		s.update(data, json, nil, f)
+		s.update(data, json, f)
 	}
 
 	return json, data, ctr, nil
@@ -64,34 +67,51 @@ func getData(s *store, f FrontEnd) (
 		}
 	}
 
+	res := flights.do(s.key, func() flightResult {
+		return fetchFresh(s.key, ctr, f)
+	})
+	if res.err != nil {
+		err = res.err
+		return
+	}
+
 	fresh = true
-	if ctr == 0 {
-		ctr, err = f.GetCounter(s.key)
-		if err != nil {
+	data = res.data
+	buf = res.json
+	ctr = res.ctr
+	s.updateCounter = ctr
+	s.lastChecked = time.Now()
+	return
+}
+
+// Fetch and encode fresh data of a resource. If ctr is 0, also retrieves the
+// update counter.
+func fetchFresh(k Key, ctr uint64, f FrontEnd) (res flightResult) {
+	res.ctr = ctr
+	if res.ctr == 0 {
+		res.ctr, res.err = f.GetCounter(k)
+		if res.err != nil {
 			return
 		}
 	}
-	s.updateCounter = ctr
-	data, err = f.GetFresh(s.key)
-	if err != nil {
+	res.data, res.err = f.GetFresh(k)
+	if res.err != nil {
 		return
 	}
 
 	if f.EncodeJSON != nil {
-		buf, err = f.EncodeJSON(data)
+		res.json, res.err = f.EncodeJSON(res.data)
 	} else {
-		buf, err = json.Marshal(data)
-	}
-	if err != nil {
-		return
+		res.json, res.err = json.Marshal(res.data)
 	}
-
-	s.lastChecked = time.Now()
 	return
 }
 
-// GetHTML retrieves post HTML from the cache or generates fresh HTML as needed
-func GetHTML(k Key, f FrontEnd) ([]byte, interface{}, uint64, error) {
+// GetHTML retrieves post HTML in the language of ln from the cache or
+// generates fresh HTML as needed
+func GetHTML(k Key, f FrontEnd, ln lang.Pack) (
+	[]byte, interface{}, uint64, error,
+) {
 	s := getStore(k)
 	s.Lock()
 	defer s.Unlock()
@@ -101,21 +121,16 @@ func GetHTML(k Key, f FrontEnd) ([]byte, interface{}, uint64, error) {
 		return nil, nil, 0, err
 	}
 
-	var html []byte
-	genHTML := func() {
-		html = []byte(f.RenderHTML(data, json))
-		s.update(data, json, html, f)
+	if fresh {
+		s.update(data, json, f)
 	}
-	if !fresh {
-		// If the cache has been filled with a JSON request, it will not have
-		// any HTML
-		if s.html != nil {
-			html = s.html
-		} else {
-			genHTML()
-		}
-	} else {
-		genHTML()
+
+	// If the cache has been filled with a JSON request or a request in a
+	// different language, it will not have the HTML yet
+	html, ok := s.html[ln.ID]
+	if !ok {
+		html = f.RenderHTML(data, json, ln)
+		s.setHTML(ln.ID, html, f)
 	}
 
 	return html, data, ctr, nil
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"github.com/bakape/meguca/lang"
 	. "github.com/bakape/meguca/test"
 	"testing"
 	"time"
@@ -59,18 +60,19 @@ func TestGetHTML(t *testing.T) {
 			fetches++
 			return "foo", nil
 		},
-		RenderHTML: func(_ interface{}, _ []byte) []byte {
+		RenderHTML: func(_ interface{}, _ []byte, ln lang.Pack) []byte {
 			renders++
-			return []byte("bar")
+			return []byte("bar" + ln.ID)
 		},
 	}
+	en := lang.Pack{ID: "en_GB"}
 
 	for i := 0; i < 2; i++ {
-		json, _, ctr, err := GetHTML(BoardKey("a", 0, false), f)
+		json, _, ctr, err := GetHTML(BoardKey("a", 0, false), f, en)
 		if err := err; err != nil {
 			t.Fatal(err)
 		}
-		AssertDeepEquals(t, string(json), `bar`)
+		AssertDeepEquals(t, string(json), `baren_GB`)
 		AssertDeepEquals(t, ctr, uint64(1))
 	}
 	assertCount(t, "fetched", 1, fetches)
@@ -82,13 +84,24 @@ func TestGetHTML(t *testing.T) {
 		if _, _, _, err := GetJSONAndData(key, f); err != nil {
 			t.Fatal(err)
 		}
-		if _, _, _, err := GetHTML(key, f); err != nil {
+		if _, _, _, err := GetHTML(key, f, en); err != nil {
 			t.Fatal(err)
 		}
 
 		assertCount(t, "fetched", 2, fetches)
 		assertCount(t, "rendered", 2, fetches)
 	})
+
+	t.Run("different language", func(t *testing.T) {
+		html, _, _, err := GetHTML(BoardKey("a", 0, false), f,
+			lang.Pack{ID: "ru_RU"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, string(html), `barru_RU`)
+		assertCount(t, "fetched", 2, fetches)
+		assertCount(t, "rendered", 3, renders)
+	})
 }
 
 func TestCounterExpiry(t *testing.T) {
@@ -37,7 +37,10 @@ type store struct {
 	updateCounter uint64
 	lastChecked   time.Time
 	data          interface{}
-	html, json    []byte
+	json          []byte
+
+	// Rendered HTML by language pack ID
+	html map[string][]byte
 
 	// Separate mutex, because accessed both from get requests and cache
 	// eviction calls
@@ -61,7 +64,7 @@ func getStore(k Key) (s *store) {
 	return s
 }
 
-// Clear the cache. Only used for testing.
+// Clear the cache
 func Clear() {
 	mu.Lock()
 	defer mu.Unlock()
@@ -94,20 +97,38 @@ func (s *store) isFresh() bool {
 	return time.Now().Sub(s.lastChecked) < expiryTime
 }
 
-// Stores the new values of s. Calculates and stores the new size. Passes the
-// delta to the central cache to fire eviction checks.
-func (s *store) update(data interface{}, json, html []byte, f FrontEnd) {
+// Stores the new values of s and discards any HTML rendered from the previous
+// ones
+func (s *store) update(data interface{}, json []byte, f FrontEnd) {
+	s.data = data
+	s.json = json
+	s.html = nil
+	s.resize(f)
+}
+
+// Stores HTML rendered in the language pack identified by lang
+func (s *store) setHTML(lang string, html []byte, f FrontEnd) {
+	if s.html == nil {
+		s.html = make(map[string][]byte, 1)
+	}
+	s.html[lang] = html
+	s.resize(f)
+}
+
+// Calculates and stores the new size of s. Passes the delta to the central
+// cache to fire eviction checks.
+func (s *store) resize(f FrontEnd) {
+	var html int
+	for _, buf := range s.html {
+		html += len(buf)
+	}
 	var newSize int
 	if f.Size == nil {
-		newSize = computeSize(data, json, html)
+		newSize = computeSize(s.data, s.json, html)
 	} else {
-		newSize = f.Size(data, json, html)
+		newSize = f.Size(s.data, s.json, html)
 	}
 
-	s.data = data
-	s.json = json
-	s.html = html
-
 	s.sizeMu.Lock()
 	delta := newSize - s.size
 	s.size = newSize
@@ -119,8 +140,8 @@ func (s *store) update(data interface{}, json, html []byte, f FrontEnd) {
 
 // Calculating the actual memory footprint of the stored post data is expensive.
 // Assume it is as big as the JSON. Most probably it's far less than that.
-func computeSize(data interface{}, json, html []byte) int {
-	newSize := len(json) + len(html)
+func computeSize(data interface{}, json []byte, html int) int {
+	newSize := len(json) + html
 	if data != nil {
 		newSize += len(json)
 	}
@@ -3,7 +3,9 @@
 import { handlers, message, connSM, connEvent } from './connection'
 import { posts, page } from './state'
 import { Post, FormModel, PostView } from './posts'
-import { PostLink, Command, PostData, ImageData, ModerationEntry } from "./common"
+import {
+	PostLink, Command, PostData, ImageData, ModerationEntry, ModerationAction,
+} from "./common"
 import { postAdded } from "./ui"
 import { incrementPostCount } from "./page"
 import { posterName } from "./options"
@@ -32,6 +34,12 @@ interface ModerationMessage extends ModerationEntry {
 	id: number
 }
 
+// Updated metadata of an uploaded file
+type FileUpdateMessage = {
+	sha1: string
+	animated_thumb: boolean
+}
+
 // Run a function on a model, if it exists
 function handle(id: number, fn: (m: Post) => void) {
 	const model = posts.get(id)
@@ -49,7 +57,9 @@ export function insertPost(data: PostData) {
 
 	const existing = posts.get(data.id)
 	if (existing) {
-		if (existing instanceof FormModel) {
+		// Boards hiding open posts publish the post again, once the author
+		// has already closed it
+		if (existing instanceof FormModel && existing.editing) {
 			existing.onAllocation(data)
 			incrementPostCount(true, "image" in data)
 		}
@@ -130,8 +140,27 @@ export default () => {
 		})
 
 	handlers[message.moderatePost] = (msg: ModerationMessage) =>
-		handle(msg.id, m =>
-			m.applyModeration(msg))
+		handle(msg.id, m => {
+			m.applyModeration(msg)
+			switch (msg.type) {
+				case ModerationAction.moveThread:
+				case ModerationAction.mergeThread:
+					// Follow the thread to its new location. Also reloads
+					// the target thread of a merge to display merged posts.
+					if (m.id === m.op) {
+						location.href = msg.data
+					}
+			}
+		})
+
+	handlers[message.fileUpdate] = (msg: FileUpdateMessage) => {
+		for (let m of posts) {
+			if (m.image && m.image.sha1 === msg.sha1) {
+				m.image.animated_thumb = msg.animated_thumb
+				m.view.renderImage(false)
+			}
+		}
+	}
 
 	handlers[message.redirect] = (url: string) =>
 		location.href = url
@@ -22,6 +22,14 @@ export const enum ModerationAction {
 	deleteBoard,
 	meidoVision,
 	purgePost,
+	stickyThread,
+	configureBoard,
+	configureServer,
+	assignStaff,
+	moveThread,
+	mergeThread,
+	shadowBanPost,
+	unhidePost,
 }
 
 // Contains fields of a post moderation log entry
@@ -113,7 +121,9 @@ export interface ImageData {
 	dims: [number, number, number, number]
 	md5: string
 	sha1: string
+	phash?: string // Perceptual hash of the thumbnail
 	name: string
+	animated_thumb?: boolean // Has an animated thumbnail preview
 
 	// Added client-side
 	expanded: boolean           // Thumbnail is expanded
@@ -11,6 +11,7 @@ export const enum message {
 	insertImage,
 	spoiler,
 	moderatePost,
+	fileUpdate,
 
 	// >= 30 are miscellaneous and do not write to post models
 	synchronise = 30,
@@ -47,6 +48,42 @@ export const enum message {
 	// Used by the client to send it's protocol version and by the server to
 	// send server and board configurations
 	configs,
+
+	// Reserve a post ID in the current thread
+	reservePost,
+
+	// Proof of work challenge to solve before creating a post
+	powChallenge,
+
+	// Client is outdated and must reload the page
+	refresh,
+
+	// Position of the last post modification message in the thread feed's
+	// message log
+	feedPosition,
+
+	// Subscribe to and receive events of the staff activity feed
+	staffFeed,
+
+	// Revert or reapply the last edit of the open post
+	undo,
+	redo,
+
+	// Envelope of a post modification message targeting the open post in a
+	// specific thread
+	targetPost,
+
+	// Add a stroke to the oekaki drawing of the open post
+	drawStroke,
+
+	// Song currently playing on the internet radio
+	nowPlaying,
+
+	// Announcement banners shown on the current board
+	announcementBanners,
+
+	// Server version, supported protocol versions and enabled features
+	handshake,
 }
 
 export type MessageHandler = (msg: {}) => void
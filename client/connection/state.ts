@@ -84,7 +84,7 @@ function leftPad(type: message): string {
 }
 
 // Routes messages from the server to the respective handler
-function onMessage(data: string, extracted: boolean) {
+export function onMessage(data: string, extracted: boolean) {
 	// First two characters of a message define its type
 	const type = parseInt(data.slice(0, 2))
 
@@ -1,5 +1,5 @@
 import { handlers, message } from "./messages"
-import { connSM, connEvent, send } from "./state"
+import { connSM, connEvent, send, onMessage } from "./state"
 import {
 	postSM, postEvent, postState, identity, FormModel, Post
 } from "../posts"
@@ -11,8 +11,51 @@ import { insertPost } from "../client"
 // Passed from the server to allow the client to synchronise state, before
 // consuming any incoming update messages.
 type SyncData = {
+	epoch: number
+	position: number
 	recent: PostState[] // Posts created within the last 15 minutes
 	moderation: { [id: number]: ModerationEntry[] }
+
+	// Resynced from the last log position. Recent only contains posts
+	// modified since then.
+	delta?: boolean
+	// Messages to apply before syncing recent posts
+	log?: string[]
+	// Number of replies left out of the last N posts view
+	omitted?: number
+}
+
+// Version of the websocket protocol implemented by this client
+const protocolVersion = 2
+
+// Optional protocol features supported by this client. "boardData" is not
+// requested, as board configurations and board pages are read from the HTTP
+// response.
+const supportedFeatures = ["liveUpdates", "radio"]
+
+// Server version, supported protocol versions and features enabled for this
+// client on the current board
+type Handshake = {
+	version: string
+	protocolVersion: number
+	minProtocolVersion: number
+	features: string[]
+}
+
+// Received from the server on each synchronisation
+let handshake: Handshake = null
+
+// Returns, if an optional protocol feature is enabled for this client on the
+// current board
+export function hasFeature(name: string): boolean {
+	return !!handshake && handshake.features.includes(name)
+}
+
+// Last known position in the message log of the synced thread's feed
+let logPosition = {
+	thread: 0,
+	epoch: 0,
+	position: 0,
 }
 
 // State of a post
@@ -26,10 +69,20 @@ type PostState = {
 // Send a requests to the server to synchronise to the current page and
 // subscribe to the appropriate event feeds
 export function synchronise() {
-	send(message.synchronise, {
+	const req = {
+		protocolVersion,
+		features: supportedFeatures,
 		board: page.board,
 		thread: page.thread,
-	})
+	} as { [key: string]: any }
+	if (page.thread && logPosition.thread === page.thread) {
+		req.epoch = logPosition.epoch
+		req.position = logPosition.position
+	}
+	if (page.thread && page.lastN) {
+		req.last = page.lastN
+	}
+	send(message.synchronise, req)
 
 	// Reclaim a post lost after disconnecting, going on standby, resuming
 	// browser tab, etc.
@@ -111,6 +164,20 @@ handlers[message.reclaim] = (code: number) => {
 	}
 }
 
+handlers[message.handshake] = (data: Handshake) => {
+	handshake = data
+}
+
+// Server does not support this client's protocol version. Reloading the page
+// loads the client version served by the server.
+handlers[message.refresh] = () =>
+	location.reload(true)
+
+// Track the last received log position of the thread feed
+handlers[message.feedPosition] = (position: number) => {
+	logPosition.position = position
+}
+
 // Synchronise to the server and start receiving updates on the appropriate
 // channel. If there are any missed messages, fetch them.
 handlers[message.synchronise] = async (data: SyncData) => {
@@ -118,6 +185,14 @@ handlers[message.synchronise] = async (data: SyncData) => {
 		return
 	}
 
+	const { epoch, position, delta, log } = data
+	logPosition = { thread: page.thread, epoch, position }
+	if (log) {
+		for (let msg of log) {
+			onMessage(msg, true)
+		}
+	}
+
 	// Skip posts before the first post in a shortened thread
 	let minID = 0
 	if (page.lastN) {
@@ -136,9 +211,12 @@ handlers[message.synchronise] = async (data: SyncData) => {
 	const { recent, moderation } = data,
 		proms: Promise<void>[] = []
 
-	for (let post of posts) {
-		if (post.editing && !(post.id in recent)) {
-			proms.push(fetchUnclosed(post))
+	// Delta syncs omit unmodified posts
+	if (!delta) {
+		for (let post of posts) {
+			if (post.editing && !(post.id in recent)) {
+				proms.push(fetchUnclosed(post))
+			}
 		}
 	}
 	for (let key in recent) {
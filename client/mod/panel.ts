@@ -215,6 +215,7 @@ class BanForm extends HidableForm {
 		const data = {
 			duration,
 			reason: this.inputElement("reason").value,
+			shadow: this.inputElement("shadow").checked,
 		}
 		const g = this.inputElement("global")
 		if (g) {
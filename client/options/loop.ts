@@ -85,7 +85,7 @@ function toggleAutoGIF() {
 				case fileTypes.gif:
 					return true
 				default:
-					return false
+					return !!image.animated_thumb
 			}
 		},
 		({ view }) =>
@@ -3,17 +3,28 @@
 import { HTML, makeAttrs, fetchJSON, escape } from '../util'
 import options from '.'
 import lang from '../lang'
+import { handlers, message } from '../connection'
 
 type RadioData = {
 	np: string
 	listeners: number
 	dj: string
+	url?: string // Website of the radio, if pushed by the server
 	[index: string]: string | number
 }
 
 let el = document.getElementById('banner-center'),
 	data: RadioData = {} as RadioData,
-	started = false
+	started = false,
+	// The board has the radio banner enabled and the server pushes the
+	// playing song. Overrides fetching from the client.
+	pushed = false
+
+handlers[message.nowPlaying] = (d: RadioData) => {
+	pushed = true
+	data = d
+	render()
+}
 
 // Replacement new post names based on currently playing song
 export const posterName = () =>
@@ -27,6 +38,9 @@ const songMap = new Map([
 // Fetch JSON from R/a/dio's or Eden's API and rerender the banner, if different data
 // received
 async function fetchData() {
+	if (pushed) {
+		return
+	}
 	let newData = {} as RadioData
 	if (options.nowPlaying === "r/a/dio") {
 		const [res, err] = await fetchJSON<any>('https://r-a-d.io/api')
@@ -76,7 +90,8 @@ function isMatch(a: {}, b: {}): boolean {
 
 // Render the banner message text
 function render() {
-	if (options.nowPlaying === "none") {
+	// Pushed empty data means the server can not reach the radio
+	if (options.nowPlaying === "none" || !data.np) {
 		el.innerHTML = _posterName = ""
 		return
 	}
@@ -99,9 +114,14 @@ function render() {
 		href: `https://google.com/search?q=${encodeURIComponent(data.np)}`,
 		target: "_blank",
 	}
-	const site = options.nowPlaying === "eden" ? "edenofthewest.com" : "r-a-d.io"
+	let site = data.url
+	if (!site) {
+		site = options.nowPlaying === "eden"
+			? "https://edenofthewest.com/"
+			: "https://r-a-d.io/"
+	}
 	el.innerHTML = HTML
-		`<a href="https://${site}/" target="_blank">
+		`<a href="${escape(site)}" target="_blank">
 			[${escape(data.listeners.toString())}] ${escape(data.dj)}
 		</a>
 		&nbsp;&nbsp;
@@ -0,0 +1,31 @@
+// Announcement banners shown on top of board and thread pages
+
+import { handlers, message } from "../connection"
+import { escape } from "../util"
+
+type AnnouncementBanner = {
+	id: number
+	board: string
+	body: string
+	start?: number
+	end?: number
+}
+
+// Replace the rendered banners with the ones currently shown on the board
+function render(banners: AnnouncementBanner[]) {
+	const el = document.getElementById("announcement-banners")
+	if (!el) {
+		return
+	}
+	let html = ""
+	for (let { id, body } of banners) {
+		html += `<div class="announcement-banner glass" data-id="${id}">`
+			+ escape(body)
+			+ `</div>`
+	}
+	el.innerHTML = html
+}
+
+export default function () {
+	handlers[message.announcementBanners] = render
+}
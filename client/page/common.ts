@@ -76,7 +76,8 @@ export function extractPost(
 		if (options.hideThumbs
 			|| options.workModeToggle
 			|| (image.spoiler && !options.spoilers)
-			|| (image.file_type === fileTypes.gif && options.autogif)
+			|| ((image.file_type === fileTypes.gif || image.animated_thumb)
+				&& options.autogif)
 		) {
 			view.renderImage(false)
 		}
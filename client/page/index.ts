@@ -1,4 +1,5 @@
 import initNavigation from "./navigation"
+import initAnnouncementBanners from "./announcement_banners"
 import * as watcher from "./thread_watcher";
 
 export { extractConfigs } from "./common"
@@ -8,5 +9,6 @@ export { watchCurrentThread } from "./thread_watcher";
 
 export function init() {
 	initNavigation();
+	initAnnouncementBanners();
 	watcher.init();
 }
@@ -72,9 +72,10 @@ export default class ImageHandler extends View<Post> {
 	// Render the actual thumbnail image
 	private renderThumbnail() {
 		const el = this.el.querySelector("figure a"),
-			{ sha1, file_type: file_type, thumb_type: thumbType, dims, spoiler } = this
-				.model
-				.image,
+			{
+				sha1, file_type: file_type, thumb_type: thumbType, dims, spoiler,
+				animated_thumb,
+			} = this.model.image,
 			src = sourcePath(sha1, file_type)
 		let thumb: string,
 			[, , thumbWidth, thumbHeight] = dims
@@ -101,6 +102,9 @@ export default class ImageHandler extends View<Post> {
 		} else if (options.autogif && file_type === fileTypes.gif) {
 			// Animated GIF thumbnails
 			thumb = src
+		} else if (options.autogif && animated_thumb) {
+			// Animated video thumbnail previews
+			thumb = animatedThumbPath(sha1)
 		} else {
 			thumb = thumbPath(sha1, thumbType)
 		}
@@ -445,6 +449,11 @@ export function thumbPath(sha1: string, thumbType: fileTypes): string {
 	return `${imageRoot()}/thumb/${sha1}.${fileTypes[thumbType]}`
 }
 
+// Get the path of the animated thumbnail preview of a video
+export function animatedThumbPath(sha1: string): string {
+	return `${imageRoot()}/thumb/${sha1}_anim.webp`
+}
+
 // Resolve the path to the source file of an upload
 export function sourcePath(sha1: string, fileType: fileTypes): string {
 	return `${imageRoot()}/src/${sha1}.${fileTypes[fileType]}`
@@ -228,6 +228,9 @@ export class Post extends Model implements PostData {
 			case ModerationAction.lockThread:
 				this.locked = data === 'true';
 				break;
+			case ModerationAction.stickyThread:
+				this.sticky = data === 'true';
+				break;
 			case ModerationAction.purgePost:
 				if (this.image) {
 					this.image = null;
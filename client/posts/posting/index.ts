@@ -12,6 +12,7 @@ import initPaste from "./paste"
 import initFullScreen from "./fullscreen"
 import initImageErr from "./image"
 import initThreads from "./threads"
+import initPow from "./pow"
 import { renderCaptchaForm, captchaLoaded } from "../../ui/captcha";
 import * as page from "../../page";
 import options from "../../options";
@@ -407,5 +408,6 @@ export default () => {
 	initFullScreen()
 	initImageErr()
 	initThreads()
+	initPow()
 	initIdentity()
 }
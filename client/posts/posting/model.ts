@@ -2,12 +2,15 @@ import { message, send, handlers } from "../../connection"
 import { Post } from "../model"
 import { ImageData, PostData } from "../../common"
 import FormView from "./view"
-import { posts, storeMine, page, storeSeenPost, boardConfig } from "../../state"
+import {
+	posts, storeMine, page, storeSeenPost, boardConfig, maxBodyLength,
+} from "../../state"
 import { postSM, postEvent, postState } from "."
 import { extend, modPaste } from "../../util"
 import { SpliceResponse } from "../../client"
 import { FileData } from "./upload"
 import { newAllocRequest } from "./identity"
+import { takeSolution } from "./pow"
 
 // Form Model of an OP post
 export default class FormModel extends Post {
@@ -101,9 +104,10 @@ export default class FormModel extends Post {
 
 	// Trim input string, if it has too many lines
 	private trimInput(val: string, write: boolean): string {
-		if (val.length > 2000) {
-			const extra = val.length - 2000;
-			val = val.slice(0, 2000)
+		const max = maxBodyLength()
+		if (val.length > max) {
+			const extra = val.length - max;
+			val = val.slice(0, max)
 			if (write) {
 				this.view.trimInput(extra);
 			}
@@ -124,7 +128,7 @@ export default class FormModel extends Post {
 
 	private send(type: message, msg: any) {
 		if (postSM.state !== postState.halted) {
-			send(type, msg)
+			sendTargeted(this.op, type, msg)
 		}
 	}
 
@@ -229,9 +233,9 @@ export default class FormModel extends Post {
 			return
 		}
 
-		if (p.body.length > 2000) {
+		if (p.body.length > maxBodyLength()) {
 			p.body = this.trimInput(p.body, false);
-			p.pos = 2000;
+			p.pos = maxBodyLength();
 		} else if (start != end) {
 			p.body = old.slice(0, start) + p.body + old.slice(end)
 			p.pos -= (end - start)
@@ -259,7 +263,7 @@ export default class FormModel extends Post {
 	}
 
 	// Request allocation of a draft post to the server
-	private requestAlloc(body: string, image: FileData | null) {
+	private async requestAlloc(body: string, image: FileData | null) {
 		const req = newAllocRequest();
 		req["open"] = true;
 		if (body) {
@@ -269,9 +273,13 @@ export default class FormModel extends Post {
 			req["image"] = image;
 		}
 
-		send(message.insertPost, req);
 		postSM.feed(postEvent.sentAllocRequest);
 		handlers[message.postID] = this.receiveID();
+
+		// Proof of work challenges are solved in the background and may not
+		// be done yet
+		extend(req, await takeSolution());
+		send(message.insertPost, req);
 	}
 
 	// Handle draft post allocation
@@ -313,7 +321,7 @@ export default class FormModel extends Post {
 				break;
 			case postState.alloc:
 				this.allocatingImage = true;
-				send(message.insertImage, data)
+				sendTargeted(this.op, message.insertImage, data)
 				break;
 		}
 	}
@@ -338,6 +346,14 @@ export default class FormModel extends Post {
 	}
 }
 
+// Send a post modification message wrapped in an envelope identifying the
+// thread of the open post it targets. The server keeps open posts in multiple
+// threads per client, so unwrapped messages could otherwise be applied to the
+// post in another thread after a resynchronisation.
+function sendTargeted(thread: number, type: message, data: any) {
+	send(message.targetPost, { thread, type, data })
+}
+
 // Find the first differing character in 2 character arrays
 function diffIndex(a: string[], b: string[]): number {
 	for (let i = 0; i < a.length; i++) {
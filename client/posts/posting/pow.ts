@@ -0,0 +1,95 @@
+// Solves proof of work challenges required for post creation
+
+import { handlers, message } from "../../connection"
+import { config } from "../../state"
+import { fetchJSON, on } from "../../util"
+
+// Challenge issued by the server
+type Challenge = {
+	challenge: string
+	difficulty: number
+}
+
+// Solution fields to attach to a post creation request
+export type Solution = {
+	challenge?: string
+	nonce?: string
+}
+
+const encoder = new TextEncoder()
+
+// Solution of the last challenge received over the websocket connection
+let pending: Promise<Solution> = Promise.resolve({})
+
+// Find a nonce, such that the SHA-256 hash of the challenge concatenated with
+// the nonce has at least the required number of leading zero bits
+async function solve({ challenge, difficulty }: Challenge): Promise<Solution> {
+	for (let i = 0; ; i++) {
+		const nonce = i.toString(36)
+		const hash = new Uint8Array(await crypto.subtle.digest("SHA-256",
+			encoder.encode(challenge + nonce)))
+		if (leadingZeros(hash) >= difficulty) {
+			return { challenge, nonce }
+		}
+	}
+}
+
+// Count leading zero bits of a hash
+function leadingZeros(hash: Uint8Array): number {
+	let n = 0
+	for (let b of hash) {
+		if (b) {
+			return n + Math.clz32(b) - 24
+		}
+		n += 8
+	}
+	return n
+}
+
+// Returns the solution to the last challenge received over the websocket
+// connection. Each solution can only be used for one post.
+export function takeSolution(): Promise<Solution> {
+	const pr = pending
+	pending = Promise.resolve({})
+	return pr
+}
+
+// Fetch and solve a challenge before submitting a post creation form
+async function submitForm(e: Event) {
+	const form = e.target as HTMLFormElement
+	e.preventDefault()
+	const [ch, err] = await fetchJSON<Challenge>("/api/pow-challenge")
+	if (err) {
+		alert(err)
+		return
+	}
+	const { challenge, nonce } = await solve(ch)
+	setField(form, "powChallenge", challenge)
+	setField(form, "powNonce", nonce)
+	form.submit()
+}
+
+// Set the value of a hidden form field, creating it, if needed
+function setField(form: HTMLFormElement, name: string, val: string) {
+	let el = form.querySelector(`input[name=${name}]`) as HTMLInputElement
+	if (!el) {
+		el = document.createElement("input")
+		el.type = "hidden"
+		el.name = name
+		form.append(el)
+	}
+	el.value = val
+}
+
+export default () => {
+	// Start solving a new challenge as soon as it is received
+	handlers[message.powChallenge] = (ch: Challenge) =>
+		pending = solve(ch)
+
+	// Post creation forms submitted without the websocket connection
+	if (config.proofOfWork) {
+		on(document, "submit", submitForm, {
+			selector: "#new-thread-form, #new-reply-form",
+		})
+	}
+}
@@ -1,7 +1,7 @@
 import PostView from "../view"
 import FormModel from "./model"
 import { Post } from "../model"
-import { boardConfig } from "../../state"
+import { boardConfig, maxBodyLength } from "../../state"
 import { setAttrs, importTemplate, atBottom, scrollToBottom } from "../../util"
 import { postSM, postEvent, postState } from "."
 import UploadForm from "./upload"
@@ -34,7 +34,7 @@ export default class FormView extends PostView {
             id: "text-input",
             name: "body",
             rows: "1",
-            maxlength: "2000",
+            maxlength: maxBodyLength().toString(),
         })
         this.el.append(importTemplate("post-controls"))
         this.resizeInput()
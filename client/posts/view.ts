@@ -277,12 +277,21 @@ export default class PostView extends ImageHandler {
                         lang.posts[data === 'true' ? "locked" : "unlocked"],
                         by)
                     break;
+                case ModerationAction.stickyThread:
+                    s = this.format("threadStickyToggled", by);
+                    break;
                 case ModerationAction.meidoVision:
                     s = this.format("viewedSameIP", by);
                     break;
                 case ModerationAction.purgePost:
                     s = this.format("purgedPost", by, data);
                     break;
+                case ModerationAction.moveThread:
+                    s = this.format("threadMoved", data, by);
+                    break;
+                case ModerationAction.mergeThread:
+                    s = this.format("threadMerged", data, by);
+                    break;
             }
             const el = document.createElement('b');
             el.setAttribute("class", "admin post-moderation");
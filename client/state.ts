@@ -11,6 +11,7 @@ interface Configs {
 	mature: boolean // Website intended for mature audiences
 	disableUserBoards: boolean
 	pruneThreads: boolean
+	proofOfWork: boolean
 	threadExpiryMin: number
 	threadExpiryMax: number
 	maxSize: number
@@ -30,6 +31,9 @@ export interface BoardConfigs {
 	title: string
 	notice: string
 	rules: string
+	maxBodyLength: number
+	postCooldown: number
+	fileTypes: string[] | null
 	[index: string]: any
 }
 
@@ -82,11 +86,12 @@ export let debug: boolean = /[\?&]debug=true/.test(location.href)
 function read(href: string): PageState {
 	const u = new URL(href, location.origin),
 		thread = u.pathname.match(/^\/\w+\/(\d+)/),
-		page = u.search.match(/[&\?]page=(\d+)/)
+		page = u.search.match(/[&\?]page=(\d+)/),
+		last = u.search.match(/[&\?]last=(50|100)\b/)
 	return {
 		href,
 		board: u.pathname.match(/^\/(\w+)\//)[1],
-		lastN: /[&\?]last=100/.test(u.search) ? 100 : 0,
+		lastN: last ? parseInt(last[1]) : 0,
 		page: page ? parseInt(page[1]) : 0,
 		catalog: /^\/\w+\/catalog/.test(u.pathname),
 		thread: parseInt(thread && thread[1]) || 0,
@@ -130,6 +135,12 @@ export function storeHidden(id: number, op: number) {
 	storeID("hidden", id, op, tenDays * 3 * 6)
 }
 
+// Returns the maximum post body length on the current board
+export function maxBodyLength(): number {
+	const max = boardConfig && boardConfig.maxBodyLength
+	return max && max < 2000 ? max : 2000
+}
+
 export function setBoardConfig(c: BoardConfigs) {
 	boardConfig = c
 }
@@ -0,0 +1,25 @@
+package common
+
+// AnnouncementBanner is a message shown in a banner on top of the pages of a
+// single board or all boards, if Board is "all". The banner can be limited to
+// a time window.
+type AnnouncementBanner struct {
+	ID    uint64 `json:"id"`
+	Board string `json:"board"`
+	Body  string `json:"body"`
+
+	// Unix timestamps of the start and end of the time window or 0, if
+	// unbound
+	Start int64 `json:"start,omitempty"`
+	End   int64 `json:"end,omitempty"`
+}
+
+// Active returns, if the banner is shown at Unix time now
+func (b AnnouncementBanner) Active(now int64) bool {
+	return (b.Start == 0 || b.Start <= now) && (b.End == 0 || now < b.End)
+}
+
+// ShownOn returns, if the banner is shown on the pages of board
+func (b AnnouncementBanner) ShownOn(board string) bool {
+	return b.Board == "all" || b.Board == board
+}
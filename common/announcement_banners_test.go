@@ -0,0 +1,32 @@
+package common
+
+import "testing"
+
+func TestAnnouncementBannerActive(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name       string
+		start, end int64
+		active     bool
+	}{
+		{"unbound", 0, 0, true},
+		{"started", 50, 0, true},
+		{"not started", 150, 0, false},
+		{"not ended", 0, 150, true},
+		{"ended", 0, 100, false},
+		{"in window", 50, 150, true},
+		{"before window", 120, 150, false},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			b := AnnouncementBanner{Start: c.start, End: c.end}
+			if a := b.Active(100); a != c.active {
+				t.Fatalf("unexpected activity: %t != %t", a, c.active)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package common
+
+// Dimensions and limits of oekaki drawings
+const (
+	DrawingWidth     = 400
+	DrawingHeight    = 400
+	MaxStrokeWidth   = 32
+	MaxDrawingPoints = 1 << 14
+
+	// Maximum total length of all line segments of a drawing in pixels along
+	// the longer axis. Bounds the cost of rendering a drawing.
+	MaxDrawingLength = 1 << 16
+)
+
+// Stroke is a single continuous line of an oekaki drawing. Drawings are
+// stored as a sequence of strokes, so they can be replayed.
+type Stroke struct {
+	// 0xRRGGBB
+	Color uint32 `json:"color"`
+	Width uint8  `json:"width"`
+	// X and Y coordinates of the points the line passes through
+	Points [][2]uint16 `json:"points"`
+}
+
+// Validate asserts the stroke is drawable on the canvas
+func (s Stroke) Validate() error {
+	switch {
+	case s.Color > 0xffffff,
+		s.Width == 0,
+		s.Width > MaxStrokeWidth,
+		len(s.Points) == 0:
+		return ErrInvalidStroke
+	case len(s.Points) > MaxDrawingPoints:
+		return ErrDrawingTooLong
+	}
+	for _, p := range s.Points {
+		if p[0] >= DrawingWidth || p[1] >= DrawingHeight {
+			return ErrInvalidStroke
+		}
+	}
+	if s.Length() > MaxDrawingLength {
+		return ErrDrawingTooLong
+	}
+	return nil
+}
+
+// Length returns the total length of the stroke's line segments in pixels
+// along the longer axis of each segment
+func (s Stroke) Length() (l int) {
+	for i := 1; i < len(s.Points); i++ {
+		dx := int(s.Points[i][0]) - int(s.Points[i-1][0])
+		dy := int(s.Points[i][1]) - int(s.Points[i-1][1])
+		if dx < 0 {
+			dx = -dx
+		}
+		if dy < 0 {
+			dy = -dy
+		}
+		if dx > dy {
+			l += dx
+		} else {
+			l += dy
+		}
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package common
+
+import "testing"
+
+func TestValidateStroke(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name   string
+		stroke Stroke
+		err    error
+	}{
+		{
+			name: "valid",
+			stroke: Stroke{
+				Color:  0xff0000,
+				Width:  3,
+				Points: [][2]uint16{{0, 0}, {399, 399}},
+			},
+		},
+		{
+			name: "invalid color",
+			stroke: Stroke{
+				Color:  0x1000000,
+				Width:  3,
+				Points: [][2]uint16{{0, 0}},
+			},
+			err: ErrInvalidStroke,
+		},
+		{
+			name: "zero width",
+			stroke: Stroke{
+				Points: [][2]uint16{{0, 0}},
+			},
+			err: ErrInvalidStroke,
+		},
+		{
+			name: "too wide",
+			stroke: Stroke{
+				Width:  MaxStrokeWidth + 1,
+				Points: [][2]uint16{{0, 0}},
+			},
+			err: ErrInvalidStroke,
+		},
+		{
+			name:   "no points",
+			stroke: Stroke{Width: 1},
+			err:    ErrInvalidStroke,
+		},
+		{
+			name: "out of bounds",
+			stroke: Stroke{
+				Width:  1,
+				Points: [][2]uint16{{DrawingWidth, 0}},
+			},
+			err: ErrInvalidStroke,
+		},
+		{
+			name: "too many points",
+			stroke: Stroke{
+				Width:  1,
+				Points: make([][2]uint16, MaxDrawingPoints+1),
+			},
+			err: ErrDrawingTooLong,
+		},
+		{
+			name: "too long",
+			stroke: Stroke{
+				Width:  1,
+				Points: zigzag(MaxDrawingLength/(DrawingWidth-1) + 2),
+			},
+			err: ErrDrawingTooLong,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if err := c.stroke.Validate(); err != c.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStrokeLength(t *testing.T) {
+	t.Parallel()
+
+	s := Stroke{
+		Points: [][2]uint16{{0, 0}, {10, 5}, {10, 25}, {4, 20}},
+	}
+	if l := s.Length(); l != 36 {
+		t.Fatalf("unexpected length: %d", l)
+	}
+}
+
+// Generate points of a line crossing the canvas horizontally n-1 times
+func zigzag(n int) [][2]uint16 {
+	points := make([][2]uint16, n)
+	for i := range points {
+		if i%2 == 1 {
+			points[i][0] = DrawingWidth - 1
+		}
+	}
+	return points
+}
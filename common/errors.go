@@ -20,6 +20,8 @@ var (
 	ErrInvalidCreds        = ErrAccessDenied("login credentials")
 	ErrBanned              = ErrAccessDenied("you are banned from this board")
 	ErrTooManyConnections  = ErrAccessDenied("too many connections")
+	ErrInvalidStroke       = ErrInvalidInput("drawing stroke")
+	ErrDrawingTooLong      = ErrTooLong("drawing")
 
 	// The poster is almost certainly spamming
 	ErrSpamDected = ErrAccessDenied("spam detected")
@@ -32,18 +34,24 @@ type StatusError struct {
 }
 
 func (e StatusError) Error() string {
-	var prefix string
+	return fmt.Sprintf("%s: %s", e.Prefix(), e.Err)
+}
+
+// Prefix returns the description of the error's status code prepended to its
+// message
+func (e StatusError) Prefix() string {
 	switch e.Code {
 	case 400:
-		prefix = "invalid input"
+		return "invalid input"
 	case 403:
-		prefix = "access denied"
+		return "access denied"
 	case 404:
-		prefix = "not found"
+		return "not found"
 	case 500:
-		prefix = "internal server error"
+		return "internal server error"
+	default:
+		return ""
 	}
-	return fmt.Sprintf("%s: %s", prefix, e.Err)
 }
 
 // ErrTooLong is passed, when a field exceeds the maximum string length for
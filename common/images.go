@@ -67,4 +67,11 @@ type ImageCommon struct {
 	Title     string    `json:"title"`
 	MD5       string    `json:"md5"`
 	SHA1      string    `json:"sha1"`
+
+	// Perceptual hash of the thumbnail for finding visually similar images.
+	// Empty for files without thumbnails.
+	PHash string `json:"phash,omitempty"`
+
+	// An animated thumbnail preview has been generated for the video
+	AnimatedThumb bool `json:"animated_thumb,omitempty"`
 }
@@ -14,6 +14,14 @@ const (
 	DeleteBoard
 	MeidoVision
 	PurgePost
+	StickyThread
+	ConfigureBoard
+	ConfigureServer
+	AssignStaff
+	MoveThread
+	MergeThread
+	ShadowBanPost
+	UnhidePost
 )
 
 // Contains fields of a post moderation log entry
@@ -23,3 +31,18 @@ type ModerationEntry struct {
 	By     string           `json:"by"`
 	Data   string           `json:"data"`
 }
+
+// StaffOnlyActions are the moderation actions, entries of which must not be
+// exposed to the public, as they would reveal a shadow ban
+var StaffOnlyActions = [...]ModerationAction{ShadowBanPost, UnhidePost}
+
+// IsStaffOnly returns, if entries of the action must not be exposed to the
+// public
+func (a ModerationAction) IsStaffOnly() bool {
+	for _, s := range StaffOnlyActions {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
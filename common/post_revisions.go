@@ -0,0 +1,25 @@
+package common
+
+// Reasons a closed post's body was modified. Closed posts can not be edited
+// and word filters are applied before closing, so purging a post is currently
+// the only modification of a closed post's body.
+const (
+	RevisionRedaction = "redaction"
+)
+
+// PostRevision is a snapshot of the body of a closed post taken before the
+// body was modified. Snapshots of redactions retain purged content, so they
+// are only available to admins and omitted from database dumps.
+type PostRevision struct {
+	ID uint64 `json:"id"`
+
+	// Reason for the modification. Currently always RevisionRedaction.
+	Reason string `json:"reason"`
+
+	// Staff account, that performed the modification, if any
+	By string `json:"by,omitempty"`
+
+	// Unix timestamp of the modification
+	Time int64  `json:"time"`
+	Body string `json:"body"`
+}
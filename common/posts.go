@@ -6,6 +6,9 @@ package common
 // TODO: Clean up this function signature
 var ParseBody func([]byte, string, uint64, uint64, string, bool) ([]Link, []Command, error)
 
+// RecordTopics forwards topics.Add to avoid cyclic imports in db/upkeep
+var RecordTopics func(board, body string)
+
 // Board is defined to enable marshalling optimizations and sorting by sticky
 // threads
 type Board struct {
@@ -33,6 +36,7 @@ type Thread struct {
 	Abbrev    bool   `json:"abbrev"`
 	Sticky    bool   `json:"sticky"`
 	Locked    bool   `json:"locked"`
+	Encrypted bool   `json:"encrypted"`
 	PostCtr   uint32 `json:"postCtr"`
 	ImageCtr  uint32 `json:"imageCtr"`
 	ReplyTime int64  `json:"replyTime"`
@@ -41,6 +45,19 @@ type Thread struct {
 	Board     string `json:"board"`
 	Post
 	Posts []Post `json:"posts"`
+
+	// Number of replies left out of an abbreviated thread
+	Omitted uint `json:"omitted,omitempty"`
+
+	// Number of posts by each poster ID in the thread. Only set on boards with
+	// poster IDs enabled.
+	PosterCounts map[string]uint `json:"posterCounts,omitempty"`
+}
+
+// IsLastN returns, if n is a valid number of last posts to show in an
+// abbreviated thread
+func IsLastN(n int) bool {
+	return n == 5 || n == 50 || n == 100
 }
 
 // Post is a generic post exposed publically through the JSON API. Either OP or
@@ -49,6 +66,8 @@ type Post struct {
 	Editing    bool              `json:"editing"`
 	Moderated  bool              `json:"-"`
 	Sage       bool              `json:"sage"`
+	Proxy      bool              `json:"proxy,omitempty"`
+	Shadowed   bool              `json:"shadowed,omitempty"`
 	ID         uint64            `json:"id"`
 	Time       int64             `json:"time"`
 	Body       string            `json:"body"`
@@ -56,6 +75,7 @@ type Post struct {
 	Name       string            `json:"name"`
 	Trip       string            `json:"trip"`
 	Auth       string            `json:"auth"`
+	PosterID   string            `json:"posterID,omitempty"`
 	Image      *Image            `json:"image"`
 	Links      []Link            `json:"links"`
 	Commands   []Command         `json:"commands"`
@@ -12,28 +12,37 @@ var (
 
 	// Project is being uint tested
 	IsTest bool
+
+	// Version of the server. Set at build time with
+	// -ldflags "-X github.com/bakape/meguca/common.Version=<version>".
+	Version = "dev"
 )
 
 // Maximum lengths of various input fields
 const (
-	MaxLenName         = 50
-	MaxLenAuth         = 50
-	MaxLenPostPassword = 100
-	MaxLenSubject      = 100
-	MaxLenBody         = 2000
-	MaxLinesBody       = 100
-	MaxLenPassword     = 50
-	MaxLenUserID       = 20
-	MaxLenBoardID      = 10
-	MaxLenBoardTitle   = 100
-	MaxLenNotice       = 500
-	MaxLenRules        = 5000
-	MaxLenEightball    = 2000
-	MaxLenReason       = 100
-	MaxNumBanners      = 20
-	MaxAssetSize       = 100 << 10
-	MaxDiceSides       = 10000
-	BumpLimit          = 5000
+	MaxLenName          = 50
+	MaxLenAuth          = 50
+	MaxLenPostPassword  = 100
+	MaxLenSubject       = 100
+	MaxLenBody          = 2000
+	MaxLenEncryptedBody = 16 << 10
+	MaxLinesBody        = 100
+	MaxLenPassword      = 50
+	MaxLenUserID        = 20
+	MaxLenBoardID       = 10
+	MaxLenBoardTitle    = 100
+	MaxLenNotice        = 500
+	MaxLenAnnouncement  = 500
+	MaxLenRules         = 5000
+	MaxLenEightball     = 2000
+	MaxLenReason        = 100
+	MaxLenWebhookURL    = 500
+	MaxLenWebhookSecret = 100
+	MaxNumBanners       = 20
+	MaxAssetSize        = 100 << 10
+	MaxDiceSides        = 10000
+	MaxDuplicateWindow  = 24 * 60 * 60
+	BumpLimit           = 5000
 )
 
 // Various cryptographic token exact lengths
@@ -54,6 +63,44 @@ var (
 	}
 )
 
+// Board policies for posts from detected proxies and Tor exit nodes
+const (
+	ProxyPolicyNone    = "none"
+	ProxyPolicyTag     = "tag"
+	ProxyPolicyCaptcha = "captcha"
+	ProxyPolicyBlock   = "block"
+)
+
+// ProxyPolicies contains all available board proxy policies
+var ProxyPolicies = []string{
+	ProxyPolicyNone, ProxyPolicyTag, ProxyPolicyCaptcha, ProxyPolicyBlock,
+}
+
+// Board policies for posts with bodies duplicating recent posts
+const (
+	DuplicatePolicyFlag   = "flag"
+	DuplicatePolicyReject = "reject"
+)
+
+// DuplicatePolicies contains all available board duplicate post policies
+var DuplicatePolicies = []string{DuplicatePolicyFlag, DuplicatePolicyReject}
+
+// Board visibility levels
+const (
+	// Listed in board navigation and included in /all/
+	VisibilityPublic = "public"
+	// Accessible to everyone, but not listed in board navigation or included
+	// in /all/ and searches across boards
+	VisibilityUnlisted = "unlisted"
+	// Accessible only to the board's staff and otherwise same as unlisted
+	VisibilityStaffOnly = "staff"
+)
+
+// BoardVisibilities contains all available board visibility levels
+var BoardVisibilities = []string{
+	VisibilityPublic, VisibilityUnlisted, VisibilityStaffOnly,
+}
+
 // Common Regex expressions
 var (
 	CommandRegexp = regexp.MustCompile(`^#(flip|\d*d\d+|8ball|pyu|pcount|sw(?:\d+:)?\d+:\d+(?:[+-]\d+)?|roulette|rcount)$`)
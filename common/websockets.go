@@ -6,8 +6,13 @@ import (
 	"strconv"
 )
 
-// ProtocolVersion is the websocket protocol version
-const ProtocolVersion = 1
+// ProtocolVersion is the websocket protocol version. Version 2 added
+// MessageHandshake.
+const ProtocolVersion = 2
+
+// MinProtocolVersion is the oldest websocket protocol version of clients, that
+// are still compatible with the server. Older clients are asked to refresh.
+const MinProtocolVersion = 1
 
 // MessageType is the identifier code for websocket message types
 type MessageType uint8
@@ -23,6 +28,9 @@ const (
 	MessageInsertImage
 	MessageSpoiler
 	MessageModeratePost
+
+	// Updates metadata of an uploaded file in all posts containing it
+	MessageFileUpdate
 )
 
 // >= 30 are miscellaneous and do not write to post models
@@ -62,6 +70,71 @@ const (
 	// Used by the client to send it's protocol version and by the server to
 	// send server and board configurations
 	MessageConfigs
+
+	// Reserves a post ID in the current thread and returns the ID and a claim
+	// token for committing the post later
+	MessageReservePost
+
+	// Sends a proof of work challenge to the client, that must be solved
+	// before creating a post
+	MessagePowChallenge
+
+	// Notify the client, it is outdated and must reload the page
+	MessageRefresh
+
+	// Sends the position of the last post modification message in the thread
+	// feed's message log. Used for resyncing after reconnecting.
+	MessageFeedPosition
+
+	// Used by staff to subscribe to the staff activity feed and by the server
+	// to send events of the feed
+	MessageStaffFeed
+
+	// Revert or reapply the last edit of the client's open post. The server
+	// responds with the resulting MessageSplice.
+	MessageUndo
+	MessageRedo
+
+	// Wraps a post modification message, identifying the thread of the
+	// client's open post it targets. Allows clients to have open posts in
+	// multiple threads at once.
+	MessageTargetPost
+
+	// Adds a stroke to the oekaki drawing of the client's open post
+	MessageDrawStroke
+
+	// Passes the song currently playing on the internet radio
+	MessageNowPlaying
+
+	// Passes all announcement banners currently shown on the client's board
+	MessageAnnouncementBanners
+
+	// Sends the server version, supported protocol versions and features
+	// enabled for the connection after synchronising
+	MessageHandshake
+)
+
+// Optional features of the websocket protocol. Advertised to clients in
+// MessageHandshake, if enabled on the server and board.
+const (
+	// Open posts are streamed to other clients, as they are being typed
+	FeatureLiveUpdates = "liveUpdates"
+
+	// Oekaki drawings can be attached to open posts
+	FeatureOekaki = "oekaki"
+
+	// Threads can be created with encrypted post bodies
+	FeatureEncryptedThreads = "encryptedThreads"
+
+	// Post creation requires solving a proof of work challenge
+	FeatureProofOfWork = "proofOfWork"
+
+	// The song playing on the internet radio is sent to clients
+	FeatureRadio = "radio"
+
+	// Board configurations and, on board pages, the board page JSON are sent
+	// on synchronising. Always enabled and implied by protocol version 1.
+	FeatureBoardData = "boardData"
 )
 
 // Forwarded functions from "github.com/bakape/megucawebsockets/feeds" to avoid circular imports
@@ -77,6 +150,9 @@ var (
 
 	// ClosePost closes a post in a feed, if it exists
 	ClosePost func(id, op uint64, links []Link, commands []Command) error
+
+	// PublishPost inserts an already closed post into a feed, if it exists
+	PublishPost func(id, op uint64) error
 )
 
 // Client exposes some globally accessible websocket client functionality
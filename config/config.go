@@ -5,6 +5,7 @@ package config
 
 import (
 	"encoding/json"
+	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/util"
 	"reflect"
 	"sort"
@@ -28,6 +29,10 @@ const (
 // ImagerMode is imager functionality setting for this meguca process
 var ImagerMode ImagerModeType
 
+// Clustered specifies, that multiple meguca processes share the database and
+// must share live updates and rate limiting state with each other
+var Clustered bool
+
 var (
 	// Ensures no reads happen, while the configuration is reloading
 	globalMu, boardMu sync.RWMutex
@@ -41,6 +46,10 @@ var (
 	// Don't handle image processing and serving in this instance
 	noImager bool
 
+	// Functions to call after the global configuration has been reloaded
+	hooksMu     sync.Mutex
+	updateHooks []func() error
+
 	// AllBoardConfigs stores board-specific configurations for the /all/
 	// metaboard. Constant.
 	AllBoardConfigs = BoardConfContainer{
@@ -69,10 +78,14 @@ var (
 		MaxHeight:         6000,
 		MaxWidth:          6000,
 		SessionExpiry:     30,
+		IPRetention:       7,
 		CharScore:         170,
 		PostCreationScore: 15000,
 		ImageScore:        15000,
+		ResyncThreshold:   10,
 		EmailErrPort:      587,
+		WebhookErrCap:     10,
+		EmailErrCap:       20,
 		Salt:              "LALALALALALALALALALALALALALALALALALALALA",
 		EmailErrMail:      "admin@email.com",
 		EmailErrPass:      "sluts",
@@ -89,6 +102,8 @@ var (
 			ThreadExpiryMin: 7,
 			ThreadExpiryMax: 14,
 			MaxSize:         5,
+			DeletionWindow:  15,
+			ReclaimWindow:   5,
 			Links:           map[string]string{"4chan": "http://www.4chan.org/"},
 		},
 	}
@@ -153,6 +168,34 @@ func Set(c Configs) error {
 	return nil
 }
 
+// OnUpdate registers a function to be called, after the global configuration
+// has been reloaded with Reload
+func OnUpdate(fn func() error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	updateHooks = append(updateHooks, fn)
+}
+
+// Reload validates c, atomically swaps it in as the global configuration and
+// notifies subsystems of the change through the registered update hooks
+func Reload(c Configs) (err error) {
+	err = c.Validate()
+	if err != nil {
+		return
+	}
+	err = Set(c)
+	if err != nil {
+		return
+	}
+
+	hooksMu.Lock()
+	hooks := make([]func() error, len(updateHooks))
+	copy(hooks, updateHooks)
+	hooksMu.Unlock()
+
+	return util.Parallel(hooks...)
+}
+
 // GetClient returns public availability configuration JSON and a truncated
 // configuration MD5 hash
 func GetClient() ([]byte, string) {
@@ -193,7 +236,7 @@ func GetAllBoardConfigs() map[string]BoardConfContainer {
 	return conf
 }
 
-// GetBoardTitles returns a slice of all existing boards and their titles
+// GetBoardTitles returns a slice of all listed boards and their titles
 func GetBoardTitles() BoardTitles {
 	boardMu.RLock()
 	defer boardMu.RUnlock()
@@ -204,7 +247,7 @@ func GetBoardTitles() BoardTitles {
 		Title: AllBoardConfigs.Title,
 	}
 	for id, conf := range boardConfigs {
-		if id == "all" {
+		if id == "all" || !conf.listed() {
 			continue
 		}
 		bt = append(bt, BoardTitle{
@@ -231,6 +274,52 @@ func GetBoards() []string {
 	return boards
 }
 
+// GetListedBoards returns an array of currently existing boards, that are
+// listed in board navigation
+func GetListedBoards() []string {
+	boardMu.RLock()
+	defer boardMu.RUnlock()
+	boards := make([]string, 0, len(boardConfigs))
+	for b, conf := range boardConfigs {
+		if b != "all" && conf.listed() {
+			boards = append(boards, b)
+		}
+	}
+	sort.Strings(boards)
+	return boards
+}
+
+// GetUnlistedBoards returns an array of currently existing boards, that are
+// excluded from board navigation, /all/ and searches across boards
+func GetUnlistedBoards() []string {
+	boardMu.RLock()
+	defer boardMu.RUnlock()
+	boards := make([]string, 0, 4)
+	for b, conf := range boardConfigs {
+		if !conf.listed() {
+			boards = append(boards, b)
+		}
+	}
+	sort.Strings(boards)
+	return boards
+}
+
+// IsUnlisted returns, if an existing board is excluded from board navigation,
+// /all/ and searches across boards
+func IsUnlisted(b string) bool {
+	boardMu.RLock()
+	defer boardMu.RUnlock()
+	conf, ok := boardConfigs[b]
+	return ok && !conf.listed()
+}
+
+// IsStaffOnly returns, if an existing board is only accessible to its staff
+func IsStaffOnly(b string) bool {
+	boardMu.RLock()
+	defer boardMu.RUnlock()
+	return boardConfigs[b].Visibility == common.VisibilityStaffOnly
+}
+
 // IsBoard returns whether the passed string is a currently existing board
 func IsBoard(b string) bool {
 	boardMu.RLock()
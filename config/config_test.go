@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/bakape/meguca/common"
+
 	. "github.com/bakape/meguca/test"
 )
 
@@ -29,6 +31,35 @@ func TestSetGet(t *testing.T) {
 	}
 }
 
+func TestReload(t *testing.T) {
+	Clear()
+
+	called := 0
+	OnUpdate(func() error {
+		called++
+		return nil
+	})
+
+	conf := Defaults
+	conf.CaptchaTags = nil
+	if err := Reload(conf); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if called != 0 {
+		t.Fatal("hooks called on invalid configuration")
+	}
+
+	conf = Defaults
+	conf.Mature = true
+	if err := Reload(conf); err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, Get(), &conf)
+	if called != 1 {
+		t.Fatalf("unexpected hook call count: %d", called)
+	}
+}
+
 func TestSetGetClient(t *testing.T) {
 	Clear()
 	std := []byte{1, 2, 3}
@@ -159,6 +190,20 @@ func TestGetBoardTitles(t *testing.T) {
 				Title: "Animu & Mango",
 			},
 		},
+		{
+			ID:         "h",
+			Visibility: common.VisibilityUnlisted,
+			BoardPublic: BoardPublic{
+				Title: "Hidden",
+			},
+		},
+		{
+			ID:         "s",
+			Visibility: common.VisibilityStaffOnly,
+			BoardPublic: BoardPublic{
+				Title: "Staff",
+			},
+		},
 	}
 	for _, c := range conf {
 		if _, err := SetBoardConfigs(c); err != nil {
@@ -166,6 +211,15 @@ func TestGetBoardTitles(t *testing.T) {
 		}
 	}
 
+	AssertDeepEquals(t, GetListedBoards(), []string{"a", "g"})
+	AssertDeepEquals(t, GetUnlistedBoards(), []string{"h", "s"})
+	if !IsUnlisted("s") || IsUnlisted("g") || IsUnlisted("nope") {
+		t.Fatal("unexpected unlisted boards")
+	}
+	if !IsStaffOnly("s") || IsStaffOnly("h") {
+		t.Fatal("unexpected staff-only boards")
+	}
+
 	AssertDeepEquals(t, GetBoardTitles(), BoardTitles{
 		{
 			ID:    "a",
@@ -181,3 +235,35 @@ func TestGetBoardTitles(t *testing.T) {
 		},
 	})
 }
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		conf := Defaults
+		if err := conf.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("lists all problems", func(t *testing.T) {
+		t.Parallel()
+
+		conf := Defaults
+		conf.MaxSize = 0
+		conf.DefaultLang = "xx"
+		conf.EmailErr = true
+		conf.EmailErrPort = 70000
+		conf.EmailErrMail = ""
+		conf.RadioAPI = "ftp://r-a-d.io/api"
+
+		err := conf.Validate()
+		verr, ok := err.(ValidationError)
+		if !ok {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		AssertDeepEquals(t, len(verr), 5)
+	})
+}
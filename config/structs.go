@@ -1,11 +1,14 @@
 package config
 
+import "github.com/bakape/meguca/common"
+
 // Configs stores the global server configuration
 type Configs struct {
 	Public
 	PruneBoards         bool   `json:"pruneBoards"`
 	HideNSFW            bool   `json:"hideNSFW"`
 	EmailErr            bool   `json:"emailErr"`
+	WebhookErr          bool   `json:"webhookErr"`
 	MaxWidth            uint16 `json:"maxWidth"`
 	MaxHeight           uint16 `json:"maxHeight"`
 	BoardExpiry         uint   `json:"boardExpiry"`
@@ -14,14 +17,30 @@ type Configs struct {
 	CharScore           uint   `json:"charScore"`
 	PostCreationScore   uint   `json:"postCreationScore"`
 	ImageScore          uint   `json:"imageScore"`
+	AuditSampling       uint   `json:"auditSampling"`
+	ResyncThreshold     uint   `json:"resyncThreshold"`
+	WebhookErrCap       uint   `json:"webhookErrCap"`
+	EmailErrCap         uint   `json:"emailErrCap"`
+	IPRetention         uint   `json:"ipRetention"`
+	HashIPs             bool   `json:"hashIPs"`
+	PublicModLogStaff   bool   `json:"publicModLogStaff"`
+	PublicImageSearch   bool   `json:"publicImageSearch"`
+	PostWebhooks        bool   `json:"postWebhooks"`
+	AnimatedThumbs      bool   `json:"animatedThumbs"`
 	RootURL             string `json:"rootURL"`
 	Salt                string `json:"salt"`
 	EmailErrMail        string `json:"emailErrMail"`
 	EmailErrPass        string `json:"emailErrPass"`
 	EmailErrSub         string `json:"emailErrSub"`
+	WebhookErrURL       string `json:"webhookErrURL"`
+	SentryDSN           string `json:"sentryDSN"`
 	FeedbackEmail       string `json:"feedbackEmail"`
+	RadioAPI            string `json:"radioAPI"`
 	FAQ                 string
+	TorExitList         string            `json:"torExitList"`
+	DNSBLs              []string          `json:"DNSBLs"`
 	CaptchaTags         []string          `json:"captchaTags"`
+	BoardCreators       []string          `json:"boardCreators"`
 	OverrideCaptchaTags map[string]string `json:"overrideCaptchaTags"`
 }
 
@@ -31,9 +50,13 @@ type Public struct {
 	Mature            bool              `json:"mature"`
 	DisableUserBoards bool              `json:"disableUserBoards"`
 	PruneThreads      bool              `json:"pruneThreads"`
+	EncryptedThreads  bool              `json:"encryptedThreads"`
+	ProofOfWork       bool              `json:"proofOfWork"`
 	ThreadExpiryMin   uint              `json:"threadExpiryMin"`
 	ThreadExpiryMax   uint              `json:"threadExpiryMax"`
 	MaxSize           uint              `json:"maxSize"`
+	DeletionWindow    uint              `json:"deletionWindow"`
+	ReclaimWindow     uint              `json:"reclaimWindow"`
 	DefaultLang       string            `json:"defaultLang"`
 	DefaultCSS        string            `json:"defaultCSS"`
 	ImageRootOverride string            `json:"imageRootOverride"`
@@ -43,9 +66,31 @@ type Public struct {
 // BoardConfigs stores board-specific configuration
 type BoardConfigs struct {
 	BoardPublic
-	DisableRobots bool     `json:"disableRobots"`
-	ID            string   `json:"id"`
-	Eightball     []string `json:"eightball"`
+	DisableRobots  bool     `json:"disableRobots"`
+	DisableCaptcha bool     `json:"disableCaptcha"`
+	ID             string   `json:"id"`
+	ProxyPolicy    string   `json:"proxyPolicy"`
+	Eightball      []string `json:"eightball"`
+
+	// Visibility level of the board. Empty is the same as
+	// common.VisibilityPublic.
+	Visibility string `json:"visibility"`
+
+	// Number of identical post bodies within DuplicateWindow seconds across
+	// all boards, after which DuplicatePolicy is applied. 0 disables.
+	DuplicateLimit  uint   `json:"duplicateLimit"`
+	DuplicateWindow uint   `json:"duplicateWindow"`
+	DuplicatePolicy string `json:"duplicatePolicy"`
+
+	// Post and thread lifecycle events are sent to this URL, if set, and
+	// signed with WebhookSecret
+	WebhookURL    string `json:"webhookURL"`
+	WebhookSecret string `json:"webhookSecret"`
+}
+
+// Returns, if the board is listed in board navigation and included in /all/
+func (c BoardConfigs) listed() bool {
+	return c.Visibility == "" || c.Visibility == common.VisibilityPublic
 }
 
 // BoardPublic contains publically accessible board-specific configurations
@@ -57,11 +102,33 @@ type BoardPublic struct {
 	NSFW       bool
 	RbText     bool   `json:"rbText"`
 	Pyu        bool   `json:"pyu"`
+	PosterIDs  bool   `json:"posterIDs"`
 	DefaultCSS string `json:"defaultCSS"`
 	Title      string `json:"title"`
 	Notice     string `json:"notice"`
 	Rules      string `json:"rules"`
 
+	// Language to serve all clients of the board in. Clients are served in
+	// their preferred language, if empty.
+	DefaultLang string `json:"defaultLang"`
+
+	// Push the internet radio's currently playing song to clients of the
+	// board
+	RadioBanner bool `json:"radioBanner"`
+
+	// Only publish posts to other clients once closed, instead of streaming
+	// them as they are being written
+	HideOpenPosts bool `json:"hideOpenPosts"`
+
+	// Overrides of global post limits. Zero values use the global defaults.
+	// MaxBodyLength can only lower the global maximum body length.
+	// PostCooldown is the minimum number of seconds between posts from the
+	// same IP on the board. FileTypes lists the extensions of allowed upload
+	// file types. All are allowed, if empty.
+	MaxBodyLength uint     `json:"maxBodyLength"`
+	PostCooldown  uint     `json:"postCooldown"`
+	FileTypes     []string `json:"fileTypes"`
+
 	// Can't use []uint8, because it marshals to string
 	Banners []uint16 `json:"banners"`
 }
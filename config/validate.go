@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"github.com/bakape/meguca/common"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// ValidationError lists all problems found in a configuration
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration:\n\t" + strings.Join(e, "\n\t")
+}
+
+// Validate asserts the configuration is suitable for use. Returns a
+// ValidationError listing all found problems, if any.
+func (c *Configs) Validate() error {
+	var errs ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if len(c.CaptchaTags) < 3 {
+		fail("too few captcha tags")
+	}
+	if c.AuditSampling > 100 {
+		fail("audit sampling exceeds 100%%")
+	}
+
+	if c.MaxSize == 0 {
+		fail("maxSize must be positive")
+	}
+	if c.MaxWidth == 0 || c.MaxHeight == 0 {
+		fail("maxWidth and maxHeight must be positive")
+	}
+	if c.SessionExpiry == 0 {
+		fail("sessionExpiry must be positive")
+	}
+	if c.IPRetention == 0 {
+		fail("ipRetention must be positive")
+	}
+	if c.PruneBoards && c.BoardExpiry == 0 {
+		fail("boardExpiry must be positive, if pruneBoards is enabled")
+	}
+	if c.PruneThreads {
+		if c.ThreadExpiryMin == 0 {
+			fail("threadExpiryMin must be positive, if pruneThreads is enabled")
+		}
+		if c.ThreadExpiryMin > c.ThreadExpiryMax {
+			fail("threadExpiryMin exceeds threadExpiryMax")
+		}
+	}
+
+	if !contains(common.Langs, c.DefaultLang) {
+		fail("invalid defaultLang: %q", c.DefaultLang)
+	}
+	if !contains(common.Themes, c.DefaultCSS) {
+		fail("invalid defaultCSS: %q", c.DefaultCSS)
+	}
+	if u, err := url.Parse(c.RootURL); err != nil || u.Scheme == "" ||
+		u.Host == "" {
+		fail("invalid rootURL: %q", c.RootURL)
+	}
+	if c.ImageRootOverride != "" {
+		if _, err := url.Parse(c.ImageRootOverride); err != nil {
+			fail("invalid imageRootOverride: %q", c.ImageRootOverride)
+		}
+	}
+
+	if c.AnimatedThumbs {
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			fail("ffmpeg executable not found, but animatedThumbs is enabled")
+		}
+	}
+
+	if c.EmailErr {
+		if c.EmailErrPort == 0 || c.EmailErrPort > 65535 {
+			fail("emailErrPort out of range: %d", c.EmailErrPort)
+		}
+		for _, f := range [...]struct{ name, val string }{
+			{"emailErrSub", c.EmailErrSub},
+			{"emailErrMail", c.EmailErrMail},
+			{"emailErrPass", c.EmailErrPass},
+		} {
+			if f.val == "" {
+				fail("%s required, if emailErr is enabled", f.name)
+			}
+		}
+		if c.EmailErrCap == 0 {
+			fail("emailErrCap must be positive, if emailErr is enabled")
+		}
+	}
+
+	if c.WebhookErr {
+		u, err := url.Parse(c.WebhookErrURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			fail("invalid webhookErrURL: %q", c.WebhookErrURL)
+		}
+		if c.WebhookErrCap == 0 {
+			fail("webhookErrCap must be positive, if webhookErr is enabled")
+		}
+	}
+
+	if c.RadioAPI != "" {
+		u, err := url.Parse(c.RadioAPI)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			fail("invalid radioAPI: %q", c.RadioAPI)
+		}
+	}
+
+	if c.SentryDSN != "" {
+		u, err := url.Parse(c.SentryDSN)
+		if err != nil || u.User == nil || u.Host == "" ||
+			strings.Trim(u.Path, "/") == "" {
+			fail("invalid sentryDSN")
+		}
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+func contains(arr []string, s string) bool {
+	for _, a := range arr {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
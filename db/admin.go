@@ -7,6 +7,7 @@ import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/imager/assets"
 	"strconv"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 )
@@ -18,9 +19,31 @@ func logModeration(tx *sql.Tx, e auth.ModLogEntry) (err error) {
 		Values(e.Type, e.Board, e.ID, e.By, e.Length, e.Data).
 		RunWith(tx).
 		Exec()
+	if err != nil || e.ID == 0 {
+		return
+	}
+
+	// Any moderation action on a reported post resolves the reports
+	_, err = sq.Update("reports").
+		Set("resolved", squirrel.Expr("now() at time zone 'utc'")).
+		Set("resolved_by", e.By).
+		Where("target = ? and resolved is null", e.ID).
+		RunWith(tx).
+		Exec()
 	return
 }
 
+// LogModeration writes a moderation action not targeting any specific post,
+// like configuration changes, to the board's moderation log
+func LogModeration(board string, entry common.ModerationEntry) error {
+	return InTransaction(false, func(tx *sql.Tx) error {
+		return logModeration(tx, auth.ModLogEntry{
+			ModerationEntry: entry,
+			Board:           board,
+		})
+	})
+}
+
 // DeletePost marks the target post as deleted
 func DeletePost(id uint64, by string) error {
 	return moderatePost(id,
@@ -54,6 +77,10 @@ func PurgePost(id uint64, by, reason string) (err error) {
 			}
 		}
 
+		err = writePostRevision(tx, post.ID, common.RevisionRedaction, by)
+		if err != nil {
+			return
+		}
 		_, err = sq.
 			Update("posts").
 			Set("body", "").
@@ -260,12 +287,17 @@ func GetSameIPPosts(id uint64, board string, by string) (
 }
 
 // SetThreadSticky sets the sticky field on a thread
-func SetThreadSticky(id uint64, sticky bool) error {
-	_, err := sq.Update("threads").
+func SetThreadSticky(id uint64, sticky bool, by string) error {
+	q := sq.Update("threads").
 		Set("sticky", sticky).
-		Where("id = ?", id).
-		Exec()
-	return err
+		Where("id = ?", id)
+	return moderatePost(id,
+		common.ModerationEntry{
+			Type: common.StickyThread,
+			By:   by,
+			Data: strconv.FormatBool(sticky),
+		},
+		&q)
 }
 
 // SetThreadLock sets the ability of users to post in a specific thread
@@ -282,28 +314,335 @@ func SetThreadLock(id uint64, locked bool, by string) error {
 		&q)
 }
 
-// GetModLog retrieves the moderation log for a specific board
-func GetModLog(board string) (log []auth.ModLogEntry, err error) {
-	log = make([]auth.ModLogEntry, 0, 64)
-	e := auth.ModLogEntry{Board: board}
-	err = queryAll(
-		sq.Select("type", "post_id", "by", "created", "length", "data").
-			From("mod_log").
-			Where("board = ?", board).
-			OrderBy("created desc"),
-		func(r *sql.Rows) (err error) {
-			err = r.Scan(&e.Type, &e.ID, &e.By, &e.Created, &e.Length,
-				&e.Data)
+// MoveThread moves a thread with all its posts to another board. Requests to
+// the old location are redirected to the new one. Returns the IDs of threads
+// containing posts, that link to the moved posts.
+func MoveThread(id uint64, board, by string) (linking []uint64, err error) {
+	from, err := getThreadBoard(id)
+	if err != nil {
+		return
+	}
+	if from == board {
+		err = common.ErrInvalidInput("thread already on board")
+		return
+	}
+
+	err = InTransaction(false, func(tx *sql.Tx) (err error) {
+		linking, err = getLinkingThreads(tx, id)
+		if err != nil {
+			return
+		}
+		err = logModeration(tx, auth.ModLogEntry{
+			ModerationEntry: common.ModerationEntry{
+				Type: common.MoveThread,
+				By:   by,
+				Data: fmt.Sprintf("/%s/%d", board, id),
+			},
+			ID:    id,
+			Board: from,
+		})
+		if err != nil {
+			return
+		}
+
+		for table, col := range map[string]string{
+			"threads": "id",
+			"posts":   "op",
+		} {
+			_, err = sq.Update(table).
+				Set("board", board).
+				Where(col+" = ?", id).
+				RunWith(tx).
+				Exec()
 			if err != nil {
 				return
 			}
-			log = append(log, e)
+		}
+		// Thread might be moved back to a board it was moved from before
+		_, err = sq.Delete("thread_redirects").
+			Where("board = ? and id = ?", board, id).
+			RunWith(tx).
+			Exec()
+		if err != nil {
 			return
-		},
+		}
+		err = setThreadRedirect(tx, from, id, id)
+		if err != nil {
+			return
+		}
+
+		// Evict all caches of the old location and restore the post count of
+		// the thread, as the eviction also clears it
+		_, err = tx.Exec(`select pg_notify('thread_deleted', $1)`,
+			fmt.Sprintf("%s,%d", from, id))
+		if err != nil {
+			return
+		}
+		return notifyPostCount(tx, id)
+	})
+	return
+}
+
+// MergeThread moves all posts of thread source into thread target and deletes
+// source. Requests to the location of source are redirected to target.
+// Returns the IDs of threads containing posts, that link to the moved posts.
+func MergeThread(source, target uint64, by string) (
+	linking []uint64, err error,
+) {
+	if source == target {
+		err = common.ErrInvalidInput("can not merge thread into itself")
+		return
+	}
+	sourceBoard, err := getThreadBoard(source)
+	if err != nil {
+		return
+	}
+	targetBoard, err := getThreadBoard(target)
+	if err != nil {
+		return
+	}
+	var encrypted [2]bool
+	for i, id := range [...]uint64{source, target} {
+		encrypted[i], err = CheckThreadEncrypted(id)
+		if err != nil {
+			return
+		}
+	}
+	if encrypted[0] != encrypted[1] {
+		err = common.ErrInvalidInput(
+			"can not merge encrypted and unencrypted threads")
+		return
+	}
+
+	entry := common.ModerationEntry{
+		Type: common.MergeThread,
+		By:   by,
+		Data: fmt.Sprintf("/%s/%d", targetBoard, target),
+	}
+	err = InTransaction(false, func(tx *sql.Tx) (err error) {
+		linking, err = getLinkingThreads(tx, source)
+		if err != nil {
+			return
+		}
+
+		// Must be logged before the posts are moved to notify clients synced
+		// to source
+		err = logModeration(tx, auth.ModLogEntry{
+			ModerationEntry: entry,
+			ID:              source,
+			Board:           sourceBoard,
+		})
+		if err != nil {
+			return
+		}
+
+		// Redirects to source must be retargeted before source is deleted
+		_, err = sq.Update("thread_redirects").
+			Set("target", target).
+			Where("target = ?", source).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return
+		}
+		err = setThreadRedirect(tx, sourceBoard, source, target)
+		if err != nil {
+			return
+		}
+
+		for _, table := range [...]string{"posts", "post_reservations"} {
+			q := sq.Update(table).
+				Set("op", target).
+				Where("op = ?", source)
+			if table == "posts" {
+				q = q.Set("board", targetBoard)
+			}
+			_, err = q.RunWith(tx).Exec()
+			if err != nil {
+				return
+			}
+		}
+		_, err = sq.Delete("threads").
+			Where("id = ?", source).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return
+		}
+
+		// Notifies clients synced to target of the merged posts
+		err = logModeration(tx, auth.ModLogEntry{
+			ModerationEntry: entry,
+			ID:              target,
+			Board:           targetBoard,
+		})
+		if err != nil {
+			return
+		}
+		return notifyPostCount(tx, target)
+	})
+	return
+}
+
+// Returns the board of a thread. Returns common.ErrInvalidInput, if id is not
+// a thread.
+func getThreadBoard(id uint64) (board string, err error) {
+	board, op, err := GetPostParenthood(id)
+	switch {
+	case err == sql.ErrNoRows:
+		err = common.ErrInvalidInput("thread does not exist")
+	case err == nil && op != id:
+		err = common.ErrInvalidInput("not a thread")
+	}
+	return
+}
+
+// Returns the IDs of threads containing posts, that link to posts in thread op
+func getLinkingThreads(tx *sql.Tx, op uint64) (threads []uint64, err error) {
+	r, err := tx.Query(
+		`select distinct source.op
+		from links as l
+		join posts as source on source.id = l.source
+		join posts as target on target.id = l.target
+		where target.op = $1 and source.op != $1`,
+		op,
 	)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	for r.Next() {
+		var id uint64
+		err = r.Scan(&id)
+		if err != nil {
+			return
+		}
+		threads = append(threads, id)
+	}
+	err = r.Err()
 	return
 }
 
+// Redirect requests to thread id on board to thread target
+func setThreadRedirect(tx *sql.Tx, board string, id, target uint64) error {
+	_, err := tx.Exec(
+		`insert into thread_redirects (board, id, target)
+		values ($1, $2, $3)
+		on conflict (board, id) do update set target = excluded.target`,
+		board, id, target,
+	)
+	return err
+}
+
+// Send the current post count of a thread to the post count caches
+func notifyPostCount(tx *sql.Tx, op uint64) error {
+	_, err := tx.Exec(
+		`select pg_notify('new_post_in_thread',
+			concat_ws(',', $1::bigint, post_count($1)))`,
+		op,
+	)
+	return err
+}
+
+// GetThreadRedirect returns the current location of a thread moved or merged
+// from id on board. Returns sql.ErrNoRows, if there is no such redirect.
+func GetThreadRedirect(board string, id uint64) (
+	targetBoard string, target uint64, err error,
+) {
+	err = sq.Select("t.board", "t.id").
+		From("thread_redirects as r").
+		Join("threads as t on t.id = r.target").
+		Where("r.board = ? and r.id = ?", board, id).
+		QueryRow().
+		Scan(&targetBoard, &target)
+	return
+}
+
+// ModLogFilter restricts the moderation log entries returned by FilterModLog.
+// Zero value fields do not restrict the result.
+type ModLogFilter struct {
+	Types        []common.ModerationAction
+	By           string
+	Since, Until time.Time
+	Limit        uint64
+	Offset       uint64
+
+	// Exclude entries of actions, that must not be exposed to the public
+	Public bool
+}
+
+// GetModLog retrieves the moderation log for a specific board
+func GetModLog(board string) ([]auth.ModLogEntry, error) {
+	return FilterModLog(board, ModLogFilter{})
+}
+
+// FilterModLog retrieves the moderation log entries of a specific board
+// matching filter
+func FilterModLog(board string, filter ModLogFilter) (
+	log []auth.ModLogEntry, err error,
+) {
+	q := filter.apply(board,
+		sq.Select("type", "post_id", "by", "created", "length", "data")).
+		OrderBy("created desc", "id desc")
+	if filter.Limit != 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset != 0 {
+		q = q.Offset(filter.Offset)
+	}
+
+	log = make([]auth.ModLogEntry, 0, 64)
+	e := auth.ModLogEntry{Board: board}
+	err = queryAll(q, func(r *sql.Rows) (err error) {
+		err = r.Scan(&e.Type, &e.ID, &e.By, &e.Created, &e.Length, &e.Data)
+		if err != nil {
+			return
+		}
+		log = append(log, e)
+		return
+	})
+	return
+}
+
+// CountModLog returns the number of moderation log entries of a specific board
+// matching filter. The Limit and Offset fields of filter are ignored.
+func CountModLog(board string, filter ModLogFilter) (n uint64, err error) {
+	err = filter.apply(board, sq.Select("count(*)")).
+		QueryRow().
+		Scan(&n)
+	return
+}
+
+// Restrict a query of the moderation log to entries of board matching filter
+func (filter ModLogFilter) apply(board string, q squirrel.SelectBuilder,
+) squirrel.SelectBuilder {
+	q = q.From("mod_log").Where("board = ?", board)
+	if len(filter.Types) != 0 {
+		types := make([]int, len(filter.Types))
+		for i, t := range filter.Types {
+			types[i] = int(t)
+		}
+		q = q.Where(squirrel.Eq{"type": types})
+	}
+	if filter.By != "" {
+		q = q.Where("by = ?", filter.By)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("created >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("created < ?", filter.Until.UTC())
+	}
+	if filter.Public {
+		types := make([]int, len(common.StaffOnlyActions))
+		for i, t := range common.StaffOnlyActions {
+			types[i] = int(t)
+		}
+		q = q.Where(squirrel.NotEq{"type": types})
+	}
+	return q
+}
+
 // GetModLog retrieves the moderation log entry by ID
 func GetModLogEntry(id uint64) (e auth.ModLogEntry, err error) {
 	err = sq.
@@ -316,3 +655,111 @@ func GetModLogEntry(id uint64) (e auth.ModLogEntry, err error) {
 			&e.Data)
 	return
 }
+
+// PostModeration identifies a moderation log entry of a post and the post's
+// thread
+type PostModeration struct {
+	OP, LogID uint64
+}
+
+// GetLastModLogID returns the ID of the latest moderation log entry
+func GetLastModLogID() (id uint64, err error) {
+	err = sq.Select("coalesce(max(id), 0)").
+		From("mod_log").
+		QueryRow().
+		Scan(&id)
+	return
+}
+
+// GetPostModerationsSince returns post moderations logged after moderation log
+// entry id in logging order
+func GetPostModerationsSince(id uint64) (mods []PostModeration, err error) {
+	err = queryAll(
+		sq.Select("m.id", "p.op").
+			From("mod_log m").
+			Join("posts p on p.id = m.post_id").
+			Where("m.id > ? and m.post_id != 0", id).
+			OrderBy("m.id"),
+		func(r *sql.Rows) (err error) {
+			var m PostModeration
+			err = r.Scan(&m.LogID, &m.OP)
+			if err != nil {
+				return
+			}
+			mods = append(mods, m)
+			return
+		},
+	)
+	return
+}
+
+// UnhidePost makes a post of a shadow banned poster visible to everyone
+func UnhidePost(id uint64, by string) error {
+	return InTransaction(false, func(tx *sql.Tx) (err error) {
+		var (
+			op    uint64
+			board string
+		)
+		err = sq.Update("posts").
+			Set("shadowed", false).
+			Where("id = ?", id).
+			Suffix("returning op, board").
+			RunWith(tx).
+			QueryRow().
+			Scan(&op, &board)
+		if err != nil {
+			return
+		}
+		err = logModeration(tx, auth.ModLogEntry{
+			ModerationEntry: common.ModerationEntry{
+				Type: common.UnhidePost,
+				By:   by,
+			},
+			Board: board,
+			ID:    id,
+		})
+		if err != nil {
+			return
+		}
+		return notifyPostCount(tx, op)
+	})
+}
+
+// GetHiddenPosts returns posts of shadow banned posters on a board, newest
+// first
+func GetHiddenPosts(board string) (posts []common.StandalonePost, err error) {
+	ids := make([]uint64, 0, 64)
+	err = queryAll(
+		sq.Select("id").
+			From("posts").
+			Where("board = ? and shadowed", board).
+			OrderBy("id desc"),
+		func(r *sql.Rows) (err error) {
+			var id uint64
+			err = r.Scan(&id)
+			if err != nil {
+				return
+			}
+			ids = append(ids, id)
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	posts = make([]common.StandalonePost, 0, len(ids))
+	for _, id := range ids {
+		var post common.StandalonePost
+		post, err = GetPost(id)
+		switch err {
+		case nil:
+			posts = append(posts, post)
+		case sql.ErrNoRows: // Deleted in race
+			err = nil
+		default:
+			return
+		}
+	}
+	return
+}
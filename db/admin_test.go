@@ -3,8 +3,11 @@ package db
 import (
 	"database/sql"
 	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/test"
 	"testing"
+	"time"
 )
 
 func prepareForModeration(t *testing.T) {
@@ -70,7 +73,7 @@ func TestStickyThread(t *testing.T) {
 	for i := range cases {
 		c := cases[i]
 		t.Run(c.name, func(t *testing.T) {
-			if err := SetThreadSticky(1, c.sticky); err != nil {
+			if err := SetThreadSticky(1, c.sticky, "admin"); err != nil {
 				t.Fatal(err)
 			}
 		})
@@ -98,6 +101,145 @@ func TestLockThread(t *testing.T) {
 	}
 }
 
+// Write board "c" with thread 2 and reply 3, that links to post 1
+func writeMergeableThread(t *testing.T) {
+	t.Helper()
+
+	err := InTransaction(false, func(tx *sql.Tx) error {
+		return WriteBoard(tx, BoardConfigs{
+			BoardConfigs: config.BoardConfigs{
+				ID:        "c",
+				Eightball: []string{"yes"},
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = WriteThread(
+		Thread{
+			ID:    2,
+			Board: "c",
+		},
+		Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:   2,
+					Time: time.Now().Unix(),
+				},
+				OP:    2,
+				Board: "c",
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = InTransaction(false, func(tx *sql.Tx) error {
+		return WritePost(tx, Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:   3,
+					Time: time.Now().Unix(),
+					Links: []common.Link{
+						{ID: 1, OP: 1, Board: "a"},
+					},
+				},
+				OP:    2,
+				Board: "c",
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveThread(t *testing.T) {
+	prepareForModeration(t)
+	writeMergeableThread(t)
+
+	_, err := MoveThread(3, "a", "admin")
+	if err == nil {
+		t.Fatal("moved reply")
+	}
+
+	linking, err := MoveThread(2, "a", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, len(linking), 0)
+	for _, id := range [...]uint64{2, 3} {
+		board, err := GetPostBoard(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.AssertDeepEquals(t, board, "a")
+	}
+
+	board, op, err := GetThreadRedirect("c", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, board, "a")
+	test.AssertDeepEquals(t, op, uint64(2))
+
+	// Moving back removes the redirect from the new location
+	_, err = MoveThread(2, "c", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = GetThreadRedirect("c", 2)
+	test.AssertDeepEquals(t, err, sql.ErrNoRows)
+	board, _, err = GetThreadRedirect("a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, board, "c")
+}
+
+func TestMergeThread(t *testing.T) {
+	prepareForModeration(t)
+	writeMergeableThread(t)
+
+	_, err := MergeThread(2, 2, "admin")
+	if err == nil {
+		t.Fatal("merged thread into itself")
+	}
+
+	linking, err := MergeThread(1, 2, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, linking, []uint64{2})
+
+	board, op, err := GetPostParenthood(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, board, "c")
+	test.AssertDeepEquals(t, op, uint64(2))
+
+	valid, err := ValidateOP(1, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, valid, false)
+
+	board, op, err = GetThreadRedirect("a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, board, "c")
+	test.AssertDeepEquals(t, op, uint64(2))
+
+	thread, err := GetThread(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, len(thread.Posts), 2)
+}
+
 func TestStaff(t *testing.T) {
 	prepareForModeration(t)
 
@@ -137,6 +279,67 @@ func TestGetModLog(t *testing.T) {
 	}
 }
 
+func TestFilterModLog(t *testing.T) {
+	prepareForModeration(t)
+
+	err := SetThreadSticky(1, true, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range [...]common.ModerationAction{
+		common.ConfigureBoard,
+		common.ShadowBanPost,
+	} {
+		err = LogModeration("a", common.ModerationEntry{
+			Type: typ,
+			By:   "admin",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := [...]struct {
+		name   string
+		filter ModLogFilter
+		count  int
+	}{
+		{"no filter", ModLogFilter{}, 3},
+		{
+			"by type",
+			ModLogFilter{
+				Types: []common.ModerationAction{common.ConfigureBoard},
+			},
+			1,
+		},
+		{"by user", ModLogFilter{By: "nobody"}, 0},
+		{"limit", ModLogFilter{Limit: 1}, 1},
+		{"offset", ModLogFilter{Offset: 1}, 2},
+		{"public", ModLogFilter{Public: true}, 2},
+		{"since", ModLogFilter{Since: time.Now().Add(time.Hour)}, 0},
+		{"until", ModLogFilter{Until: time.Now().Add(-time.Hour)}, 0},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			log, err := FilterModLog("a", c.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.AssertDeepEquals(t, len(log), c.count)
+		})
+	}
+
+	t.Run("count", func(t *testing.T) {
+		n, err := CountModLog("a", ModLogFilter{Public: true, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.AssertDeepEquals(t, n, uint64(2))
+	})
+}
+
 func TestGetModLogEntry(t *testing.T) {
 	t.Run("ban_unban", TestBanUnban) // So we have something in the log
 
@@ -191,3 +394,47 @@ func TestCanPerform(t *testing.T) {
 		})
 	}
 }
+
+func TestUnhidePost(t *testing.T) {
+	prepareForModeration(t)
+
+	err := InTransaction(false, func(tx *sql.Tx) error {
+		return WritePost(tx, Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:       2,
+					Time:     time.Now().Unix(),
+					Shadowed: true,
+				},
+				OP:    1,
+				Board: "a",
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertVisible := func(visible bool) {
+		t.Helper()
+
+		thread, err := GetThread(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.AssertDeepEquals(t, len(thread.Posts) == 1, visible)
+
+		hidden, err := GetHiddenPosts("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.AssertDeepEquals(t, len(hidden) == 0, visible)
+	}
+
+	assertVisible(false)
+	err = UnhidePost(2, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertVisible(true)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bakape/meguca/common"
+)
+
+// CreateAnnouncementBanner writes a new announcement banner and sets its ID
+func CreateAnnouncementBanner(b *common.AnnouncementBanner) error {
+	return InTransaction(false, func(tx *sql.Tx) (err error) {
+		err = sq.Insert("announcement_banners").
+			Columns("board", "body", "start_time", "end_time").
+			Values(b.Board, b.Body, b.Start, b.End).
+			Suffix("returning id").
+			RunWith(tx).
+			QueryRow().
+			Scan(&b.ID)
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec("notify announcement_banners_updated")
+		return
+	})
+}
+
+func selectAnnouncementBanners() squirrel.SelectBuilder {
+	return sq.Select("id", "board", "body", "start_time", "end_time").
+		From("announcement_banners")
+}
+
+func scanAnnouncementBanner(r rowScanner) (
+	b common.AnnouncementBanner, err error,
+) {
+	err = r.Scan(&b.ID, &b.Board, &b.Body, &b.Start, &b.End)
+	return
+}
+
+// GetAnnouncementBanner retrieves an announcement banner by ID
+func GetAnnouncementBanner(id uint64) (common.AnnouncementBanner, error) {
+	return scanAnnouncementBanner(selectAnnouncementBanners().
+		Where("id = ?", id).
+		QueryRow())
+}
+
+// DeleteAnnouncementBanner deletes an announcement banner by ID
+func DeleteAnnouncementBanner(id uint64) error {
+	return InTransaction(false, func(tx *sql.Tx) (err error) {
+		_, err = sq.Delete("announcement_banners").
+			Where("id = ?", id).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec("notify announcement_banners_updated")
+		return
+	})
+}
+
+// GetAnnouncementBanners retrieves all announcement banners, that have not yet
+// ended, in creation order
+func GetAnnouncementBanners() (banners []common.AnnouncementBanner, err error) {
+	banners = make([]common.AnnouncementBanner, 0, 4)
+	err = queryAll(
+		selectAnnouncementBanners().
+			Where(`end_time = 0
+				or end_time > floor(extract(epoch from now()))`).
+			OrderBy("id"),
+		func(r *sql.Rows) (err error) {
+			b, err := scanAnnouncementBanner(r)
+			if err != nil {
+				return
+			}
+			banners = append(banners, b)
+			return
+		},
+	)
+	return
+}
+
+// ListenAnnouncementBanners calls fn, after any announcement banner is created
+// or deleted, and after the connection to the database has been restored
+func ListenAnnouncementBanners(fn func() error) error {
+	return ListenResync("announcement_banners_updated", func(_ string) error {
+		return fn()
+	}, fn)
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+	"testing"
+	"time"
+)
+
+func TestAnnouncementBanners(t *testing.T) {
+	assertTableClear(t, "announcement_banners")
+
+	now := time.Now().Unix()
+	banners := []common.AnnouncementBanner{
+		{Board: "all", Body: "global"},
+		{Board: "a", Body: "scheduled", Start: now + 3600, End: now + 7200},
+		{Board: "a", Body: "ended", Start: now - 7200, End: now - 3600},
+	}
+	for i := range banners {
+		err := CreateAnnouncementBanner(&banners[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if banners[i].ID == 0 {
+			t.Fatal("no banner ID set")
+		}
+	}
+
+	b, err := GetAnnouncementBanner(banners[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, b, banners[1])
+
+	res, err := GetAnnouncementBanners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, res, banners[:2])
+
+	err = DeleteAnnouncementBanner(banners[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetAnnouncementBanner(banners[0].ID)
+	if err != sql.ErrNoRows {
+		UnexpectedError(t, err)
+	}
+	res, err = GetAnnouncementBanners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, res, banners[1:2])
+}
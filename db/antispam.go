@@ -134,7 +134,7 @@ func IncrementSpamScore(ip string, increment time.Duration) {
 
 	spamMu.Lock()
 	defer spamMu.Unlock()
-	spamScoreBuffer[ip] += increment
+	spamScoreBuffer[HashIP(ip)] += increment
 }
 
 // resetSpamScore resets a spam score to zero by IP
@@ -142,6 +142,7 @@ func resetSpamScore(ip string) (err error) {
 	if !config.Get().Captcha {
 		return
 	}
+	ip = HashIP(ip)
 	spamMu.Lock()
 	defer spamMu.Unlock()
 	delete(spamScoreBuffer, ip)
@@ -149,6 +150,15 @@ func resetSpamScore(ip string) (err error) {
 	return
 }
 
+// NeedBoardCaptcha returns, if the user needs a captcha to post on a board.
+// Boards can exempt their posters from captchas.
+func NeedBoardCaptcha(board, ip string) (bool, error) {
+	if config.GetBoardConfigs(board).DisableCaptcha {
+		return false, nil
+	}
+	return NeedCaptcha(ip)
+}
+
 // NeedCaptcha returns, if the user needs a captcha
 // to proceed with usage of server resources
 func NeedCaptcha(ip string) (need bool, err error) {
@@ -175,6 +185,7 @@ func NeedCaptcha(ip string) (need bool, err error) {
 
 // Merge cached and DB value and return current score
 func getSpamScore(ip string) (score time.Time, err error) {
+	ip = HashIP(ip)
 	spamMu.RLock()
 	defer spamMu.RUnlock()
 
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bakape/meguca/auth"
+	"github.com/lib/pq"
+)
+
+// ErrNoTokenOwner is returned, when creating an API token owned by an account
+// that does not exist
+var ErrNoTokenOwner = errors.New("token owner account does not exist")
+
+// CreateAPIToken writes a new API token identified by hash and sets its
+// database ID and creation time
+func CreateAPIToken(t *auth.APIToken, hash string) (err error) {
+	err = sq.Insert("api_tokens").
+		Columns("hash", "name", "owner", "scopes", "rate_limit",
+			"rate_interval").
+		Values(hash, t.Name, t.Owner, pq.StringArray(t.Scopes), t.RateLimit,
+			t.RateInterval).
+		Suffix("returning id, created").
+		QueryRow().
+		Scan(&t.ID, &t.Created)
+	if err, ok := err.(*pq.Error); ok &&
+		err.Code.Name() == "foreign_key_violation" {
+		return ErrNoTokenOwner
+	}
+	return
+}
+
+func selectAPITokens() squirrel.SelectBuilder {
+	return sq.Select("id", "name", "owner", "scopes", "rate_limit",
+		"rate_interval", "created", "last_used", "requests", "limited").
+		From("api_tokens")
+}
+
+func scanAPIToken(r rowScanner) (t auth.APIToken, err error) {
+	var scopes pq.StringArray
+	err = r.Scan(&t.ID, &t.Name, &t.Owner, &scopes, &t.RateLimit,
+		&t.RateInterval, &t.Created, &t.LastUsed, &t.Requests, &t.Limited)
+	t.Scopes = []string(scopes)
+	return
+}
+
+// GetAPIToken retrieves an API token by the hash of the token
+func GetAPIToken(hash string) (auth.APIToken, error) {
+	return scanAPIToken(selectAPITokens().
+		Where("hash = ?", hash).
+		QueryRow())
+}
+
+// GetAPITokens retrieves all API tokens and their usage metrics
+func GetAPITokens() (tokens []auth.APIToken, err error) {
+	tokens = make([]auth.APIToken, 0, 8)
+	err = queryAll(
+		selectAPITokens().OrderBy("id"),
+		func(r *sql.Rows) (err error) {
+			t, err := scanAPIToken(r)
+			if err != nil {
+				return
+			}
+			tokens = append(tokens, t)
+			return
+		},
+	)
+	return
+}
+
+// RevokeAPIToken deletes an API token. Returns sql.ErrNoRows, if no such
+// token exists.
+func RevokeAPIToken(id uint64) (err error) {
+	res, err := sq.Delete("api_tokens").
+		Where("id = ?", id).
+		Exec()
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	return
+}
+
+// RecordAPITokenUsage adds to the usage metrics of an API token.
+// requests is the number of authenticated requests and limited the number of
+// those rejected by the token's rate limit.
+func RecordAPITokenUsage(id, requests, limited uint64, lastUsed time.Time,
+) (err error) {
+	_, err = sq.Update("api_tokens").
+		Set("requests", squirrel.Expr("requests + ?", requests)).
+		Set("limited", squirrel.Expr("limited + ?", limited)).
+		Set("last_used", lastUsed).
+		Where("id = ?", id).
+		Exec()
+	return
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/auth"
+	. "github.com/bakape/meguca/test"
+	"testing"
+	"time"
+)
+
+func TestAPITokens(t *testing.T) {
+	assertTableClear(t, "accounts")
+
+	tok := auth.APIToken{
+		Name:         "bot",
+		Owner:        sampleUserID,
+		Scopes:       []string{auth.ScopeRead, auth.ScopePost},
+		RateLimit:    10,
+		RateInterval: 60,
+	}
+	hash := auth.HashAPIToken("foo")
+
+	err := CreateAPIToken(&tok, hash)
+	if err != ErrNoTokenOwner {
+		UnexpectedError(t, err)
+	}
+
+	writeSampleUser(t)
+	err = CreateAPIToken(&tok, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.ID == 0 {
+		t.Fatal("no token ID set")
+	}
+
+	used := time.Now().Round(time.Second)
+	err = RecordAPITokenUsage(tok.ID, 3, 1, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RecordAPITokenUsage(tok.ID, 2, 0, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := GetAPIToken(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.LastUsed == nil || !res.LastUsed.Equal(used) {
+		t.Fatalf("unexpected last use time: %v", res.LastUsed)
+	}
+	res.LastUsed = nil
+	tok.Requests = 5
+	tok.Limited = 1
+	res.Created = tok.Created
+	AssertDeepEquals(t, res, tok)
+
+	tokens, err := GetAPITokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, len(tokens), 1)
+
+	err = RevokeAPIToken(tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetAPIToken(hash)
+	if err != sql.ErrNoRows {
+		UnexpectedError(t, err)
+	}
+	err = RevokeAPIToken(tok.ID)
+	if err != sql.ErrNoRows {
+		UnexpectedError(t, err)
+	}
+}
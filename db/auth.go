@@ -157,21 +157,48 @@ func GetOwnedBoards(account string) (boards []string, err error) {
 	return
 }
 
+// GetStaffBoards returns boards the account holder holds any staff position
+// on. "all" denotes a global position.
+func GetStaffBoards(account string) (boards []string, err error) {
+	if account == "admin" {
+		return []string{"all"}, nil
+	}
+
+	err = queryAll(
+		sq.Select("distinct board").
+			From("staff").
+			Where("account = ?", account),
+		func(r *sql.Rows) (err error) {
+			var board string
+			err = r.Scan(&board)
+			if err != nil {
+				return
+			}
+			boards = append(boards, board)
+			return
+		},
+	)
+	return
+}
+
 func getBans() squirrel.SelectBuilder {
-	return sq.Select("ip", "board", "forPost", "reason", "by", "expires").
+	return sq.Select("ip", "board", "forPost", "reason", "by", "expires",
+		"shadow").
 		From("bans").
 		Where("expires >= now() at time zone 'utc'")
 }
 
 func scanBanRecord(rs rowScanner) (b auth.BanRecord, err error) {
-	err = rs.Scan(&b.IP, &b.Board, &b.ForPost, &b.Reason, &b.By, &b.Expires)
+	err = rs.Scan(&b.IP, &b.Board, &b.ForPost, &b.Reason, &b.By, &b.Expires,
+		&b.Shadow)
 	return
 }
 
-// GetBanInfo retrieves information about a specific ban
+// GetBanInfo retrieves information about a specific ban. Shadow bans are never
+// disclosed to the banned.
 func GetBanInfo(ip, board string) (auth.BanRecord, error) {
 	r := getBans().
-		Where("ip = ? and board = ?", ip, board).
+		Where("ip = ? and board = ? and not shadow", ip, board).
 		QueryRow()
 	return scanBanRecord(r)
 }
@@ -195,8 +222,9 @@ func GetBoardBans(board string) (b []auth.BanRecord, err error) {
 	return
 }
 
-// GetIP returns an IP of the poster that created a post. Posts older than 7
-// days will not have this information.
+// GetIP returns an IP of the poster that created a post as stored in the
+// database. Posts older than the IP data retention period will not have this
+// information.
 func GetIP(id uint64) (string, error) {
 	var ip sql.NullString
 	err := sq.Select("ip").
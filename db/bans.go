@@ -14,21 +14,33 @@ import (
 var (
 	// board: IP: IsBanned
 	banCache = map[string]map[string]bool{}
-	bansMu   sync.RWMutex
+	// board: IP: IsShadowBanned
+	shadowBanCache = map[string]map[string]bool{}
+	bansMu         sync.RWMutex
 )
 
-func writeBan(tx *sql.Tx, ip string, entry auth.ModLogEntry) (err error) {
+// Write a ban to the ban table. Entries of type common.ShadowBanPost write
+// a shadow ban. Any other type is treated as a regular ban. hashed is the
+// time the stored IP representation was created at, which determines the IP
+// hashing salt, that must be retained for the ban.
+func writeBan(tx *sql.Tx, ip string, hashed time.Time, entry auth.ModLogEntry,
+) (err error) {
+	shadow := entry.Type == common.ShadowBanPost
 	_, err = sq.Insert("bans").
-		Columns("ip", "board", "forPost", "reason", "by", "expires").
+		Columns("ip", "board", "forPost", "reason", "by", "expires", "shadow",
+			"ip_hashed").
 		Values(ip, entry.Board, entry.ID, entry.Data, entry.By,
-			time.Now().UTC().Add(time.Second*time.Duration(entry.Length))).
+			time.Now().UTC().Add(time.Second*time.Duration(entry.Length)),
+			shadow, hashed.UTC()).
 		RunWith(tx).
 		Exec()
 	if err != nil {
 		return
 	}
 
-	entry.Type = common.BanPost // Just in case the caller did not set it
+	if !shadow {
+		entry.Type = common.BanPost // Just in case the caller did not set it
+	}
 	return logModeration(tx, entry)
 }
 
@@ -47,7 +59,7 @@ func propagateBans(board string, ip string) (err error) {
 // Automatically bans an IP
 func SystemBan(ip, reason string, length time.Duration) (err error) {
 	return InTransaction(false, func(tx *sql.Tx) error {
-		return systemBanTx(tx, ip, reason, length)
+		return systemBanTx(tx, HashIP(ip), reason, length)
 	})
 }
 
@@ -55,7 +67,7 @@ func systemBanTx(tx *sql.Tx, ip, reason string, length time.Duration,
 ) (
 	err error,
 ) {
-	return writeBan(tx, ip, auth.ModLogEntry{
+	return writeBan(tx, ip, time.Now(), auth.ModLogEntry{
 		ModerationEntry: common.ModerationEntry{
 			Type:   common.BanPost,
 			Data:   reason,
@@ -75,7 +87,29 @@ func systemBanTx(tx *sql.Tx, ip, reason string, length time.Duration,
 // banned IPs.
 func Ban(board, reason, by string, length time.Duration, id uint64,
 ) (err error) {
-	ip, err := GetIP(id)
+	return ban(board, reason, by, length, id, common.BanPost)
+}
+
+// ShadowBan bans the IP of a post's author from a specific board without
+// notifying them. Their new posts are accepted, but hidden from everyone
+// except themselves and staff.
+func ShadowBan(board, reason, by string, length time.Duration, id uint64,
+) (err error) {
+	return ban(board, reason, by, length, id, common.ShadowBanPost)
+}
+
+func ban(board, reason, by string, length time.Duration, id uint64,
+	typ common.ModerationAction,
+) (err error) {
+	var (
+		ip       sql.NullString
+		postTime int64
+	)
+	err = sq.Select("ip", "time").
+		From("posts").
+		Where("id = ?", id).
+		QueryRow().
+		Scan(&ip, &postTime)
 	switch err {
 	case nil:
 	case sql.ErrNoRows:
@@ -84,11 +118,14 @@ func Ban(board, reason, by string, length time.Duration, id uint64,
 		return
 	}
 
+	// The post's IP was hashed on post creation
+	hashed := time.Unix(postTime, 0)
+
 	// Write ban messages to posts and ban table
 	err = InTransaction(false, func(tx *sql.Tx) (err error) {
-		return writeBan(tx, ip, auth.ModLogEntry{
+		return writeBan(tx, ip.String, hashed, auth.ModLogEntry{
 			ModerationEntry: common.ModerationEntry{
-				Type:   common.BanPost,
+				Type:   typ,
 				Length: uint64(length / time.Second),
 				By:     by,
 				Data:   reason,
@@ -101,7 +138,12 @@ func Ban(board, reason, by string, length time.Duration, id uint64,
 		return
 	}
 
-	return propagateBans(board, ip)
+	if typ == common.ShadowBanPost {
+		// Shadow banned clients must stay connected and unaware
+		_, err = db.Exec(`notify bans_updated`)
+		return
+	}
+	return propagateBans(board, ip.String)
 }
 
 // Unban lifts a ban from a specific post on a specific board
@@ -134,66 +176,152 @@ func loadBans() error {
 	if err := RefreshBanCache(); err != nil {
 		return err
 	}
-	return Listen("bans_updated", func(_ string) error {
+	return ListenResync("bans_updated", func(_ string) error {
 		return RefreshBanCache()
-	})
+	}, RefreshBanCache)
 }
 
 func selectBans(colums ...string) squirrel.SelectBuilder {
 	return sq.Select(colums...).
-		Options("distinct on (ip, board)").
+		Options("distinct on (ip, board, shadow)").
 		From("bans").
 		Where("expires > now() at time zone 'utc'").
-		OrderBy("ip", "board", "expires desc")
+		OrderBy("ip", "board", "shadow", "expires desc")
 }
 
 // RefreshBanCache loads up to date bans from the database and caches them in
 // memory
 func RefreshBanCache() (err error) {
-	bans := make([]auth.Ban, 0, 16)
-	err = queryAll(selectBans("ip", "board"), func(r *sql.Rows) error {
-		var b auth.Ban
-		err := r.Scan(&b.IP, &b.Board)
-		if err != nil {
-			return err
-		}
-		bans = append(bans, b)
-		return nil
-	})
+	var (
+		bans   = map[string]map[string]bool{}
+		shadow = map[string]map[string]bool{}
+	)
+	err = queryAll(
+		selectBans("ip", "board", "shadow"),
+		func(r *sql.Rows) (err error) {
+			var (
+				b        auth.Ban
+				isShadow bool
+			)
+			err = r.Scan(&b.IP, &b.Board, &isShadow)
+			if err != nil {
+				return
+			}
+
+			dst := bans
+			if isShadow {
+				dst = shadow
+			}
+			board, ok := dst[b.Board]
+			if !ok {
+				board = map[string]bool{}
+				dst[b.Board] = board
+			}
+			board[b.IP] = true
+			return
+		},
+	)
 	if err != nil {
 		return
 	}
 
-	new := map[string]map[string]bool{}
-	for _, b := range bans {
-		board, ok := new[b.Board]
-		if !ok {
-			board = map[string]bool{}
-			new[b.Board] = board
-		}
-		board[b.IP] = true
-	}
-
 	bansMu.Lock()
-	banCache = new
+	banCache = bans
+	shadowBanCache = shadow
 	bansMu.Unlock()
 
 	return
 }
 
 // IsBanned checks,  if the IP is banned on the target board or globally
-func IsBanned(board, ip string) error {
+func IsBanned(board, ip string) (err error) {
+	hashes := ipHashes(ip)
+	for i, h := range hashes {
+		err = isBanned(board, h)
+		switch {
+		case err == nil:
+			continue
+		case err != common.ErrBanned:
+			return
+		}
+		if i != 0 {
+			// Carry bans over to the IP's hash under the current salt
+			err = rekeyBans(h, hashes[0])
+			if err != nil {
+				return
+			}
+			err = common.ErrBanned
+		}
+		return
+	}
+	return
+}
+
+// Copy active bans of a stored IP representation to another one
+func rekeyBans(from, to string) (err error) {
+	_, err = db.Exec(
+		`insert into bans (ip, board, forPost, reason, by, expires, shadow,
+			ip_hashed)
+		select $1, board, forPost, reason, by, expires, shadow,
+			now() at time zone 'utc'
+		from bans
+		where ip = $2 and expires > now() at time zone 'utc'
+		on conflict do nothing`,
+		to, from,
+	)
+	if err != nil {
+		return
+	}
+	_, err = db.Exec(`notify bans_updated`)
+	return
+}
+
+// IsShadowBanned checks, if the IP is shadow banned on the target board or
+// globally
+func IsShadowBanned(board, ip string) (banned bool, err error) {
+	for _, h := range ipHashes(ip) {
+		banned, err = isInBanCache(board, h, true)
+		if err != nil || banned {
+			return
+		}
+	}
+	return
+}
+
+// Check, if a stored IP representation is banned on the target board or
+// globally
+func isBanned(board, ip string) error {
+	banned, err := isInBanCache(board, ip, false)
+	switch {
+	case err != nil:
+		return err
+	case banned:
+		return common.ErrBanned
+	default:
+		return nil
+	}
+}
+
+// Check, if a stored IP representation is banned or shadow banned on the
+// target board or globally
+func isInBanCache(board, ip string, shadow bool) (bool, error) {
 	bansMu.RLock()
 	defer bansMu.RUnlock()
-	global := banCache["all"]
-	ips := banCache[board]
+	cache := banCache
+	if shadow {
+		cache = shadowBanCache
+	}
+	global := cache["all"]
+	ips := cache[board]
 
 	if (global != nil && global[ip]) || (ips != nil && ips[ip]) {
 		// Need to assert ban has not expired and cache is invalid
 
-		r, err := selectBans("board").Where("ip = ?", ip).Query()
+		r, err := selectBans("board").
+			Where("ip = ? and shadow = ?", ip, shadow).
+			Query()
 		if err != nil {
-			return err
+			return false, err
 		}
 		defer r.Close()
 
@@ -204,7 +332,7 @@ func IsBanned(board, ip string) error {
 		for r.Next() {
 			err = r.Scan(&resBoard)
 			if err != nil {
-				return err
+				return false, err
 			}
 			if resBoard == "all" || resBoard == board {
 				matched = true
@@ -213,7 +341,7 @@ func IsBanned(board, ip string) error {
 		}
 		err = r.Err()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if matched {
@@ -227,11 +355,9 @@ func IsBanned(board, ip string) error {
 					}
 				}()
 			}
-
-			return common.ErrBanned
 		}
-		return nil
+		return matched, nil
 	}
 
-	return nil
+	return false, nil
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"github.com/bakape/meguca/common"
 	. "github.com/bakape/meguca/test"
 	"testing"
@@ -31,3 +32,33 @@ func TestBanUnban(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestShadowBan(t *testing.T) {
+	prepareForModeration(t)
+
+	err := ShadowBan("a", "test", "admin", time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RefreshBanCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = IsBanned("a", "::1")
+	if err != nil {
+		UnexpectedError(t, err)
+	}
+	for board, std := range map[string]bool{"a": true, "c": false} {
+		banned, err := IsShadowBanned(board, "::1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, banned, std)
+	}
+
+	_, err = GetBanInfo(HashIP("::1"), "a")
+	if err != sql.ErrNoRows {
+		UnexpectedError(t, err)
+	}
+}
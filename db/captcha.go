@@ -25,11 +25,12 @@ func ValidateCaptcha(req auth.Captcha, ip string) (err error) {
 		return
 	}
 	err = captchouli.CheckCaptcha(req.CaptchaID, req.Solution)
+	hashed := HashIP(ip)
 	switch err {
 	case nil:
 		_, err = sq.Insert("last_solved_captchas").
 			Columns("ip").
-			Values(ip).
+			Values(hashed).
 			Suffix(
 				`on conflict (ip) do
 				update set time = now() at time zone 'utc'`).
@@ -41,7 +42,7 @@ func ValidateCaptcha(req auth.Captcha, ip string) (err error) {
 	case captchouli.ErrInvalidSolution:
 		_, err = sq.Insert("failed_captchas").
 			Columns("ip", "expires").
-			Values(ip, time.Now().Add(time.Hour).UTC()).
+			Values(hashed, time.Now().Add(time.Hour).UTC()).
 			Exec()
 		if err != nil {
 			return
@@ -50,7 +51,7 @@ func ValidateCaptcha(req auth.Captcha, ip string) (err error) {
 		var count int
 		err = sq.Select("count(*)").
 			From("failed_captchas").
-			Where("ip = ? and expires > now() at time zone 'utc'", ip).
+			Where("ip = ? and expires > now() at time zone 'utc'", hashed).
 			QueryRow().
 			Scan(&count)
 		if err != nil {
@@ -77,7 +78,7 @@ func SolvedCaptchaRecently(ip string, dur time.Duration) (has bool, err error) {
 	}
 	err = sq.Select("true").
 		From("last_solved_captchas").
-		Where("ip = ? and time > ?", ip, time.Now().UTC().Add(-dur)).
+		Where("ip = ? and time > ?", HashIP(ip), time.Now().UTC().Add(-dur)).
 		QueryRow().
 		Scan(&has)
 	if err == sql.ErrNoRows {
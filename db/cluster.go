@@ -0,0 +1,108 @@
+package db
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Postgres rejects notification payloads of 8000 bytes or more. Larger cluster
+// messages are passed through the cluster_messages table.
+const maxNotifyPayload = 7900
+
+// NotifyCluster sends a message to all instances listening with ListenCluster,
+// including this one
+func NotifyCluster(msg []byte) (err error) {
+	payload := string(msg)
+	if len(payload) > maxNotifyPayload {
+		var id uint64
+		err = sq.Insert("cluster_messages").
+			Columns("payload").
+			Values(payload).
+			Suffix("returning id").
+			QueryRow().
+			Scan(&id)
+		if err != nil {
+			return
+		}
+		payload = "#" + strconv.FormatUint(id, 10)
+	}
+	_, err = db.Exec(`select pg_notify('cluster', $1)`, payload)
+	return
+}
+
+// ListenCluster calls fn with each message sent with NotifyCluster. resync is
+// called after the connection to the database has been restored and must
+// reload any state kept up to date by fn.
+func ListenCluster(fn func(msg []byte) error, resync func() error) error {
+	return ListenResync("cluster", func(payload string) (err error) {
+		if strings.HasPrefix(payload, "#") {
+			var id uint64
+			id, err = strconv.ParseUint(payload[1:], 10, 64)
+			if err != nil {
+				return
+			}
+			err = sq.Select("payload").
+				From("cluster_messages").
+				Where("id = ?", id).
+				QueryRow().
+				Scan(&payload)
+			if err != nil {
+				return
+			}
+		}
+		return fn([]byte(payload))
+	}, resync)
+}
+
+// TakeRateLimitToken consumes a token from a rate limiting bucket shared by
+// all instances. The bucket holds up to capacity tokens and refills at rate
+// tokens per second. Returns, if the request is allowed and, if not, the time
+// after which it can be retried.
+func TakeRateLimitToken(class, key string, capacity, rate float64) (
+	ok bool, wait time.Duration, err error,
+) {
+	// Refill the bucket for the time passed since the last request and take
+	// a token, if at least one is available
+	const refilled = `least($3::float8,
+		rate_limits.tokens
+		+ extract(epoch from (now() at time zone 'utc') - rate_limits.last)
+		::float8 * $4::float8)`
+	var tokens float64
+	err = db.
+		QueryRow(
+			`insert into rate_limits (class, key, tokens, last, allowed)
+			values ($1, $2, $3::float8 - 1, now() at time zone 'utc', true)
+			on conflict (class, key) do update
+			set tokens = case
+					when `+refilled+` >= 1 then `+refilled+` - 1
+					else `+refilled+`
+				end,
+				allowed = `+refilled+` >= 1,
+				last = now() at time zone 'utc'
+			returning tokens, allowed`,
+			class, key, capacity, rate,
+		).
+		Scan(&tokens, &ok)
+	if err != nil || ok {
+		return
+	}
+	wait = time.Duration(math.Max(0, 1-tokens) / rate * float64(time.Second))
+	return
+}
+
+// ClaimModLogEntry marks a moderation log entry as published to the event bus.
+// Returns false, if another instance has already claimed it.
+func ClaimModLogEntry(id uint64) (claimed bool, err error) {
+	res, err := sq.Update("mod_log").
+		Set("published", true).
+		Where("id = ? and not published", id).
+		Exec()
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	claimed = n != 0
+	return
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+	"testing"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	assertTableClear(t, "rate_limits")
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := TakeRateLimitToken("test", "::1", 2, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("request %d rate limited", i)
+		}
+	}
+
+	ok, wait, err := TakeRateLimitToken("test", "::1", 2, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("burst not rate limited")
+	}
+	if wait <= 0 {
+		t.Fatalf("unexpected retry delay: %s", wait)
+	}
+
+	// Other keys are not affected
+	ok, _, err = TakeRateLimitToken("test", "::2", 2, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("other key rate limited")
+	}
+}
+
+func TestNotifyCluster(t *testing.T) {
+	assertTableClear(t, "cluster_messages")
+
+	// Small messages are passed inline
+	err := NotifyCluster([]byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	count := func() {
+		t.Helper()
+		err := sq.Select("count(*)").From("cluster_messages").Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	count()
+	AssertDeepEquals(t, n, 0)
+
+	err = NotifyCluster([]byte(GenString(maxNotifyPayload + 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count()
+	AssertDeepEquals(t, n, 1)
+}
+
+func TestClaimModLogEntry(t *testing.T) {
+	prepareForModeration(t)
+
+	err := LogModeration("a", common.ModerationEntry{
+		Type: common.ConfigureBoard,
+		By:   "admin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := GetLastModLogID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, std := range [...]bool{true, false} {
+		claimed, err := ClaimModLogEntry(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, claimed, std)
+	}
+}
@@ -123,7 +123,7 @@ func SetPcount(c uint64) error {
 func WritePyuLimit(tx *sql.Tx, ip string, b string) error {
 	_, err := sq.Insert("pyu_limit").
 		Columns("ip", "board", "restricted", "pcount").
-		Values(ip, b, false, 4).
+		Values(HashIP(ip), b, false, 4).
 		RunWith(tx).
 		Exec()
 	return err
@@ -133,7 +133,7 @@ func WritePyuLimit(tx *sql.Tx, ip string, b string) error {
 func PyuLimitExists(tx *sql.Tx, ip string, b string) (e bool, err error) {
 	err = sq.Select("count(1)").
 		From("pyu_limit").
-		Where("ip = ? and board = ?", ip, b).
+		Where("ip = ? and board = ?", HashIP(ip), b).
 		RunWith(tx).
 		QueryRow().
 		Scan(&e)
@@ -144,7 +144,7 @@ func PyuLimitExists(tx *sql.Tx, ip string, b string) (e bool, err error) {
 func GetPyuLimit(tx *sql.Tx, ip string, b string) (c uint8, err error) {
 	err = sq.Select("pcount").
 		From("pyu_limit").
-		Where("ip = ? and board = ?", ip, b).
+		Where("ip = ? and board = ?", HashIP(ip), b).
 		RunWith(tx).
 		QueryRow().
 		Scan(&c)
@@ -156,7 +156,7 @@ func GetPyuLimitRestricted(tx *sql.Tx, ip string, b string,
 ) (restricted bool, err error) {
 	err = sq.Select("restricted").
 		From("pyu_limit").
-		Where("ip = ? and board = ?", ip, b).
+		Where("ip = ? and board = ?", HashIP(ip), b).
 		RunWith(tx).
 		QueryRow().
 		Scan(&restricted)
@@ -167,7 +167,7 @@ func GetPyuLimitRestricted(tx *sql.Tx, ip string, b string,
 func SetPyuLimitRestricted(tx *sql.Tx, ip string, b string) (err error) {
 	_, err = sq.Update("pyu_limit").
 		Set("restricted", true).
-		Where("ip = ? and board = ?", ip, b).
+		Where("ip = ? and board = ?", HashIP(ip), b).
 		RunWith(tx).
 		Exec()
 	return
@@ -182,7 +182,7 @@ func DecrementPyuLimit(tx *sql.Tx, ip string, b string) (err error) {
 
 	_, err = sq.Update("pyu_limit").
 		Set("pcount", pcount-1).
-		Where("ip = ? and board = ?", ip, b).
+		Where("ip = ? and board = ?", HashIP(ip), b).
 		RunWith(tx).
 		Exec()
 	return
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/bakape/meguca/assets"
 	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	mlog "github.com/bakape/meguca/log"
 	"github.com/bakape/meguca/templates"
@@ -21,16 +22,30 @@ type BoardConfigs struct {
 	Created time.Time
 }
 
+// Subsystems to notify of global configuration changes
+func init() {
+	config.OnUpdate(templates.Recompile)
+	config.OnUpdate(auth.LoadCaptchaServices)
+}
+
 // Load configs from the database and update on each change
 func loadConfigs() error {
 	conf, err := GetConfigs()
 	if err != nil {
 		return err
 	}
+	// Fail fast on startup instead of at runtime in the subsystems using the
+	// configuration
+	err = conf.Validate()
+	if err != nil {
+		return err
+	}
 	config.Set(conf)
 	mlog.Init(mlog.Email)
+	mlog.Init(mlog.Webhook)
+	mlog.Init(mlog.Sentry)
 
-	return Listen("config_updates", updateConfigs)
+	return ListenResync("config_updates", updateConfigs, ReloadConfigs)
 }
 
 // GetConfigs retrieves global configurations. Only used in tests.
@@ -56,8 +71,12 @@ func decodeConfigs(data string) (c config.Configs, err error) {
 func getBoardConfigs() squirrel.SelectBuilder {
 	return sq.Select(
 		"readOnly", "textOnly", "forcedAnon", "disableRobots", "flags", "NSFW",
-		"rbText", "pyu", "id", "defaultCSS", "title", "notice",
-		"rules", "eightball",
+		"rbText", "pyu", "posterIDs", "id", "defaultCSS", "title", "notice",
+		"rules", "eightball", "proxyPolicy", "duplicateLimit",
+		"duplicateWindow", "duplicatePolicy", "disableCaptcha",
+		"maxBodyLength", "postCooldown", "fileTypes", "hideOpenPosts",
+		"webhookURL", "webhookSecret", "defaultLang", "radioBanner",
+		"visibility",
 	).
 		From("boards")
 }
@@ -75,17 +94,25 @@ func loadBoardConfigs() (err error) {
 	if err != nil {
 		return
 	}
-	return Listen("board_updated", updateBoardConfigs)
+	return ListenResync("board_updated", updateBoardConfigs,
+		resyncBoardConfigs)
 }
 
 func scanBoardConfigs(r rowScanner) (c config.BoardConfigs, err error) {
-	var eightball pq.StringArray
+	var eightball, fileTypes pq.StringArray
 	err = r.Scan(
 		&c.ReadOnly, &c.TextOnly, &c.ForcedAnon, &c.DisableRobots, &c.Flags,
-		&c.NSFW, &c.RbText, &c.Pyu,
+		&c.NSFW, &c.RbText, &c.Pyu, &c.PosterIDs,
 		&c.ID, &c.DefaultCSS, &c.Title, &c.Notice, &c.Rules, &eightball,
+		&c.ProxyPolicy, &c.DuplicateLimit, &c.DuplicateWindow,
+		&c.DuplicatePolicy, &c.DisableCaptcha, &c.MaxBodyLength,
+		&c.PostCooldown, &fileTypes, &c.HideOpenPosts, &c.WebhookURL,
+		&c.WebhookSecret, &c.DefaultLang, &c.RadioBanner, &c.Visibility,
 	)
 	c.Eightball = []string(eightball)
+	if len(fileTypes) != 0 {
+		c.FileTypes = []string(fileTypes)
+	}
 	return
 }
 
@@ -95,37 +122,78 @@ func WriteBoard(tx *sql.Tx, c BoardConfigs) error {
 		Columns(
 			"id", "readOnly", "textOnly", "forcedAnon", "disableRobots",
 			"flags", "NSFW",
-			"rbText", "pyu", "created", "defaultCSS", "title",
-			"notice", "rules", "eightball",
+			"rbText", "pyu", "posterIDs", "created", "defaultCSS", "title",
+			"notice", "rules", "eightball", "proxyPolicy", "duplicateLimit",
+			"duplicateWindow", "duplicatePolicy", "disableCaptcha",
+			"maxBodyLength", "postCooldown", "fileTypes", "hideOpenPosts",
+			"webhookURL", "webhookSecret", "defaultLang", "radioBanner",
+			"visibility",
 		).
 		Values(
 			c.ID, c.ReadOnly, c.TextOnly, c.ForcedAnon, c.DisableRobots,
-			c.Flags, c.NSFW, c.RbText, c.Pyu,
+			c.Flags, c.NSFW, c.RbText, c.Pyu, c.PosterIDs,
 			c.Created, c.DefaultCSS, c.Title, c.Notice, c.Rules,
-			pq.StringArray(c.Eightball),
+			pq.StringArray(c.Eightball), c.ProxyPolicy, c.DuplicateLimit,
+			c.DuplicateWindow, c.DuplicatePolicy, c.DisableCaptcha,
+			c.MaxBodyLength, c.PostCooldown, fileTypeArray(c.FileTypes),
+			c.HideOpenPosts, c.WebhookURL, c.WebhookSecret, c.DefaultLang,
+			c.RadioBanner, boardVisibility(c.Visibility),
 		).
 		RunWith(tx).
 		Exec()
 	return err
 }
 
+// Convert allowed file types for writing to the database. The column is not
+// nullable.
+func fileTypeArray(types []string) pq.StringArray {
+	if types == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(types)
+}
+
+// Convert board visibility for writing to the database. The column is not
+// nullable.
+func boardVisibility(v string) string {
+	if v == "" {
+		return common.VisibilityPublic
+	}
+	return v
+}
+
 // UpdateBoard updates board configurations
 func UpdateBoard(c config.BoardConfigs) (err error) {
 	_, err = sq.Update("boards").
 		SetMap(map[string]interface{}{
-			"readOnly":      c.ReadOnly,
-			"textOnly":      c.TextOnly,
-			"forcedAnon":    c.ForcedAnon,
-			"disableRobots": c.DisableRobots,
-			"flags":         c.Flags,
-			"NSFW":          c.NSFW,
-			"rbText":        c.RbText,
-			"pyu":           c.Pyu,
-			"defaultCSS":    c.DefaultCSS,
-			"title":         c.Title,
-			"notice":        c.Notice,
-			"rules":         c.Rules,
-			"eightball":     pq.StringArray(c.Eightball),
+			"readOnly":        c.ReadOnly,
+			"textOnly":        c.TextOnly,
+			"forcedAnon":      c.ForcedAnon,
+			"disableRobots":   c.DisableRobots,
+			"flags":           c.Flags,
+			"NSFW":            c.NSFW,
+			"rbText":          c.RbText,
+			"pyu":             c.Pyu,
+			"posterIDs":       c.PosterIDs,
+			"defaultCSS":      c.DefaultCSS,
+			"title":           c.Title,
+			"notice":          c.Notice,
+			"rules":           c.Rules,
+			"eightball":       pq.StringArray(c.Eightball),
+			"proxyPolicy":     c.ProxyPolicy,
+			"duplicateLimit":  c.DuplicateLimit,
+			"duplicateWindow": c.DuplicateWindow,
+			"duplicatePolicy": c.DuplicatePolicy,
+			"disableCaptcha":  c.DisableCaptcha,
+			"maxBodyLength":   c.MaxBodyLength,
+			"postCooldown":    c.PostCooldown,
+			"fileTypes":       fileTypeArray(c.FileTypes),
+			"hideOpenPosts":   c.HideOpenPosts,
+			"webhookURL":      c.WebhookURL,
+			"webhookSecret":   c.WebhookSecret,
+			"defaultLang":     c.DefaultLang,
+			"radioBanner":     c.RadioBanner,
+			"visibility":      boardVisibility(c.Visibility),
 		}).
 		Where("id = ?", c.ID).
 		Exec()
@@ -133,14 +201,17 @@ func UpdateBoard(c config.BoardConfigs) (err error) {
 }
 
 func updateConfigs(_ string) error {
+	return ReloadConfigs()
+}
+
+// ReloadConfigs re-reads the global configuration from the database and
+// applies it without restarting the server
+func ReloadConfigs() error {
 	conf, err := GetConfigs()
 	if err != nil {
 		return util.WrapError("reloading configuration", err)
 	}
-	config.Set(conf)
-	mlog.Update()
-
-	return util.Parallel(templates.Recompile, auth.LoadCaptchaServices)
+	return config.Reload(conf)
 }
 
 func updateBoardConfigs(board string) error {
@@ -168,6 +239,41 @@ func updateBoardConfigs(board string) error {
 	}
 }
 
+// Reload the configurations of all boards including boards created or deleted,
+// while board update notifications were not being received
+func resyncBoardConfigs() (err error) {
+	boards := make(map[string]bool)
+	for _, b := range config.GetBoards() {
+		boards[b] = true
+	}
+	err = queryAll(sq.Select("id").From("boards"),
+		func(r *sql.Rows) (err error) {
+			var id string
+			err = r.Scan(&id)
+			boards[id] = true
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	for b := range boards {
+		err = updateBoardConfigs(b)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ReloadBoardConfigs propagates the current configurations of a board from
+// the database to the config package. Used to not have to wait for the
+// database change notification.
+func ReloadBoardConfigs(board string) error {
+	return updateBoardConfigs(board)
+}
+
 // GetBoardConfigs retrives the configurations of a specific board
 func GetBoardConfigs(board string) (config.BoardConfigs, error) {
 	q := getBoardConfigs().Where("id = ?", board)
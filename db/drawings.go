@@ -0,0 +1,31 @@
+package db
+
+import (
+	"encoding/json"
+
+	"github.com/bakape/meguca/common"
+)
+
+// WriteDrawing stores the strokes of an oekaki drawing attached to a post for
+// replaying
+func WriteDrawing(id uint64, strokes []common.Stroke) (err error) {
+	buf, err := json.Marshal(strokes)
+	if err != nil {
+		return
+	}
+	_, err = sq.Insert("post_drawings").
+		Columns("id", "strokes").
+		Values(id, string(buf)).
+		Exec()
+	return
+}
+
+// GetDrawing retrieves the JSON-encoded strokes of a post's oekaki drawing
+func GetDrawing(id uint64) (buf []byte, err error) {
+	err = sq.Select("strokes").
+		From("post_drawings").
+		Where("id = ?", id).
+		QueryRow().
+		Scan(&buf)
+	return
+}
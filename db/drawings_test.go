@@ -0,0 +1,47 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+	"testing"
+)
+
+func TestWriteDrawing(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	_, err := GetDrawing(1)
+	if err != sql.ErrNoRows {
+		UnexpectedError(t, err)
+	}
+
+	strokes := []common.Stroke{
+		{
+			Color:  0xff0000,
+			Width:  3,
+			Points: [][2]uint16{{1, 2}, {3, 4}},
+		},
+		{
+			Width:  1,
+			Points: [][2]uint16{{5, 6}},
+		},
+	}
+	err = WriteDrawing(1, strokes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := GetDrawing(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []common.Stroke
+	err = json.Unmarshal(buf, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, res, strokes)
+}
@@ -0,0 +1,320 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/bakape/meguca/common"
+
+	"github.com/go-playground/log"
+	"github.com/lib/pq"
+)
+
+// Tables included in database dumps in order of insertion on restore.
+// Ephemeral tables, like sessions, captchas and spam scores, are omitted.
+// IP hashing salts are retained, so hashed IPs of restored bans still match.
+// Post revisions are omitted, as they retain the content of purged posts.
+var dumpTables = [...]string{
+	"main", "ip_salts", "accounts", "api_tokens", "boards", "staff",
+	"banners", "loading_animations", "images", "threads", "thread_redirects",
+	"posts", "post_drawings", "links", "post_moderation", "bans", "mod_log",
+	"reports", "announcement_banners",
+}
+
+// Tables contained in dumps of previous versions, that are no longer
+// restored. Their rows are skipped.
+var droppedDumpTables = map[string]bool{
+	"post_revisions": true,
+}
+
+// Extracts the sequence name from a column default
+var nextvalRegexp = regexp.MustCompile(`^nextval\('([^']+)'::regclass\)$`)
+
+// DumpTables returns the names of all tables included in database dumps in
+// the order they must be restored in
+func DumpTables() []string {
+	return dumpTables[:]
+}
+
+// Dump reads all rows of the tables returned by DumpTables from a consistent
+// snapshot of the database and passes them JSON-encoded to fn in order.
+// start is called with the schema version of the dump before any rows.
+//
+// Bodies of posts still open for editing are only stored in the database
+// after the post is closed, so such posts are dumped with empty bodies.
+func Dump(start func(version int) error,
+	fn func(table string, row []byte) error,
+) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`set transaction isolation level repeatable read read only`)
+	if err != nil {
+		return
+	}
+	var v int
+	err = tx.QueryRow(`select val from main where id = 'version'`).Scan(&v)
+	if err != nil {
+		return
+	}
+	err = start(v)
+	if err != nil {
+		return
+	}
+
+	for _, t := range dumpTables {
+		err = dumpTable(tx, t, fn)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func dumpTable(tx *sql.Tx, table string,
+	fn func(table string, row []byte) error,
+) (err error) {
+	q := fmt.Sprintf(`select row_to_json(t) from %s as t`, table)
+	if table == "main" {
+		// Set by the migrations on restore
+		q += ` where id != 'version'`
+	}
+	r, err := tx.Query(q)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	var row []byte
+	for r.Next() {
+		err = r.Scan(&row)
+		if err != nil {
+			return
+		}
+		err = fn(table, row)
+		if err != nil {
+			return
+		}
+	}
+	return r.Err()
+}
+
+// Restore restores a dump of schema version v into an uninitialized database.
+// fn must pass all rows of the dump to insert in the order of DumpTables.
+// The database is migrated to the latest version after the restore.
+// Requires Connect to have been called.
+func Restore(v int, fn func(insert func(table string, row []byte) error) error,
+) (err error) {
+	current, err := schemaVersion()
+	if err != nil {
+		return
+	}
+	if current != 0 {
+		return errors.New("database already initialized")
+	}
+	if v < 1 || v > version {
+		return common.ErrInvalidInput(
+			fmt.Sprintf("unsupported dump version: %d", v))
+	}
+
+	log.Infof("initializing database at version %d", v)
+	err = runMigrations(0, v)
+	if err != nil {
+		return
+	}
+	err = restore(fn)
+	if err != nil {
+		return
+	}
+	return runMigrations(v, version)
+}
+
+// Replace the contents of all dumped tables with the rows passed by fn in a
+// single transaction.
+// Not run through InTransaction, as fn consumes the dump and can not be
+// retried.
+func restore(fn func(insert func(table string, row []byte) error) error,
+) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// Dumps of older schema versions do not contain tables created by later
+	// migrations
+	tables, err := existingTables(tx)
+	if err != nil {
+		return
+	}
+
+	// Triggers would send notifications and overwrite restored thread bump
+	// times and post counters
+	for _, t := range tables {
+		_, err = tx.Exec(fmt.Sprintf(`alter table %s disable trigger user`, t))
+		if err != nil {
+			return
+		}
+	}
+
+	// Remove rows written by the migrations, like the default accounts and
+	// configurations
+	for i := len(tables) - 1; i >= 0; i-- {
+		q := `delete from ` + tables[i]
+		if tables[i] == "main" {
+			q += ` where id != 'version'`
+		}
+		_, err = tx.Exec(q)
+		if err != nil {
+			return
+		}
+	}
+
+	allowed := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		allowed[t] = true
+	}
+	stmts := make(map[string]*sql.Stmt, len(tables))
+	err = fn(func(table string, row []byte) (err error) {
+		if !allowed[table] {
+			if droppedDumpTables[table] {
+				return nil
+			}
+			return common.ErrInvalidInput("unknown table: " + table)
+		}
+		q := stmts[table]
+		if q == nil {
+			q, err = tx.Prepare(fmt.Sprintf(
+				`insert into %[1]s
+					select * from json_populate_record(null::%[1]s, $1)`,
+				table))
+			if err != nil {
+				return
+			}
+			stmts[table] = q
+		}
+		_, err = q.Exec(string(row))
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	err = resetSequences(tx, tables)
+	if err != nil {
+		return
+	}
+	for _, t := range tables {
+		_, err = tx.Exec(fmt.Sprintf(`alter table %s enable trigger user`, t))
+		if err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+// Returns the tables of dumpTables present in the current database schema in
+// the order of dumpTables
+func existingTables(tx *sql.Tx) (tables []string, err error) {
+	existing := make(map[string]bool, len(dumpTables))
+	r, err := tx.Query(
+		`select table_name
+		from information_schema.tables
+		where table_schema = 'public' and table_name = any($1)`,
+		pq.StringArray(dumpTables[:]),
+	)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	for r.Next() {
+		var t string
+		err = r.Scan(&t)
+		if err != nil {
+			return
+		}
+		existing[t] = true
+	}
+	err = r.Err()
+	if err != nil {
+		return
+	}
+
+	tables = make([]string, 0, len(dumpTables))
+	for _, t := range dumpTables {
+		if existing[t] {
+			tables = append(tables, t)
+		}
+	}
+	return
+}
+
+// Advance sequences used as column defaults of restored tables past the
+// highest restored value
+func resetSequences(tx *sql.Tx, tables []string) (err error) {
+	type column struct {
+		table, name, seq string
+	}
+
+	r, err := tx.Query(
+		`select table_name, column_name, column_default
+		from information_schema.columns
+		where table_schema = 'public'
+			and table_name = any($1)
+			and column_default like 'nextval(%'`,
+		pq.StringArray(tables),
+	)
+	if err != nil {
+		return
+	}
+	var cols []column
+	for r.Next() {
+		var c column
+		var def string
+		err = r.Scan(&c.table, &c.name, &def)
+		if err != nil {
+			r.Close()
+			return
+		}
+		if m := nextvalRegexp.FindStringSubmatch(def); m != nil {
+			c.seq = m[1]
+			cols = append(cols, c)
+		}
+	}
+	r.Close()
+	err = r.Err()
+	if err != nil {
+		return
+	}
+
+	// Sequences can be shared between tables, like post_id
+	max := make(map[string]int64, len(cols))
+	for _, c := range cols {
+		var m sql.NullInt64
+		err = tx.QueryRow(
+			fmt.Sprintf(`select max(%s) from %s`, c.name, c.table),
+		).
+			Scan(&m)
+		if err != nil {
+			return
+		}
+		if _, ok := max[c.seq]; !ok || m.Int64 > max[c.seq] {
+			max[c.seq] = m.Int64
+		}
+	}
+	for seq, m := range max {
+		if m == 0 {
+			continue
+		}
+		_, err = tx.Exec(`select setval($1, $2)`, seq, m)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
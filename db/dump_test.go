@@ -0,0 +1,128 @@
+package db
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+type dumpedRow struct {
+	table string
+	row   string
+}
+
+func dumpAll(t *testing.T) (rows []dumpedRow) {
+	t.Helper()
+	err := Dump(
+		func(v int) error {
+			AssertDeepEquals(t, v, version)
+			return nil
+		},
+		func(table string, row []byte) error {
+			rows = append(rows, dumpedRow{table, string(row)})
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestDumpRestore(t *testing.T) {
+	insertPost(t)
+
+	dumped := dumpAll(t)
+	tables := make(map[string]bool)
+	for _, r := range dumped {
+		tables[r.table] = true
+	}
+	for _, table := range [...]string{"accounts", "boards", "threads", "posts"} {
+		if !tables[table] {
+			t.Fatalf("table not dumped: %s", table)
+		}
+	}
+
+	err := restore(func(insert func(table string, row []byte) error) error {
+		for _, r := range dumped {
+			if err := insert(r.table, []byte(r.row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Row order within tables is not guaranteed
+	sortRows := func(rows []dumpedRow) []dumpedRow {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].table != rows[j].table {
+				return rows[i].table < rows[j].table
+			}
+			return rows[i].row < rows[j].row
+		})
+		return rows
+	}
+	restored := sortRows(dumpAll(t))
+	AssertDeepEquals(t, restored, sortRows(dumped))
+
+	t.Run("unknown table", func(t *testing.T) {
+		err := restore(func(insert func(string, []byte) error) error {
+			return insert("sessions", []byte("{}"))
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("existing tables", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		tables, err := existingTables(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, tables, DumpTables())
+
+		_, err = tx.Exec(`drop table post_drawings`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables, err = existingTables(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, table := range tables {
+			if table == "post_drawings" {
+				t.Fatal("dropped table listed")
+			}
+		}
+		if len(tables) != len(dumpTables)-1 {
+			t.Fatalf("unexpected table count: %d", len(tables))
+		}
+	})
+
+	t.Run("initialized database", func(t *testing.T) {
+		err := Restore(version, func(func(string, []byte) error) error {
+			return nil
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("dropped table", func(t *testing.T) {
+		err := restore(func(insert func(string, []byte) error) error {
+			return insert("post_revisions", []byte("{}"))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
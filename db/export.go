@@ -0,0 +1,64 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/common"
+)
+
+// ExportBoard reads up to limit closed posts, not hidden by shadow bans, of a
+// board with IDs greater than after in ascending ID order. The ID of the last
+// returned post can be passed as after to retrieve the next page.
+func ExportBoard(board string, after, limit uint64) (
+	posts []common.StandalonePost, err error,
+) {
+	posts = make([]common.StandalonePost, 0, limit)
+	var (
+		res   common.StandalonePost
+		post  postScanner
+		img   imageScanner
+		pArgs = post.ScanArgs()
+		iArgs = img.ScanArgs()
+		args  = make([]interface{}, 2, 2+len(pArgs)+len(iArgs))
+	)
+	args[0] = &res.OP
+	args[1] = &res.Board
+	args = append(args, pArgs...)
+	args = append(args, iArgs...)
+
+	err = queryAll(
+		sq.Select("p.op, p.board, "+postSelectsSQL).
+			From("posts as p").
+			LeftJoin("images as i on p.SHA1 = i.SHA1").
+			Where(
+				`p.board = ? and p.id > ? and p.editing = false
+					and p.shadowed = false`,
+				board, after,
+			).
+			OrderBy("p.id").
+			Limit(limit),
+		func(r *sql.Rows) (err error) {
+			err = r.Scan(args...)
+			if err != nil {
+				return
+			}
+			res.Post, err = extractPost(post, img)
+			if err != nil {
+				return
+			}
+			posts = append(posts, res)
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	moderated := make([]*common.Post, 0, 64)
+	for i := range posts {
+		if posts[i].Moderated {
+			moderated = append(moderated, &posts[i].Post)
+		}
+	}
+	err = injectModeration(moderated)
+	return
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/bakape/meguca/common"
+)
+
+func TestExportBoard(t *testing.T) {
+	p := insertPost(t)
+
+	// Posts hidden by shadow bans must not be exported
+	err := InTransaction(false, func(tx *sql.Tx) error {
+		return WritePost(tx, Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:       p.ID + 1,
+					Time:     time.Now().Unix(),
+					Shadowed: true,
+				},
+				OP:    1,
+				Board: "a",
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := ExportBoard("a", 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("unexpected post count: %d", len(posts))
+	}
+	for i, id := range [...]uint64{1, p.ID} {
+		if posts[i].ID != id {
+			t.Fatalf("unexpected post order: %d != %d", posts[i].ID, id)
+		}
+		if posts[i].Board != "a" || posts[i].OP != 1 {
+			t.Fatalf("unexpected post location: %v", posts[i])
+		}
+	}
+
+	// Pagination
+	posts, err = ExportBoard("a", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != 1 {
+		t.Fatalf("unexpected first page: %v", posts)
+	}
+	posts, err = ExportBoard("a", posts[0].ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != p.ID {
+		t.Fatalf("unexpected second page: %v", posts)
+	}
+
+	posts, err = ExportBoard("c", 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Fatalf("posts exported from wrong board: %v", posts)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"io"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/imager/assets"
 	"github.com/bakape/meguca/util"
 	"time"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/lib/pq"
 )
 
@@ -44,12 +46,12 @@ func writeImageTx(tx *sql.Tx, i common.ImageCommon) (err error) {
 		Insert("images").
 		Columns(
 			"audio", "video", "file_type", "thumb_type", "dims", "length",
-			"size", "MD5", "SHA1", "Title", "Artist",
+			"size", "MD5", "SHA1", "Title", "Artist", "phash",
 		).
 		Values(
 			i.Audio, i.Video, int(i.FileType), int(i.ThumbType),
 			pq.GenericArray{A: i.Dims}, i.Length, i.Size, i.MD5, i.SHA1,
-			i.Title, i.Artist,
+			i.Title, i.Artist, i.PHash,
 		).
 		RunWith(tx).
 		Exec()
@@ -172,6 +174,100 @@ func GetImage(sha1 string) (img common.ImageCommon, err error) {
 	return scanner.Val().ImageCommon, nil
 }
 
+// SetAnimatedThumb records an animated thumbnail preview has been generated
+// for an image. Advances the reply time of all threads containing the image
+// without bumping them, so cached thread and board JSON is regenerated.
+func SetAnimatedThumb(SHA1 string) error {
+	return InTransaction(false, func(tx *sql.Tx) (err error) {
+		_, err = sq.Update("images").
+			Set("animated_thumb", true).
+			Where("SHA1 = ?", SHA1).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec(
+			`select bump_thread(op)
+			from (
+				select distinct op
+				from posts
+				where SHA1 = $1
+			) as t`,
+			SHA1,
+		)
+		return
+	})
+}
+
+// GetImageThreads returns the IDs of all threads with posts containing an
+// image
+func GetImageThreads(SHA1 string) (ops []uint64, err error) {
+	err = queryAll(
+		sq.Select("distinct op").
+			From("posts").
+			Where("SHA1 = ?", SHA1),
+		func(r *sql.Rows) (err error) {
+			var op uint64
+			err = r.Scan(&op)
+			if err != nil {
+				return
+			}
+			ops = append(ops, op)
+			return
+		},
+	)
+	return
+}
+
+// Maximum Hamming distance of perceptual hashes of images considered similar
+const maxPHashDistance = 8
+
+// FindImagePosts returns links to the newest posts across all boards with an
+// image, which has a hash column equal to hash. column must be one of "md5",
+// "sha1" or "phash". Perceptual hashes also match visually similar images
+// within maxPHashDistance. Posts of shadow banned posters and posts on
+// unlisted boards are only included, if staff is true.
+func FindImagePosts(column, hash string, staff bool, limit uint64) (
+	posts []common.Link, err error,
+) {
+	q := sq.Select("p.id", "p.op", "p.board").
+		From("posts as p").
+		Join("images as i on p.SHA1 = i.SHA1").
+		OrderBy("p.id desc").
+		Limit(limit)
+	if column == "phash" {
+		// Count the differing bits of the hex encoded hashes
+		q = q.Where(
+			`i.phash is not null
+			and length(replace(
+				(('x' || i.phash)::bit(64) # ('x' || ?)::bit(64))::text,
+				'0', '')) <= ?`,
+			hash, maxPHashDistance,
+		)
+	} else {
+		q = q.Where("i."+column+" = ?", hash)
+	}
+	if !staff {
+		q = q.Where("not p.shadowed")
+		if hidden := config.GetUnlistedBoards(); len(hidden) != 0 {
+			q = q.Where(squirrel.NotEq{"p.board": hidden})
+		}
+	}
+
+	posts = make([]common.Link, 0, 16)
+	err = queryAll(q, func(r *sql.Rows) (err error) {
+		var l common.Link
+		err = r.Scan(&l.ID, &l.OP, &l.Board)
+		if err != nil {
+			return
+		}
+		posts = append(posts, l)
+		return
+	})
+	return
+}
+
 // SpoilerImage spoilers an already allocated image
 func SpoilerImage(id, op uint64) error {
 	_, err := sq.Update("posts").
@@ -306,3 +306,40 @@ func TestImageExists(t *testing.T) {
 	}
 	test.AssertDeepEquals(t, exists, true)
 }
+
+func TestFindImagePosts(t *testing.T) {
+	std := assets.StdJPEG
+	std.PHash = "c3e1f0f8e0c08181"
+
+	assertTableClear(t, "images", "boards")
+	err := WriteImage(std.ImageCommon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSampleBoard(t)
+	writeSampleThread(t)
+	insertSampleImage(t)
+
+	found := []common.Link{{ID: 1, OP: 1, Board: "a"}}
+	cases := [...]struct {
+		name, column, hash string
+		std                []common.Link
+	}{
+		{"md5", "md5", std.MD5, found},
+		{"sha1", "sha1", std.SHA1, found},
+		{"phash", "phash", std.PHash, found},
+		{"similar phash", "phash", "c3e1f0f8e0c08180", found},
+		{"no match", "phash", "0000000000000000", []common.Link{}},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			posts, err := FindImagePosts(c.column, c.hash, false, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.AssertDeepEquals(t, posts, c.std)
+		})
+	}
+}
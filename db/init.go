@@ -104,7 +104,10 @@ func LoadTestDB(suffix string) (close func() error, err error) {
 	return
 }
 
-func loadDB(dbSuffix string) (err error) {
+// Connect connects to the PostgreSQL database without performing schema
+// upgrades or loading any server state. For use by command line tools
+// operating alongside a running server.
+func Connect() (err error) {
 	db, err = sql.Open("postgres", ConnArgs)
 	if err != nil {
 		return
@@ -113,6 +116,14 @@ func loadDB(dbSuffix string) (err error) {
 	sq = squirrel.StatementBuilder.
 		RunWith(squirrel.NewStmtCacheProxy(db)).
 		PlaceholderFormat(squirrel.Dollar)
+	return db.Ping()
+}
+
+func loadDB(dbSuffix string) (err error) {
+	err = Connect()
+	if err != nil {
+		return
+	}
 
 	var exists bool
 	const q = `select exists (
@@ -144,9 +155,9 @@ func loadDB(dbSuffix string) (err error) {
 				return err
 			}
 
-			// Depends on loadBanners and loadLoadingAnimations, so has to be
-			// sequential
-			return loadBoardConfigs()
+			// Depend on loadConfigs, loadBanners and loadLoadingAnimations, so
+			// have to be sequential
+			return util.Waterfall(loadIPSalts, loadBoardConfigs)
 		},
 	)
 
@@ -168,6 +179,9 @@ func checkVersion() (err error) {
 	err = db.QueryRow(`select val from main where id = 'version'`).Scan(&v)
 	switch err {
 	case nil, sql.ErrNoRows:
+		if v > version {
+			return errNewerVersion(v)
+		}
 		return runMigrations(v, version)
 	default:
 		return
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"github.com/bakape/meguca/config"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	// Active IP hashing salts ordered from newest to oldest
+	ipSalts   [][]byte
+	ipSaltsMu sync.RWMutex
+)
+
+// Returns the IP data retention period and IP hashing salt rotation interval
+func ipRetention() time.Duration {
+	days := config.Get().IPRetention
+	if days == 0 {
+		days = config.Defaults.IPRetention
+	}
+	return time.Duration(days) * time.Hour * 24
+}
+
+// Hash an IP with a salt into an IPv6 address in the fd00::/8 unique local
+// range, so hashes can be stored in and compared with inet columns
+func hashIPWith(ip string, salt []byte) string {
+	h := hmac.New(sha256.New, salt)
+	h.Write([]byte(ip))
+	sum := h.Sum(nil)
+	sum[0] = 0xfd
+	return net.IP(sum[:net.IPv6len]).String()
+}
+
+// HashIP returns the salted hash of an IP to be stored instead of the raw
+// address, if IP hashing is enabled. Otherwise returns the IP unchanged.
+func HashIP(ip string) string {
+	if ip == "" || !config.Get().HashIPs {
+		return ip
+	}
+
+	ipSaltsMu.RLock()
+	defer ipSaltsMu.RUnlock()
+	if len(ipSalts) == 0 {
+		return ip
+	}
+	return hashIPWith(ip, ipSalts[0])
+}
+
+// Returns all stored representations of an IP under the current and previous
+// salts ordered from newest to oldest. The raw IP is included last, as it may
+// still be stored from before IP hashing was enabled.
+func ipHashes(ip string) []string {
+	if ip == "" || !config.Get().HashIPs {
+		return []string{ip}
+	}
+
+	ipSaltsMu.RLock()
+	defer ipSaltsMu.RUnlock()
+	if len(ipSalts) == 0 {
+		return []string{ip}
+	}
+	hashes := make([]string, len(ipSalts)+1)
+	for i, s := range ipSalts {
+		hashes[i] = hashIPWith(ip, s)
+	}
+	hashes[len(ipSalts)] = ip
+	return hashes
+}
+
+// MatchIP returns, if a raw IP matches an IP stored in the database
+func MatchIP(ip, stored string) bool {
+	for _, h := range ipHashes(ip) {
+		if h == stored {
+			return true
+		}
+	}
+	return false
+}
+
+// Load IP hashing salts still in use and generate the first one, if none
+func loadIPSalts() (err error) {
+	salts := make([][]byte, 0, 2)
+	err = queryAll(
+		sq.Select("salt").
+			From("ip_salts").
+			OrderBy("created desc"),
+		func(r *sql.Rows) (err error) {
+			var s []byte
+			err = r.Scan(&s)
+			if err != nil {
+				return
+			}
+			salts = append(salts, s)
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+	if len(salts) == 0 {
+		return rotateIPSalt()
+	}
+
+	ipSaltsMu.Lock()
+	ipSalts = salts
+	ipSaltsMu.Unlock()
+	return
+}
+
+// Generate a new IP hashing salt and delete salts, that can no longer be
+// referenced by any post or active ban. A salt is referenced by bans of IPs
+// hashed between its creation and the creation of the next salt.
+func rotateIPSalt() (err error) {
+	salt := make([]byte, 32)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return
+	}
+	err = InTransaction(false, func(tx *sql.Tx) (err error) {
+		_, err = sq.Insert("ip_salts").
+			Columns("salt").
+			Values(salt).
+			RunWith(tx).
+			Exec()
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec(
+			`delete from ip_salts as s
+			where created < $1
+				and not exists (
+					select 1
+					from bans as b
+					where b.expires > now() at time zone 'utc'
+						and b.ip_hashed >= s.created
+						and b.ip_hashed < coalesce(
+							(
+								select min(n.created)
+								from ip_salts as n
+								where n.created > s.created
+							),
+							'infinity'
+						)
+				)`,
+			time.Now().UTC().Add(-ipRetention()*2),
+		)
+		return
+	})
+	if err != nil {
+		return
+	}
+	return loadIPSalts()
+}
+
+// Rotate the IP hashing salt, once the current one is older than the IP data
+// retention period
+func rotateIPSaltIfDue() (err error) {
+	var due bool
+	err = sq.Select().
+		Column("coalesce(max(created), 'epoch') < ?",
+			time.Now().UTC().Add(-ipRetention())).
+		From("ip_salts").
+		QueryRow().
+		Scan(&due)
+	if err != nil {
+		return
+	}
+	if due {
+		return rotateIPSalt()
+	}
+	return loadIPSalts()
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"github.com/bakape/meguca/config"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHashIP(t *testing.T) {
+	config.Set(config.Configs{})
+	if h := HashIP("::1"); h != "::1" {
+		t.Fatalf("IP hashed with hashing disabled: %s", h)
+	}
+
+	config.Set(config.Configs{
+		HashIPs: true,
+	})
+	defer config.Set(config.Configs{})
+	if err := rotateIPSalt(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := HashIP("::1")
+	ip := net.ParseIP(h)
+	if ip == nil || ip[0] != 0xfd {
+		t.Fatalf("invalid hash: %s", h)
+	}
+	if HashIP("::1") != h {
+		t.Fatal("hash not deterministic")
+	}
+	if HashIP("::2") == h {
+		t.Fatal("hash collision")
+	}
+
+	if err := rotateIPSalt(); err != nil {
+		t.Fatal(err)
+	}
+	if HashIP("::1") == h {
+		t.Fatal("salt not rotated")
+	}
+	if !MatchIP("::1", h) {
+		t.Fatal("previous hash not matched")
+	}
+	if MatchIP("::2", h) {
+		t.Fatal("different IP matched")
+	}
+	if !MatchIP("::1", "::1") {
+		t.Fatal("IP stored before enabling hashing not matched")
+	}
+}
+
+func TestIPSaltRetention(t *testing.T) {
+	assertTableClear(t, "ip_salts", "boards")
+	writeSampleBoard(t)
+	config.Set(config.Configs{
+		HashIPs: true,
+	})
+	defer config.Set(config.Configs{})
+
+	old := time.Now().UTC().Add(-ipRetention() * 3)
+	for i, created := range [...]time.Time{old, old.Add(time.Hour)} {
+		_, err := sq.Insert("ip_salts").
+			Columns("salt", "created").
+			Values([]byte{byte(i)}, created).
+			Exec()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Active ban of an IP hashed with the oldest salt
+	_, err := sq.Insert("bans").
+		Columns("ip", "board", "reason", "by", "expires", "ip_hashed").
+		Values("fd00::1", "a", "foo", "admin",
+			time.Now().UTC().Add(time.Hour), old.Add(time.Minute)).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotateIPSalt(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The banned IP's salt and the new one are retained
+	var n int
+	err = sq.Select("count(*)").From("ip_salts").QueryRow().Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("unexpected salt count: %d", n)
+	}
+	ipSaltsMu.RLock()
+	defer ipSaltsMu.RUnlock()
+	if len(ipSalts) != 2 || ipSalts[1][0] != 0 {
+		t.Fatalf("banned IP's salt not loaded: %v", ipSalts)
+	}
+}
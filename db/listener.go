@@ -0,0 +1,174 @@
+package db
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/common"
+
+	"github.com/go-playground/log"
+	"github.com/lib/pq"
+)
+
+var (
+	// Time a listener may stay disconnected, before an error alert is logged
+	listenerAlertThreshold = time.Minute
+
+	// Idle listener connections are pinged at this interval to detect
+	// silently dropped connections
+	listenerPingInterval = 90 * time.Second
+
+	listenersMu sync.Mutex
+	listeners   []*listenerState
+)
+
+// ListenerStatus describes the connection state of a database notification
+// listener
+type ListenerStatus struct {
+	// Notification channel listened on
+	Event     string `json:"event"`
+	Connected bool   `json:"connected"`
+	// Unix timestamp of the loss of connection. 0, if connected.
+	DownSince int64 `json:"downSince"`
+	// Times the connection was restored
+	Reconnects uint `json:"reconnects"`
+}
+
+// GetListenerStatus returns the connection state of all database notification
+// listeners
+func GetListenerStatus() []ListenerStatus {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
+	status := make([]ListenerStatus, 0, len(listeners))
+	for _, s := range listeners {
+		status = append(status, s.status())
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Event < status[j].Event
+	})
+	return status
+}
+
+// Tracks the connection state of a listener and alerts, when the connection
+// stays lost for longer than listenerAlertThreshold
+type listenerState struct {
+	mu         sync.Mutex
+	event      string
+	connected  bool
+	downSince  time.Time
+	alerted    bool
+	reconnects uint
+}
+
+func (s *listenerState) status() ListenerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := ListenerStatus{
+		Event:      s.event,
+		Connected:  s.connected,
+		Reconnects: s.reconnects,
+	}
+	if !s.connected {
+		st.DownSince = s.downSince.Unix()
+	}
+	return st
+}
+
+// Handle connection events of the underlying pq.Listener
+func (s *listenerState) onEvent(ev pq.ListenerEventType, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch ev {
+	case pq.ListenerEventConnected:
+		s.connected = true
+	case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+		if !s.connected {
+			return
+		}
+		s.connected = false
+		s.downSince = time.Now()
+		log.Warnf("db: listener %s disconnected: %s", s.event, err)
+		time.AfterFunc(listenerAlertThreshold, s.checkDown)
+	case pq.ListenerEventReconnected:
+		s.connected = true
+		s.reconnects++
+		if s.alerted {
+			log.Infof("db: listener %s reconnected after %s", s.event,
+				time.Since(s.downSince).Round(time.Second))
+			s.alerted = false
+		}
+	}
+}
+
+// Log an error alert, if the listener is still disconnected
+func (s *listenerState) checkDown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connected || s.alerted ||
+		time.Since(s.downSince) < listenerAlertThreshold {
+		return
+	}
+	s.alerted = true
+	log.Errorf(
+		"db: listener %s down for %s: live updates are not being received",
+		s.event, time.Since(s.downSince).Round(time.Second))
+}
+
+// ListenResync is like Listen, but also calls resync after the connection to
+// the database has been restored. Notifications sent while disconnected are
+// lost, so resync must reload any state kept up to date by fn.
+func ListenResync(event string, fn func(msg string) error,
+	resync func() error,
+) (err error) {
+	if common.IsTest {
+		return
+	}
+
+	s := &listenerState{
+		event:     event,
+		connected: true,
+	}
+	// Reconnects with exponential backoff from 1 to 10 seconds
+	l := pq.NewListener(ConnArgs, time.Second, time.Second*10, s.onEvent)
+	err = l.Listen(event)
+	if err != nil {
+		return
+	}
+
+	listenersMu.Lock()
+	listeners = append(listeners, s)
+	listenersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case msg := <-l.Notify:
+				if msg == nil {
+					// Sent after the connection has been restored
+					if resync == nil {
+						continue
+					}
+					if err := resync(); err != nil {
+						log.Errorf("db: listener %s: resync: %s", event, err)
+					}
+					continue
+				}
+				if err := fn(msg.Extra); err != nil {
+					log.Errorf(
+						"error on database event id=`%s` msg=`%s` error=`%s`\n",
+						event, msg.Extra, err)
+				}
+			case <-time.After(listenerPingInterval):
+				// Failure causes the listener to reconnect
+				go l.Ping()
+			}
+		}
+	}()
+
+	return
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/lib/pq"
+)
+
+func TestListenerState(t *testing.T) {
+	old := listenerAlertThreshold
+	listenerAlertThreshold = 10 * time.Millisecond
+	defer func() {
+		listenerAlertThreshold = old
+	}()
+
+	s := &listenerState{
+		event:     "foo",
+		connected: true,
+	}
+	s.onEvent(pq.ListenerEventDisconnected, errors.New("connection reset"))
+	s.onEvent(pq.ListenerEventConnectionAttemptFailed, errors.New("refused"))
+	st := s.status()
+	AssertDeepEquals(t, st.Connected, false)
+	if st.DownSince == 0 {
+		t.Fatal("no disconnection time")
+	}
+
+	// Alerted, once down past the threshold
+	time.Sleep(5 * listenerAlertThreshold)
+	s.mu.Lock()
+	alerted := s.alerted
+	s.mu.Unlock()
+	AssertDeepEquals(t, alerted, true)
+
+	s.onEvent(pq.ListenerEventReconnected, nil)
+	AssertDeepEquals(t, s.status(), ListenerStatus{
+		Event:      "foo",
+		Connected:  true,
+		Reconnects: 1,
+	})
+	s.mu.Lock()
+	alerted = s.alerted
+	s.mu.Unlock()
+	AssertDeepEquals(t, alerted, false)
+}
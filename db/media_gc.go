@@ -0,0 +1,270 @@
+package db
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/imager/assets"
+
+	"github.com/go-playground/log"
+	"github.com/lib/pq"
+)
+
+// Modes of handling media files not referenced by any image in the database
+const (
+	// Delete orphaned files
+	MediaGCDelete = "delete"
+	// Move orphaned files to the quarantine/ directory of the storage
+	// backend
+	MediaGCQuarantine = "quarantine"
+	// Only log orphaned files and the space they occupy
+	MediaGCReport = "report"
+	// Disable the orphaned media garbage collector
+	MediaGCOff = "off"
+)
+
+var (
+	// MediaGCMode sets how orphaned media files are handled
+	MediaGCMode = MediaGCDelete
+
+	// Files modified more recently are never collected, as they might belong
+	// to an upload, that has not been committed yet
+	mediaGCGracePeriod = time.Hour
+
+	// Maximum number of images looked up per query
+	mediaGCBatchSize = 1000
+
+	mediaGCMu    sync.Mutex
+	mediaGCStats MediaGCStats
+)
+
+// MediaGCStats contains statistics of the orphaned media garbage collector
+type MediaGCStats struct {
+	Mode string `json:"mode"`
+	// Unix timestamp of the last run. 0, if never run.
+	LastRun int64 `json:"lastRun"`
+	// Files inspected in the last run
+	Scanned int `json:"scanned"`
+	// Orphaned files found in the last run
+	Orphans int `json:"orphans"`
+	// Size of orphaned files found in the last run in bytes
+	OrphanedBytes int64 `json:"orphanedBytes"`
+	// Files deleted or quarantined since server start
+	Collected int `json:"collected"`
+	// Space reclaimed since server start in bytes
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
+
+// GetMediaGCStats returns statistics of the orphaned media garbage collector
+func GetMediaGCStats() MediaGCStats {
+	mediaGCMu.Lock()
+	defer mediaGCMu.Unlock()
+
+	s := mediaGCStats
+	s.Mode = MediaGCMode
+	return s
+}
+
+// Media file found in storage
+type mediaFile struct {
+	key  string
+	sha1 string
+	size int64
+}
+
+// Delete or quarantine files in storage, that do not belong to any image in
+// the database. Such files are left behind by failed uploads and interrupted
+// deletions.
+func collectOrphanedMedia() (err error) {
+	mode := MediaGCMode
+	if mode == MediaGCOff {
+		return
+	}
+
+	s := assets.GetStorage()
+	files, err := scanMediaFiles(s, time.Now().Add(-mediaGCGracePeriod))
+	if err != nil {
+		return
+	}
+	orphans, err := findOrphanedMedia(files)
+	if err != nil {
+		return
+	}
+
+	var (
+		orphanedBytes, reclaimed int64
+		collected                int
+	)
+	for _, f := range orphans {
+		orphanedBytes += f.size
+		switch mode {
+		case MediaGCReport:
+			log.Infof("media gc: orphaned file: %s (%d bytes)", f.key, f.size)
+			continue
+		case MediaGCQuarantine:
+			err = quarantineMedia(s, f.key)
+		default:
+			err = s.Delete(f.key)
+		}
+		switch {
+		case err == nil:
+			collected++
+			reclaimed += f.size
+		case os.IsNotExist(err):
+			err = nil
+		default:
+			return
+		}
+	}
+	if len(orphans) != 0 {
+		verb := "deleted"
+		switch mode {
+		case MediaGCReport:
+			verb = "found"
+		case MediaGCQuarantine:
+			verb = "quarantined"
+		}
+		log.Infof("media gc: %s %d orphaned files (%d bytes)", verb,
+			len(orphans), orphanedBytes)
+	}
+
+	mediaGCMu.Lock()
+	defer mediaGCMu.Unlock()
+	mediaGCStats.LastRun = time.Now().Unix()
+	mediaGCStats.Scanned = len(files)
+	mediaGCStats.Orphans = len(orphans)
+	mediaGCStats.OrphanedBytes = orphanedBytes
+	mediaGCStats.Collected += collected
+	mediaGCStats.ReclaimedBytes += reclaimed
+	return
+}
+
+// List all uploaded files and thumbnails not modified after before
+func scanMediaFiles(s assets.Storage, before time.Time,
+) (files []mediaFile, err error) {
+	for _, prefix := range [...]string{"src/", "thumb/"} {
+		err = s.Walk(prefix, func(f assets.FileInfo) error {
+			if f.ModTime.After(before) {
+				return nil
+			}
+			sha1 := mediaFileSHA1(path.Base(f.Key))
+			if sha1 == "" {
+				// Not an uploaded file
+				return nil
+			}
+			files = append(files, mediaFile{
+				key:  f.Key,
+				sha1: sha1,
+				size: f.Size,
+			})
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Extract the SHA1 hash from a media file name. Returns an empty string, if
+// the name is not of an uploaded file.
+func mediaFileSHA1(name string) string {
+	if len(name) < 41 || (name[40] != '.' && name[40] != '_') {
+		return ""
+	}
+	for _, b := range []byte(name[:40]) {
+		if (b < '0' || b > '9') && (b < 'a' || b > 'f') {
+			return ""
+		}
+	}
+	return name[:40]
+}
+
+// Return files, that are not the source file, thumbnail or animated
+// thumbnail of any image in the database
+func findOrphanedMedia(files []mediaFile) (orphans []mediaFile, err error) {
+	for i := 0; i < len(files); i += mediaGCBatchSize {
+		end := i + mediaGCBatchSize
+		if end > len(files) {
+			end = len(files)
+		}
+		batch := files[i:end]
+
+		sha1s := make([]string, len(batch))
+		for i, f := range batch {
+			sha1s[i] = f.sha1
+		}
+		var expected map[string]bool
+		expected, err = imageFileKeys(sha1s)
+		if err != nil {
+			return
+		}
+		for _, f := range batch {
+			if !expected[f.key] {
+				orphans = append(orphans, f)
+			}
+		}
+	}
+	return
+}
+
+// Read the storage keys of all files of images with the passed SHA1 hashes
+func imageFileKeys(sha1s []string) (keys map[string]bool, err error) {
+	r, err := db.Query(
+		`select sha1, file_type, thumb_type, animated_thumb
+		from images
+		where sha1 = any($1)`,
+		pq.StringArray(sha1s),
+	)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	keys = make(map[string]bool, len(sha1s)*2)
+	for r.Next() {
+		var (
+			sha1                string
+			fileType, thumbType uint8
+			animatedThumb       bool
+		)
+		err = r.Scan(&sha1, &fileType, &thumbType, &animatedThumb)
+		if err != nil {
+			return
+		}
+		for _, k := range assets.GetFileKeys(sha1, fileType, thumbType) {
+			keys[k] = true
+		}
+		if animatedThumb {
+			keys[assets.AnimatedThumbKey(sha1)] = true
+		}
+	}
+	err = r.Err()
+	return
+}
+
+// Move an orphaned file into the "quarantine/" prefix, preserving its key
+func quarantineMedia(s assets.Storage, key string) (err error) {
+	if l, ok := s.(*assets.LocalStorage); ok {
+		dst := l.Path("quarantine/" + key)
+		err = os.MkdirAll(filepath.Dir(dst), 0700)
+		if err != nil {
+			return
+		}
+		return os.Rename(l.Path(key), dst)
+	}
+
+	r, info, err := s.Open(key)
+	if err != nil {
+		return
+	}
+	err = s.Write("quarantine/"+key, r, info.Size)
+	r.Close()
+	if err != nil {
+		return
+	}
+	return s.Delete(key)
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakape/meguca/imager/assets"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestMediaFileSHA1(t *testing.T) {
+	t.Parallel()
+
+	const sha1 = "012a2f912c9ee93ceb0ccb8684a29ec571990a94"
+	cases := [...]struct {
+		name, sha1 string
+	}{
+		{sha1 + ".jpg", sha1},
+		{sha1 + "_anim.webp", sha1},
+		{".write_test123", ""},
+		{sha1, ""},
+		{"012A2F912C9EE93CEB0CCB8684A29EC571990A94.jpg", ""},
+	}
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, mediaFileSHA1(c.name), c.sha1)
+		})
+	}
+}
+
+func TestCollectOrphanedMedia(t *testing.T) {
+	assertTableClear(t, "images")
+	defer setupImageDirs(t)()
+	writeSampleImage(t)
+
+	oldGrace, oldMode := mediaGCGracePeriod, MediaGCMode
+	mediaGCGracePeriod = 0
+	defer func() {
+		mediaGCGracePeriod, MediaGCMode = oldGrace, oldMode
+	}()
+
+	img := assets.StdJPEG
+	paths := assets.GetFilePaths(img.SHA1, img.FileType, img.ThumbType)
+	orphan := filepath.Join("images", "src",
+		"a9f6d4c0ae5e2b1b4ac1a0e5b27a9d8f28e7d7c9.png")
+	for _, p := range [...]string{paths[0], paths[1], orphan} {
+		if err := ioutil.WriteFile(p, []byte("foo"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertExists := func(t *testing.T, path string, exists bool) {
+		t.Helper()
+		_, err := os.Stat(path)
+		if exists && err != nil {
+			t.Fatal(err)
+		}
+		if !exists && !os.IsNotExist(err) {
+			t.Fatalf("file not removed: %s", path)
+		}
+	}
+
+	t.Run("report", func(t *testing.T) {
+		MediaGCMode = MediaGCReport
+		if err := collectOrphanedMedia(); err != nil {
+			t.Fatal(err)
+		}
+		assertExists(t, orphan, true)
+		s := GetMediaGCStats()
+		AssertDeepEquals(t, s.Orphans, 1)
+		AssertDeepEquals(t, s.OrphanedBytes, int64(3))
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		MediaGCMode = MediaGCQuarantine
+		if err := collectOrphanedMedia(); err != nil {
+			t.Fatal(err)
+		}
+		assertExists(t, orphan, false)
+		assertExists(t,
+			filepath.Join("images", "quarantine", "src", filepath.Base(orphan)),
+			true)
+		for _, p := range paths {
+			assertExists(t, p, true)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		MediaGCMode = MediaGCDelete
+		if err := ioutil.WriteFile(orphan, []byte("foo"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		before := GetMediaGCStats().ReclaimedBytes
+		if err := collectOrphanedMedia(); err != nil {
+			t.Fatal(err)
+		}
+		assertExists(t, orphan, false)
+		AssertDeepEquals(t, GetMediaGCStats().ReclaimedBytes, before+3)
+	})
+}
@@ -20,6 +20,8 @@ import (
 
 var version = len(migrations)
 
+// Schema changes applied in order. The database schema version is the number
+// of applied migrations and is stored in the "main" table.
 var migrations = []func(*sql.Tx) error{
 	func(tx *sql.Tx) (err error) {
 		// Initialize DB
@@ -1082,6 +1084,435 @@ var migrations = []func(*sql.Tx) error{
 			`alter table images rename column thumbType to thumb_type`,
 		)
 	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table post_reservations (
+				id bigint primary key,
+				op bigint not null references threads on delete cascade,
+				token text not null unique,
+				ip inet not null,
+				expires timestamp not null
+			)`,
+			createIndex("post_reservations", "expires"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column proxyPolicy varchar(7) not null default 'none'`,
+			`alter table posts
+				add column proxy bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column posterIDs bool not null default false`,
+			`alter table posts add column poster_id varchar(8)`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column duplicateLimit int not null default 0,
+				add column duplicateWindow int not null default 3600,
+				add column duplicatePolicy varchar(6) not null default 'flag'`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table threads
+				add column encrypted bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table ip_salts (
+				id serial primary key,
+				salt bytea not null,
+				created timestamp not null default (now() at time zone 'utc')
+			)`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column disableCaptcha bool not null default false,
+				add column maxBodyLength int not null default 0,
+				add column postCooldown int not null default 0,
+				add column fileTypes varchar(4)[] not null default '{}'`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table images
+				add column animated_thumb bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table reports
+				add column resolved timestamp,
+				add column resolved_by text`,
+			createIndex("reports", "target"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table thread_redirects (
+				board text not null,
+				id bigint not null,
+				target bigint not null references threads on delete cascade,
+				primary key (board, id)
+			)`,
+			createIndex("thread_redirects", "target"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		// Posts of shadow banned posters neither bump threads nor count
+		// towards the public post count
+		return execAll(tx,
+			`alter table bans
+				add column shadow bool not null default false`,
+			`alter table posts
+				add column shadowed bool not null default false`,
+			`create index posts_shadowed on posts (board) where shadowed`,
+			`create or replace function post_count(op bigint)
+			returns bigint as $$
+			declare
+				c bigint;
+			begin
+				select count(*) into c
+					from posts
+					where posts.op = post_count.op and not posts.shadowed;
+				return c;
+			end;
+			$$ language plpgsql`,
+			`create or replace function on_posts_insert()
+			returns trigger as $$
+			begin
+				if not new.shadowed then
+					perform bump_thread(new.op, not new.sage);
+					perform pg_notify('new_post_in_thread',
+						new.op || ',' || post_count(new.op));
+				end if;
+				return null;
+			end;
+			$$ language plpgsql`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column hideOpenPosts bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table posts add column disconnected timestamp`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table post_drawings (
+				id bigint primary key references posts on delete cascade,
+				strokes jsonb not null
+			)`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column webhookURL varchar(500) not null default '',
+				add column webhookSecret varchar(100) not null default ''`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table api_tokens (
+				id bigserial primary key,
+				hash char(64) not null unique,
+				name varchar(100) not null,
+				owner varchar(20) not null references accounts on delete cascade,
+				scopes varchar(10)[] not null,
+				rate_limit int not null,
+				rate_interval int not null,
+				created timestamptz not null default now(),
+				last_used timestamptz,
+				requests bigint not null default 0,
+				limited bigint not null default 0
+			)`,
+			createIndex("api_tokens", "owner"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create unlogged table cluster_messages (
+				id bigserial primary key,
+				created timestamp not null default (now() at time zone 'utc'),
+				payload text not null
+			)`,
+			`create unlogged table rate_limits (
+				class varchar(10) not null,
+				key text not null,
+				tokens float8 not null,
+				last timestamp not null,
+				allowed bool not null,
+				primary key (class, key)
+			)`,
+			`alter table mod_log
+				add column published bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column defaultLang varchar(5) not null default ''`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column radioBanner bool not null default false`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table images add column phash char(16)`,
+			createIndex("images", "md5"),
+			createIndex("images", "phash"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table announcement_banners (
+				id bigserial primary key,
+				board varchar(10) not null,
+				body varchar(500) not null,
+				start_time bigint not null default 0,
+				end_time bigint not null default 0
+			)`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`alter table boards
+				add column visibility varchar(8) not null default 'public'`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return execAll(tx,
+			`create table post_revisions (
+				id bigserial primary key,
+				post_id bigint not null references posts on delete cascade,
+				reason varchar(10) not null,
+				by varchar(20),
+				time bigint not null default extract(epoch from now()),
+				body varchar(2000) not null
+			)`,
+			createIndex("post_revisions", "post_id"),
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		// Configurations written before these fields existed decode them as
+		// zero, which fails validation
+		return patchConfigs(tx, func(conf *config.Configs) {
+			if conf.IPRetention == 0 {
+				conf.IPRetention = config.Defaults.IPRetention
+			}
+			if conf.WebhookErrCap == 0 {
+				conf.WebhookErrCap = config.Defaults.WebhookErrCap
+			}
+			if conf.EmailErrCap == 0 {
+				conf.EmailErrCap = config.Defaults.EmailErrCap
+			}
+		})
+	},
+	func(tx *sql.Tx) (err error) {
+		return patchConfigs(tx, func(conf *config.Configs) {
+			if conf.DeletionWindow == 0 {
+				conf.DeletionWindow = config.Defaults.DeletionWindow
+			}
+		})
+	},
+	func(tx *sql.Tx) (err error) {
+		// Time the stored IP of a ban was hashed at. Determines the IP
+		// hashing salts, that must be retained for active bans.
+		return execAll(tx,
+			`alter table bans
+				add column ip_hashed timestamp not null
+					default (now() at time zone 'utc')`,
+			`update bans as b
+				set ip_hashed = to_timestamp(p.time) at time zone 'utc'
+				from posts as p
+				where p.id = b.forPost`,
+		)
+	},
+	func(tx *sql.Tx) (err error) {
+		return patchConfigs(tx, func(conf *config.Configs) {
+			if conf.ResyncThreshold == 0 {
+				conf.ResyncThreshold = config.Defaults.ResyncThreshold
+			}
+		})
+	},
+	func(tx *sql.Tx) (err error) {
+		// Encrypted post bodies are base64 encoded ciphertext up to
+		// common.MaxLenEncryptedBody long. Plain text bodies are still
+		// limited by the server.
+		return execAll(tx,
+			`alter table posts alter column body type text`,
+			`alter table post_revisions alter column body type text`,
+		)
+	},
+}
+
+// Migrations reverting migrations[i] by index i. Only recent schema changes
+// can be reverted.
+var downMigrations = map[int]func(*sql.Tx) error{
+	80: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table images rename column file_type to fileType`,
+			`alter table images rename column thumb_type to thumbType`,
+		)
+	},
+	81: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table post_reservations`)
+	},
+	82: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table boards drop column proxyPolicy`,
+			`alter table posts drop column proxy`,
+		)
+	},
+	83: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table boards drop column posterIDs`,
+			`alter table posts drop column poster_id`,
+		)
+	},
+	84: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table boards
+				drop column duplicateLimit,
+				drop column duplicateWindow,
+				drop column duplicatePolicy`,
+		)
+	},
+	85: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table threads drop column encrypted`)
+	},
+	86: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table ip_salts`)
+	},
+	87: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table boards
+				drop column disableCaptcha,
+				drop column maxBodyLength,
+				drop column postCooldown,
+				drop column fileTypes`,
+		)
+	},
+	88: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table images drop column animated_thumb`)
+	},
+	89: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`drop index reports_target`,
+			`alter table reports
+				drop column resolved,
+				drop column resolved_by`,
+		)
+	},
+	90: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table thread_redirects`)
+	},
+	91: func(tx *sql.Tx) (err error) {
+		err = dropFunctions(tx, "post_count")
+		if err != nil {
+			return
+		}
+		err = registerFunctions(tx, "post_count")
+		if err != nil {
+			return
+		}
+		err = loadSQL(tx, "triggers/posts")
+		if err != nil {
+			return
+		}
+		return execAll(tx,
+			`alter table bans drop column shadow`,
+			`alter table posts drop column shadowed`,
+		)
+	},
+	92: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table boards drop column hideOpenPosts`)
+	},
+	93: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table posts drop column disconnected`)
+	},
+	94: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table post_drawings`)
+	},
+	95: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table boards
+				drop column webhookURL,
+				drop column webhookSecret`,
+		)
+	},
+	96: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table api_tokens`)
+	},
+	97: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`drop table cluster_messages`,
+			`drop table rate_limits`,
+			`alter table mod_log drop column published`,
+		)
+	},
+	98: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table boards drop column defaultLang`)
+	},
+	99: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table boards drop column radioBanner`)
+	},
+	100: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`drop index images_md5`,
+			`alter table images drop column phash`,
+		)
+	},
+	101: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table announcement_banners`)
+	},
+	102: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table boards drop column visibility`)
+	},
+	103: func(tx *sql.Tx) error {
+		return execAll(tx, `drop table post_revisions`)
+	},
+	// Patched configuration fields are ignored by older versions
+	104: func(*sql.Tx) error {
+		return nil
+	},
+	105: func(*sql.Tx) error {
+		return nil
+	},
+	106: func(tx *sql.Tx) error {
+		return execAll(tx, `alter table bans drop column ip_hashed`)
+	},
+	107: func(*sql.Tx) error {
+		return nil
+	},
+	108: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`alter table posts
+				alter column body type varchar(2000) using left(body, 2000)`,
+			`alter table post_revisions
+				alter column body type varchar(2000) using left(body, 2000)`,
+		)
+	},
 }
 
 func createIndex(table, column string) string {
@@ -1148,32 +1579,144 @@ func loadSQL(tx *sql.Tx, paths ...string) (err error) {
 }
 
 // Run migrations from version `from`to version `to`
-func runMigrations(from, to int) (err error) {
-	for i := from; i < to; i++ {
-		if !common.IsTest {
-			log.Infof("upgrading database to version %d", i+1)
+func runMigrations(from, to int) error {
+	return migrate(from, to, false)
+}
+
+// Apply up or down migrations from version `from` to version `to`, each in
+// its own transaction. With dryRun all migrations are run in a single
+// transaction, that is rolled back.
+func migrate(from, to int, dryRun bool) (err error) {
+	if to < 0 || to > version {
+		return fmt.Errorf("invalid database version: %d", to)
+	}
+	step := 1
+	if to < from {
+		step = -1
+		for i := to; i < from; i++ {
+			if downMigrations[i] == nil {
+				return fmt.Errorf("database version %d can not be reverted",
+					i+1)
+			}
 		}
-		err = InTransaction(false, func(tx *sql.Tx) (err error) {
-			err = migrations[i](tx)
+	}
+
+	logStep := func(v int) {
+		if common.IsTest {
+			return
+		}
+		verb := "upgrading"
+		if step == -1 {
+			verb = "downgrading"
+		}
+		if dryRun {
+			verb = "dry run: " + verb
+		}
+		log.Infof("%s database to version %d", verb, v)
+	}
+
+	if dryRun {
+		var tx *sql.Tx
+		tx, err = db.Begin()
+		if err != nil {
+			return
+		}
+		defer tx.Rollback()
+		for v := from; v != to; v += step {
+			logStep(v + step)
+			err = applyMigration(tx, v, v+step)
 			if err != nil {
-				return
+				return fmt.Errorf("migration error: %d -> %d: %s: %#v",
+					v, v+step, err, err)
 			}
+		}
+		return
+	}
 
-			// Write new version number
-			_, err = tx.Exec(
-				`update main set val = $1 where id = 'version'`,
-				i+1,
-			)
-			return
+	for v := from; v != to; v += step {
+		logStep(v + step)
+		err = InTransaction(false, func(tx *sql.Tx) error {
+			return applyMigration(tx, v, v+step)
 		})
 		if err != nil {
 			return fmt.Errorf("migration error: %d -> %d: %s: %#v",
-				i, i+1, err, err)
+				v, v+step, err, err)
 		}
 	}
 	return
 }
 
+// Apply a single migration between adjacent versions and write the new
+// version number
+func applyMigration(tx *sql.Tx, from, to int) (err error) {
+	if to > from {
+		err = migrations[from](tx)
+	} else {
+		err = downMigrations[to](tx)
+	}
+	if err != nil {
+		return
+	}
+	_, err = tx.Exec(`update main set val = $1 where id = 'version'`, to)
+	return
+}
+
+// SchemaVersion returns the current version of the database schema and the
+// latest version known to this build. Requires Connect to have been called.
+func SchemaVersion() (current, latest int, err error) {
+	current, err = schemaVersion()
+	latest = version
+	return
+}
+
+// Read the schema version from the database. Returns 0 for uninitialized
+// databases.
+func schemaVersion() (v int, err error) {
+	var exists bool
+	err = db.QueryRow(
+		`select exists (
+			select 1 from information_schema.tables
+				where table_schema = 'public' and table_name = 'main'
+		)`,
+	).
+		Scan(&exists)
+	if err != nil || !exists {
+		return
+	}
+	err = db.QueryRow(`select val from main where id = 'version'`).Scan(&v)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
+
+// Migrate migrates the database schema to version `to`, applying up or down
+// migrations as needed. A negative `to` migrates to the latest version. With
+// dryRun the migrations are tested in a transaction, that is rolled back.
+// Requires Connect to have been called.
+func Migrate(to int, dryRun bool) (err error) {
+	from, err := schemaVersion()
+	if err != nil {
+		return
+	}
+	if from == 0 {
+		return errors.New("database not initialized")
+	}
+	if from > version {
+		return errNewerVersion(from)
+	}
+	if to < 0 {
+		to = version
+	}
+	return migrate(from, to, dryRun)
+}
+
+func errNewerVersion(v int) error {
+	return fmt.Errorf(
+		"database version %d is newer than the latest known version %d",
+		v, version)
+}
+
 func rollBack(tx *sql.Tx, err error) error {
 	if rbErr := tx.Rollback(); rbErr != nil {
 		err = util.WrapError(err.Error(), rbErr)
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestDownMigrations(t *testing.T) {
+	for i := range downMigrations {
+		if i < 0 || i >= version {
+			t.Fatalf("revert of nonexistent migration: %d", i)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("dry run revert", func(t *testing.T) {
+		err := Migrate(80, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := schemaVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, v, version)
+	})
+
+	t.Run("irreversible", func(t *testing.T) {
+		if err := Migrate(1, true); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		if err := Migrate(version+1, true); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		if err := Migrate(-1, false); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package db
 import (
 	"database/sql"
 	"encoding/binary"
+	"time"
 
+	"github.com/bakape/meguca/config"
 	"github.com/boltdb/bolt"
 )
 
@@ -108,3 +110,39 @@ func cleanUpOpenPostBodies() (err error) {
 		})
 	})
 }
+
+// SetOpenPostDisconnected records the author of an open post has lost
+// connection to it. Unless reclaimed within the configured reclaim window, the
+// post is closed.
+func SetOpenPostDisconnected(id uint64) error {
+	_, err := sq.Update("posts").
+		Set("disconnected", time.Now().UTC()).
+		Where("id = ? and editing = true", id).
+		Exec()
+	return err
+}
+
+// ReclaimOpenPost resumes editing of an open post by a reconnected author.
+// Returns false, if the post is already closed or the reclaim window has
+// passed.
+func ReclaimOpenPost(id uint64) (ok bool, err error) {
+	q := sq.Update("posts").
+		Set("disconnected", nil).
+		Where("id = ? and editing = true", id).
+		Suffix("returning true")
+	if w := reclaimWindow(); w != 0 {
+		q = q.Where("(disconnected is null or disconnected > ?)",
+			time.Now().Add(-w).UTC())
+	}
+	err = q.QueryRow().Scan(&ok)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
+
+// Time a disconnected author has to reclaim their open post. 0 means
+// indefinitely.
+func reclaimWindow() time.Duration {
+	return time.Duration(config.Get().ReclaimWindow) * time.Minute
+}
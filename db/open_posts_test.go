@@ -3,7 +3,10 @@ package db
 import (
 	"database/sql"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	. "github.com/bakape/meguca/test"
 	"testing"
+	"time"
 )
 
 func TestCleanUpOpenPostBodies(t *testing.T) {
@@ -77,3 +80,79 @@ func TestCleanUpOpenPostBodies(t *testing.T) {
 		})
 	}
 }
+
+func TestReclaimOpenPost(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	conf := config.Get()
+	defer config.Set(*conf)
+	c := *conf
+	c.ReclaimWindow = 5
+	config.Set(c)
+
+	err := InTransaction(false, func(tx *sql.Tx) (err error) {
+		for _, p := range [...]struct {
+			open bool
+			id   uint64
+		}{
+			{false, 2},
+			{true, 3},
+			{true, 4},
+		} {
+			err = WritePost(tx, Post{
+				StandalonePost: common.StandalonePost{
+					OP:    1,
+					Board: "a",
+					Post: common.Post{
+						ID:      p.id,
+						Editing: p.open,
+					},
+				},
+			})
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range [...]uint64{2, 3} {
+		err = SetOpenPostDisconnected(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = sq.Update("posts").
+		Set("disconnected", time.Now().Add(-time.Minute*6).UTC()).
+		Where("id = 4").
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [...]struct {
+		name string
+		id   uint64
+		ok   bool
+	}{
+		{"closed", 2, false},
+		{"does not exist", 99, false},
+		{"within window", 3, true},
+		{"window passed", 4, false},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			ok, err := ReclaimOpenPost(c.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertDeepEquals(t, ok, c.ok)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/bakape/meguca/common"
+)
+
+// Snapshot the current body of a post before modifying it. by is the staff
+// account performing the modification or "" for none. Posts still open for
+// editing are skipped, as their bodies are not yet stored in the database.
+func writePostRevision(tx *sql.Tx, id uint64, reason, by string) (err error) {
+	var byArg interface{}
+	if by != "" {
+		byArg = by
+	}
+	_, err = tx.Exec(
+		`insert into post_revisions (post_id, reason, by, body)
+			select id, $2, $3, body
+			from posts
+			where id = $1 and not editing`,
+		id, reason, byArg,
+	)
+	return
+}
+
+// GetPostRevisions retrieves all snapshots of a post's body in chronological
+// order
+func GetPostRevisions(id uint64) (revs []common.PostRevision, err error) {
+	revs = make([]common.PostRevision, 0, 4)
+	err = queryAll(
+		sq.Select("id", "reason", "by", "time", "body").
+			From("post_revisions").
+			Where("post_id = ?", id).
+			OrderBy("id"),
+		func(r *sql.Rows) (err error) {
+			var (
+				rev common.PostRevision
+				by  sql.NullString
+			)
+			err = r.Scan(&rev.ID, &rev.Reason, &by, &rev.Time, &rev.Body)
+			if err != nil {
+				return
+			}
+			rev.By = by.String
+			revs = append(revs, rev)
+			return
+		},
+	)
+	return
+}
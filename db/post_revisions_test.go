@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestPostRevisions(t *testing.T) {
+	prepareForModeration(t)
+
+	revs, err := GetPostRevisions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, revs, []common.PostRevision{})
+
+	_, err = sq.Update("posts").
+		Set("body", "foo bar").
+		Where("id = 1").
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PurgePost(1, "admin", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err = GetPostRevisions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 1 {
+		t.Fatalf("unexpected revision count: %d", len(revs))
+	}
+	rev := revs[0]
+	if rev.ID == 0 || rev.Time == 0 {
+		t.Fatal("revision ID or time not set")
+	}
+	rev.ID = 0
+	rev.Time = 0
+	AssertDeepEquals(t, rev, common.PostRevision{
+		Reason: common.RevisionRedaction,
+		By:     "admin",
+		Body:   "foo bar",
+	})
+}
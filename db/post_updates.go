@@ -3,23 +3,30 @@ package db
 import (
 	"database/sql"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/events"
 )
 
 // ClosePost closes an open post and commits any links and hash commands
 func ClosePost(id, op uint64, body string, links []common.Link,
 	com []common.Command,
 ) (err error) {
+	var (
+		shadowed bool
+		board    string
+	)
 	err = InTransaction(false, func(tx *sql.Tx) (err error) {
-		_, err = sq.Update("posts").
+		err = sq.Update("posts").
 			SetMap(map[string]interface{}{
 				"editing":  false,
 				"body":     body,
 				"commands": commandRow(com),
-				"password": nil,
 			}).
 			Where("id = ?", id).
+			Suffix("returning shadowed, board").
 			RunWith(tx).
-			Exec()
+			QueryRow().
+			Scan(&shadowed, &board)
 		if err != nil {
 			return
 		}
@@ -30,12 +37,32 @@ func ClosePost(id, op uint64, body string, links []common.Link,
 		return
 	}
 
-	if !common.IsTest {
-		// TODO: Propagate this with DB listener
+	// TODO: Propagate this with DB listener
+	switch {
+	case common.IsTest:
+	case shadowed:
+		// Closing of posts hidden by a shadow ban is only sent to the author
+	case config.GetBoardConfigs(board).HideOpenPosts:
+		// Other clients have not seen the post yet
+		err = common.PublishPost(id, op)
+	default:
 		err = common.ClosePost(id, op, links, com)
-		if err != nil {
-			return
-		}
+	}
+	if err != nil {
+		return
+	}
+	if !shadowed {
+		events.Publish(events.Event{
+			Type:   events.PostClosed,
+			Board:  board,
+			Thread: op,
+			Post:   id,
+			Data: events.ClosedPost{
+				Body:     body,
+				Links:    links,
+				Commands: com,
+			},
+		})
 	}
 
 	return deleteOpenPostBody(id)
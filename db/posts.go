@@ -2,8 +2,12 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 )
@@ -36,6 +40,15 @@ func GetPostBoard(id uint64) (board string, err error) {
 	return
 }
 
+// GetPostIP returns the IP or IP hash a post was created from. Returns an
+// empty string, if the IP was already cleared.
+func GetPostIP(id uint64) (ip string, err error) {
+	var s sql.NullString
+	err = selectPost(id, "ip").Scan(&s)
+	ip = s.String
+	return
+}
+
 func getCounter(q squirrel.SelectBuilder) (uint64, error) {
 	var c sql.NullInt64
 	err := q.QueryRow().Scan(&c)
@@ -67,7 +80,8 @@ func WritePost(tx *sql.Tx, p Post) (err error) {
 		spoiler bool
 	)
 	if p.IP != "" {
-		ip = &p.IP
+		hashed := HashIP(p.IP)
+		ip = &hashed
 	}
 	if p.Image != nil {
 		img = &p.Image.SHA1
@@ -80,13 +94,13 @@ func WritePost(tx *sql.Tx, p Post) (err error) {
 			"editing", "spoiler", "id", "board", "op", "time", "body", "flag",
 			"name", "trip", "auth", "password", "ip",
 			"SHA1", "imageName",
-			"commands",
+			"commands", "proxy", "shadowed",
 		).
 		Values(
 			p.Editing, spoiler, p.ID, p.Board, p.OP, p.Time, p.Body, p.Flag,
 			p.Name, p.Trip, p.Auth, p.Password, ip,
 			img, imgName,
-			commandRow(p.Commands),
+			commandRow(p.Commands), p.Proxy, p.Shadowed,
 		).
 		RunWith(tx).
 		Exec()
@@ -105,21 +119,30 @@ func WritePost(tx *sql.Tx, p Post) (err error) {
 }
 
 // Insert Post into thread and set its ID and creation time.
-// Thread OPs must have their post ID set to the thread ID.
+// Thread OPs must have their post ID set to the thread ID. Replies committed
+// from a post reservation must have their ID set to the reserved ID.
 // Any images are to be inserted in a separate call.
 func InsertPost(tx *sql.Tx, p *Post) (err error) {
+	var posterID *string
+	if config.GetBoardConfigs(p.Board).PosterIDs {
+		p.PosterID = PosterID(p.OP, p.IP)
+		posterID = &p.PosterID
+	}
+
 	args := make([]interface{}, 0, 16)
 	args = append(args,
 		p.Editing, p.Board, p.OP, p.Body, p.Flag,
-		p.Name, p.Trip, p.Auth, p.Password, p.IP)
+		p.Name, p.Trip, p.Auth, p.Password, HashIP(p.IP), p.Proxy, posterID,
+		p.Shadowed)
 
 	q := sq.Insert("posts").
 		Columns(
 			"editing", "board", "op", "body", "flag",
-			"name", "trip", "auth", "password", "ip",
+			"name", "trip", "auth", "password", "ip", "proxy", "poster_id",
+			"shadowed",
 		)
 
-	if p.ID != 0 { // OP of a thread
+	if p.ID != 0 { // OP of a thread or reserved post
 		q = q.Columns("id")
 		args = append(args, p.ID)
 	}
@@ -133,6 +156,99 @@ func InsertPost(tx *sql.Tx, p *Post) (err error) {
 	return
 }
 
+// PosterID generates a poster's ID unique to the thread, that can not be
+// reversed to the poster's IP
+func PosterID(op uint64, ip string) string {
+	h := sha256.New()
+	h.Write([]byte(config.Get().Salt))
+	h.Write(encodeUint64Heap(op))
+	h.Write([]byte(ip))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))[:8]
+}
+
+// Count the posts of each poster ID in a thread. Posts hidden by a shadow ban
+// are not counted.
+func getPosterCounts(tx *sql.Tx, op uint64) (counts map[string]uint, err error) {
+	r, err := sq.Select("poster_id", "count(*)").
+		From("posts").
+		Where("op = ? and poster_id is not null and not shadowed", op).
+		GroupBy("poster_id").
+		RunWith(tx).
+		Query()
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	var (
+		id string
+		n  uint
+	)
+	for r.Next() {
+		err = r.Scan(&id, &n)
+		if err != nil {
+			return
+		}
+		if counts == nil {
+			counts = make(map[string]uint)
+		}
+		counts[id] = n
+	}
+	err = r.Err()
+	return
+}
+
+// ForEachPostSince runs fn on the board, body and creation time of each closed
+// post created after since. Posts hidden by a shadow ban and posts in
+// encrypted threads are skipped.
+func ForEachPostSince(since int64, fn func(board, body string, time int64),
+) error {
+	var (
+		board, body string
+		t           int64
+	)
+	return queryAll(
+		sq.Select("p.board", "p.body", "p.time").
+			From("posts as p").
+			Join("threads as t on p.op = t.id").
+			Where("p.time > ? and p.editing = false and not p.shadowed",
+				since).
+			Where("not t.encrypted"),
+		func(r *sql.Rows) (err error) {
+			err = r.Scan(&board, &body, &t)
+			if err != nil {
+				return
+			}
+			fn(board, body, t)
+			return
+		},
+	)
+}
+
+// HasPostedWithin returns, if an IP has created a post on a board within the
+// passed duration
+func HasPostedWithin(board, ip string, d time.Duration) (has bool, err error) {
+	err = sq.Select().
+		Column(`exists (
+			select 1
+			from posts
+			where board = ? and ip = ? and time > ?
+		)`,
+			board, HashIP(ip), time.Now().Add(-d).Unix()).
+		QueryRow().
+		Scan(&has)
+	return
+}
+
+// IsPostOpen returns, if a post exists and is still open for editing
+func IsPostOpen(id uint64) (open bool, err error) {
+	err = selectPost(id, "editing").Scan(&open)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
+
 // GetPostPassword retrieves a post's modification password
 func GetPostPassword(id uint64) (p []byte, err error) {
 	err = sq.Select("password").From("posts").Where("id = ?", id).Scan(&p)
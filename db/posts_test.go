@@ -124,3 +124,94 @@ func TestGetPostPassword(t *testing.T) {
 	}
 	test.AssertDeepEquals(t, res, p.Password)
 }
+
+func TestPosterCounts(t *testing.T) {
+	p := insertPost(t)
+	id := PosterID(1, "::1")
+	assertExec(t,
+		`update posts set poster_id = $1 where id in (1, $2)`,
+		id, p.ID)
+
+	// Posts hidden by shadow bans must not be counted
+	err := InTransaction(false, func(tx *sql.Tx) error {
+		return WritePost(tx, Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:       p.ID + 1,
+					Time:     time.Now().Unix(),
+					Shadowed: true,
+				},
+				OP:    1,
+				Board: "a",
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExec(t,
+		`update posts set poster_id = $1 where id = $2`,
+		PosterID(1, "::2"), p.ID+1)
+
+	var counts map[string]uint
+	err = InTransaction(true, func(tx *sql.Tx) (err error) {
+		counts, err = getPosterCounts(tx, 1)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, counts, map[string]uint{id: 2})
+
+	if PosterID(1, "::2") == id || PosterID(2, "::1") == id {
+		t.Fatal("poster ID collision")
+	}
+}
+
+func TestForEachPostSince(t *testing.T) {
+	p := insertPost(t)
+
+	// Posts hidden by shadow bans must not be included
+	err := InTransaction(false, func(tx *sql.Tx) error {
+		return WritePost(tx, Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:       p.ID + 1,
+					Time:     time.Now().Unix(),
+					Body:     "hidden",
+					Shadowed: true,
+				},
+				OP:    1,
+				Board: "a",
+			},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither must posts in encrypted threads
+	err = InTransaction(false, func(tx *sql.Tx) error {
+		return InsertThread(tx, "", true, &Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					Body: "ciphertext",
+				},
+				Board: "a",
+			},
+			IP: "::1",
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bodies []string
+	err = ForEachPostSince(0, func(board, body string, time int64) {
+		bodies = append(bodies, body)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, bodies, []string{"", ""})
+}
@@ -13,8 +13,9 @@ import (
 )
 
 const (
-	postSelectsSQL = `p.editing, p.moderated, p.spoiler, p.sage, p.id,
-	p.time, p.body, p.flag, p.name, p.trip, p.auth,
+	postSelectsSQL = `p.editing, p.moderated, p.spoiler, p.sage, p.proxy,
+	p.shadowed, p.id,
+	p.time, p.body, p.flag, p.name, p.trip, p.auth, p.poster_id,
 	(select array_agg((l.target, linked_post.op, linked_thread.board))
 		from links as l
 		join posts as linked_post on l.target = linked_post.id
@@ -28,15 +29,17 @@ const (
 	(
 		select count(*)
 		from posts
-		where t.id = posts.op
+		where t.id = posts.op and not posts.shadowed
 	),
 	(
 		select count(*)
 		from posts
 		where t.id = posts.op
 			and posts.SHA1 is not null
+			and not posts.shadowed
 	),
-	t.replyTime, t.bumpTime, t.subject, t.locked, ` + postSelectsSQL
+	t.replyTime, t.bumpTime, t.subject, t.locked, t.encrypted, ` +
+		postSelectsSQL
 
 	getOPSQL = `
 	select ` + threadSelectsSQL + `
@@ -50,7 +53,7 @@ const (
 		select ` + postSelectsSQL + `
 		from posts as p
 		left outer join images as i on p.SHA1 = i.SHA1
-		where p.op = $1 and p.id != $1
+		where p.op = $1 and p.id != $1 and not p.shadowed
 		order by p.id desc
 		limit $2
 	)
@@ -59,10 +62,10 @@ const (
 )
 
 type imageScanner struct {
-	Audio, Video, Spoiler             sql.NullBool
-	FileType, ThumbType, Length, Size sql.NullInt64
-	Name, SHA1, MD5, Title, Artist    sql.NullString
-	Dims                              pq.Int64Array
+	Audio, Video, Spoiler, AnimatedThumb  sql.NullBool
+	FileType, ThumbType, Length, Size     sql.NullInt64
+	Name, SHA1, MD5, Title, Artist, PHash sql.NullString
+	Dims                                  pq.Int64Array
 }
 
 // Returns and array of pointers to the struct fields for passing to
@@ -71,6 +74,7 @@ func (i *imageScanner) ScanArgs() []interface{} {
 	return []interface{}{
 		&i.Audio, &i.Video, &i.FileType, &i.ThumbType, &i.Dims,
 		&i.Length, &i.Size, &i.MD5, &i.SHA1, &i.Title, &i.Artist,
+		&i.AnimatedThumb, &i.PHash,
 	}
 }
 
@@ -99,6 +103,9 @@ func (i *imageScanner) Val() *common.Image {
 			SHA1:      i.SHA1.String,
 			Title:     i.Title.String,
 			Artist:    i.Artist.String,
+			PHash:     i.PHash.String,
+
+			AnimatedThumb: i.AnimatedThumb.Bool,
 		},
 		Name: i.Name.String,
 	}
@@ -108,14 +115,16 @@ type postScanner struct {
 	common.Post
 	spoiler   bool
 	imageName string
+	posterID  sql.NullString
 	links     linkScanner
 	commands  commandRow
 }
 
 func (p *postScanner) ScanArgs() []interface{} {
 	return []interface{}{
-		&p.Editing, &p.Moderated, &p.spoiler, &p.Sage, &p.ID, &p.Time, &p.Body,
-		&p.Flag, &p.Name, &p.Trip, &p.Auth, &p.links, &p.commands,
+		&p.Editing, &p.Moderated, &p.spoiler, &p.Sage, &p.Proxy, &p.Shadowed,
+		&p.ID, &p.Time, &p.Body,
+		&p.Flag, &p.Name, &p.Trip, &p.Auth, &p.posterID, &p.links, &p.commands,
 		&p.imageName,
 	}
 }
@@ -123,6 +132,7 @@ func (p *postScanner) ScanArgs() []interface{} {
 func (p postScanner) Val() (common.Post, error) {
 	p.Links = []common.Link(p.links)
 	p.Commands = []common.Command(p.commands)
+	p.PosterID = p.posterID.String
 
 	return p.Post, nil
 }
@@ -186,12 +196,44 @@ func GetThread(id uint64, lastN int) (t common.Thread, err error) {
 			}
 			t.Posts = append(t.Posts, p)
 		}
-		return r.Err()
+		err = r.Err()
+		if err != nil {
+			return
+		}
+
+		// Count the replies left out, if the limit was reached
+		if lastN != 0 && len(t.Posts) == lastN {
+			err = tx.
+				QueryRow(
+					`select count(*) from posts
+					where op = $1 and id != $1 and not shadowed`,
+					id,
+				).
+				Scan(&t.Omitted)
+			if err != nil {
+				return
+			}
+			t.Omitted -= uint(lastN)
+		}
+
+		t.PosterCounts, err = getPosterCounts(tx, id)
+		return
 	})
 	if err != nil {
 		return
 	}
 
+	// Open replies are only published once closed on some boards
+	if config.GetBoardConfigs(t.Board).HideOpenPosts {
+		closed := t.Posts[:0]
+		for _, p := range t.Posts {
+			if !p.Editing {
+				closed = append(closed, p)
+			}
+		}
+		t.Posts = closed
+	}
+
 	// Inject bodies and moderation into open posts
 	open := make([]*common.Post, 0, 64)
 	moderated := make([]*common.Post, 0, 64)
@@ -213,11 +255,11 @@ func scanOP(r rowScanner) (t common.Thread, err error) {
 		img   imageScanner
 		pArgs = post.ScanArgs()
 		iArgs = img.ScanArgs()
-		args  = make([]interface{}, 0, 8+len(pArgs)+len(iArgs))
+		args  = make([]interface{}, 0, 9+len(pArgs)+len(iArgs))
 	)
 	args = append(args,
 		&t.Sticky, &t.Board, &t.PostCtr, &t.ImageCtr, &t.ReplyTime, &t.BumpTime,
-		&t.Subject, &t.Locked,
+		&t.Subject, &t.Locked, &t.Encrypted,
 	)
 	args = append(args, pArgs...)
 	args = append(args, iArgs...)
@@ -291,11 +333,21 @@ func GetPost(id uint64) (res common.StandalonePost, err error) {
 	return
 }
 
+// Select OPs of threads not created by shadow banned posters
 func getOPs() squirrel.SelectBuilder {
 	return sq.Select(threadSelectsSQL).
 		From("threads as t").
 		Join("posts as p on t.id = p.id").
-		LeftJoin("images as i on p.SHA1 = i.SHA1")
+		LeftJoin("images as i on p.SHA1 = i.SHA1").
+		Where("not p.shadowed")
+}
+
+// Select IDs of threads not created by shadow banned posters
+func getThreadIDs() squirrel.SelectBuilder {
+	return sq.Select("t.id").
+		From("threads as t").
+		Join("posts as p on t.id = p.id").
+		Where("not p.shadowed")
 }
 
 // GetBoardCatalog retrieves all OPs of a single board
@@ -308,15 +360,15 @@ func GetBoardCatalog(board string) (b common.Board, err error) {
 
 // GetThreadIDs retrieves all threads IDs on the board in bump order with stickies first
 func GetThreadIDs(board string) ([]uint64, error) {
-	return scanThreadIDs(sq.Select("id").
-		From("threads").
-		Where("board = ?", board).
-		OrderBy("sticky desc, bumpTime desc"))
+	return scanThreadIDs(getThreadIDs().
+		Where("t.board = ?", board).
+		OrderBy("t.sticky desc, t.bumpTime desc"))
 }
 
 // GetAllBoardCatalog retrieves all threads for the "/all/" meta-board
 func GetAllBoardCatalog() (board common.Board, err error) {
-	board, err = scanCatalog(getOPs().OrderBy("bumpTime desc"))
+	board, err = scanCatalog(excludeUnlisted(getOPs()).
+		OrderBy("bumpTime desc"))
 	if err != nil {
 		return
 	}
@@ -338,9 +390,16 @@ func GetAllBoardCatalog() (board common.Board, err error) {
 
 // GetAllThreadsIDs retrieves all threads IDs in bump order
 func GetAllThreadsIDs() ([]uint64, error) {
-	return scanThreadIDs(sq.Select("id").
-		From("threads").
-		OrderBy("bumpTime desc"))
+	return scanThreadIDs(excludeUnlisted(getThreadIDs()).
+		OrderBy("t.bumpTime desc"))
+}
+
+// Exclude threads on boards not included in "/all/"
+func excludeUnlisted(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	if hidden := config.GetUnlistedBoards(); len(hidden) != 0 {
+		q = q.Where(squirrel.NotEq{"t.board": hidden})
+	}
+	return q
 }
 
 func scanCatalog(q squirrel.SelectBuilder) (board common.Board, err error) {
@@ -449,6 +508,9 @@ func injectModeration(posts []*common.Post) (err error) {
 		if err != nil {
 			return
 		}
+		if e.Type.IsStaffOnly() {
+			continue
+		}
 		byID[id].Moderation = append(byID[id].Moderation, e)
 	}
 
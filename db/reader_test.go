@@ -364,6 +364,7 @@ func testGetThread(t *testing.T) {
 	sliced := thread1
 	sliced.Posts = sliced.Posts[1:]
 	sliced.Abbrev = true
+	sliced.Omitted = 1
 
 	cases := [...]struct {
 		name  string
@@ -432,3 +433,55 @@ func testGetThread(t *testing.T) {
 		})
 	}
 }
+
+func TestGetThreadHideOpenPosts(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	err := InTransaction(false, func(tx *sql.Tx) (err error) {
+		for i, editing := range [...]bool{false, true} {
+			err = WritePost(tx, Post{
+				StandalonePost: common.StandalonePost{
+					Post: common.Post{
+						ID:      uint64(i + 2),
+						Editing: editing,
+					},
+					OP:    1,
+					Board: "a",
+				},
+			})
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer config.Clear()
+	for _, hide := range [...]bool{false, true} {
+		config.SetBoardConfigs(config.BoardConfigs{
+			ID: "a",
+			BoardPublic: config.BoardPublic{
+				HideOpenPosts: hide,
+			},
+		})
+
+		thread, err := GetThread(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]uint64, 0, len(thread.Posts))
+		for _, p := range thread.Posts {
+			ids = append(ids, p.ID)
+		}
+		std := []uint64{2, 3}
+		if hide {
+			std = std[:1]
+		}
+		AssertDeepEquals(t, ids, std)
+	}
+}
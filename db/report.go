@@ -20,7 +20,7 @@ func Report(id uint64, board, reason, ip string, illegal bool) error {
 
 	_, err := sq.Insert("reports").
 		Columns("target", "board", "reason", "by", "illegal").
-		Values(id, board, reason, ip, illegal).
+		Values(id, board, reason, HashIP(ip), illegal).
 		Exec()
 
 	return err
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Time a reserved post ID stays claimable, before it is reaped
+const reservationTimeout = time.Minute * 5
+
+// ErrInvalidReservation occurs, when a client tries to commit a post with a
+// post reservation claim token, that does not exist, has expired or belongs
+// to a different thread or IP.
+var ErrInvalidReservation = common.ErrInvalidInput("invalid post reservation")
+
+// ReservePost allocates a post ID in a thread without creating any publically
+// visible post. Returns the reserved ID and a claim token, that must be
+// presented to commit the post.
+func ReservePost(op uint64, ip string) (id uint64, token string, err error) {
+	token, err = auth.RandomID(32)
+	if err != nil {
+		return
+	}
+	err = sq.Insert("post_reservations").
+		Columns("id", "op", "token", "ip", "expires").
+		Values(
+			squirrel.Expr("nextval('post_id')"),
+			op,
+			token,
+			HashIP(ip),
+			time.Now().Add(reservationTimeout).UTC(),
+		).
+		Suffix("returning id").
+		QueryRow().
+		Scan(&id)
+	return
+}
+
+// ClaimPostReservation consumes a post reservation, so its ID can be used for
+// inserting the post. Must be called in the same transaction as the post
+// insertion.
+func ClaimPostReservation(tx *sql.Tx, op uint64, ip, token string) (
+	id uint64, err error,
+) {
+	err = tx.QueryRow(
+		`delete from post_reservations
+		where token = $1
+			and op = $2
+			and ip = $3
+			and expires > now() at time zone 'utc'
+		returning id`,
+		token, op, HashIP(ip),
+	).
+		Scan(&id)
+	if err == sql.ErrNoRows {
+		err = ErrInvalidReservation
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	. "github.com/bakape/meguca/test"
+	"testing"
+)
+
+func TestPostReservations(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	id, token, err := ReservePost(1, "::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == 0 || token == "" {
+		t.Fatalf("invalid reservation: %d %s", id, token)
+	}
+
+	cases := [...]struct {
+		name, ip, token string
+		op, id          uint64
+		err             error
+	}{
+		{"wrong thread", "::1", token, 2, 0, ErrInvalidReservation},
+		{"wrong IP", "::2", token, 1, 0, ErrInvalidReservation},
+		{"wrong token", "::1", "foo", 1, 0, ErrInvalidReservation},
+		{"valid", "::1", token, 1, id, nil},
+		{"already claimed", "::1", token, 1, 0, ErrInvalidReservation},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			var res uint64
+			err := InTransaction(false, func(tx *sql.Tx) (err error) {
+				res, err = ClaimPostReservation(tx, c.op, c.ip, c.token)
+				return
+			})
+			if err != c.err {
+				UnexpectedError(t, err)
+			}
+			AssertDeepEquals(t, res, c.id)
+		})
+	}
+}
+
+func TestExpireReservations(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	_, token, err := ReservePost(1, "::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExec(t,
+		`update post_reservations
+		set expires = now() at time zone 'utc' - interval '1 minute'`,
+	)
+	expireRows("post_reservations")
+
+	err = InTransaction(false, func(tx *sql.Tx) (err error) {
+		_, err = ClaimPostReservation(tx, 1, "::1", token)
+		return
+	})
+	if err != ErrInvalidReservation {
+		UnexpectedError(t, err)
+	}
+}
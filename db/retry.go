@@ -0,0 +1,240 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/bakape/meguca/common"
+
+	"github.com/lib/pq"
+)
+
+// Circuit breaker states
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+var (
+	// Maximum attempts of a transaction failing with transient errors
+	maxAttempts = 4
+
+	// Delay before the first retry. Doubled with each subsequent retry.
+	retryBaseDelay = 50 * time.Millisecond
+
+	// Maximum delay between retries
+	retryMaxDelay = time.Second
+
+	// Consecutive transient failures, after which the circuit is opened
+	circuitThreshold = 5
+
+	// Time the circuit stays open for, before a trial transaction is allowed
+	circuitCooldown = 10 * time.Second
+
+	errCircuitOpen = common.StatusError{
+		Err:  errors.New("database temporarily unavailable"),
+		Code: 503,
+	}
+
+	breaker circuitBreaker
+
+	// Counters of transaction outcomes
+	txAttempts, txRetries, txFailures, txRejected uint64
+)
+
+// TransactionStats contains counters of database transaction retries and
+// failures since server start
+type TransactionStats struct {
+	// Transaction attempts including retries
+	Attempts uint64 `json:"attempts"`
+	// Attempts retried after transient errors
+	Retries uint64 `json:"retries"`
+	// Transactions, that failed with transient errors after all retries
+	Failures uint64 `json:"failures"`
+	// Transactions rejected by the open circuit breaker
+	Rejected uint64 `json:"rejected"`
+	// State of the circuit breaker. One of "closed", "open" or "half-open".
+	Circuit string `json:"circuit"`
+}
+
+// GetTransactionStats returns counters of database transaction retries and
+// failures
+func GetTransactionStats() TransactionStats {
+	var circuit string
+	switch breaker.currentState() {
+	case circuitClosed:
+		circuit = "closed"
+	case circuitOpen:
+		circuit = "open"
+	default:
+		circuit = "half-open"
+	}
+	return TransactionStats{
+		Attempts: atomic.LoadUint64(&txAttempts),
+		Retries:  atomic.LoadUint64(&txRetries),
+		Failures: atomic.LoadUint64(&txFailures),
+		Rejected: atomic.LoadUint64(&txRejected),
+		Circuit:  circuit,
+	}
+}
+
+// Sheds load during database outages by rejecting transactions after
+// consecutive transient failures. After a cooldown a single trial transaction
+// is allowed through to probe, if the database has recovered.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	// Trial transaction in progress in the half-open state
+	probing bool
+}
+
+// Returns the breaker state without side effects
+func (b *circuitBreaker) currentState() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Returns, if a transaction may be attempted
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < circuitCooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record the outcome of a transaction attempt. Only transient errors count as
+// failures.
+func (b *circuitBreaker) record(transient bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !transient {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= circuitThreshold {
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Wraps a transient error, that must not be retried
+type unretryable struct {
+	error
+}
+
+// Run fn, retrying transient errors with exponential backoff, unless the
+// circuit breaker is open
+func withRetry(fn func() error) (err error) {
+	for i := 0; i < maxAttempts; i++ {
+		if !breaker.allow() {
+			atomic.AddUint64(&txRejected, 1)
+			return errCircuitOpen
+		}
+		if i != 0 {
+			atomic.AddUint64(&txRetries, 1)
+		}
+		atomic.AddUint64(&txAttempts, 1)
+
+		err = fn()
+		final := false
+		if u, ok := err.(unretryable); ok {
+			err = u.error
+			final = true
+		}
+		transient := isTransient(err)
+		breaker.record(transient)
+		if !transient {
+			return
+		}
+		if final {
+			break
+		}
+		if i != maxAttempts-1 {
+			time.Sleep(retryDelay(i))
+		}
+	}
+	atomic.AddUint64(&txFailures, 1)
+	return
+}
+
+// Delay before retry number i with jitter to avoid synchronized retry storms
+func retryDelay(i int) time.Duration {
+	d := retryBaseDelay << uint(i)
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Returns, if an error is likely to be resolved by retrying the transaction
+func isTransient(err error) bool {
+	switch err {
+	case nil:
+		return false
+	case driver.ErrBadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	switch err := err.(type) {
+	case *pq.Error:
+		return isTransientCode(err.Code)
+	case net.Error:
+		return true
+	case syscall.Errno:
+		return err == syscall.ECONNREFUSED || err == syscall.ECONNRESET
+	}
+	return false
+}
+
+// Returns, if a PostgreSQL error code denotes a condition, that is likely to
+// be resolved by retrying the transaction
+func isTransientCode(code pq.ErrorCode) bool {
+	switch code {
+	case "40001", // serialization_failure
+		"40P01", // deadlock_detected
+		"53300", // too_many_connections
+		"57P01", // admin_shutdown
+		"57P03": // cannot_connect_now
+		return true
+	}
+	// Connection exceptions
+	return code.Class() == "08"
+}
+
+// Returns, if a transaction is known to have been rolled back after an error
+// on commit. Other commit errors, like lost connections, leave the outcome of
+// the transaction unknown, so it can not be safely retried.
+func isRolledBack(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/lib/pq"
+)
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"nil", nil, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"other", errors.New("foo"), false},
+	}
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, isTransient(c.err), c.transient)
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	oldDelay, oldCooldown := retryBaseDelay, circuitCooldown
+	retryBaseDelay = time.Millisecond
+	circuitCooldown = 50 * time.Millisecond
+	defer func() {
+		retryBaseDelay, circuitCooldown = oldDelay, oldCooldown
+		breaker = circuitBreaker{}
+	}()
+	breaker = circuitBreaker{}
+
+	t.Run("recovers", func(t *testing.T) {
+		calls := 0
+		err := withRetry(func() error {
+			calls++
+			if calls < 3 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, calls, 3)
+	})
+
+	t.Run("non-transient error", func(t *testing.T) {
+		calls := 0
+		err := withRetry(func() error {
+			calls++
+			return errors.New("foo")
+		})
+		AssertDeepEquals(t, err, errors.New("foo"))
+		AssertDeepEquals(t, calls, 1)
+	})
+
+	t.Run("unretryable", func(t *testing.T) {
+		calls := 0
+		err := withRetry(func() error {
+			calls++
+			return unretryable{driver.ErrBadConn}
+		})
+		AssertDeepEquals(t, err, driver.ErrBadConn)
+		AssertDeepEquals(t, calls, 1)
+		breaker = circuitBreaker{}
+	})
+
+	t.Run("circuit breaking", func(t *testing.T) {
+		fail := func() error {
+			return driver.ErrBadConn
+		}
+		withRetry(fail)
+		withRetry(fail)
+		if s := GetTransactionStats().Circuit; s != "open" {
+			t.Fatalf("unexpected circuit state: %s", s)
+		}
+		AssertDeepEquals(t, withRetry(fail), errCircuitOpen)
+
+		// Trial transaction closes the circuit
+		time.Sleep(circuitCooldown)
+		err := withRetry(func() error {
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, GetTransactionStats().Circuit, "closed")
+	})
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/common"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// ModerationStats contains moderation metrics of a board or staff member over
+// a period of time
+type ModerationStats struct {
+	ReportsFiled    uint64 `json:"reportsFiled,omitempty"`
+	ReportsResolved uint64 `json:"reportsResolved"`
+	Bans            uint64 `json:"bans"`
+	Deletions       uint64 `json:"deletions"`
+	// Mean time from a report being filed to it being resolved in seconds
+	AvgResponseTime float64 `json:"avgResponseTime"`
+	// Deletions per thousand posts created on the board
+	DeletionsPerMille float64 `json:"deletionsPerMille,omitempty"`
+}
+
+// BoardModerationStats contains moderation metrics of a board and each staff
+// member, that performed moderation on the board
+type BoardModerationStats struct {
+	ModerationStats
+	Staff map[string]ModerationStats `json:"staff"`
+}
+
+// GetModerationStats computes moderation metrics of a board for the period
+// since the passed time. Data older than ModLogRetention days is not retained.
+func GetModerationStats(board string, since time.Time) (
+	s BoardModerationStats, err error,
+) {
+	since = since.UTC()
+	s.Staff = make(map[string]ModerationStats)
+
+	err = sq.Select("count(*)").
+		From("reports").
+		Where("board = ? and created >= ?", board, since).
+		QueryRow().
+		Scan(&s.ReportsFiled)
+	if err != nil {
+		return
+	}
+
+	// Per staff member report resolution
+	err = queryAll(
+		sq.Select("resolved_by", "count(*)",
+			"extract(epoch from avg(resolved - created))").
+			From("reports").
+			Where("board = ? and resolved >= ?", board, since).
+			GroupBy("resolved_by"),
+		func(r *sql.Rows) (err error) {
+			var (
+				by  string
+				st  ModerationStats
+				avg sql.NullFloat64
+			)
+			err = r.Scan(&by, &st.ReportsResolved, &avg)
+			if err != nil {
+				return
+			}
+			st.AvgResponseTime = avg.Float64
+			s.Staff[by] = st
+
+			// Weighted by the amount of resolved reports
+			n := s.ReportsResolved + st.ReportsResolved
+			s.AvgResponseTime = (s.AvgResponseTime*float64(s.ReportsResolved) +
+				st.AvgResponseTime*float64(st.ReportsResolved)) / float64(n)
+			s.ReportsResolved = n
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	// Per staff member bans and deletions
+	err = queryAll(
+		sq.Select("by").
+			Column(squirrel.Expr("count(*) filter (where type = ?)",
+				common.BanPost)).
+			Column(squirrel.Expr(
+				"count(*) filter (where type in (?, ?, ?))",
+				common.DeletePost, common.DeleteImage, common.PurgePost)).
+			From("mod_log").
+			Where("board = ? and created >= ?", board, since).
+			GroupBy("by"),
+		func(r *sql.Rows) (err error) {
+			var (
+				by             string
+				bans, deletion uint64
+			)
+			err = r.Scan(&by, &bans, &deletion)
+			if err != nil || bans+deletion == 0 {
+				return
+			}
+			st := s.Staff[by]
+			st.Bans = bans
+			st.Deletions = deletion
+			s.Staff[by] = st
+			s.Bans += bans
+			s.Deletions += deletion
+			return
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	var posts uint64
+	err = sq.Select("count(*)").
+		From("posts").
+		Where("board = ? and time >= ?", board, since.Unix()).
+		QueryRow().
+		Scan(&posts)
+	if err != nil {
+		return
+	}
+	if posts != 0 {
+		s.DeletionsPerMille = float64(s.Deletions) * 1000 / float64(posts)
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	. "github.com/bakape/meguca/test"
+	"testing"
+	"time"
+)
+
+func TestModerationStats(t *testing.T) {
+	assertTableClear(t, "boards", "reports", "mod_log")
+	writeSampleBoard(t)
+	writeSampleThread(t)
+
+	err := Report(1, "a", "foo", "::1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Report(1, "a", "bar", "::2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DeletePost(1, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := GetModerationStats("a", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AvgResponseTime < 0 {
+		t.Fatalf("negative response time: %f", s.AvgResponseTime)
+	}
+
+	// Sync dynamic fields
+	st := s.Staff["admin"]
+	st.AvgResponseTime = 0
+	s.Staff["admin"] = st
+	s.AvgResponseTime = 0
+
+	AssertDeepEquals(t, s, BoardModerationStats{
+		ModerationStats: ModerationStats{
+			ReportsFiled:      2,
+			ReportsResolved:   2,
+			Deletions:         1,
+			DeletionsPerMille: 1000,
+		},
+		Staff: map[string]ModerationStats{
+			"admin": {
+				ReportsResolved: 2,
+				Deletions:       1,
+			},
+		},
+	})
+}
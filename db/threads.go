@@ -52,37 +52,43 @@ func DiffThreadPostCounts(old map[uint64]uint64) (
 }
 
 func loadThreadPostCounts() (err error) {
-	r, err := sq.Select("op, count(*)").
-		From("posts").
-		GroupBy("op").
-		Query()
+	err = readThreadPostCounts()
 	if err != nil {
 		return
 	}
-	defer r.Close()
-
-	postCountCacheMu.Lock()
-	defer postCountCacheMu.Unlock()
+	return listenForThreadUpdates()
+}
 
-	var thread, postCount uint64
-	for r.Next() {
-		err = r.Scan(&thread, &postCount)
-		if err != nil {
+// Replace the post count cache with the current post counts of all threads
+func readThreadPostCounts() (err error) {
+	counts := make(map[uint64]uint64)
+	err = queryAll(
+		sq.Select("op, count(*) filter (where not shadowed)").
+			From("posts").
+			GroupBy("op"),
+		func(r *sql.Rows) (err error) {
+			var thread, postCount uint64
+			err = r.Scan(&thread, &postCount)
+			if err != nil {
+				return
+			}
+			counts[thread] = postCount
 			return
-		}
-		postCountCache[thread] = postCount
-	}
-	err = r.Err()
+		},
+	)
 	if err != nil {
 		return
 	}
 
-	return listenForThreadUpdates()
+	postCountCacheMu.Lock()
+	postCountCache = counts
+	postCountCacheMu.Unlock()
+	return
 }
 
 // Separate function for easier testing
 func listenForThreadUpdates() (err error) {
-	err = Listen("thread_deleted", func(msg string) (err error) {
+	err = ListenResync("thread_deleted", func(msg string) (err error) {
 		_, id, err := SplitBoardAndID(msg)
 		if err != nil {
 			return
@@ -92,12 +98,12 @@ func listenForThreadUpdates() (err error) {
 		delete(postCountCache, id)
 		postCountCacheMu.Unlock()
 		return
-	})
+	}, readThreadPostCounts)
 	if err != nil {
 		return
 	}
 
-	return Listen("new_post_in_thread", func(msg string) (err error) {
+	return ListenResync("new_post_in_thread", func(msg string) (err error) {
 		retErr := func() error {
 			return fmt.Errorf("invalid message: `%s`", msg)
 		}
@@ -119,7 +125,7 @@ func listenForThreadUpdates() (err error) {
 		postCountCache[id] = postCount
 		postCountCacheMu.Unlock()
 		return
-	})
+	}, readThreadPostCounts)
 }
 
 // Thread is a template for writing new threads to the database
@@ -156,10 +162,13 @@ func ValidateOP(id uint64, board string) (valid bool, err error) {
 
 // InsertThread inserts a new thread into the database.
 // Sets ID, OP and time on inserted post.
-func InsertThread(tx *sql.Tx, subject string, p *Post) (err error) {
+// encrypted specifies, if the thread's post bodies are end-to-end encrypted.
+func InsertThread(tx *sql.Tx, subject string, encrypted bool, p *Post) (
+	err error,
+) {
 	err = sq.Insert("threads").
-		Columns("board", "subject").
-		Values(p.Board, subject).
+		Columns("board", "subject", "encrypted").
+		Values(p.Board, subject, encrypted).
 		Suffix("returning id").
 		RunWith(tx).
 		Scan(&p.ID)
@@ -202,6 +211,17 @@ func CheckThreadLocked(id uint64) (locked bool, err error) {
 	return
 }
 
+// CheckThreadEncrypted checks, if a thread's post bodies are end-to-end
+// encrypted
+func CheckThreadEncrypted(id uint64) (encrypted bool, err error) {
+	err = sq.Select("encrypted").
+		From("threads").
+		Where("id = ?", id).
+		QueryRow().
+		Scan(&encrypted)
+	return
+}
+
 func Read()  {
 
 }
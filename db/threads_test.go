@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/test"
 	"testing"
@@ -18,6 +19,12 @@ func TestThreadBools(t *testing.T) {
 		t.Fatal(err)
 	}
 	test.AssertDeepEquals(t, false, locked)
+
+	encrypted, err := CheckThreadEncrypted(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, false, encrypted)
 }
 
 func TestDiffPostCount(t *testing.T) {
@@ -106,7 +113,7 @@ func TestInsertThread(t *testing.T) {
 		Password: []byte("6+53653cs3ds"),
 	}
 	err := InTransaction(false, func(tx *sql.Tx) (err error) {
-		return InsertThread(tx, "test", &p)
+		return InsertThread(tx, "test", false, &p)
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -121,3 +128,43 @@ func TestInsertThread(t *testing.T) {
 		t.Fatal(p.ID)
 	}
 }
+
+func TestInsertEncryptedThread(t *testing.T) {
+	assertTableClear(t, "boards")
+	writeSampleBoard(t)
+
+	body := base64.StdEncoding.EncodeToString(
+		make([]byte, common.MaxLenEncryptedBody/4*3))
+	newPost := func(op uint64) Post {
+		return Post{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					Body: body,
+				},
+				OP:    op,
+				Board: "a",
+			},
+			IP:       "::1",
+			Password: []byte("6+53653cs3ds"),
+		}
+	}
+
+	op := newPost(0)
+	err := InTransaction(false, func(tx *sql.Tx) (err error) {
+		err = InsertThread(tx, "test", true, &op)
+		if err != nil {
+			return
+		}
+		reply := newPost(op.ID)
+		return InsertPost(tx, &reply)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := GetPost(op.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertDeepEquals(t, p.Body, body)
+}
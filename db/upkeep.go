@@ -11,9 +11,14 @@ import (
 	"github.com/bakape/meguca/config"
 	"time"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/go-playground/log"
 )
 
+// ModLogRetention is the number of days moderation log entries and reports
+// are retained for
+const ModLogRetention = 7
+
 // Run database clean up tasks at server start and regular intervals. Must be
 // launched in separate goroutine.
 func runCleanupTasks() {
@@ -41,7 +46,14 @@ func runCleanupTasks() {
 func runMinuteTasks() {
 	if config.ImagerMode != config.ImagerOnly {
 		logError("open post cleanup", closeDanglingPosts())
-		expireRows("image_tokens", "bans", "failed_captchas")
+		expireRows("image_tokens", "bans", "failed_captchas",
+			"post_reservations")
+		if config.Clustered {
+			expireBy("created < now() at time zone 'utc' + '-1 minute'",
+				"cluster_messages")
+			expireBy("last < now() at time zone 'utc' + '-1 hour'",
+				"rate_limits")
+		}
 	}
 }
 
@@ -56,8 +68,11 @@ func runHalfTasks() {
 func runHourTasks() {
 	if config.ImagerMode != config.ImagerOnly {
 		expireRows("sessions")
-		expireBy("created < now() at time zone 'utc' + '-7 days'",
+		expireBy(
+			fmt.Sprintf("created < now() at time zone 'utc' + '-%d days'",
+				ModLogRetention),
 			"mod_log", "reports")
+		logError("rotate IP hashing salt", rotateIPSaltIfDue())
 		logError("remove identity info", removeIdentityInfo())
 		logError("thread cleanup", deleteOldThreads())
 		logError("board cleanup", deleteUnusedBoards())
@@ -67,6 +82,7 @@ func runHourTasks() {
 	}
 	if config.ImagerMode != config.NoImager {
 		logError("image cleanup", deleteUnusedImages())
+		logError("orphaned media cleanup", collectOrphanedMedia())
 	}
 }
 
@@ -92,36 +108,47 @@ func expireRows(tables ...string) {
 	expireBy("expires < now() at time zone 'utc'", tables...)
 }
 
-// Remove poster-identifying info from posts older than 7 days
+// Remove poster-identifying info from posts older than the IP data retention
+// period
 func removeIdentityInfo() error {
 	_, err := sq.Update("posts").
 		Set("ip", nil).
 		Set("password", nil).
-		Where(`time < extract(epoch from now() at time zone 'utc'
-			- interval '7 days')`).
+		Where("time < ?", time.Now().Add(-ipRetention()).Unix()).
 		Where("ip is not null").
 		Exec()
 	return err
 }
 
-// Close any open posts that have not been closed for 30 minutes
+// Close any open posts that have not been closed for 15 minutes or whose
+// authors have not reclaimed them within the reclaim window after
+// disconnecting
 func closeDanglingPosts() error {
 	type post struct {
-		id, op uint64
-		board  string
-		ip     sql.NullString
+		id, op   uint64
+		board    string
+		ip       sql.NullString
+		shadowed bool
 	}
 	var (
 		posts = make([]post, 0, 8)
 		p     post
+		stale = squirrel.Or{
+			squirrel.Expr(`time
+				< floor(extract(epoch from now() at time zone 'utc')) - 900`),
+		}
 	)
+	if w := reclaimWindow(); w != 0 {
+		stale = append(stale,
+			squirrel.Expr("disconnected < ?", time.Now().Add(-w).UTC()))
+	}
 	err := queryAll(
-		sq.Select("id", "op", "board", "ip").
+		sq.Select("id", "op", "board", "ip", "shadowed").
 			From("posts").
-			Where(`editing = true and time
-				< floor(extract(epoch from now() at time zone 'utc')) - 900`),
+			Where("editing = true").
+			Where(stale),
 		func(r *sql.Rows) (err error) {
-			err = r.Scan(&p.id, &p.op, &p.board, &p.ip)
+			err = r.Scan(&p.id, &p.op, &p.board, &p.ip, &p.shadowed)
 			if err != nil {
 				return err
 			}
@@ -156,6 +183,10 @@ func closeDanglingPosts() error {
 		if err != nil {
 			return err
 		}
+		// Posts hidden by a shadow ban must not leak into public topics
+		if common.RecordTopics != nil && !p.shadowed {
+			common.RecordTopics(p.board, body)
+		}
 	}
 
 	return nil
@@ -50,6 +50,16 @@ func TestOpenPostClosing(t *testing.T) {
 				OP: 1,
 			},
 		},
+		{
+			StandalonePost: common.StandalonePost{
+				Post: common.Post{
+					ID:      4,
+					Editing: true,
+					Time:    time.Now().Unix(),
+				},
+				OP: 1,
+			},
+		},
 	}
 	err := InTransaction(false, func(tx *sql.Tx) error {
 		for _, p := range posts {
@@ -64,6 +74,21 @@ func TestOpenPostClosing(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	conf := config.Get()
+	defer config.Set(*conf)
+	c := *conf
+	c.ReclaimWindow = 5
+	config.Set(c)
+
+	// Author disconnected and did not return in time
+	_, err = sq.Update("posts").
+		Set("disconnected", time.Now().Add(-time.Minute*6).UTC()).
+		Where("id = 4").
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	if err := closeDanglingPosts(); err != nil {
 		t.Fatal(err)
 	}
@@ -75,6 +100,7 @@ func TestOpenPostClosing(t *testing.T) {
 	}{
 		{"closed", 2, false},
 		{"untouched", 3, true},
+		{"not reclaimed", 4, false},
 	}
 
 	for i := range cases {
@@ -4,13 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"github.com/bakape/meguca/common"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/Masterminds/squirrel"
-	"github.com/go-playground/log"
 	"github.com/lib/pq"
 )
 
@@ -20,22 +17,31 @@ type rowScanner interface {
 
 // InTransaction runs a function inside a transaction and handles comminting and rollback on error.
 // readOnly: the DBMS can optimise read-only transactions for better concurrency
+// Transactions failing with transient errors are retried, so fn may be called
+// multiple times.
 //
 // TODO: Get rid off readOnly param, once reader ported to output JSON
-func InTransaction(readOnly bool, fn func(*sql.Tx) error) (err error) {
-	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
-		ReadOnly: readOnly,
-	})
-	if err != nil {
-		return
-	}
+func InTransaction(readOnly bool, fn func(*sql.Tx) error) error {
+	return withRetry(func() (err error) {
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
+			ReadOnly: readOnly,
+		})
+		if err != nil {
+			return
+		}
 
-	err = fn(tx)
-	if err != nil {
-		tx.Rollback()
+		err = fn(tx)
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+		if err != nil && !isRolledBack(err) {
+			// Transaction might have been committed
+			err = unretryable{err}
+		}
 		return
-	}
-	return tx.Commit()
+	})
 }
 
 // Run fn on all returned rows in a query
@@ -65,36 +71,8 @@ func IsConflictError(err error) bool {
 }
 
 // Listen assigns a function to listen to Postgres notifications on a channel
-func Listen(event string, fn func(msg string) error) (err error) {
-	if common.IsTest {
-		return
-	}
-
-	l := pq.NewListener(
-		ConnArgs,
-		time.Second,
-		time.Second*10,
-		func(_ pq.ListenerEventType, _ error) {},
-	)
-	err = l.Listen(event)
-	if err != nil {
-		return
-	}
-
-	go func() {
-		for msg := range l.Notify {
-			if msg == nil {
-				continue
-			}
-			if err := fn(msg.Extra); err != nil {
-				log.Errorf(
-					"error on database event id=`%s` msg=`%s` error=`%s`\n",
-					event, msg.Extra, err)
-			}
-		}
-	}()
-
-	return
+func Listen(event string, fn func(msg string) error) error {
+	return ListenResync(event, fn, nil)
 }
 
 // Execute all SQL statement strings and return on first error, if any
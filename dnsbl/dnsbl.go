@@ -0,0 +1,287 @@
+// Package dnsbl detects posters connecting through open proxies and Tor exit
+// nodes by querying DNS-based blackhole lists and a periodically refreshed Tor
+// exit node list
+package dnsbl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/bakape/meguca/config"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+const (
+	// Time to cache DNSBL lookup results for
+	cacheTTL = time.Hour
+
+	// Timeout of DNSBL lookups
+	lookupTimeout = time.Second * 2
+
+	// Interval of Tor exit node list refreshes
+	torRefreshInterval = time.Minute * 30
+
+	// Timeout of Tor exit node list requests
+	torFetchTimeout = time.Second * 30
+)
+
+var (
+	// DNSBL lookup results by IP
+	cache = lookupCache{
+		entries: make(map[string]cacheEntry, 1<<10),
+	}
+
+	// Tor exit node IPs and the URL of the list they were fetched from
+	torMu    sync.RWMutex
+	torExits = make(map[string]struct{})
+	torURL   string
+
+	torClient = &http.Client{Timeout: torFetchTimeout}
+
+	// Overridable for tests
+	lookupHost = net.DefaultResolver.LookupHost
+)
+
+type cacheEntry struct {
+	listed  bool
+	expires time.Time
+}
+
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func (c *lookupCache) get(ip string) (listed, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[ip]
+	if !ok || time.Now().After(e.expires) {
+		return false, false
+	}
+	return e.listed, true
+}
+
+func (c *lookupCache) set(ip string, listed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ip] = cacheEntry{
+		listed:  listed,
+		expires: time.Now().Add(cacheTTL),
+	}
+}
+
+// Remove expired cache entries
+func (c *lookupCache) clean() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for ip, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, ip)
+		}
+	}
+}
+
+// Init starts periodic refreshing of the Tor exit node list. The list is
+// first loaded in the background, so server startup is not delayed.
+func Init() error {
+	config.OnUpdate(onConfigUpdate)
+
+	go func() {
+		logTorRefresh()
+		for range time.Tick(torRefreshInterval) {
+			cache.clean()
+			logTorRefresh()
+		}
+	}()
+	return nil
+}
+
+// Refresh the Tor exit node list and log any error
+func logTorRefresh() {
+	err := refreshTorExits()
+	if err != nil {
+		log.Errorf("dnsbl: fetching Tor exit node list: %s", err)
+	}
+}
+
+// Apply configuration changes. Cached lookup results are discarded, as the
+// DNSBL zones might have changed. The Tor exit node list is fetched in the
+// background, if its URL changed.
+func onConfigUpdate() error {
+	cache.mu.Lock()
+	cache.entries = make(map[string]cacheEntry, 1<<10)
+	cache.mu.Unlock()
+
+	torMu.RLock()
+	changed := torURL != config.Get().TorExitList
+	torMu.RUnlock()
+	if changed {
+		go logTorRefresh()
+	}
+	return nil
+}
+
+// Enabled returns, if any proxy detection sources are configured
+func Enabled() bool {
+	conf := config.Get()
+	return len(conf.DNSBLs) != 0 || conf.TorExitList != ""
+}
+
+// IsProxy returns, if an IP is a known Tor exit node or listed on any of the
+// configured DNSBLs. Lookup failures are treated as not listed.
+func IsProxy(ip string) bool {
+	if isTorExit(ip) {
+		return true
+	}
+
+	listed, ok := cache.get(ip)
+	if ok {
+		return listed
+	}
+	zones := config.Get().DNSBLs
+	if len(zones) == 0 {
+		return false
+	}
+	listed = lookupAll(ip, zones)
+	cache.set(ip, listed)
+	return listed
+}
+
+func isTorExit(ip string) bool {
+	torMu.RLock()
+	defer torMu.RUnlock()
+	_, ok := torExits[ip]
+	return ok
+}
+
+// Query all DNSBL zones in parallel
+func lookupAll(ip string, zones []string) bool {
+	name, err := reverseIP(ip)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	res := make(chan bool, len(zones))
+	for _, z := range zones {
+		go func(zone string) {
+			res <- isListed(ctx, name+"."+zone)
+		}(strings.Trim(z, ". "))
+	}
+	for range zones {
+		if <-res {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns, if a DNSBL query name resolves to a listing
+func isListed(ctx context.Context, name string) bool {
+	addrs, err := lookupHost(ctx, name)
+	if err != nil {
+		// NXDOMAIN means not listed. Fail open on all other errors.
+		if e, ok := err.(*net.DNSError); !ok || !e.IsNotFound {
+			log.Warnf("dnsbl: %s", err)
+		}
+		return false
+	}
+
+	// DNSBLs respond with addresses in 127.0.0.0/8
+	for _, a := range addrs {
+		if ip := net.ParseIP(a).To4(); ip != nil && ip[0] == 127 {
+			return true
+		}
+	}
+	return false
+}
+
+// Format IP for a DNSBL query. IPv4 octets and IPv6 nibbles are reversed.
+func reverseIP(s string) (string, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP: %s", s)
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+
+	const hex = "0123456789abcdef"
+	buf := make([]byte, 0, 63)
+	for i := len(ip) - 1; i >= 0; i-- {
+		if i != len(ip)-1 {
+			buf = append(buf, '.')
+		}
+		buf = append(buf, hex[ip[i]&0xf], '.', hex[ip[i]>>4])
+	}
+	return string(buf), nil
+}
+
+// Fetch the configured Tor exit node list
+func refreshTorExits() (err error) {
+	url := config.Get().TorExitList
+	if url == "" {
+		torMu.Lock()
+		torExits = make(map[string]struct{})
+		torURL = ""
+		torMu.Unlock()
+		return
+	}
+
+	res, err := torClient.Get(url)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	exits, err := parseTorExits(res.Body)
+	if err != nil {
+		return
+	}
+	torMu.Lock()
+	torExits = exits
+	torURL = url
+	torMu.Unlock()
+	return
+}
+
+// Parse a Tor exit node list. Supports both plain lists of IPs and the
+// "ExitAddress" lines of the Tor Project's exit-addresses format.
+func parseTorExits(r io.Reader) (exits map[string]struct{}, err error) {
+	exits = make(map[string]struct{}, 1<<11)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		var ip string
+		switch {
+		case len(fields) == 1:
+			ip = fields[0]
+		case len(fields) >= 2 && fields[0] == "ExitAddress":
+			ip = fields[1]
+		default:
+			continue
+		}
+		if net.ParseIP(ip) != nil {
+			exits[ip] = struct{}{}
+		}
+	}
+	err = s.Err()
+	return
+}
@@ -0,0 +1,91 @@
+package dnsbl
+
+import (
+	"context"
+	"github.com/bakape/meguca/config"
+	. "github.com/bakape/meguca/test"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReverseIP(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		in, out string
+	}{
+		{"127.0.0.2", "2.0.0.127"},
+		{"192.168.1.20", "20.1.168.192"},
+		{
+			"2001:db8::1",
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2",
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.in, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := reverseIP(c.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertDeepEquals(t, res, c.out)
+		})
+	}
+}
+
+func TestParseTorExits(t *testing.T) {
+	t.Parallel()
+
+	const list = `ExitNode 0011BD2485AD45D984EC4159C88FC066E5E3300E
+Published 2019-03-20 03:09:41
+LastStatus 2019-03-20 04:03:13
+ExitAddress 162.247.74.201 2019-03-20 04:07:45
+1.2.3.4
+invalid
+`
+	exits, err := parseTorExits(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, exits, map[string]struct{}{
+		"162.247.74.201": {},
+		"1.2.3.4":        {},
+	})
+}
+
+func TestIsProxy(t *testing.T) {
+	config.Set(config.Configs{
+		DNSBLs: []string{"dnsbl.example.com"},
+	})
+	defer config.Clear()
+	lookupHost = func(_ context.Context, name string) ([]string, error) {
+		if name == "2.0.0.127.dnsbl.example.com" {
+			return []string{"127.0.0.2"}, nil
+		}
+		return nil, &net.DNSError{IsNotFound: true}
+	}
+	defer func() {
+		lookupHost = net.DefaultResolver.LookupHost
+	}()
+	torMu.Lock()
+	torExits = map[string]struct{}{"10.0.0.1": {}}
+	torMu.Unlock()
+
+	cases := [...]struct {
+		ip    string
+		proxy bool
+	}{
+		{"127.0.0.2", true},
+		{"127.0.0.3", false},
+		{"10.0.0.1", true},
+	}
+	for _, c := range cases {
+		if p := IsProxy(c.ip); p != c.proxy {
+			t.Errorf("unexpected result for %s: %v", c.ip, p)
+		}
+	}
+}
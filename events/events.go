@@ -0,0 +1,89 @@
+// Package events publishes post and thread lifecycle events to internal
+// subscribers and external webhooks
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	"github.com/go-playground/log"
+)
+
+// Type is the type of an event
+type Type string
+
+// Types of published events
+const (
+	PostCreated   Type = "post_created"
+	PostClosed    Type = "post_closed"
+	ImageUploaded Type = "image_uploaded"
+	ThreadCreated Type = "thread_created"
+	Moderation    Type = "moderation"
+)
+
+// Maximum number of events waiting to be passed to subscribers
+const queueSize = 1 << 10
+
+var (
+	queue = make(chan Event, queueSize)
+
+	subscribers struct {
+		sync.RWMutex
+		fns []func(Event)
+	}
+)
+
+// Event is a change in the lifecycle of a post or thread
+type Event struct {
+	Type   Type   `json:"type"`
+	Time   int64  `json:"time"`
+	Board  string `json:"board"`
+	Thread uint64 `json:"thread"`
+	Post   uint64 `json:"post"`
+
+	// Type-specific payload. The created post for PostCreated and
+	// ThreadCreated, ClosedPost for PostClosed, the image for ImageUploaded
+	// and the moderation log entry for Moderation.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// ClosedPost is the payload of a PostClosed event
+type ClosedPost struct {
+	Body     string           `json:"body"`
+	Links    []common.Link    `json:"links,omitempty"`
+	Commands []common.Command `json:"commands,omitempty"`
+}
+
+func init() {
+	go func() {
+		for e := range queue {
+			subscribers.RLock()
+			for _, fn := range subscribers.fns {
+				fn(e)
+			}
+			subscribers.RUnlock()
+		}
+	}()
+}
+
+// Subscribe registers fn to be called for every published event. fn is
+// called from a single goroutine and must not block.
+func Subscribe(fn func(Event)) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	subscribers.fns = append(subscribers.fns, fn)
+}
+
+// Publish passes an event to all subscribers asynchronously. If the queue is
+// full, the event is dropped.
+func Publish(e Event) {
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("events: queue full: dropping %s event", e.Type)
+	}
+}
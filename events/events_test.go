@@ -0,0 +1,41 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestPublish(t *testing.T) {
+	received := make(chan Event, 1)
+	Subscribe(func(e Event) {
+		if e.Board != "publish" {
+			return
+		}
+		select {
+		case received <- e:
+		default:
+		}
+	})
+
+	Publish(Event{
+		Type:  PostCreated,
+		Board: "publish",
+		Post:  2,
+	})
+	select {
+	case e := <-received:
+		if e.Time == 0 {
+			t.Fatal("time not set")
+		}
+		e.Time = 0
+		AssertDeepEquals(t, e, Event{
+			Type:  PostCreated,
+			Board: "publish",
+			Post:  2,
+		})
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+}
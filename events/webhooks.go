@@ -0,0 +1,184 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/bakape/meguca/config"
+	"github.com/go-playground/log"
+)
+
+const (
+	// Number of concurrent webhook deliveries
+	webhookWorkers = 4
+
+	// Maximum number of attempts to deliver an event to a webhook
+	maxAttempts = 5
+)
+
+var (
+	deliveries = make(chan delivery, queueSize)
+
+	// Webhook URLs are set by board owners, so requests must not reach
+	// internal services. No proxy is used, as the dialed address would then
+	// not be the webhook's.
+	client = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 10 * time.Second,
+				Control: checkWebhookAddr,
+			}).DialContext,
+		},
+	}
+
+	// Delay before the first retry of a failed delivery. Doubled with each
+	// consecutive retry. Overridden in tests.
+	retryDelay = 2 * time.Second
+
+	// Allow delivery to loopback and private network addresses. Overridden in
+	// tests.
+	allowPrivateAddrs bool
+
+	errForbiddenAddr = errors.New("address not publicly routable")
+)
+
+// Event pending delivery to a board's webhook
+type delivery struct {
+	url, secret string
+	typ         Type
+	body        []byte
+}
+
+func init() {
+	Subscribe(queueWebhook)
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for d := range deliveries {
+				d.send()
+			}
+		}()
+	}
+}
+
+// Queue delivery of an event to the webhook of its board, if any
+func queueWebhook(e Event) {
+	// Configs are not loaded in some tests
+	if c := config.Get(); c == nil || !c.PostWebhooks {
+		return
+	}
+	conf := config.GetBoardConfigs(e.Board)
+	if conf.WebhookURL == "" {
+		return
+	}
+
+	buf, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("webhook: %s", err)
+		return
+	}
+	select {
+	case deliveries <- delivery{
+		url:    conf.WebhookURL,
+		secret: conf.WebhookSecret,
+		typ:    e.Type,
+		body:   buf,
+	}:
+	default:
+		log.Warnf("webhook: queue full: dropping %s event of /%s/", e.Type,
+			e.Board)
+	}
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 signature of a webhook request
+// body. Receivers verify requests by comparing it to the X-Meguca-Signature
+// header.
+func Sign(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Deliver the event, retrying with exponential backoff on network and server
+// errors
+func (d delivery) send() {
+	delay := retryDelay
+	for i := 1; ; i++ {
+		retry, err := d.attempt()
+		switch {
+		case err == nil:
+			return
+		case !retry || i == maxAttempts:
+			log.Errorf("webhook: %s: %s", d.url, err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Make a single delivery attempt. Returns, if the delivery should be retried
+// on failure.
+func (d delivery) attempt() (retry bool, err error) {
+	req, err := http.NewRequest("POST", d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Meguca-Event", string(d.typ))
+	req.Header.Set("X-Meguca-Signature", "sha256="+Sign(d.secret, d.body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return !errors.Is(err, errForbiddenAddr), err
+	}
+	// Drain body to allow reusing the connection
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<10))
+	res.Body.Close()
+
+	switch code := res.StatusCode; {
+	case code >= 200 && code < 300:
+		return false, nil
+	case code == 429 || code >= 500:
+		return true, fmt.Errorf("unexpected status: %s", res.Status)
+	default:
+		return false, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+}
+
+// Reject connections to loopback, link-local, private network and other
+// non-public addresses. Checked on the resolved address of each connection,
+// so redirects and DNS rebinding can not bypass it.
+func checkWebhookAddr(network, address string, _ syscall.RawConn) error {
+	if allowPrivateAddrs {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil,
+		ip.IsUnspecified(),
+		ip.IsLoopback(),
+		ip.IsPrivate(),
+		ip.IsLinkLocalUnicast(),
+		ip.IsLinkLocalMulticast(),
+		ip.IsInterfaceLocalMulticast(),
+		ip.IsMulticast():
+		return errForbiddenAddr
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakape/meguca/config"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestSign(t *testing.T) {
+	AssertDeepEquals(t,
+		Sign("key", []byte("The quick brown fox jumps over the lazy dog")),
+		"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	type request struct {
+		event, signature string
+		body             []byte
+	}
+
+	var attempts int32
+	received := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// Fail the first attempt to test retrying
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				w.WriteHeader(500)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			received <- request{
+				event:     r.Header.Get("X-Meguca-Event"),
+				signature: r.Header.Get("X-Meguca-Signature"),
+				body:      body,
+			}
+		},
+	))
+	defer srv.Close()
+
+	defer func(d time.Duration) {
+		retryDelay = d
+	}(retryDelay)
+	retryDelay = time.Millisecond
+	allowPrivateAddrs = true
+	defer func() {
+		allowPrivateAddrs = false
+	}()
+
+	defer config.Clear()
+	config.Set(config.Configs{
+		PostWebhooks: true,
+	})
+	defer config.ClearBoards()
+	_, err := config.SetBoardConfigs(config.BoardConfigs{
+		ID:            "webhook",
+		WebhookURL:    srv.URL,
+		WebhookSecret: "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	std := Event{
+		Type:   PostClosed,
+		Time:   1,
+		Board:  "webhook",
+		Thread: 1,
+		Post:   2,
+		Data:   ClosedPost{Body: "bar"},
+	}
+	Publish(std)
+
+	select {
+	case req := <-received:
+		AssertDeepEquals(t, req.event, string(PostClosed))
+		AssertDeepEquals(t, req.signature, "sha256="+Sign("foo", req.body))
+
+		var res struct {
+			Event
+			Data ClosedPost `json:"data"`
+		}
+		err := json.Unmarshal(req.body, &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, res.Data, std.Data)
+		res.Event.Data = std.Data
+		AssertDeepEquals(t, res.Event, std)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
+
+func TestCheckWebhookAddr(t *testing.T) {
+	cases := [...]struct {
+		addr string
+		err  error
+	}{
+		{"93.184.216.34:443", nil},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", nil},
+		{"127.0.0.1:80", errForbiddenAddr},
+		{"[::1]:80", errForbiddenAddr},
+		{"10.0.0.1:80", errForbiddenAddr},
+		{"192.168.1.1:80", errForbiddenAddr},
+		{"172.16.0.1:80", errForbiddenAddr},
+		{"169.254.169.254:80", errForbiddenAddr},
+		{"[fe80::1]:80", errForbiddenAddr},
+		{"[fd00::1]:80", errForbiddenAddr},
+		{"0.0.0.0:80", errForbiddenAddr},
+	}
+	for _, c := range cases {
+		t.Run(c.addr, func(t *testing.T) {
+			AssertDeepEquals(t, checkWebhookAddr("tcp", c.addr, nil), c.err)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package imager
+
+import (
+	"context"
+	"fmt"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/imager/assets"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+const (
+	// Length of the source video used for animated thumbnail previews
+	animatedThumbLength = 2 * time.Second
+
+	// Frame rate of animated thumbnail previews
+	animatedThumbFPS = 8
+
+	// Maximum time to spend generating a single animated thumbnail preview
+	animatedThumbTimeout = time.Minute
+)
+
+// Queued animated thumbnail preview jobs. Processed one at a time separately
+// from upload thumbnailing, so they never delay uploads.
+var animatedThumbJobs = make(chan common.ImageCommon, 64)
+
+func init() {
+	go func() {
+		for img := range animatedThumbJobs {
+			err := generateAnimatedThumb(img)
+			if err != nil {
+				log.Errorf("animated thumbnail: %s: %s", img.SHA1, err)
+			}
+		}
+	}()
+}
+
+// Returns, if an animated thumbnail preview can be generated for a file
+func needsAnimatedThumb(img common.ImageCommon) bool {
+	if !img.Video || img.ThumbType == common.NoFile {
+		return false
+	}
+	switch img.FileType {
+	case common.WEBM, common.MP4:
+		return true
+	default:
+		return false
+	}
+}
+
+// Queue generation of an animated thumbnail preview for a video upload, if
+// enabled. Previews are optional, so jobs are dropped, if the queue is full.
+func queueAnimatedThumb(img common.ImageCommon) {
+	if !config.Get().AnimatedThumbs || !needsAnimatedThumb(img) {
+		return
+	}
+	select {
+	case animatedThumbJobs <- img:
+	default:
+		log.Warnf("animated thumbnail: queue full: dropping %s", img.SHA1)
+	}
+}
+
+// Generate an animated thumbnail preview from the start of a video, record it
+// in the database and notify any thread feeds containing the video
+func generateAnimatedThumb(img common.ImageCommon) (err error) {
+	src, cleanup, err := assets.LocalCopy(
+		assets.GetFileKeys(img.SHA1, img.FileType, img.ThumbType)[0])
+	if err != nil {
+		return
+	}
+	defer cleanup()
+	tmp, err := ioutil.TempFile("", "meguca_anim")
+	if err != nil {
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		animatedThumbTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ffmpeg",
+		animatedThumbArgs(src, tmp.Name(), img.Dims[2], img.Dims[3])...,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, out)
+	}
+	err = writeAnimatedThumb(img.SHA1, tmp.Name())
+	if err != nil {
+		return
+	}
+
+	err = db.SetAnimatedThumb(img.SHA1)
+	if err != nil {
+		return
+	}
+	return sendFileUpdate(img.SHA1)
+}
+
+// Write a generated animated thumbnail preview to storage
+func writeAnimatedThumb(SHA1, path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return
+	}
+	return assets.WriteFile(assets.AnimatedThumbKey(SHA1), f, stat.Size())
+}
+
+// Build ffmpeg arguments for encoding an animated thumbnail preview with the
+// same dimensions as the static thumbnail
+func animatedThumbArgs(src, dst string, width, height uint16) []string {
+	return []string{
+		"-loglevel", "error",
+		"-threads", "1",
+		"-t", fmt.Sprintf("%.3f", animatedThumbLength.Seconds()),
+		"-i", src,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%d,scale=%d:%d", animatedThumbFPS, width,
+			height),
+		"-loop", "0",
+		"-quality", "60",
+		"-f", "webp",
+		"-y", dst,
+	}
+}
+
+// Notify all thread feeds with posts containing a file of updated file
+// metadata
+func sendFileUpdate(SHA1 string) (err error) {
+	ops, err := db.GetImageThreads(SHA1)
+	if err != nil {
+		return
+	}
+	msg, err := common.EncodeMessage(common.MessageFileUpdate, struct {
+		SHA1          string `json:"sha1"`
+		AnimatedThumb bool   `json:"animated_thumb"`
+	}{
+		SHA1:          SHA1,
+		AnimatedThumb: true,
+	})
+	if err != nil {
+		return
+	}
+	for _, op := range ops {
+		common.SendTo(op, msg)
+	}
+	return
+}
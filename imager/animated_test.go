@@ -0,0 +1,80 @@
+package imager
+
+import (
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+	"testing"
+)
+
+func TestNeedsAnimatedThumb(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		img  common.ImageCommon
+		need bool
+	}{
+		{
+			name: "webm",
+			img: common.ImageCommon{
+				Video:     true,
+				FileType:  common.WEBM,
+				ThumbType: common.WEBP,
+			},
+			need: true,
+		},
+		{
+			name: "mp4 without video",
+			img: common.ImageCommon{
+				Audio:     true,
+				FileType:  common.MP4,
+				ThumbType: common.WEBP,
+			},
+		},
+		{
+			name: "no thumbnail",
+			img: common.ImageCommon{
+				Video:     true,
+				FileType:  common.WEBM,
+				ThumbType: common.NoFile,
+			},
+		},
+		{
+			name: "image",
+			img: common.ImageCommon{
+				Video:     true,
+				FileType:  common.GIF,
+				ThumbType: common.WEBP,
+			},
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			if n := needsAnimatedThumb(c.img); n != c.need {
+				LogUnexpected(t, c.need, n)
+			}
+		})
+	}
+}
+
+func TestAnimatedThumbArgs(t *testing.T) {
+	t.Parallel()
+
+	AssertDeepEquals(t, animatedThumbArgs("src.webm", "dst.webp", 150, 84),
+		[]string{
+			"-loglevel", "error",
+			"-threads", "1",
+			"-t", "2.000",
+			"-i", "src.webm",
+			"-an",
+			"-vf", "fps=8,scale=150:84",
+			"-loop", "0",
+			"-quality", "60",
+			"-f", "webp",
+			"-y", "dst.webp",
+		})
+}
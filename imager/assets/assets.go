@@ -2,7 +2,9 @@
 package assets
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/util"
@@ -10,6 +12,9 @@ import (
 	"path/filepath"
 )
 
+// Suffix of animated thumbnail preview file names
+const animatedThumbSuffix = "_anim.webp"
+
 // Only used in tests, but we still need them exported
 var (
 	//  StdJPEG is a JPEG sample image standard struct. Only used in tests.
@@ -58,6 +63,16 @@ func GetFilePaths(SHA1 string, fileType, thumbType uint8) (paths [2]string) {
 	return
 }
 
+// GetAnimatedThumbFilePath generates the file path of an animated thumbnail
+// preview of a video upload
+func GetAnimatedThumbFilePath(SHA1 string) string {
+	return filepath.FromSlash(util.ConcatStrings(
+		"images/thumb/",
+		SHA1,
+		animatedThumbSuffix,
+	))
+}
+
 // RelativeSourcePath returns a file's source path relative to the root path
 func RelativeSourcePath(fileType uint8, SHA1 string) string {
 	return util.ConcatStrings(
@@ -110,6 +125,12 @@ func ThumbPath(thumbType uint8, SHA1 string) string {
 	)
 }
 
+// AnimatedThumbPath returns the path to the animated thumbnail preview of a
+// video upload
+func AnimatedThumbPath(SHA1 string) string {
+	return util.ConcatStrings(imageRoot(), "/thumb/", SHA1, animatedThumbSuffix)
+}
+
 // SourcePath returns the path to the source file on an image
 func SourcePath(fileType uint8, SHA1 string) string {
 	return util.ConcatStrings(
@@ -121,12 +142,13 @@ func SourcePath(fileType uint8, SHA1 string) string {
 	)
 }
 
-// Write writes file assets to disk
+// Write writes file assets to storage
 func Write(SHA1 string, fileType, thumbType uint8, src, thumb io.ReadSeeker,
 ) (
 	err error,
 ) {
-	paths := GetFilePaths(SHA1, fileType, thumbType)
+	keys := GetFileKeys(SHA1, fileType, thumbType)
+	s := GetStorage()
 
 	// Write files in parallel
 	ch := make(chan error)
@@ -134,59 +156,71 @@ func Write(SHA1 string, fileType, thumbType uint8, src, thumb io.ReadSeeker,
 		if thumb == nil {
 			ch <- nil
 		} else {
-			ch <- writeFile(paths[1], thumb)
+			ch <- writeFile(s, keys[1], thumb)
 		}
 	}()
 
-	for _, err := range [...]error{writeFile(paths[0], src), <-ch} {
-		switch {
-		// Ignore files already written and not cleaned up for some reason
-		case err == nil, os.IsExist(err):
-		default:
+	for _, err := range [...]error{writeFile(s, keys[0], src), <-ch} {
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Write a single file to disk with the appropriate permissions and flags
-func writeFile(path string, src io.ReadSeeker) (err error) {
-	file, err := os.Create(path)
-	if err != nil {
-		return
-	}
-	defer file.Close()
-
-	_, err = src.Seek(0, 0)
+// Write a single file to storage from the start of src
+func writeFile(s Storage, key string, src io.ReadSeeker) (err error) {
+	size, err := seekSize(src)
 	if err != nil {
 		return
 	}
-	_, err = io.Copy(file, src)
-	return
+	return s.Write(key, src, size)
 }
 
 // Delete deletes file assets belonging to a single upload
 func Delete(SHA1 string, fileType, thumbType uint8) error {
-	for _, path := range GetFilePaths(SHA1, fileType, thumbType) {
-		// Ignore somehow absent images
-		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	s := GetStorage()
+	keys := GetFileKeys(SHA1, fileType, thumbType)
+	for _, key := range [...]string{
+		keys[0], keys[1], AnimatedThumbKey(SHA1),
+	} {
+		// Absent images are ignored by all backends
+		if err := s.Delete(key); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// CreateDirs creates directories for processed image storage
+// CreateDirs creates directories for processed image storage and asserts
+// they are writable
 func CreateDirs() error {
+	// Only needed for local storage
+	if _, ok := GetStorage().(*LocalStorage); !ok {
+		return nil
+	}
 	for _, dir := range [...]string{"src", "thumb"} {
 		path := filepath.Join("images", dir)
 		if err := os.MkdirAll(path, 0700); err != nil {
 			return err
 		}
+		if err := checkWritable(path); err != nil {
+			return fmt.Errorf("image directory not writable: %s", err)
+		}
 	}
 	return nil
 }
 
+// Assert files can be created in a directory
+func checkWritable(dir string) error {
+	f, err := ioutil.TempFile(dir, ".write_test")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
 // DeleteDirs recursively deletes the image storage folder. Only used for
 // cleaning up after tests.
 func DeleteDirs() error {
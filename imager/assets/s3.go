@@ -0,0 +1,389 @@
+package assets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modes of serving files from S3-compatible storage
+const (
+	// Serve files through this server
+	S3ServeProxy = "proxy"
+	// Redirect clients to presigned URLs
+	S3ServePresigned = "presigned"
+)
+
+// Payload hash of requests with unsigned bodies
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config configures an S3-compatible storage backend, like Amazon S3 or
+// MinIO
+type S3Config struct {
+	// Base URL of the service like "https://s3.amazonaws.com"
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Bucket   string `json:"bucket"`
+
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+
+	// Address buckets as part of the path instead of the host name.
+	// Required by MinIO and most other self-hosted services.
+	PathStyle bool `json:"pathStyle"`
+
+	// One of "proxy" or "presigned"
+	Serve string `json:"serve"`
+
+	// Validity of presigned URLs in seconds
+	PresignExpiry uint `json:"presignExpiry"`
+}
+
+// S3Storage stores files in a bucket of an S3-compatible service
+type S3Storage struct {
+	conf     S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage creates a storage backend for an S3-compatible service
+func NewS3Storage(conf S3Config) (s *S3Storage, err error) {
+	if conf.Region == "" {
+		conf.Region = "us-east-1"
+	}
+	if conf.Serve == "" {
+		conf.Serve = S3ServeProxy
+	}
+	if conf.PresignExpiry == 0 {
+		conf.PresignExpiry = 3600
+	}
+	switch {
+	case conf.Bucket == "":
+		return nil, errors.New("s3: no bucket set")
+	case conf.AccessKey == "" || conf.SecretKey == "":
+		return nil, errors.New("s3: no credentials set")
+	case conf.Serve != S3ServeProxy && conf.Serve != S3ServePresigned:
+		return nil, fmt.Errorf("s3: invalid serving mode: %s", conf.Serve)
+	case conf.PresignExpiry > 7*24*3600:
+		return nil, errors.New("s3: presigned URL expiry too long")
+	}
+
+	u, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint: %s", conf.Endpoint)
+	}
+	s = &S3Storage{
+		conf:     conf,
+		endpoint: u,
+		client: &http.Client{
+			Timeout: time.Minute * 10,
+		},
+	}
+	return
+}
+
+// PresignExpiry returns the validity duration of presigned URLs
+func (s *S3Storage) PresignExpiry() time.Duration {
+	return time.Duration(s.conf.PresignExpiry) * time.Second
+}
+
+// Build the URL of an object or the bucket, if key is empty
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	p := strings.TrimSuffix(u.Path, "/")
+	if s.conf.PathStyle {
+		p += "/" + s.conf.Bucket
+	} else {
+		u.Host = s.conf.Bucket + "." + u.Host
+	}
+	u.Path = p + "/" + key
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u
+}
+
+// Write implements Storage
+func (s *S3Storage) Write(key string, r io.Reader, size int64) (err error) {
+	req, err := http.NewRequest("PUT", s.objectURL(key).String(),
+		ioutil.NopCloser(r))
+	if err != nil {
+		return
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	_, err = s.do(req, unsignedPayload)
+	return
+}
+
+// Open implements Storage
+func (s *S3Storage) Open(key string) (
+	r io.ReadCloser, info FileInfo, err error,
+) {
+	req, err := http.NewRequest("GET", s.objectURL(key).String(), nil)
+	if err != nil {
+		return
+	}
+	res, err := s.do(req, "")
+	if err != nil {
+		return
+	}
+	info = FileInfo{
+		Key:  key,
+		Size: res.ContentLength,
+	}
+	info.ModTime, _ = http.ParseTime(res.Header.Get("Last-Modified"))
+	return res.Body, info, nil
+}
+
+// Delete implements Storage
+func (s *S3Storage) Delete(key string) (err error) {
+	req, err := http.NewRequest("DELETE", s.objectURL(key).String(), nil)
+	if err != nil {
+		return
+	}
+	res, err := s.do(req, "")
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	res.Body.Close()
+	return
+}
+
+// Response of the ListObjectsV2 operation
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// Walk implements Storage
+func (s *S3Storage) Walk(prefix string, fn func(FileInfo) error) (err error) {
+	var token string
+	for {
+		u := s.objectURL("")
+		q := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = q.Encode()
+
+		var req *http.Request
+		req, err = http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return
+		}
+		var res *http.Response
+		res, err = s.do(req, "")
+		if err != nil {
+			return
+		}
+		var list s3ListResult
+		err = xml.NewDecoder(res.Body).Decode(&list)
+		res.Body.Close()
+		if err != nil {
+			return
+		}
+
+		for _, c := range list.Contents {
+			err = fn(FileInfo{
+				Key:     c.Key,
+				Size:    c.Size,
+				ModTime: c.LastModified,
+			})
+			if err != nil {
+				return
+			}
+		}
+		if !list.IsTruncated || list.NextContinuationToken == "" {
+			return
+		}
+		token = list.NextContinuationToken
+	}
+}
+
+// URL implements Storage. Returns presigned URLs in the "presigned" serving
+// mode.
+func (s *S3Storage) URL(key string) (string, error) {
+	if s.conf.Serve != S3ServePresigned {
+		return "", nil
+	}
+	return s.presign("GET", key, time.Now())
+}
+
+// Create a presigned URL for an object valid for the configured duration
+// from t
+func (s *S3Storage) presign(method, key string, t time.Time) (string, error) {
+	u := s.objectURL(key)
+	t = t.UTC()
+	scope := s.scope(t)
+	q := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.conf.AccessKey + "/" + scope},
+		"X-Amz-Date":          {t.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.FormatUint(uint64(s.conf.PresignExpiry), 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(q)
+
+	canonical := strings.Join([]string{
+		method,
+		uriEncode(u.Path, false),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + s.sign(t, scope, canonical)
+	return u.String(), nil
+}
+
+// Time format of AWS Signature Version 4
+const amzDateFormat = "20060102T150405Z"
+
+// Credential scope of a request
+func (s *S3Storage) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.conf.Region + "/s3/aws4_request"
+}
+
+// Sign a canonical request with AWS Signature Version 4
+func (s *S3Storage) sign(t time.Time, scope, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format(amzDateFormat),
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + s.conf.SecretKey)
+	for _, part := range [...]string{
+		t.Format("20060102"), s.conf.Region, "s3", "aws4_request", toSign,
+	} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(key)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// Sign and send a request. payloadHash defaults to the hash of an empty body.
+// Returns an error satisfying os.IsNotExist for missing objects.
+func (s *S3Storage) do(req *http.Request, payloadHash string,
+) (res *http.Response, err error) {
+	if payloadHash == "" {
+		hash := sha256.Sum256(nil)
+		payloadHash = hex.EncodeToString(hash[:])
+	}
+	t := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + t.Format(amzDateFormat) + "\n",
+		strings.Join(signed, ";"),
+		payloadHash,
+	}, "\n")
+	scope := s.scope(t)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.conf.AccessKey, scope, strings.Join(signed, ";"),
+		s.sign(t, scope, canonical)))
+
+	res, err = s.client.Do(req)
+	if err != nil {
+		return
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode == 404 {
+		return nil, &os.PathError{
+			Op:   strings.ToLower(req.Method),
+			Path: req.URL.Path,
+			Err:  os.ErrNotExist,
+		}
+	}
+	var body struct {
+		Code, Message string
+	}
+	xml.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&body)
+	return nil, fmt.Errorf("s3: %s %s: %d %s: %s", req.Method, req.URL.Path,
+		res.StatusCode, body.Code, body.Message)
+}
+
+// Encode query parameters sorted by key with RFC 3986 percent-encoding
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Percent-encode a string as required by AWS Signature Version 4. Slashes
+// are only encoded, if encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
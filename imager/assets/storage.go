@@ -0,0 +1,281 @@
+package assets
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/util"
+)
+
+// Storage backend names
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+var (
+	storageMu sync.RWMutex
+	storage   Storage = NewLocalStorage("images")
+)
+
+// Storage is a backend for uploaded files and their thumbnails. Files are
+// addressed by slash-separated keys relative to the image root like
+// "src/<SHA1>.jpg".
+type Storage interface {
+	// Write a file of size bytes, replacing any existing file with the same
+	// key
+	Write(key string, r io.Reader, size int64) error
+
+	// Open a file for reading. Returns an error satisfying os.IsNotExist, if
+	// the file does not exist. The returned reader also implements
+	// io.ReadSeeker, if the backend supports seeking.
+	Open(key string) (io.ReadCloser, FileInfo, error)
+
+	// Delete a file. Deleting absent files is not an error.
+	Delete(key string) error
+
+	// Walk calls fn for all files with keys starting with prefix
+	Walk(prefix string, fn func(FileInfo) error) error
+
+	// URL returns a URL clients can download a file from directly or an
+	// empty string, if files must be served through this server
+	URL(key string) (string, error)
+}
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// SetStorage sets the storage backend for all uploaded files
+func SetStorage(s Storage) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	storage = s
+}
+
+// GetStorage returns the storage backend for all uploaded files
+func GetStorage() Storage {
+	storageMu.RLock()
+	defer storageMu.RUnlock()
+	return storage
+}
+
+// GetFileKeys generates storage keys of the source file and its thumbnail
+func GetFileKeys(SHA1 string, fileType, thumbType uint8) [2]string {
+	return [2]string{
+		util.ConcatStrings("src/", SHA1, ".", common.Extensions[fileType]),
+		util.ConcatStrings("thumb/", SHA1, ".", common.Extensions[thumbType]),
+	}
+}
+
+// AnimatedThumbKey generates the storage key of an animated thumbnail
+// preview of a video upload
+func AnimatedThumbKey(SHA1 string) string {
+	return util.ConcatStrings("thumb/", SHA1, animatedThumbSuffix)
+}
+
+// Open opens a stored file for reading
+func Open(key string) (io.ReadCloser, FileInfo, error) {
+	return GetStorage().Open(key)
+}
+
+// URL returns a URL clients can download a stored file from directly or an
+// empty string, if the file must be served through this server
+func URL(key string) (string, error) {
+	return GetStorage().URL(key)
+}
+
+// WriteFile writes a file of known size to storage
+func WriteFile(key string, r io.Reader, size int64) error {
+	return GetStorage().Write(key, r, size)
+}
+
+// LocalCopy returns the path of a stored file on the local file system for
+// processing by external programs. Files in remote storage are downloaded to
+// a temporary file. cleanup must be called after the file is no longer
+// needed.
+func LocalCopy(key string) (path string, cleanup func(), err error) {
+	s := GetStorage()
+	if l, ok := s.(*LocalStorage); ok {
+		return l.Path(key), func() {}, nil
+	}
+
+	r, _, err := s.Open(key)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	f, err := ioutil.TempFile("", "meguca_asset")
+	if err != nil {
+		return
+	}
+	cleanup = func() {
+		os.Remove(f.Name())
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return
+	}
+	return f.Name(), cleanup, nil
+}
+
+// Returns the size of a seekable file and rewinds it
+func seekSize(r io.ReadSeeker) (size int64, err error) {
+	size, err = r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	return
+}
+
+// Copy all files from storage backend from to storage backend to. Files
+// already present in to are skipped. Files are not deleted from from, so the
+// copy can be verified before switching backends.
+func Copy(from, to Storage, progress func(FileInfo)) (copied int, err error) {
+	existing := make(map[string]bool)
+	err = to.Walk("", func(f FileInfo) error {
+		existing[f.Key] = true
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	err = from.Walk("", func(f FileInfo) (err error) {
+		if existing[f.Key] {
+			return
+		}
+		r, info, err := from.Open(f.Key)
+		if err != nil {
+			return
+		}
+		defer r.Close()
+		err = to.Write(f.Key, r, info.Size)
+		if err != nil {
+			return fmt.Errorf("%s: %s", f.Key, err)
+		}
+		copied++
+		if progress != nil {
+			progress(f)
+		}
+		return
+	})
+	return
+}
+
+// LocalStorage stores files in a directory on the local file system
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a storage backend storing files in the directory
+// root
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root}
+}
+
+// Path returns the file system path of a file
+func (s *LocalStorage) Path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// Write implements Storage
+func (s *LocalStorage) Write(key string, r io.Reader, _ int64) (err error) {
+	p := s.Path(key)
+	err = os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return
+}
+
+// Open implements Storage
+func (s *LocalStorage) Open(key string) (
+	r io.ReadCloser, info FileInfo, err error,
+) {
+	f, err := os.Open(s.Path(key))
+	if err != nil {
+		return
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return
+	}
+	if stat.IsDir() {
+		f.Close()
+		err = &os.PathError{Op: "open", Path: key, Err: os.ErrNotExist}
+		return
+	}
+	info = FileInfo{
+		Key:     key,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+	}
+	return f, info, nil
+}
+
+// Delete implements Storage
+func (s *LocalStorage) Delete(key string) error {
+	err := os.Remove(s.Path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Walk implements Storage
+func (s *LocalStorage) Walk(prefix string, fn func(FileInfo) error) error {
+	return filepath.Walk(s.root, func(p string, info os.FileInfo, err error,
+	) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		return fn(FileInfo{
+			Key:     key,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	})
+}
+
+// URL implements Storage. Local files are always served through this server.
+func (s *LocalStorage) URL(string) (string, error) {
+	return "", nil
+}
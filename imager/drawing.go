@@ -0,0 +1,149 @@
+package imager
+
+import (
+	"bytes"
+	"github.com/bakape/meguca/common"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Wraps an in-memory file to satisfy multipart.File
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error {
+	return nil
+}
+
+// UploadDrawing renders an oekaki drawing into a PNG and processes it like an
+// uploaded file. Rendering is queued with other thumbnailing jobs. Returns an
+// image allocation token.
+func UploadDrawing(strokes []common.Stroke) (token string, err error) {
+	ch := make(chan thumbnailingResponse)
+	scheduleJob <- jobRequest{
+		strokes: strokes,
+		res:     ch,
+	}
+	res := <-ch
+	return res.imageID, res.err
+}
+
+// Render a drawing and process it like an uploaded file
+func processDrawing(strokes []common.Stroke) (token string, err error) {
+	buf, err := RenderDrawing(strokes)
+	if err != nil {
+		return
+	}
+	return processRequest(memoryFile{bytes.NewReader(buf)}, len(buf))
+}
+
+// RenderDrawing flattens drawing strokes onto a white canvas and encodes it
+// as PNG
+func RenderDrawing(strokes []common.Stroke) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, common.DrawingWidth,
+		common.DrawingHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, s := range strokes {
+		drawStroke(img, s)
+	}
+
+	var w bytes.Buffer
+	err := png.Encode(&w, img)
+	return w.Bytes(), err
+}
+
+// Draw a stroke as a sequence of thick line segments with round joints
+func drawStroke(img *image.RGBA, s common.Stroke) {
+	if len(s.Points) == 0 {
+		return
+	}
+
+	c := color.RGBA{
+		R: uint8(s.Color >> 16),
+		G: uint8(s.Color >> 8),
+		B: uint8(s.Color),
+		A: 255,
+	}
+	r := float64(s.Width) / 2
+	prev := s.Points[0]
+	fillSegment(img, prev, prev, r, c)
+	for _, p := range s.Points[1:] {
+		fillSegment(img, prev, p, r, c)
+		prev = p
+	}
+}
+
+// Fill all pixels within distance r of the line segment from a to b. Each
+// row is filled as a single span, so the cost is proportional to the area
+// covered.
+func fillSegment(img *image.RGBA, a, b [2]uint16, r float64, c color.RGBA) {
+	ax, ay := float64(a[0]), float64(a[1])
+	bx, by := float64(b[0]), float64(b[1])
+	dx, dy := bx-ax, by-ay
+	l := math.Hypot(dx, dy)
+
+	bounds := img.Bounds()
+	minY := int(math.Ceil(math.Min(ay, by) - r))
+	if minY < bounds.Min.Y {
+		minY = bounds.Min.Y
+	}
+	maxY := int(math.Floor(math.Max(ay, by) + r))
+	if maxY >= bounds.Max.Y {
+		maxY = bounds.Max.Y - 1
+	}
+
+	for y := minY; y <= maxY; y++ {
+		fy := float64(y)
+		left, right := math.Inf(1), math.Inf(-1)
+		span := func(from, to float64) {
+			if from <= to {
+				left = math.Min(left, from)
+				right = math.Max(right, to)
+			}
+		}
+
+		// Round caps
+		for _, p := range [...][2]float64{{ax, ay}, {bx, by}} {
+			if h := r*r - (fy-p[1])*(fy-p[1]); h >= 0 {
+				h = math.Sqrt(h)
+				span(p[0]-h, p[0]+h)
+			}
+		}
+
+		// Body of the segment as the intersection of a slab along the segment
+		// and one along its normal
+		if l != 0 {
+			from, to := slab(fy, ax, ay, dx, dy, 0, l*l)
+			nFrom, nTo := slab(fy, ax, ay, -dy, dx, -r*l, r*l)
+			span(math.Max(from, nFrom), math.Min(to, nTo))
+		}
+
+		from := int(math.Ceil(math.Max(left, float64(bounds.Min.X))))
+		to := int(math.Floor(math.Min(right, float64(bounds.Max.X-1))))
+		for x := from; x <= to; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// Returns the interval of x, for which lo <= (x-ax)*ux + (y-ay)*uy <= hi. The
+// interval is empty, if from > to.
+func slab(y, ax, ay, ux, uy, lo, hi float64) (from, to float64) {
+	k := (y - ay) * uy
+	if ux == 0 {
+		if lo <= k && k <= hi {
+			return math.Inf(-1), math.Inf(1)
+		}
+		return math.Inf(1), math.Inf(-1)
+	}
+	from = ax + (lo-k)/ux
+	to = ax + (hi-k)/ux
+	if from > to {
+		from, to = to, from
+	}
+	return
+}
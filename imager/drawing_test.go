@@ -0,0 +1,54 @@
+package imager
+
+import (
+	"bytes"
+	"github.com/bakape/meguca/common"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderDrawing(t *testing.T) {
+	t.Parallel()
+
+	buf, err := RenderDrawing([]common.Stroke{
+		{
+			Color:  0xff0000,
+			Width:  3,
+			Points: [][2]uint16{{10, 10}, {20, 10}, {20, 30}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != common.DrawingWidth ||
+		bounds.Dy() != common.DrawingHeight {
+		t.Fatalf("unexpected dimensions: %v", bounds)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	cases := [...]struct {
+		name  string
+		point image.Point
+		std   color.RGBA
+	}{
+		{"start", image.Pt(10, 10), red},
+		{"first segment", image.Pt(15, 10), red},
+		{"brush width", image.Pt(15, 11), red},
+		{"second segment", image.Pt(20, 25), red},
+		{"background", image.Pt(15, 20), color.RGBA{255, 255, 255, 255}},
+	}
+	for _, c := range cases {
+		got := color.RGBAModel.Convert(img.At(c.point.X, c.point.Y))
+		if got != c.std {
+			t.Errorf("%s: expected %v; got %v", c.name, c.std, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package imager
+
+import (
+	"encoding/hex"
+	"image"
+)
+
+// Compute a 64 bit perceptual difference hash of an image and return it hex
+// encoded. The image is downscaled to a 9x8 grid of average luminance and
+// each bit is set, if a cell is brighter than its right neighbour. Visually
+// similar images produce hashes with a small Hamming distance.
+func perceptualHash(img image.Image) string {
+	const w, h = 9, 8
+
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return ""
+	}
+
+	var grid [h][w]float64
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := b.Min.Y + (y+1)*b.Dy()/h
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := b.Min.X + (x+1)*b.Dx()/w
+			if x1 == x0 {
+				x1++
+			}
+
+			var sum float64
+			for i := y0; i < y1; i++ {
+				for j := x0; j < x1; j++ {
+					r, g, b, _ := img.At(j, i).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) +
+						0.114*float64(b)
+				}
+			}
+			grid[y][x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	var hash [8]byte
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			if grid[y][x] > grid[y][x+1] {
+				hash[y] |= 1 << uint(x)
+			}
+		}
+	}
+	return hex.EncodeToString(hash[:])
+}
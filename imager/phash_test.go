@@ -0,0 +1,50 @@
+package imager
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPerceptualHash(t *testing.T) {
+	t.Parallel()
+
+	// Brightness decreasing from left to right
+	gradient := func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := uint8(255 - x*255/w)
+				img.Set(x, y, color.RGBA{v, v, v, 255})
+			}
+		}
+		return img
+	}
+
+	uniform := image.NewRGBA(image.Rect(0, 0, 150, 100))
+	for i := range uniform.Pix {
+		uniform.Pix[i] = 0xff
+	}
+
+	cases := [...]struct {
+		name string
+		img  image.Image
+		hash string
+	}{
+		{"uniform", uniform, "0000000000000000"},
+		{"gradient", gradient(150, 150), "ffffffffffffffff"},
+		{"scaled gradient", gradient(40, 90), "ffffffffffffffff"},
+		{"smaller than grid", gradient(3, 2), "2424242424242424"},
+		{"empty", image.NewRGBA(image.Rect(0, 0, 0, 0)), ""},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if h := perceptualHash(c.img); h != c.hash {
+				t.Fatalf("unexpected hash: %s != %s", h, c.hash)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"hash"
 	"io"
+	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/db"
 	"mime/multipart"
 	"sync"
@@ -25,7 +26,9 @@ var (
 type jobRequest struct {
 	file multipart.File
 	size int
-	res  chan<- thumbnailingResponse
+	// Oekaki drawing to render and process instead of file, if any
+	strokes []common.Stroke
+	res     chan<- thumbnailingResponse
 }
 
 type thumbnailingResponse struct {
@@ -37,7 +40,11 @@ type thumbnailingResponse struct {
 func requestThumbnailing(file multipart.File, size int,
 ) <-chan thumbnailingResponse {
 	ch := make(chan thumbnailingResponse)
-	scheduleJob <- jobRequest{file, size, ch}
+	scheduleJob <- jobRequest{
+		file: file,
+		size: size,
+		res:  ch,
+	}
 	return ch
 }
 
@@ -45,8 +52,16 @@ func requestThumbnailing(file multipart.File, size int,
 func init() {
 	go func() {
 		for {
-			req := <-scheduleJob
-			id, err := processRequest(req.file, req.size)
+			var (
+				req = <-scheduleJob
+				id  string
+				err error
+			)
+			if req.strokes != nil {
+				id, err = processDrawing(req.strokes)
+			} else {
+				id, err = processRequest(req.file, req.size)
+			}
 			req.res <- thumbnailingResponse{id, err}
 		}
 	}()
@@ -51,6 +51,9 @@ func TestImageProcessing(t *testing.T) {
 			assertThumbnail(t, thumb)
 			assertDims(t, img.Dims, c.dims)
 			assertFileType(t, img.ThumbType, common.WEBP)
+			if len(img.PHash) != 16 {
+				t.Fatalf("invalid perceptual hash: %s", img.PHash)
+			}
 
 			t.Logf(`dims: %dx%d`, img.Dims[2], img.Dims[3])
 			writeSample(t, fmt.Sprintf("thumb_%s.webp", c.ext), thumb)
@@ -14,6 +14,7 @@ import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	mlog "github.com/bakape/meguca/log"
 	"mime/multipart"
 	"net/http"
 	"strconv"
@@ -23,7 +24,6 @@ import (
 	"github.com/chai2010/webp"
 
 	"github.com/bakape/thumbnailer"
-	"github.com/go-playground/log"
 )
 
 // Minimal capacity of large buffers in the pool
@@ -122,6 +122,8 @@ func validateUploader(r *http.Request) (err error) {
 	if err != nil {
 		return
 	}
+	// Uploads are not bound to a board, so board captcha exemptions can not
+	// be applied
 	need, err := db.NeedCaptcha(ip)
 	if err != nil {
 		return
@@ -198,7 +200,8 @@ func LogError(w http.ResponseWriter, r *http.Request, err error) {
 	if ipErr != nil {
 		ip = "invalid IP"
 	}
-	log.Errorf("upload error: by %s: %s: %#v", ip, err, err)
+	mlog.FromContext(r.Context(), "imager").
+		Errorf("upload error: by %s: %s: %#v", ip, err, err)
 }
 
 // ParseUpload parses the upload form. Separate function for cleaner error
@@ -274,6 +277,9 @@ func newThumbnail(f multipart.File, SHA1 string) (
 		token, err = db.NewImageToken(tx, img.SHA1)
 		return
 	})
+	if err == nil {
+		queueAnimatedThumb(img)
+	}
 	return
 }
 
@@ -327,6 +333,7 @@ func processFile(f multipart.File, img *common.ImageCommon,
 		b := thumbImage.Bounds()
 		img.Dims[2] = uint16(b.Dx())
 		img.Dims[3] = uint16(b.Dy())
+		img.PHash = perceptualHash(thumbImage)
 	}
 
 	img.MD5, img.Size, err = hashFile(f, md5.New(),
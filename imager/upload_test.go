@@ -168,6 +168,10 @@ func TestNewThumbnail(t *testing.T) {
 	assertCode(t, rec.Code, 200)
 
 	img := getImageRecord(t, assets.StdJPEG.SHA1)
+	if len(img.PHash) != 16 {
+		t.Fatalf("invalid perceptual hash: %s", img.PHash)
+	}
+	img.PHash = ""
 	test.AssertDeepEquals(t, img, assets.StdJPEG.ImageCommon)
 	assertFiles(t, "sample.jpg", assets.StdJPEG.SHA1, common.JPEG, common.WEBP)
 }
@@ -3,14 +3,19 @@ package lang
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/static"
 )
 
 var (
-	// Currently used language pack
-	pack Pack
+	// All loaded language packs by ID
+	packs map[string]Pack
 
 	// Precompiled table of relations between browser Accept-Language HTTP
 	// header values and internal POSIX language codes
@@ -24,7 +29,12 @@ type Pack struct {
 	UI, Options     map[string]string
 	Forms           map[string][2]string
 	Templates       map[string][]string
-	Common          struct {
+
+	// Translations of error messages sent to clients keyed by the original
+	// message
+	Errors map[string]string
+
+	Common struct {
 		UI      map[string]string    `json:"ui"`
 		Format  map[string]string    `json:"format"`
 		Posts   map[string]string    `json:"posts"`
@@ -35,12 +45,35 @@ type Pack struct {
 	}
 }
 
-// Load loads and parses the selected JSON language pack
+// Load loads and parses all JSON language packs
 func Load() (err error) {
-	lang := config.Get().DefaultLang
+	loaded := make(map[string]Pack, len(common.Langs))
+	codes := make(map[string]string, len(common.Langs)*2)
+	for _, id := range common.Langs {
+		var p Pack
+		p, err = loadPack(id)
+		if err != nil {
+			return
+		}
+		loaded[id] = p
+
+		// Match both the full language tag and the bare language, if no
+		// other pack has claimed it yet
+		tag := strings.ToLower(strings.Replace(id, "_", "-", 1))
+		codes[tag] = id
+		if base := tag[:strings.IndexByte(tag, '-')]; codes[base] == "" {
+			codes[base] = id
+		}
+	}
+
+	packs = loaded
+	languageCodes = codes
+	return
+}
 
+func loadPack(id string) (p Pack, err error) {
 	readJSON := func(file string, dst interface{}) (err error) {
-		f, err := static.FS.Open(fmt.Sprintf("/lang/%s/%s", lang, file))
+		f, err := static.FS.Open(fmt.Sprintf("/lang/%s/%s", id, file))
 		if err != nil {
 			return
 		}
@@ -48,21 +81,125 @@ func Load() (err error) {
 		return json.NewDecoder(f).Decode(dst)
 	}
 
-	err = readJSON("server.json", &pack)
+	err = readJSON("server.json", &p)
 	if err != nil {
 		return
 	}
-	err = readJSON("common.json", &pack.Common)
+	err = readJSON("common.json", &p.Common)
 	if err != nil {
 		return
 	}
-
-	pack.ID = lang
-
+	p.ID = id
 	return
 }
 
-// Get returns the loaded language pack
+// Get returns the language pack of the server's default language
 func Get() Pack {
-	return pack
+	return packs[config.Get().DefaultLang]
+}
+
+// GetByID returns the language pack by ID. Falls back to the server's default
+// language, if no such pack exists.
+func GetByID(id string) Pack {
+	if p, ok := packs[id]; ok {
+		return p
+	}
+	return Get()
+}
+
+// FromRequest returns the language pack to render a response to r with.
+// board is the board the response is rendered for or "" for none.
+func FromRequest(r *http.Request, board string) Pack {
+	return GetByID(Negotiate(r.Header.Get("Accept-Language"), board))
+}
+
+// Negotiate returns the ID of the language to serve a client with. A language
+// set for the board takes precedence over the client's preferred languages in
+// the Accept-Language header, which in turn take precedence over the server's
+// default language.
+func Negotiate(acceptLanguage, board string) string {
+	if board != "" && board != "all" {
+		if id := config.GetBoardConfigs(board).DefaultLang; id != "" {
+			return id
+		}
+	}
+	if id := parseAcceptLanguage(acceptLanguage); id != "" {
+		return id
+	}
+	return config.Get().DefaultLang
+}
+
+// Return the ID of the most preferred available language from an
+// Accept-Language header value or "", if none are available
+func parseAcceptLanguage(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	type choice struct {
+		id string
+		q  float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(part)
+		q := 1.0
+		if i := strings.IndexByte(tag, ';'); i != -1 {
+			param := strings.TrimSpace(tag[i+1:])
+			tag = strings.TrimSpace(tag[:i])
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				q, err = strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					continue
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		tag = strings.ToLower(tag)
+		id, ok := languageCodes[tag]
+		if !ok {
+			if i := strings.IndexByte(tag, '-'); i != -1 {
+				id, ok = languageCodes[tag[:i]]
+			}
+		}
+		if ok {
+			choices = append(choices, choice{id, q})
+		}
+	}
+	if len(choices) == 0 {
+		return ""
+	}
+
+	// Stable, so languages of equal weight retain the client's order
+	sort.SliceStable(choices, func(i, j int) bool {
+		return choices[i].q > choices[j].q
+	})
+	return choices[0].id
+}
+
+// Error returns the message of an error sent to a client translated to the
+// pack's language. Untranslated messages are returned as is.
+func (p Pack) Error(err error) string {
+	msg := err.Error()
+	if s, ok := p.Errors[msg]; ok {
+		return s
+	}
+	if err, ok := err.(common.StatusError); ok && err.Err != nil {
+		prefix, inner := err.Prefix(), err.Err.Error()
+		if prefix == "" {
+			return msg
+		}
+		if s, ok := p.Errors[prefix]; ok {
+			prefix = s
+		}
+		if s, ok := p.Errors[inner]; ok {
+			inner = s
+		}
+		return prefix + ": " + inner
+	}
+	return msg
 }
@@ -0,0 +1,87 @@
+package lang
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bakape/meguca/common"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	languageCodes = map[string]string{
+		"en-gb": "en_GB",
+		"en":    "en_GB",
+		"ru-ru": "ru_RU",
+		"ru":    "ru_RU",
+		"pt-br": "pt_BR",
+		"pt":    "pt_BR",
+	}
+
+	cases := [...]struct {
+		name, header, id string
+	}{
+		{"empty", "", ""},
+		{"unavailable", "de-DE, ja", ""},
+		{"exact match", "ru-RU", "ru_RU"},
+		{"bare language", "ru", "ru_RU"},
+		{"other region", "pt-PT", "pt_BR"},
+		{"first available", "de, ru;q=0.8, en;q=0.5", "ru_RU"},
+		{"by weight", "en;q=0.3, ru;q=0.9", "ru_RU"},
+		{"equal weight", "en-GB, ru-RU", "en_GB"},
+		{"zero weight", "ru;q=0, en;q=0.1", "en_GB"},
+		{"invalid weight", "ru;q=abc, en;q=0.1", "en_GB"},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if id := parseAcceptLanguage(c.header); id != c.id {
+				t.Fatalf("unexpected language: %s != %s", id, c.id)
+			}
+		})
+	}
+}
+
+func TestPackError(t *testing.T) {
+	p := Pack{
+		Errors: map[string]string{
+			"invalid input":                  "неверные данные",
+			"captcha":                        "капча",
+			"you are banned from this board": "вы забанены на этой доске",
+		},
+	}
+
+	cases := [...]struct {
+		name string
+		err  error
+		msg  string
+	}{
+		{"untranslated", errors.New("foo"), "foo"},
+		{
+			"status error",
+			common.ErrInvalidCaptcha,
+			"неверные данные: капча",
+		},
+		{
+			"untranslated prefix",
+			common.ErrBanned,
+			"access denied: вы забанены на этой доске",
+		},
+		{
+			"untranslated message",
+			common.ErrInvalidInput("bar"),
+			"неверные данные: bar",
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if msg := p.Error(c.err); msg != c.msg {
+				t.Fatalf("unexpected message: `%s` != `%s`", msg, c.msg)
+			}
+		})
+	}
+}
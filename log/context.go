@@ -0,0 +1,75 @@
+package mlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-playground/log"
+)
+
+type contextKey int
+
+// Keys of IDs carried by contexts
+const (
+	requestIDContextKey contextKey = iota
+	connIDContextKey
+)
+
+// NewID generates a random ID for a request or connection
+func NewID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// WithConnID tags a log entry with the ID of the websocket connection being
+// handled
+func WithConnID(e log.Entry, id string) log.Entry {
+	return e.WithField(ConnIDKey, id)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the ID of an HTTP
+// request
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// ContextWithConnID returns a copy of ctx carrying the ID of a websocket
+// connection
+func ContextWithConnID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, connIDContextKey, id)
+}
+
+// RequestID returns the ID of the HTTP request carried by ctx or an empty
+// string, if none
+func RequestID(ctx context.Context) string {
+	return contextString(ctx, requestIDContextKey)
+}
+
+// ConnID returns the ID of the websocket connection carried by ctx or an empty
+// string, if none
+func ConnID(ctx context.Context) string {
+	return contextString(ctx, connIDContextKey)
+}
+
+func contextString(ctx context.Context, key contextKey) string {
+	if ctx == nil {
+		return ""
+	}
+	s, _ := ctx.Value(key).(string)
+	return s
+}
+
+// FromContext returns a log entry of a module tagged with any request and
+// connection IDs carried by ctx. ctx may be nil.
+func FromContext(ctx context.Context, module string) log.Entry {
+	e := Module(module)
+	if id := RequestID(ctx); id != "" {
+		e = WithRequestID(e, id)
+	}
+	if id := ConnID(ctx); id != "" {
+		e = WithConnID(e, id)
+	}
+	return e
+}
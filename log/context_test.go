@@ -0,0 +1,30 @@
+package mlog
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := ContextWithRequestID(context.Background(), "foo")
+	ctx = ContextWithConnID(ctx, "bar")
+	AssertDeepEquals(t, RequestID(ctx), "foo")
+	AssertDeepEquals(t, ConnID(ctx), "bar")
+
+	fields := make(map[string]interface{})
+	for _, f := range FromContext(ctx, "websockets").Fields {
+		fields[f.Key] = f.Value
+	}
+	AssertDeepEquals(t, fields, map[string]interface{}{
+		ModuleKey:    "websockets",
+		RequestIDKey: "foo",
+		ConnIDKey:    "bar",
+	})
+
+	// No IDs
+	AssertDeepEquals(t, len(FromContext(nil, "db").Fields), 1)
+}
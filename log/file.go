@@ -0,0 +1,192 @@
+package mlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Time format of rotated log file name suffixes. Sorts lexically.
+const rotationTimeFormat = "20060102T150405.000"
+
+// FileOptions configures the rotating log file handler
+type FileOptions struct {
+	// Path of the log file. Rotated files are written to the same directory.
+	Path string
+	// Size in MB and age in hours after which the log file is rotated.
+	// 0 disables rotation by the respective criteria.
+	MaxSize, MaxAge uint
+	// Number of rotated files to retain. 0 retains all.
+	MaxBackups uint
+	// Compress rotated files with gzip
+	Compress bool
+}
+
+// Log file writer with size- and age-based rotation
+type rotatingFile struct {
+	mu     sync.Mutex
+	opts   FileOptions
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// Compression and pruning of rotated files run in the background, so
+	// writes are not blocked. Serialized by cleanupMu.
+	cleanupMu sync.Mutex
+	cleanup   sync.WaitGroup
+}
+
+// Open or create a log file with rotation
+func openRotatingFile(opts FileOptions) (f *rotatingFile, err error) {
+	f = &rotatingFile{opts: opts}
+	err = f.open()
+	return
+}
+
+func (f *rotatingFile) open() (err error) {
+	f.file, err = os.OpenFile(f.opts.Path,
+		os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	stats, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+	f.size = stats.Size()
+	f.opened = time.Now()
+	return
+}
+
+// Write to the log file and rotate it beforehand, if needed
+func (f *rotatingFile) Write(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.needsRotation(len(p)) {
+		err = f.rotate()
+		if err != nil {
+			return
+		}
+	}
+	n, err = f.file.Write(p)
+	f.size += int64(n)
+	return
+}
+
+// Close the log file and wait for clean up of rotated files to complete
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.file.Close()
+	f.cleanup.Wait()
+	return err
+}
+
+// Returns, if the log file must be rotated before writing n bytes
+func (f *rotatingFile) needsRotation(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if max := int64(f.opts.MaxSize) << 20; max != 0 && f.size+int64(n) > max {
+		return true
+	}
+	max := time.Duration(f.opts.MaxAge) * time.Hour
+	return max != 0 && time.Since(f.opened) > max
+}
+
+// Move the current log file aside, open a new one and compress and prune
+// rotated files in the background
+func (f *rotatingFile) rotate() (err error) {
+	err = f.file.Close()
+	if err != nil {
+		return
+	}
+	name := f.opts.Path + "." + time.Now().UTC().Format(rotationTimeFormat)
+	err = os.Rename(f.opts.Path, name)
+	if err != nil {
+		return
+	}
+	err = f.open()
+	if err != nil {
+		return
+	}
+
+	f.cleanup.Add(1)
+	go func() {
+		defer f.cleanup.Done()
+		err := f.cleanUp(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mlog: rotating log file: %s\n", err)
+		}
+	}()
+	return
+}
+
+// Compress a rotated file, if enabled, and prune old rotated files
+func (f *rotatingFile) cleanUp(rotated string) (err error) {
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	if f.opts.Compress {
+		err = compressFile(rotated)
+		if err != nil {
+			return
+		}
+	}
+	return f.prune()
+}
+
+// Remove the oldest rotated files in excess of the retention count
+func (f *rotatingFile) prune() (err error) {
+	if f.opts.MaxBackups == 0 {
+		return
+	}
+	rotated, err := filepath.Glob(f.opts.Path + ".*")
+	if err != nil {
+		return
+	}
+	if len(rotated) <= int(f.opts.MaxBackups) {
+		return
+	}
+	sort.Strings(rotated)
+	for _, p := range rotated[:len(rotated)-int(f.opts.MaxBackups)] {
+		err = os.Remove(p)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Replace a file with its gzip-compressed version
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+
+	w := gzip.NewWriter(dst)
+	_, err = io.Copy(w, src)
+	if err != nil {
+		return
+	}
+	err = w.Close()
+	if err != nil {
+		return
+	}
+	return os.Remove(path)
+}
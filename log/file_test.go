@@ -0,0 +1,104 @@
+package mlog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "meguca.log")
+	f, err := openRotatingFile(FileOptions{
+		Path:       path,
+		MaxSize:    1,
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := []byte(strings.Repeat("a", 1<<19-1) + "\n")
+	for i := 0; i < 8; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+		// Ensure distinct rotated file names
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// Waits for rotated files to be compressed and pruned
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("unexpected rotated files: %v", rotated)
+	}
+	for _, p := range rotated {
+		if !strings.HasSuffix(p, ".gz") {
+			t.Fatalf("not compressed: %s", p)
+		}
+		assertGzipSize(t, p, 2*len(line))
+	}
+
+	stats, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Size() != int64(2*len(line)) {
+		t.Fatalf("unexpected log file size: %d", stats.Size())
+	}
+}
+
+func assertGzipSize(t *testing.T, path string, size int) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != size {
+		t.Fatalf("unexpected uncompressed size: %d", len(buf))
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	f := rotatingFile{
+		opts: FileOptions{
+			MaxAge: 1,
+		},
+		size:   1,
+		opened: time.Now().Add(-2 * time.Hour),
+	}
+	if !f.needsRotation(1) {
+		t.Fatal("expired log file not rotated")
+	}
+	f.opened = time.Now()
+	if f.needsRotation(1) {
+		t.Fatal("fresh log file rotated")
+	}
+}
@@ -2,6 +2,7 @@
 package mlog
 
 import (
+	"os"
 	"sync"
 
 	"github.com/bakape/meguca/config"
@@ -21,70 +22,198 @@ const (
 	Console handler = iota
 	// Email is the email handler
 	Email
+	// Webhook posts errors to a Discord or Slack webhook
+	Webhook
+	// Sentry forwards errors with stack traces to Sentry
+	Sentry
+	// File is the rotating log file handler. Configured by FileConfig.
+	File
+	// Syslog ships logs to local or remote syslog. Configured by
+	// SyslogConfig.
+	Syslog
+	// Journald ships logs to the local journald. Configured by SyslogConfig.
+	Journald
 )
 
 var (
 	// Ensures no data races
 	rw sync.RWMutex
 
-	// Ensure email handler is only added once
-	once sync.Once
+	// Ensure the configuration update hook is only registered once
+	hookOnce sync.Once
 
 	// ConsoleHandler is the console handler
 	ConsoleHandler *console.Console
 
+	// Standard library logger already redirected to the logger
+	stdRedirected bool
+
 	// Email handler
 	eLog *email.Email
+
+	// Collapses repeated errors and caps the emails sent by eLog
+	eLogThrottle *throttledHandler
+
+	// Discord or Slack webhook handler
+	webhook *webhookHandler
+
+	// Sentry handler
+	sentry *sentryHandler
+
+	// JSONOutput makes the Console and File handlers write entries as
+	// newline-delimited JSON objects instead of plain text
+	JSONOutput bool
+
+	// FileConfig configures the File handler
+	FileConfig FileOptions
+
+	// SyslogConfig configures the Syslog and Journald handlers
+	SyslogConfig SyslogOptions
 )
 
-// Init initializes the logger.
+// SyslogOptions configures the Syslog and Journald handlers
+type SyslogOptions struct {
+	// Address of the syslog server. Either "local" or of the form
+	// "udp://host:port", "tcp://host:port" or "tls://host:port".
+	// Not used by the Journald handler.
+	Address string
+	// Syslog facility name like "daemon" or "local0". Defaults to "daemon".
+	Facility string
+	// Tag identifying the program in the system log
+	Tag string
+}
+
+// Init initializes a handler. Initializing an already initialized handler
+// replaces it, which applies changes to JSONOutput, FileConfig and
+// SyslogConfig at runtime.
 func Init(h handler) {
 	rw.Lock()
 	defer rw.Unlock()
 
 	switch h {
 	case Console:
-		ConsoleHandler = console.New(true)
+		// Also redirects the standard library logger, so is created even
+		// with JSON output. The redirection must only be done once.
+		ConsoleHandler = console.New(!stdRedirected)
+		stdRedirected = true
 		ConsoleHandler.SetTimestampFormat(DefaultTimeFormat)
-		log.AddHandler(ConsoleHandler, log.AllLevels...)
+		if JSONOutput {
+			setHandler(Console, newJSONHandler(os.Stderr), log.AllLevels...)
+		} else {
+			setHandler(Console, ConsoleHandler, log.AllLevels...)
+		}
 	case Email:
 		conf := config.Get()
 
 		eLog = email.New(conf.EmailErrSub, int(conf.EmailErrPort),
 			conf.EmailErrMail, conf.EmailErrPass, conf.EmailErrMail,
 			[]string{conf.EmailErrMail})
-
-		eLog.SetEnabled(conf.EmailErr)
 		eLog.SetTimestampFormat(DefaultTimeFormat)
-
-		if conf.EmailErr {
-			once.Do(func() {
-				log.AddHandler(eLog, log.ErrorLevel, log.PanicLevel,
-					log.AlertLevel, log.FatalLevel)
-			})
+		eLogThrottle = newThrottledHandler(eLog, conf.EmailErrCap)
+
+		// Replace any previous instance
+		removeHandler(Email)
+		configureEmail()
+		registerUpdateHook()
+	case Webhook:
+		if webhook == nil {
+			webhook = newWebhookHandler()
+			setHandler(Webhook, webhook, log.ErrorLevel, log.PanicLevel,
+				log.AlertLevel, log.FatalLevel)
+		}
+		configureWebhook()
+		registerUpdateHook()
+	case Sentry:
+		if sentry == nil {
+			sentry = newSentryHandler()
+			setHandler(Sentry, sentry, log.ErrorLevel, log.PanicLevel,
+				log.FatalLevel)
+		}
+		configureSentry()
+		registerUpdateHook()
+	case File:
+		w, err := openRotatingFile(FileConfig)
+		if err != nil {
+			log.Fatal("Failed to open log file: ", err)
+		}
+		if JSONOutput {
+			setHandler(File, closingHandler{newJSONHandler(w), w},
+				log.AllLevels...)
+		} else {
+			fileHandler := console.New(false)
+			fileHandler.SetDisplayColor(false)
+			fileHandler.SetTimestampFormat(DefaultTimeFormat)
+			fileHandler.SetWriter(w)
+			setHandler(File, closingHandler{fileHandler, w},
+				log.AllLevels...)
+		}
+	case Syslog, Journald:
+		create := newSyslogHandler
+		if h == Journald {
+			create = newJournaldHandler
+		}
+		sh, err := create(SyslogConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to system log: ", err)
 		}
+		setHandler(h, sh, log.AllLevels...)
 	default:
 		log.Fatal("Invalid mlog handler: ", h)
 	}
 }
 
+// Update handlers configured by the global configuration on configuration
+// changes
+func registerUpdateHook() {
+	hookOnce.Do(func() {
+		config.OnUpdate(func() error {
+			Update()
+			return nil
+		})
+	})
+}
+
+func configureWebhook() {
+	conf := config.Get()
+	webhook.configure(conf.WebhookErr, conf.WebhookErrURL, conf.WebhookErrCap)
+}
+
+func configureSentry() {
+	// DSN is validated on configuration, so this can not fail
+	sentry.configure(config.Get().SentryDSN)
+}
+
 // Update the logger.
 func Update() {
 	rw.Lock()
 	defer rw.Unlock()
 
+	if webhook != nil {
+		configureWebhook()
+	}
+	if sentry != nil {
+		configureSentry()
+	}
+	if eLog != nil {
+		configureEmail()
+	}
+}
+
+// Apply the email configuration and add or remove the email handler, if
+// email alerts were enabled or disabled
+func configureEmail() {
 	conf := config.Get()
 
 	eLog.SetEmailConfig(conf.EmailErrSub, int(conf.EmailErrPort),
 		conf.EmailErrMail, conf.EmailErrPass, conf.EmailErrMail,
 		[]string{conf.EmailErrMail})
-
-	eLog.SetEnabled(conf.EmailErr)
-
-	if conf.EmailErr {
-		once.Do(func() {
-			log.AddHandler(eLog, log.ErrorLevel, log.PanicLevel, log.AlertLevel,
-				log.FatalLevel)
-		})
+	eLogThrottle.setCap(conf.EmailErrCap)
+
+	switch {
+	case !conf.EmailErr:
+		removeHandler(Email)
+	case !isRegistered(Email):
+		setHandler(Email, eLogThrottle, log.ErrorLevel, log.PanicLevel,
+			log.AlertLevel, log.FatalLevel)
 	}
 }
@@ -0,0 +1,102 @@
+package mlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+// Keys of log entry fields with special meaning
+const (
+	// ModuleKey is the field key of the name of the module producing an entry
+	ModuleKey = "module"
+
+	// RequestIDKey is the field key of the ID of the request being handled
+	RequestIDKey = "request_id"
+
+	// ConnIDKey is the field key of the ID of the websocket connection being
+	// handled
+	ConnIDKey = "conn_id"
+)
+
+// Module returns a log entry tagged with the name of the module producing it
+func Module(name string) log.Entry {
+	return log.WithField(ModuleKey, name)
+}
+
+// WithRequestID tags a log entry with the ID of the request being handled
+func WithRequestID(e log.Entry, id string) log.Entry {
+	return e.WithField(RequestIDKey, id)
+}
+
+// Fields returns a log entry with arbitrary fields from alternating key and
+// value arguments
+func Fields(kv ...interface{}) log.Entry {
+	fields := make([]log.Field, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields = append(fields, log.F(fmt.Sprint(kv[i]), kv[i+1]))
+	}
+	return log.WithFields(fields...)
+}
+
+// Writes log entries as newline-delimited flat JSON objects. All entry
+// fields are written as top level keys.
+type jsonHandler struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newJSONHandler(w io.Writer) *jsonHandler {
+	return &jsonHandler{w: w}
+}
+
+// Log handles the log entry
+func (h *jsonHandler) Log(e log.Entry) {
+	buf := encodeJSONEntry(e)
+	h.mu.Lock()
+	h.w.Write(buf)
+	h.mu.Unlock()
+}
+
+// Encode a log entry as a single line JSON object
+func encodeJSONEntry(e log.Entry) []byte {
+	m := make(map[string]interface{}, len(e.Fields)+3)
+	for _, f := range e.Fields {
+		m[f.Key] = jsonValue(f.Value)
+	}
+	m["level"] = strings.ToLower(e.Level.String())
+	m["timestamp"] = e.Timestamp.UTC().Format(time.RFC3339Nano)
+	m["message"] = e.Message
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		// Some field value failed to encode. Fall back to string
+		// representations for all fields.
+		for _, f := range e.Fields {
+			m[f.Key] = fmt.Sprint(f.Value)
+		}
+		buf, _ = json.Marshal(m)
+	}
+	return append(buf, '\n')
+}
+
+// Convert values without a meaningful JSON representation to strings
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Time:
+		return v
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,53 @@
+package mlog
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+func TestEncodeJSONEntry(t *testing.T) {
+	t.Parallel()
+
+	e := WithRequestID(Module("feeds"), "abc").WithFields(
+		log.F("error", errors.New("foo")),
+		log.F("duration", time.Second),
+		log.F("thread", 1),
+	)
+	e.Message = "bar"
+	e.Level = log.WarnLevel
+	e.Timestamp = time.Unix(0, 0)
+
+	buf := encodeJSONEntry(e)
+	if buf[len(buf)-1] != '\n' {
+		t.Fatal("no trailing newline")
+	}
+	var res map[string]interface{}
+	if err := json.Unmarshal(buf, &res); err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, res, map[string]interface{}{
+		"level":      "warn",
+		"timestamp":  "1970-01-01T00:00:00Z",
+		"message":    "bar",
+		"module":     "feeds",
+		"request_id": "abc",
+		"error":      "foo",
+		"duration":   "1s",
+		"thread":     float64(1),
+	})
+}
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	AssertDeepEquals(t, Fields("a", 1, "b", "c", "odd").Fields, []log.Field{
+		log.F("a", 1),
+		log.F("b", "c"),
+	})
+}
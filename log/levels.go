@@ -0,0 +1,119 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/log"
+)
+
+// DefaultModule is the key of the minimum log level of modules without an
+// explicitly set level
+const DefaultModule = "*"
+
+var (
+	levelsMu sync.RWMutex
+
+	// Minimum level of entries logged for each module
+	moduleLevels = map[string]log.Level{
+		DefaultModule: log.DebugLevel,
+	}
+)
+
+// Returns, if an entry is at or above the minimum level of its module
+func enabled(e log.Entry) bool {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	min, ok := moduleLevels[entryModule(e)]
+	if !ok {
+		min = moduleLevels[DefaultModule]
+	}
+	return e.Level >= min
+}
+
+// Determine the module that produced an entry. Either set explicitly through
+// Module() or derived from the conventional "module: message" prefix.
+func entryModule(e log.Entry) string {
+	for _, f := range e.Fields {
+		if f.Key == ModuleKey {
+			return fmt.Sprint(f.Value)
+		}
+	}
+
+	i := strings.IndexByte(e.Message, ':')
+	if i <= 0 || i > 32 {
+		return ""
+	}
+	for _, r := range e.Message[:i] {
+		if (r < 'a' || r > 'z') && r != '_' {
+			return ""
+		}
+	}
+	return e.Message[:i]
+}
+
+// Levels returns the names of the minimum log levels of each module.
+// DefaultModule is the minimum level of all other modules.
+func Levels() map[string]string {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	levels := make(map[string]string, len(moduleLevels))
+	for m, l := range moduleLevels {
+		levels[m] = strings.ToLower(l.String())
+	}
+	return levels
+}
+
+// SetLevels replaces the minimum log levels of modules. Level names are case
+// insensitive. Set DefaultModule to change the minimum level of all modules
+// without an explicit level. It defaults to "debug".
+func SetLevels(levels map[string]string) error {
+	parsed := make(map[string]log.Level, len(levels)+1)
+	parsed[DefaultModule] = log.DebugLevel
+	for m, name := range levels {
+		if m == "" {
+			return errors.New("empty log module name")
+		}
+		l, err := parseLevel(name)
+		if err != nil {
+			return err
+		}
+		parsed[m] = l
+	}
+
+	levelsMu.Lock()
+	moduleLevels = parsed
+	levelsMu.Unlock()
+	return nil
+}
+
+// ParseLevels parses a comma-separated list of module=level pairs
+func ParseLevels(s string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.IndexByte(pair, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("invalid log level: %s", pair)
+		}
+		levels[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	return levels, nil
+}
+
+func parseLevel(name string) (log.Level, error) {
+	name = strings.ToUpper(name)
+	for _, l := range log.AllLevels {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid log level: %s", name)
+}
@@ -0,0 +1,73 @@
+package mlog
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+func TestEntryModule(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		e    log.Entry
+		mod  string
+	}{
+		{"field", Module("db").WithField("a", 1), "db"},
+		{"prefix", log.Entry{Message: "websockets: by ::1: foo"}, "websockets"},
+		{"no prefix", log.Entry{Message: "listening on http://[::1]"}, ""},
+	}
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, entryModule(c.e), c.mod)
+		})
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	defer SetLevels(nil)
+
+	levels, err := ParseLevels("websockets=warn, *=info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevels(levels); err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, Levels(), map[string]string{
+		"websockets": "warn",
+		"*":          "info",
+	})
+
+	cases := [...]struct {
+		msg     string
+		level   log.Level
+		enabled bool
+	}{
+		{"websockets: foo", log.InfoLevel, false},
+		{"websockets: foo", log.ErrorLevel, true},
+		{"db: foo", log.InfoLevel, true},
+		{"db: foo", log.DebugLevel, false},
+	}
+	for _, c := range cases {
+		e := log.Entry{
+			Message: c.msg,
+			Level:   c.level,
+		}
+		if enabled(e) != c.enabled {
+			t.Fatalf("unexpected filtering: %s %s", c.level, c.msg)
+		}
+	}
+
+	if err := SetLevels(map[string]string{"db": "verbose"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := ParseLevels("db"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,129 @@
+package mlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-playground/log"
+)
+
+var (
+	registryMu sync.Mutex
+
+	// Registered handlers ordered by kind. Replaced as a whole on each change,
+	// so that dispatching entries never locks.
+	registered atomic.Value // []registration
+
+	// Ensures the dispatcher is only added to the logger once
+	dispatchOnce sync.Once
+)
+
+// Handler registered for a handler kind
+type registration struct {
+	kind    handler
+	handler log.Handler
+	// Bitmask of levels the handler is registered for
+	levels uint16
+}
+
+// Fans out entries to all registered handlers. The underlying logger only
+// supports adding handlers, so this is the only handler added to it.
+type dispatcher struct{}
+
+// Log handles the log entry
+func (dispatcher) Log(e log.Entry) {
+	regs, _ := registered.Load().([]registration)
+	if len(regs) == 0 || !enabled(e) {
+		return
+	}
+	for _, r := range regs {
+		if r.levels&(1<<e.Level) != 0 {
+			r.handler.Log(e)
+		}
+	}
+}
+
+// Register a handler of a kind for levels, replacing and closing any handler
+// already registered for the kind. Entries are filtered by the per-module
+// minimum levels.
+func setHandler(kind handler, h log.Handler, levels ...log.Level) {
+	dispatchOnce.Do(func() {
+		log.AddHandler(dispatcher{}, log.AllLevels...)
+	})
+
+	var mask uint16
+	for _, l := range levels {
+		mask |= 1 << l
+	}
+	modifyRegistry(kind, &registration{
+		kind:    kind,
+		handler: h,
+		levels:  mask,
+	})
+}
+
+// Remove unregisters and closes the handler of a kind, if any. Handlers can be
+// registered again with Init.
+func Remove(h handler) {
+	rw.Lock()
+	defer rw.Unlock()
+
+	removeHandler(h)
+}
+
+func removeHandler(kind handler) {
+	modifyRegistry(kind, nil)
+}
+
+// Replace or, if reg is nil, remove the registration of kind
+func modifyRegistry(kind handler, reg *registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	old, _ := registered.Load().([]registration)
+	regs := make([]registration, 0, len(old)+1)
+	var replaced log.Handler
+	for _, r := range old {
+		if r.kind == kind {
+			replaced = r.handler
+		} else {
+			regs = append(regs, r)
+		}
+	}
+	if reg != nil {
+		regs = append(regs, *reg)
+		sort.Slice(regs, func(i, j int) bool {
+			return regs[i].kind < regs[j].kind
+		})
+	}
+	registered.Store(regs)
+
+	// Entries still being dispatched to the replaced handler may fail to be
+	// written after this
+	if c, ok := replaced.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "mlog: closing handler: %s\n", err)
+		}
+	}
+}
+
+// Returns, if a handler of a kind is registered
+func isRegistered(kind handler) bool {
+	regs, _ := registered.Load().([]registration)
+	for _, r := range regs {
+		if r.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Attaches a closer to a handler, so the closer is closed with the handler
+type closingHandler struct {
+	log.Handler
+	io.Closer
+}
@@ -0,0 +1,61 @@
+package mlog
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+// Records forwarded entries and whether it was closed
+type closableRecorder struct {
+	recordingHandler
+	closed bool
+}
+
+func (h *closableRecorder) Close() error {
+	h.closed = true
+	return nil
+}
+
+func TestHandlerRegistry(t *testing.T) {
+	const kind handler = 100
+	defer removeHandler(kind)
+
+	dispatch := func(level log.Level) {
+		dispatcher{}.Log(log.Entry{
+			Message: "foo",
+			Level:   level,
+		})
+	}
+
+	var first closableRecorder
+	setHandler(kind, &first, log.ErrorLevel)
+	if !isRegistered(kind) {
+		t.Fatal("handler not registered")
+	}
+	dispatch(log.InfoLevel)
+	dispatch(log.ErrorLevel)
+	AssertDeepEquals(t, len(first.messages()), 1)
+
+	// Replacing closes the previous handler
+	var second closableRecorder
+	setHandler(kind, &second, log.AllLevels...)
+	if !first.closed {
+		t.Fatal("replaced handler not closed")
+	}
+	dispatch(log.InfoLevel)
+	AssertDeepEquals(t, len(first.messages()), 1)
+	AssertDeepEquals(t, len(second.messages()), 1)
+
+	Remove(kind)
+	if !second.closed {
+		t.Fatal("removed handler not closed")
+	}
+	if isRegistered(kind) {
+		t.Fatal("handler still registered")
+	}
+	dispatch(log.ErrorLevel)
+	AssertDeepEquals(t, len(second.messages()), 1)
+}
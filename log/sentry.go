@@ -0,0 +1,355 @@
+package mlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+// Release is the build version entries are tagged with in Sentry. Can be set
+// at build time with
+// -ldflags "-X github.com/bakape/meguca/log.Release=<version>".
+// Defaults to the VCS revision embedded in the binary.
+var Release string
+
+// Import path prefix of the program's own packages. Used to mark stack frames
+// as application code.
+const appPackage = "github.com/bakape/meguca/"
+
+// Keys of entry fields, that can identify users and are never sent to Sentry
+var scrubbedFields = map[string]bool{
+	"ip":        true,
+	"by":        true,
+	"user":      true,
+	"userid":    true,
+	"user_id":   true,
+	"session":   true,
+	"password":  true,
+	"token":     true,
+	"cookie":    true,
+	"email":     true,
+	"useragent": true,
+}
+
+func init() {
+	if Release != "" {
+		return
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			Release = s.Value
+			return
+		}
+	}
+	if info.Main.Version != "(devel)" {
+		Release = info.Main.Version
+	}
+}
+
+// Sentry DSN decomposed into the store API endpoint and authentication key
+type sentryDSN struct {
+	storeURL, publicKey string
+}
+
+// Parse a DSN of the form "https://<key>@<host>/<project>"
+func parseSentryDSN(s string) (dsn sentryDSN, err error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return
+	}
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		err = errors.New("invalid Sentry DSN")
+		return
+	}
+	project := path.Base(u.Path)
+	if project == "/" || project == "." {
+		err = errors.New("no project in Sentry DSN")
+		return
+	}
+	dsn.publicKey = u.User.Username()
+	dsn.storeURL = fmt.Sprintf("%s://%s%s/api/%s/store/",
+		u.Scheme, u.Host, strings.TrimSuffix(path.Dir(u.Path), "/"), project)
+	return
+}
+
+// Forwards error entries with stack traces to Sentry or any service
+// compatible with its store API
+type sentryHandler struct {
+	mu      sync.RWMutex
+	enabled bool
+	dsn     sentryDSN
+	// Encoded events waiting to be sent. Sent asynchronously, so that logging
+	// never blocks on the network.
+	queue  chan []byte
+	client *http.Client
+}
+
+func newSentryHandler() *sentryHandler {
+	h := &sentryHandler{
+		queue:  make(chan []byte, 64),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	go h.sendLoop()
+	return h
+}
+
+// Set the DSN events are sent to. An empty DSN disables the handler.
+func (h *sentryHandler) configure(dsn string) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.enabled = dsn != ""
+	if !h.enabled {
+		return
+	}
+	h.dsn, err = parseSentryDSN(dsn)
+	if err != nil {
+		h.enabled = false
+	}
+	return
+}
+
+// Log handles the log entry
+func (h *sentryHandler) Log(e log.Entry) {
+	h.mu.RLock()
+	enabled := h.enabled
+	h.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	buf, err := json.Marshal(newSentryEvent(e, captureStack()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mlog: sentry: %s\n", err)
+		return
+	}
+	select {
+	case h.queue <- buf:
+	default:
+		// Drop events during error storms
+	}
+}
+
+func (h *sentryHandler) sendLoop() {
+	for buf := range h.queue {
+		// Errors can not be logged through the logger without recursing into
+		// this handler
+		if err := h.send(buf); err != nil {
+			fmt.Fprintf(os.Stderr, "mlog: sentry: %s\n", err)
+		}
+	}
+}
+
+func (h *sentryHandler) send(buf []byte) (err error) {
+	h.mu.RLock()
+	dsn := h.dsn
+	h.mu.RUnlock()
+
+	req, err := http.NewRequest("POST", dsn.storeURL, bytes.NewReader(buf))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf(
+		"Sentry sentry_version=7, sentry_client=meguca/1.0, sentry_key=%s",
+		dsn.publicKey))
+	res, err := h.client.Do(req)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		err = fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return
+}
+
+// Event in the Sentry store API format
+type sentryEvent struct {
+	EventID    string                 `json:"event_id"`
+	Timestamp  string                 `json:"timestamp"`
+	Level      string                 `json:"level"`
+	Logger     string                 `json:"logger"`
+	Platform   string                 `json:"platform"`
+	Release    string                 `json:"release,omitempty"`
+	ServerName string                 `json:"server_name,omitempty"`
+	Message    string                 `json:"message"`
+	Extra      map[string]interface{} `json:"extra,omitempty"`
+	Exception  struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Build a Sentry event from a log entry with any user-identifying data
+// scrubbed
+func newSentryEvent(e log.Entry, frames []sentryFrame) (ev sentryEvent) {
+	id := make([]byte, 16)
+	rand.Read(id)
+	ev.EventID = hex.EncodeToString(id)
+	ev.Timestamp = e.Timestamp.UTC().Format("2006-01-02T15:04:05")
+	ev.Platform = "go"
+	ev.Release = Release
+	ev.ServerName, _ = os.Hostname()
+	ev.Message = scrubIPs(e.Message)
+	ev.Logger = "meguca"
+
+	switch e.Level {
+	case log.PanicLevel, log.FatalLevel:
+		ev.Level = "fatal"
+	default:
+		ev.Level = "error"
+	}
+
+	for _, f := range e.Fields {
+		if f.Key == ModuleKey {
+			ev.Logger = fmt.Sprint(f.Value)
+		}
+		if scrubbedFields[strings.ToLower(f.Key)] {
+			continue
+		}
+		if ev.Extra == nil {
+			ev.Extra = make(map[string]interface{}, len(e.Fields))
+		}
+		v := jsonValue(f.Value)
+		if s, ok := v.(string); ok {
+			v = scrubIPs(s)
+		}
+		ev.Extra[f.Key] = v
+	}
+
+	// Messages are conventionally prefixed with the producing subsystem
+	exc := sentryException{
+		Type:  ev.Logger,
+		Value: ev.Message,
+	}
+	if i := strings.IndexByte(ev.Message, ':'); i > 0 && i < 64 {
+		exc.Type = ev.Message[:i]
+	}
+	exc.Stacktrace.Frames = frames
+	ev.Exception.Values = []sentryException{exc}
+	return
+}
+
+// Capture the stack of the goroutine that produced the log entry, excluding
+// the logging frames. When logging from a deferred recovery, this includes
+// the frames of the panic site.
+func captureStack() (frames []sentryFrame) {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(1, pc)
+	it := runtime.CallersFrames(pc[:n])
+	for {
+		f, more := it.Next()
+		mod, fn := splitFunctionName(f.Function)
+		switch {
+		case mod == "runtime",
+			strings.HasPrefix(mod, "github.com/go-playground/log"),
+			mod == appPackage+"log":
+		default:
+			frames = append(frames, sentryFrame{
+				Function: fn,
+				Module:   mod,
+				Filename: path.Base(f.File),
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+				InApp:    strings.HasPrefix(mod, appPackage),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+
+	// Sentry expects the innermost frame last
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return
+}
+
+// Split a fully qualified function name into package path and function name
+func splitFunctionName(name string) (pkg, fn string) {
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot == -1 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// Replace any IP addresses in s
+func scrubIPs(s string) string {
+	isIPChar := func(r rune) bool {
+		return r == '.' || r == ':' || (r >= '0' && r <= '9') ||
+			(r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+	}
+
+	var (
+		w     strings.Builder
+		start = -1
+	)
+	flush := func(end int) {
+		if start == -1 {
+			return
+		}
+		tok := s[start:end]
+		if net.ParseIP(tok) == nil {
+			// Might be followed by punctuation
+			tok = strings.TrimRight(tok, ".:")
+		}
+		if strings.ContainsAny(tok, ".:") && net.ParseIP(tok) != nil {
+			w.WriteString(strings.Replace(s[start:end], tok, "[ip]", 1))
+		} else {
+			w.WriteString(s[start:end])
+		}
+		start = -1
+	}
+	for i, r := range s {
+		if isIPChar(r) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		w.WriteRune(r)
+	}
+	flush(len(s))
+	return w.String()
+}
@@ -0,0 +1,112 @@
+package mlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+func TestParseSentryDSN(t *testing.T) {
+	t.Parallel()
+
+	dsn, err := parseSentryDSN("https://abc@sentry.example.com/prefix/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, dsn, sentryDSN{
+		storeURL:  "https://sentry.example.com/prefix/api/42/store/",
+		publicKey: "abc",
+	})
+
+	for _, s := range [...]string{
+		"https://sentry.example.com/42",
+		"https://abc@sentry.example.com/",
+	} {
+		if _, err := parseSentryDSN(s); err == nil {
+			t.Fatalf("expected error: %s", s)
+		}
+	}
+}
+
+func TestScrubIPs(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct{ in, out string }{
+		{"server: 127.0.0.1: foo", "server: [ip]: foo"},
+		{"by ::1: bar", "by [ip]: bar"},
+		{"from 2001:db8::ff00:42:8329.", "from [ip]."},
+		{"at 15:04:05 deadbeef file.go:12", "at 15:04:05 deadbeef file.go:12"},
+	}
+	for _, c := range cases {
+		AssertDeepEquals(t, scrubIPs(c.in), c.out)
+	}
+}
+
+func TestSentryHandler(t *testing.T) {
+	received := make(chan sentryEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("X-Sentry-Auth"),
+				"sentry_key=key") {
+				t.Error("no auth header")
+			}
+			buf, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			var ev sentryEvent
+			if err := json.Unmarshal(buf, &ev); err != nil {
+				t.Error(err)
+			}
+			received <- ev
+		},
+	))
+	defer srv.Close()
+
+	h := newSentryHandler()
+	err := h.configure(strings.Replace(srv.URL, "://", "://key@", 1) + "/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Log(log.Entry{
+		Message:   "websockets: by 10.0.0.1: foo",
+		Level:     log.ErrorLevel,
+		Timestamp: time.Now(),
+		Fields: []log.Field{
+			log.F("ip", "10.0.0.1"),
+			log.F(ModuleKey, "websockets"),
+		},
+	})
+
+	select {
+	case ev := <-received:
+		AssertDeepEquals(t, ev.Message, "websockets: by [ip]: foo")
+		AssertDeepEquals(t, ev.Logger, "websockets")
+		AssertDeepEquals(t, ev.Level, "error")
+		if _, ok := ev.Extra["ip"]; ok {
+			t.Fatal("IP field not scrubbed")
+		}
+		exc := ev.Exception.Values[0]
+		AssertDeepEquals(t, exc.Type, "websockets")
+		// Logging frames and thus this test's frames are excluded
+		frames := exc.Stacktrace.Frames
+		if len(frames) == 0 {
+			t.Fatal("no stack trace")
+		}
+		for _, f := range frames {
+			if f.Module == appPackage+"log" {
+				t.Fatalf("logging frame not excluded: %s", f.Function)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,370 @@
+// +build !windows
+
+package mlog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"log/syslog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+// Path of the journald native protocol socket
+const journaldSocket = "/run/systemd/journal/socket"
+
+// Facility names and their syslog facilities
+var facilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// Sends a formatted message with a syslog severity
+type severityWriter interface {
+	write(sev syslog.Priority, msg string) error
+	Close() error
+}
+
+// Ships log entries to a syslog or journald severityWriter
+type syslogHandler struct {
+	w severityWriter
+}
+
+// Log handles the log entry
+func (h syslogHandler) Log(e log.Entry) {
+	h.w.write(severity(e.Level), formatSyslogMessage(e))
+}
+
+// Close the connection to syslog
+func (h syslogHandler) Close() error {
+	return h.w.Close()
+}
+
+// Map log levels to syslog severities
+func severity(l log.Level) syslog.Priority {
+	switch l {
+	case log.DebugLevel:
+		return syslog.LOG_DEBUG
+	case log.InfoLevel:
+		return syslog.LOG_INFO
+	case log.NoticeLevel:
+		return syslog.LOG_NOTICE
+	case log.WarnLevel:
+		return syslog.LOG_WARNING
+	case log.ErrorLevel:
+		return syslog.LOG_ERR
+	case log.AlertLevel:
+		return syslog.LOG_ALERT
+	default: // Panic and fatal
+		return syslog.LOG_CRIT
+	}
+}
+
+// Format the message and fields of an entry. Timestamp and level are conveyed
+// by the syslog protocol.
+func formatSyslogMessage(e log.Entry) string {
+	var w strings.Builder
+	w.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&w, " %s=%v", f.Key, f.Value)
+	}
+	return w.String()
+}
+
+func parseFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslog.LOG_DAEMON, nil
+	}
+	f, ok := facilities[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility: %s", name)
+	}
+	return f, nil
+}
+
+// Parse a syslog address of the form "local", "udp://host:port",
+// "tcp://host:port" or "tls://host:port"
+func parseSyslogAddress(s string) (network, addr string, err error) {
+	if s == "local" {
+		return
+	}
+	i := strings.Index(s, "://")
+	if i == -1 {
+		err = fmt.Errorf("invalid syslog address: %s", s)
+		return
+	}
+	network, addr = s[:i], s[i+3:]
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		err = fmt.Errorf("unsupported syslog network: %s", network)
+	}
+	return
+}
+
+// Create a handler shipping log entries to local or remote syslog
+func newSyslogHandler(opts SyslogOptions) (log.Handler, error) {
+	network, addr, err := parseSyslogAddress(opts.Address)
+	if err != nil {
+		return nil, err
+	}
+	facility, err := parseFacility(opts.Facility)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "tls" {
+		w, err := dialTLSSyslog(addr, facility, opts.Tag)
+		if err != nil {
+			return nil, err
+		}
+		return syslogHandler{w}, nil
+	}
+	w, err := syslog.Dial(network, addr, facility|syslog.LOG_INFO, opts.Tag)
+	if err != nil {
+		return nil, err
+	}
+	return syslogHandler{stdSyslogWriter{w}}, nil
+}
+
+// Adapts the standard library syslog writer
+type stdSyslogWriter struct {
+	*syslog.Writer
+}
+
+func (w stdSyslogWriter) write(sev syslog.Priority, msg string) error {
+	switch sev {
+	case syslog.LOG_DEBUG:
+		return w.Debug(msg)
+	case syslog.LOG_INFO:
+		return w.Info(msg)
+	case syslog.LOG_NOTICE:
+		return w.Notice(msg)
+	case syslog.LOG_WARNING:
+		return w.Warning(msg)
+	case syslog.LOG_ERR:
+		return w.Err(msg)
+	case syslog.LOG_ALERT:
+		return w.Alert(msg)
+	default:
+		return w.Crit(msg)
+	}
+}
+
+// Writes RFC 5424 messages with octet-counting framing to a remote syslog
+// server over TLS as per RFC 5425. Reconnects on write failure.
+type tlsSyslogWriter struct {
+	mu             sync.Mutex
+	addr, tag      string
+	hostname       string
+	facility       syslog.Priority
+	conn           net.Conn
+	config         *tls.Config
+	dial           func() (net.Conn, error)
+	reconnectAfter time.Time
+	closed         bool
+}
+
+func dialTLSSyslog(addr string, facility syslog.Priority, tag string) (
+	w *tlsSyslogWriter, err error,
+) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+	w = &tlsSyslogWriter{
+		addr:     addr,
+		tag:      tag,
+		facility: facility,
+		config:   &tls.Config{ServerName: host},
+	}
+	w.hostname, _ = os.Hostname()
+	if w.hostname == "" {
+		w.hostname = "-"
+	}
+	w.dial = func() (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second},
+			"tcp", w.addr, w.config)
+	}
+	w.conn, err = w.dial()
+	return
+}
+
+func (w *tlsSyslogWriter) write(sev syslog.Priority, msg string) (
+	err error,
+) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+	if w.conn == nil {
+		// Throttle reconnection attempts to an unreachable server
+		if time.Now().Before(w.reconnectAfter) {
+			return
+		}
+		w.conn, err = w.dial()
+		if err != nil {
+			w.reconnectAfter = time.Now().Add(10 * time.Second)
+			return
+		}
+	}
+
+	_, err = w.conn.Write(w.format(sev, msg, time.Now()))
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	return
+}
+
+// Close the connection to the syslog server
+func (w *tlsSyslogWriter) Close() (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+	w.closed = true
+	return
+}
+
+// Format an octet-counted RFC 5424 message
+func (w *tlsSyslogWriter) format(sev syslog.Priority, msg string,
+	t time.Time,
+) []byte {
+	tag := w.tag
+	if tag == "" {
+		tag = "-"
+	}
+	m := fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+		w.facility|sev, t.UTC().Format(time.RFC3339Nano), w.hostname, tag,
+		os.Getpid(), msg)
+	return []byte(fmt.Sprintf("%d %s", len(m), m))
+}
+
+// Create a handler shipping log entries to the local journald
+func newJournaldHandler(opts SyslogOptions) (log.Handler, error) {
+	facility, err := parseFacility(opts.Facility)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(journaldSocket); err != nil {
+		return nil, fmt.Errorf("journald not available: %s", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram",
+		&net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return journaldHandler{
+		conn: conn,
+		addr: &net.UnixAddr{
+			Name: journaldSocket,
+			Net:  "unixgram",
+		},
+		tag:      opts.Tag,
+		facility: facility,
+	}, nil
+}
+
+// Ships log entries to the local journald with all entry fields as journal
+// fields
+type journaldHandler struct {
+	conn     *net.UnixConn
+	addr     *net.UnixAddr
+	tag      string
+	facility syslog.Priority
+}
+
+// Log handles the log entry
+func (h journaldHandler) Log(e log.Entry) {
+	h.conn.WriteToUnix(h.encode(e), h.addr)
+}
+
+// Close the journald socket
+func (h journaldHandler) Close() error {
+	return h.conn.Close()
+}
+
+// Encode an entry in the journald native protocol format
+func (h journaldHandler) encode(e log.Entry) []byte {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", e.Message)
+	writeJournalField(&buf, "PRIORITY", fmt.Sprint(int(severity(e.Level))))
+	writeJournalField(&buf, "SYSLOG_FACILITY",
+		fmt.Sprint(int(h.facility>>3)))
+	if h.tag != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", h.tag)
+	}
+	for _, f := range e.Fields {
+		key := journalFieldName(f.Key)
+		if key == "" {
+			continue
+		}
+		writeJournalField(&buf, key, fmt.Sprint(f.Value))
+	}
+	return buf.Bytes()
+}
+
+// Write a journal field. Values containing newlines are written in the binary
+// length-prefixed format.
+func writeJournalField(buf *bytes.Buffer, key, val string) {
+	buf.WriteString(key)
+	if strings.IndexByte(val, '\n') == -1 {
+		buf.WriteByte('=')
+		buf.WriteString(val)
+	} else {
+		buf.WriteByte('\n')
+		binary.Write(buf, binary.LittleEndian, uint64(len(val)))
+		buf.WriteString(val)
+	}
+	buf.WriteByte('\n')
+}
+
+// Convert a log field key to a valid journal field name. Journal field names
+// consist of uppercase letters, digits and underscores and must not start with
+// an underscore or digit. Returns "" for keys that can not be converted.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for _, r := range strings.ToUpper(key) {
+		switch {
+		case r >= 'A' && r <= 'Z', r == '_', r >= '0' && r <= '9':
+			if len(b) == 0 && (r == '_' || r <= '9') {
+				continue
+			}
+			b = append(b, byte(r))
+		case len(b) != 0:
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,135 @@
+// +build !windows
+
+package mlog
+
+import (
+	"bytes"
+	"log/syslog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+func TestParseSyslogAddress(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		in, network, addr string
+		err               bool
+	}{
+		{"local", "", "", false},
+		{"udp://localhost:514", "udp", "localhost:514", false},
+		{"tls://logs:6514", "tls", "logs:6514", false},
+		{"http://logs:80", "", "", true},
+		{"logs:514", "", "", true},
+	}
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.in, func(t *testing.T) {
+			t.Parallel()
+
+			network, addr, err := parseSyslogAddress(c.in)
+			if (err != nil) != c.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.err {
+				return
+			}
+			AssertDeepEquals(t, network, c.network)
+			AssertDeepEquals(t, addr, c.addr)
+		})
+	}
+}
+
+func TestRemoteSyslog(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := newSyslogHandler(SyslogOptions{
+		Address:  "udp://" + conn.LocalAddr().String(),
+		Facility: "local0",
+		Tag:      "meguca",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Log(log.Entry{
+		Message: "foo",
+		Level:   log.WarnLevel,
+		Fields:  []log.Field{log.F("thread", 1)},
+	})
+
+	buf := make([]byte, 1<<10)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	for _, s := range [...]string{"<132>", "meguca", "foo thread=1"} {
+		if !strings.Contains(msg, s) {
+			t.Fatalf("%q not in message: %s", s, msg)
+		}
+	}
+}
+
+func TestTLSSyslogFormat(t *testing.T) {
+	t.Parallel()
+
+	w := tlsSyslogWriter{
+		tag:      "meguca",
+		hostname: "host",
+		facility: syslog.LOG_DAEMON,
+	}
+	msg := string(w.format(syslog.LOG_ERR, "foo",
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)))
+	i := strings.IndexByte(msg, ' ')
+	if msg[:i] != strconv.Itoa(len(msg)-i-1) {
+		t.Fatalf("invalid octet count: %s", msg)
+	}
+	header := "<27>1 2000-01-01T00:00:00Z host meguca "
+	if !strings.HasPrefix(msg[i+1:], header) {
+		t.Fatalf("invalid header: %s", msg)
+	}
+	if !strings.HasSuffix(msg, " - - foo") {
+		t.Fatalf("invalid message: %s", msg)
+	}
+}
+
+func TestJournaldEncoding(t *testing.T) {
+	t.Parallel()
+
+	h := journaldHandler{
+		tag:      "meguca",
+		facility: syslog.LOG_DAEMON,
+	}
+	buf := h.encode(log.Entry{
+		Message: "foo\nbar",
+		Level:   log.InfoLevel,
+		Fields: []log.Field{
+			log.F("request_id", "abc"),
+			log.F("_private", 1),
+			log.F("!", 2),
+		},
+	})
+
+	var std bytes.Buffer
+	std.WriteString("MESSAGE\n\x07\x00\x00\x00\x00\x00\x00\x00foo\nbar\n")
+	std.WriteString("PRIORITY=6\n")
+	std.WriteString("SYSLOG_FACILITY=3\n")
+	std.WriteString("SYSLOG_IDENTIFIER=meguca\n")
+	std.WriteString("REQUEST_ID=abc\n")
+	std.WriteString("PRIVATE=1\n")
+	AssertDeepEquals(t, string(buf), std.String())
+}
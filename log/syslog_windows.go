@@ -0,0 +1,17 @@
+package mlog
+
+import (
+	"errors"
+
+	"github.com/go-playground/log"
+)
+
+var errNoSyslog = errors.New("syslog and journald not supported on Windows")
+
+func newSyslogHandler(SyslogOptions) (log.Handler, error) {
+	return nil, errNoSyslog
+}
+
+func newJournaldHandler(SyslogOptions) (log.Handler, error) {
+	return nil, errNoSyslog
+}
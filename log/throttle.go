@@ -0,0 +1,151 @@
+package mlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+// Window, within which identical entries are collapsed into a digest.
+// Overridden in tests.
+var dedupWindow = 10 * time.Minute
+
+// Forwards entries to a handler, collapsing identical entries within
+// dedupWindow into a single digest entry with a count and enforcing a cap on
+// the number of entries forwarded per hour. Used to prevent error storms from
+// sending thousands of emails.
+type throttledHandler struct {
+	mu   sync.Mutex
+	next log.Handler
+	// Maximum entries forwarded per hour
+	cap uint
+	// Entries forwarded in the current hour
+	sent      uint
+	hourStart time.Time
+	// Entries seen in the current window by level and message
+	seen map[string]*occurrence
+	// Entries dropped because of the cap, since the last digest
+	capped    uint
+	scheduled bool
+}
+
+// Entry seen in the current window
+type occurrence struct {
+	level   log.Level
+	message string
+	// Times the entry was repeated and not forwarded
+	repeats uint
+}
+
+func newThrottledHandler(next log.Handler, cap uint) *throttledHandler {
+	return &throttledHandler{
+		next: next,
+		cap:  cap,
+		seen: make(map[string]*occurrence),
+	}
+}
+
+// Set the maximum entries forwarded per hour
+func (h *throttledHandler) setCap(cap uint) {
+	h.mu.Lock()
+	h.cap = cap
+	h.mu.Unlock()
+}
+
+// Log handles the log entry
+func (h *throttledHandler) Log(e log.Entry) {
+	h.mu.Lock()
+	key := e.Level.String() + ":" + e.Message
+	if o, ok := h.seen[key]; ok {
+		o.repeats++
+		h.mu.Unlock()
+		return
+	}
+	h.seen[key] = &occurrence{
+		level:   e.Level,
+		message: e.Message,
+	}
+	if !h.scheduled {
+		h.scheduled = true
+		time.AfterFunc(dedupWindow, h.flush)
+	}
+	ok := h.underCap()
+	if ok {
+		h.sent++
+	} else {
+		h.capped++
+	}
+	h.mu.Unlock()
+
+	if ok {
+		h.next.Log(e)
+	}
+}
+
+// Returns, if another entry can be forwarded this hour
+func (h *throttledHandler) underCap() bool {
+	if now := time.Now(); now.Sub(h.hourStart) >= time.Hour {
+		h.hourStart = now
+		h.sent = 0
+	}
+	return h.sent < h.cap
+}
+
+// Forward a digest of repeated and capped entries from the ending window
+func (h *throttledHandler) flush() {
+	h.mu.Lock()
+	h.scheduled = false
+
+	var (
+		level   = log.ErrorLevel
+		repeats []string
+	)
+	for _, o := range h.seen {
+		if o.repeats == 0 {
+			continue
+		}
+		if o.level > level {
+			level = o.level
+		}
+		repeats = append(repeats,
+			fmt.Sprintf("repeated %d times: %s", o.repeats, o.message))
+	}
+	if len(repeats) == 0 && h.capped == 0 {
+		h.seen = make(map[string]*occurrence)
+		h.mu.Unlock()
+		return
+	}
+	if !h.underCap() {
+		// Retain counts and keep collapsing entries until the cap resets
+		h.scheduled = true
+		time.AfterFunc(dedupWindow, h.flush)
+		h.mu.Unlock()
+		return
+	}
+	h.sent++
+
+	sort.Strings(repeats)
+	var w strings.Builder
+	w.WriteString("Error digest\n")
+	for _, r := range repeats {
+		w.WriteString(r)
+		w.WriteByte('\n')
+	}
+	if h.capped != 0 {
+		fmt.Fprintf(&w, "%d errors not sent due to the hourly cap\n",
+			h.capped)
+	}
+	h.seen = make(map[string]*occurrence)
+	h.capped = 0
+	h.mu.Unlock()
+
+	h.next.Log(log.Entry{
+		Message:   strings.TrimSuffix(w.String(), "\n"),
+		Level:     level,
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,87 @@
+package mlog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+// Records forwarded entries
+type recordingHandler struct {
+	mu      sync.Mutex
+	entries []log.Entry
+}
+
+func (h *recordingHandler) Log(e log.Entry) {
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	h.mu.Unlock()
+}
+
+func (h *recordingHandler) messages() (msgs []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range h.entries {
+		msgs = append(msgs, e.Message)
+	}
+	return
+}
+
+func TestThrottledHandler(t *testing.T) {
+	dedupWindow = 50 * time.Millisecond
+	defer func() {
+		dedupWindow = 10 * time.Minute
+	}()
+
+	var rec recordingHandler
+	h := newThrottledHandler(&rec, 3)
+	logErr := func(msg string) {
+		h.Log(log.Entry{
+			Message: msg,
+			Level:   log.ErrorLevel,
+		})
+	}
+
+	for i := 0; i < 100; i++ {
+		logErr("db: connection refused")
+	}
+	logErr("foo")
+	logErr("bar")
+	logErr("baz")
+
+	// Identical errors are collapsed and the cap is enforced
+	AssertDeepEquals(t, rec.messages(), []string{
+		"db: connection refused",
+		"foo",
+		"bar",
+	})
+
+	// Digest is retained, until the cap resets
+	time.Sleep(3 * dedupWindow)
+	AssertDeepEquals(t, len(rec.messages()), 3)
+
+	h.mu.Lock()
+	h.hourStart = time.Time{}
+	h.mu.Unlock()
+	time.Sleep(2 * dedupWindow)
+
+	msgs := rec.messages()
+	AssertDeepEquals(t, len(msgs), 4)
+	for _, s := range []string{
+		"repeated 99 times: db: connection refused",
+		"1 errors not sent due to the hourly cap",
+	} {
+		if !strings.Contains(msgs[3], s) {
+			t.Fatalf("digest does not contain %q: %s", s, msgs[3])
+		}
+	}
+
+	// Window is reset after the digest
+	logErr("foo")
+	AssertDeepEquals(t, len(rec.messages()), 5)
+}
@@ -0,0 +1,184 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/log"
+)
+
+const (
+	// Maximum length of a webhook message. Discord rejects messages longer
+	// than 2000 characters.
+	maxWebhookMessage = 1900
+
+	// Maximum number of alerts retained, while waiting for the cap to reset
+	maxPendingAlerts = 100
+)
+
+// Time to collect alerts for, before sending them in one message.
+// Overridden in tests.
+var webhookBatchInterval = 5 * time.Second
+
+// Posts batched error alerts to a Discord or Slack incoming webhook with a cap
+// on the number of messages sent per minute
+type webhookHandler struct {
+	mu      sync.Mutex
+	enabled bool
+	url     string
+	// Maximum messages to send per minute
+	cap uint
+	// Messages sent in the current minute
+	sent        uint
+	windowStart time.Time
+	// Alerts waiting to be sent
+	pending []string
+	// Alerts discarded, because of the cap, since the last sent message
+	dropped   uint
+	scheduled bool
+	client    *http.Client
+}
+
+func newWebhookHandler() *webhookHandler {
+	return &webhookHandler{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Update webhook URL, per minute message cap and enable or disable the
+// handler
+func (h *webhookHandler) configure(enabled bool, url string, cap uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.enabled = enabled
+	h.url = url
+	h.cap = cap
+	if !enabled {
+		h.pending = nil
+		h.dropped = 0
+	}
+}
+
+// Log handles the log entry
+func (h *webhookHandler) Log(e log.Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.enabled {
+		return
+	}
+	if len(h.pending) == maxPendingAlerts {
+		h.dropped++
+	} else {
+		h.pending = append(h.pending, formatAlert(e))
+	}
+	if !h.scheduled {
+		h.scheduled = true
+		time.AfterFunc(webhookBatchInterval, h.flush)
+	}
+}
+
+// Format a log entry as a single alert line
+func formatAlert(e log.Entry) string {
+	var w strings.Builder
+	fmt.Fprintf(&w, "**%s** %s %s", e.Level,
+		e.Timestamp.Format(DefaultTimeFormat), e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&w, " %s=%v", f.Key, f.Value)
+	}
+	return w.String()
+}
+
+// Send pending alerts as one message, if the cap allows
+func (h *webhookHandler) flush() {
+	h.mu.Lock()
+	h.scheduled = false
+	if !h.enabled || (len(h.pending) == 0 && h.dropped == 0) {
+		h.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(h.windowStart) >= time.Minute {
+		h.windowStart = now
+		h.sent = 0
+	}
+	if h.sent >= h.cap {
+		// Retry once the cap resets
+		h.scheduled = true
+		time.AfterFunc(h.windowStart.Add(time.Minute).Sub(now), h.flush)
+		h.mu.Unlock()
+		return
+	}
+	h.sent++
+
+	msg := buildAlertMessage(h.pending, h.dropped)
+	h.pending = nil
+	h.dropped = 0
+	url := h.url
+	h.mu.Unlock()
+
+	// Errors can not be logged through the logger without recursing into
+	// this handler
+	if err := h.post(url, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "mlog: webhook: %s\n", err)
+	}
+}
+
+// Join alerts into one message within the message length limit
+func buildAlertMessage(alerts []string, dropped uint) string {
+	var w strings.Builder
+	for i, a := range alerts {
+		if w.Len()+len(a)+1 > maxWebhookMessage {
+			dropped += uint(len(alerts) - i)
+			break
+		}
+		w.WriteString(a)
+		w.WriteByte('\n')
+	}
+	if dropped != 0 {
+		fmt.Fprintf(&w, "… and %d more errors. See the server logs.", dropped)
+	}
+	return strings.TrimSuffix(w.String(), "\n")
+}
+
+// Post a message to a Discord or Slack webhook
+func (h *webhookHandler) post(url, msg string) (err error) {
+	// Discord and Slack use different keys for the message text
+	var body interface{}
+	if isDiscordWebhook(url) {
+		body = struct {
+			Content string `json:"content"`
+		}{msg}
+	} else {
+		body = struct {
+			Text string `json:"text"`
+		}{msg}
+	}
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+
+	res, err := h.client.Post(url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		err = fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return
+}
+
+func isDiscordWebhook(url string) bool {
+	return strings.Contains(url, "discord.com/") ||
+		strings.Contains(url, "discordapp.com/")
+}
@@ -0,0 +1,88 @@
+package mlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/bakape/meguca/test"
+
+	"github.com/go-playground/log"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	webhookBatchInterval = 10 * time.Millisecond
+	defer func() {
+		webhookBatchInterval = 5 * time.Second
+	}()
+
+	received := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var msg struct {
+				Text string
+			}
+			if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+				t.Error(err)
+			}
+			received <- msg.Text
+		},
+	))
+	defer srv.Close()
+
+	h := newWebhookHandler()
+	h.configure(true, srv.URL, 1)
+
+	logErr := func(msg string) {
+		h.Log(log.Entry{
+			Message: msg,
+			Level:   log.ErrorLevel,
+		})
+	}
+	logErr("foo")
+	logErr("bar")
+
+	select {
+	case msg := <-received:
+		// Alerts are batched into one message
+		if !strings.Contains(msg, "foo") || !strings.Contains(msg, "bar") {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+
+	// Exceeds the per minute cap
+	logErr("baz")
+	select {
+	case msg := <-received:
+		t.Fatalf("cap exceeded: %s", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	h.mu.Lock()
+	AssertDeepEquals(t, len(h.pending), 1)
+	h.mu.Unlock()
+}
+
+func TestBuildAlertMessage(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", maxWebhookMessage/2)
+	msg := buildAlertMessage([]string{"foo", long, long}, 1)
+	AssertDeepEquals(t, msg,
+		"foo\n"+long+"\n… and 2 more errors. See the server logs.")
+}
+
+func TestIsDiscordWebhook(t *testing.T) {
+	t.Parallel()
+
+	if !isDiscordWebhook("https://discord.com/api/webhooks/1/a") {
+		t.Fatal("discord webhook not detected")
+	}
+	if isDiscordWebhook("https://hooks.slack.com/services/a/b/c") {
+		t.Fatal("slack webhook detected as discord")
+	}
+}
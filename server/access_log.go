@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	mlog "github.com/bakape/meguca/log"
+
+	"github.com/go-playground/log"
+)
+
+// Access log formats
+const (
+	accessLogCombined = "combined"
+	accessLogJSON     = "json"
+)
+
+// Format to write access logs in. Access logs are disabled, if empty.
+var accessLogFormat string
+
+func validateAccessLogFormat(f string) error {
+	switch f {
+	case "", accessLogCombined, accessLogJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid access log format: %s", f)
+	}
+}
+
+// Assign each request an ID, that is sent to the client in the X-Request-ID
+// header and attached to any error logs of the request, and write access logs,
+// if enabled
+func accessLogHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mlog.NewID()
+		w.Header().Set("X-Request-ID", id)
+		r = r.WithContext(mlog.ContextWithRequestID(r.Context(), id))
+		if accessLogFormat == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &accessRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		logAccess(r, rec, time.Since(start))
+	})
+}
+
+// Log entry of the server module tagged with the ID of the request, if any
+func requestLog(r *http.Request) log.Entry {
+	return mlog.FromContext(r.Context(), "server")
+}
+
+// Records the status code and size of a response
+type accessRecorder struct {
+	http.ResponseWriter
+	status   int
+	written  int
+	hijacked bool
+}
+
+func (w *accessRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessRecorder) Write(p []byte) (n int, err error) {
+	if w.status == 0 {
+		w.status = 200
+	}
+	n, err = w.ResponseWriter.Write(p)
+	w.written += n
+	return
+}
+
+func (w *accessRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *accessRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	w.hijacked = true
+	return h.Hijack()
+}
+
+// Write an access log entry for a completed request
+func logAccess(r *http.Request, rec *accessRecorder, latency time.Duration) {
+	status := rec.status
+	switch {
+	case rec.hijacked:
+		// Websocket upgrades are written to the hijacked connection
+		status = http.StatusSwitchingProtocols
+	case status == 0:
+		status = 200
+	}
+	ip, err := auth.GetIP(r)
+	if err != nil {
+		ip = "-"
+	}
+
+	e := mlog.FromContext(r.Context(), "access")
+	if accessLogFormat == accessLogJSON {
+		e.WithFields(
+			log.F("ip", ip),
+			log.F("method", r.Method),
+			log.F("path", r.RequestURI),
+			log.F("proto", r.Proto),
+			log.F("status", status),
+			log.F("bytes", rec.written),
+			log.F("latency_ms", float64(latency)/float64(time.Millisecond)),
+			log.F("referer", r.Referer()),
+			log.F("user_agent", r.UserAgent()),
+		).Info("access")
+		return
+	}
+	e.WithField("latency", latency).Info(formatCombined(r, ip, status,
+		rec.written, time.Now()))
+}
+
+// Format a request in the Apache combined log format
+func formatCombined(r *http.Request, ip string, status, written int,
+	t time.Time,
+) string {
+	dash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return strings.Replace(s, `"`, `\"`, -1)
+	}
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %d "%s" "%s"`,
+		ip, t.Format("02/Jan/2006:15:04:05 -0700"), r.Method, r.RequestURI,
+		r.Proto, status, written, dash(r.Referer()), dash(r.UserAgent()))
+}
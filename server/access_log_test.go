@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	mlog "github.com/bakape/meguca/log"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestAccessLogHandler(t *testing.T) {
+	var id string
+	h := accessLogHandler(http.HandlerFunc(func(
+		w http.ResponseWriter,
+		r *http.Request,
+	) {
+		id = mlog.RequestID(r.Context())
+		w.WriteHeader(404)
+		w.Write([]byte("foo"))
+	}))
+
+	rec, req := newPair("/foo")
+	h.ServeHTTP(rec, req)
+	assertCode(t, rec, 404)
+	if len(id) != 16 {
+		t.Fatalf("invalid request ID: %q", id)
+	}
+	assertHeaders(t, rec, map[string]string{
+		"X-Request-ID": id,
+	})
+}
+
+func TestAccessRecorder(t *testing.T) {
+	t.Parallel()
+
+	w, _ := newPair("/")
+	rec := &accessRecorder{ResponseWriter: w}
+	rec.Write([]byte("foo"))
+	rec.WriteHeader(500)
+	rec.Write([]byte("bar"))
+	AssertDeepEquals(t, rec.status, 200)
+	AssertDeepEquals(t, rec.written, 6)
+}
+
+func TestFormatCombined(t *testing.T) {
+	t.Parallel()
+
+	_, req := newPair("/all/?a=1")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", `foo "bar"`)
+	ts := time.Date(2019, 3, 7, 13, 5, 9, 0, time.UTC)
+
+	AssertDeepEquals(t, formatCombined(req, "::1", 200, 1024, ts),
+		`::1 - - [07/Mar/2019:13:05:09 +0000] "GET /all/?a=1 HTTP/1.1" 200 1024 `+
+			`"https://example.com/" "foo \"bar\""`)
+}
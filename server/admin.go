@@ -9,14 +9,20 @@ import (
 	"fmt"
 	"io"
 	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/cache"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
+	mlog "github.com/bakape/meguca/log"
 	"github.com/bakape/meguca/templates"
+	"github.com/bakape/meguca/websockets"
 	"github.com/bakape/meguca/websockets/feeds"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,6 +33,8 @@ const (
 
 	maxAnswers      = 100  // Maximum number of eightball answers
 	maxEightballLen = 2000 // Total chars in eightball
+	maxPostCooldown = 3600 // Maximum board post cooldown in seconds
+	modLogPageSize  = 100  // Moderation log entries per page
 )
 
 var (
@@ -35,12 +43,18 @@ var (
 	errNoticeTooLong    = common.ErrTooLong("notice")
 	errRulesTooLong     = common.ErrTooLong("rules")
 	errReasonTooLong    = common.ErrTooLong("reason")
+	errWebhookTooLong   = common.ErrTooLong("webhook URL")
+	errSecretTooLong    = common.ErrTooLong("webhook secret")
+	errInvalidWebhook   = common.ErrInvalidInput("invalid webhook URL")
+	errInvalidLang      = common.ErrInvalidInput("invalid default language")
 	errTooManyAnswers   = common.ErrInvalidInput("too many eightball answers")
 	errInvalidBoardName = common.ErrInvalidInput("invalid board name")
 	errBoardNameTaken   = common.ErrInvalidInput("board name taken")
 	errNoReason         = common.ErrInvalidInput("no reason provided")
 	errNoDuration       = common.ErrInvalidInput("no ban duration provided")
 	errAccessDenied     = common.ErrAccessDenied("missing permissions")
+	errNotHidden        = common.ErrInvalidInput("post not hidden")
+	errHiddenPostOpen   = common.ErrInvalidInput("hidden post still open")
 
 	boardNameValidation = regexp.MustCompile(`^[a-z0-9]{1,10}$`)
 )
@@ -49,9 +63,102 @@ type boardActionRequest struct {
 	Board string
 }
 
+// Page of a board's moderation log
+type modLogPage struct {
+	Page    uint64             `json:"page"`
+	Pages   uint64             `json:"pages"`
+	Entries []auth.ModLogEntry `json:"entries"`
+}
+
 type boardCreationRequest struct {
 	auth.Captcha
 	ID, Title string
+
+	// Optional initial board configurations. ID is always overridden and
+	// Title defaults to the Title field of the request.
+	Configs *config.BoardConfigs
+
+	// Optional initial staff. The creating account is always a board owner.
+	Staff staffList
+}
+
+// Staff of a board by position
+type staffList struct {
+	Owners, Moderators, Janitors []string
+}
+
+// Validate staff list for writing to a board
+func (s staffList) validate() error {
+	// Ensure there always is at least one board owner
+	if len(s.Owners) == 0 {
+		return common.ErrInvalidInput("no board owners set")
+	}
+	// Maximum of 100 staff per position
+	for _, s := range [...][]string{s.Owners, s.Moderators, s.Janitors} {
+		if len(s) > 100 {
+			return common.ErrInvalidInput("too many staff per position")
+		}
+	}
+	return nil
+}
+
+// Convert to a map of positions for writing to the database
+func (s staffList) toMap() map[string][]string {
+	return map[string][]string{
+		"owners":     s.Owners,
+		"moderators": s.Moderators,
+		"janitors":   s.Janitors,
+	}
+}
+
+// Board-specific overrides of global post limits. Can only be set by the
+// "admin" account.
+type boardLimits struct {
+	DisableCaptcha bool     `json:"disableCaptcha"`
+	MaxBodyLength  uint     `json:"maxBodyLength"`
+	PostCooldown   uint     `json:"postCooldown"`
+	FileTypes      []string `json:"fileTypes"`
+}
+
+// Extract limit overrides from board configurations
+func limitsOf(conf config.BoardConfigs) boardLimits {
+	return boardLimits{
+		DisableCaptcha: conf.DisableCaptcha,
+		MaxBodyLength:  conf.MaxBodyLength,
+		PostCooldown:   conf.PostCooldown,
+		FileTypes:      conf.FileTypes,
+	}
+}
+
+// Set limit overrides on board configurations
+func (l boardLimits) apply(conf *config.BoardConfigs) {
+	conf.DisableCaptcha = l.DisableCaptcha
+	conf.MaxBodyLength = l.MaxBodyLength
+	conf.PostCooldown = l.PostCooldown
+	conf.FileTypes = l.FileTypes
+}
+
+// Validate limit overrides for writing to a board
+func (l boardLimits) validate() error {
+	switch {
+	case l.MaxBodyLength > common.MaxLenBody:
+		return common.ErrInvalidInput("max body length exceeds global maximum")
+	case l.PostCooldown > maxPostCooldown:
+		return common.ErrInvalidInput("post cooldown too long")
+	case len(l.FileTypes) > len(common.Extensions):
+		return common.ErrInvalidInput("too many file types")
+	}
+
+outer:
+	for _, t := range l.FileTypes {
+		for _, ext := range common.Extensions {
+			if t == ext {
+				continue outer
+			}
+		}
+		return common.ErrInvalidInput("invalid file type: " + t)
+	}
+	return nil
 }
 
 // Decode JSON sent in a request with a read limit of 8 KB. Returns if the
@@ -77,7 +184,7 @@ func configureBoard(w http.ResponseWriter, r *http.Request) {
 		}
 
 		msg.ID = extractParam(r, "board")
-		_, err = canPerform(w, r, msg.ID, auth.BoardOwner, true)
+		creds, err := canPerform(w, r, msg.ID, auth.BoardOwner, true)
 		if err != nil {
 			return
 		}
@@ -86,7 +193,62 @@ func configureBoard(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return
 		}
-		return db.UpdateBoard(msg)
+
+		// Limit overrides are set separately by the "admin" account
+		limitsOf(config.GetBoardConfigs(msg.ID).BoardConfigs).apply(&msg)
+
+		prev := config.GetBoardConfigs(msg.ID).Visibility
+		err = db.UpdateBoard(msg)
+		if err != nil {
+			return
+		}
+		if msg.Visibility != prev {
+			// Threads of the board are added to or removed from /all/
+			cache.DeleteByBoard("all")
+		}
+		return db.LogModeration(msg.ID, common.ModerationEntry{
+			Type: common.ConfigureBoard,
+			By:   creds.UserID,
+		})
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Set board-specific overrides of global post limits. Available only to the
+// "admin" account.
+func configureBoardLimits(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		err = isAdmin(w, r)
+		if err != nil {
+			return
+		}
+		board := extractParam(r, "board")
+		if !auth.IsNonMetaBoard(board) {
+			return errInvalidBoardName
+		}
+
+		var msg boardLimits
+		err = decodeJSON(w, r, &msg)
+		if err != nil {
+			return
+		}
+		err = msg.validate()
+		if err != nil {
+			return
+		}
+
+		conf := config.GetBoardConfigs(board).BoardConfigs
+		msg.apply(&conf)
+		err = db.UpdateBoard(conf)
+		if err != nil {
+			return
+		}
+		return db.LogModeration(board, common.ModerationEntry{
+			Type: common.ConfigureBoard,
+			By:   "admin",
+		})
 	}()
 	if err != nil {
 		httpError(w, r, err)
@@ -172,6 +334,10 @@ func validateBoardConfigs(w http.ResponseWriter, conf config.BoardConfigs,
 		err = errRulesTooLong
 	case len(conf.Title) > common.MaxLenBoardTitle:
 		err = errTitleTooLong
+	case len(conf.WebhookURL) > common.MaxLenWebhookURL:
+		err = errWebhookTooLong
+	case len(conf.WebhookSecret) > common.MaxLenWebhookSecret:
+		err = errSecretTooLong
 	}
 	if err != nil {
 		return
@@ -186,6 +352,76 @@ func validateBoardConfigs(w http.ResponseWriter, conf config.BoardConfigs,
 	}
 	if !matched {
 		err = common.ErrInvalidInput("invalid default theme")
+		return
+	}
+
+	if conf.DefaultLang != "" {
+		matched = false
+		for _, l := range common.Langs {
+			if conf.DefaultLang == l {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			err = errInvalidLang
+			return
+		}
+	}
+
+	if conf.ProxyPolicy != "" {
+		matched = false
+		for _, p := range common.ProxyPolicies {
+			if conf.ProxyPolicy == p {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			err = common.ErrInvalidInput("invalid proxy policy")
+			return
+		}
+	}
+
+	if conf.Visibility != "" {
+		matched = false
+		for _, v := range common.BoardVisibilities {
+			if conf.Visibility == v {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			err = common.ErrInvalidInput("invalid board visibility")
+			return
+		}
+	}
+
+	if conf.DuplicateLimit != 0 {
+		if conf.DuplicateWindow == 0 ||
+			conf.DuplicateWindow > common.MaxDuplicateWindow {
+			err = common.ErrInvalidInput("invalid duplicate post window")
+			return
+		}
+		matched = false
+		for _, p := range common.DuplicatePolicies {
+			if conf.DuplicatePolicy == p {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			err = common.ErrInvalidInput("invalid duplicate post policy")
+			return
+		}
+	}
+
+	if conf.WebhookURL != "" {
+		u, uErr := url.Parse(conf.WebhookURL)
+		if uErr != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			err = errInvalidWebhook
+		}
 	}
 	return
 }
@@ -213,6 +449,85 @@ func servePrivateServerConfigs(w http.ResponseWriter, r *http.Request) {
 	serveJSON(w, r, "", config.Get())
 }
 
+// Serve sampled websocket message metadata. Available only to the "admin"
+// account.
+func serveAuditSamples(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", websockets.AuditSamples())
+}
+
+// Serve database transaction retry and failure counters and the circuit
+// breaker state. Available only to the "admin" account.
+func serveTransactionStats(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", db.GetTransactionStats())
+}
+
+// Serve the connection state of database notification listeners. Available
+// only to the "admin" account.
+func serveListenerStatus(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", db.GetListenerStatus())
+}
+
+// Serve statistics of the orphaned media garbage collector. Available only to
+// the "admin" account.
+func serveMediaGCStats(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", db.GetMediaGCStats())
+}
+
+// Serve the minimum log levels of each module. Available only to the "admin"
+// account.
+func serveLogLevels(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", mlog.Levels())
+}
+
+// Replace the minimum log levels of modules at runtime. Available only to the
+// "admin" account.
+func setLogLevels(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var msg map[string]string
+		err = decodeJSON(w, r, &msg)
+		if err != nil {
+			return
+		}
+		err = isAdmin(w, r)
+		if err != nil {
+			return
+		}
+		err = mlog.SetLevels(msg)
+		if err != nil {
+			return common.ErrInvalidInput(err.Error())
+		}
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
 func isAdmin(w http.ResponseWriter, r *http.Request) (err error) {
 	creds, err := isLoggedIn(w, r)
 	if err != nil {
@@ -260,7 +575,7 @@ func createBoard(w http.ResponseWriter, r *http.Request) {
 
 		// Validate request data
 		switch {
-		case creds.UserID != "admin" && config.Get().DisableUserBoards:
+		case !canCreateBoards(creds.UserID):
 			err = errAccessDenied
 		case !boardNameValidation.MatchString(msg.ID),
 			msg.ID == "",
@@ -284,30 +599,75 @@ func createBoard(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		ip, err := auth.GetIP(r)
-		if err != nil {
-			return
+		conf := config.BoardConfigs{
+			BoardPublic: config.BoardPublic{
+				Title:      msg.Title,
+				DefaultCSS: config.Get().DefaultCSS,
+			},
+			ID:        msg.ID,
+			Eightball: config.EightballDefaults,
 		}
-		has, err := db.SolvedCaptchaRecently(ip, time.Minute)
+		if msg.Configs != nil {
+			conf = *msg.Configs
+			conf.ID = msg.ID
+			if conf.Title == "" {
+				conf.Title = msg.Title
+			}
+			err = validateBoardConfigs(w, conf)
+			if err != nil {
+				return
+			}
+
+			// Only the "admin" account can override global post limits
+			if creds.UserID == "admin" {
+				err = limitsOf(conf).validate()
+				if err != nil {
+					return
+				}
+			} else {
+				boardLimits{}.apply(&conf)
+			}
+		}
+
+		staff := msg.Staff
+		isOwner := false
+		for _, o := range staff.Owners {
+			if o == creds.UserID {
+				isOwner = true
+				break
+			}
+		}
+		if !isOwner {
+			staff.Owners = append(staff.Owners, creds.UserID)
+		}
+		err = staff.validate()
 		if err != nil {
 			return
 		}
-		if !has {
-			err = errInvalidCaptcha
-			return
+
+		// The admin account is exempt from captchas to allow automated board
+		// provisioning
+		if creds.UserID != "admin" {
+			var ip string
+			ip, err = auth.GetIP(r)
+			if err != nil {
+				return
+			}
+			var has bool
+			has, err = db.SolvedCaptchaRecently(ip, time.Minute)
+			if err != nil {
+				return
+			}
+			if !has {
+				err = errInvalidCaptcha
+				return
+			}
 		}
 
 		err = db.InTransaction(false, func(tx *sql.Tx) (err error) {
 			err = db.WriteBoard(tx, db.BoardConfigs{
-				Created: time.Now().UTC(),
-				BoardConfigs: config.BoardConfigs{
-					BoardPublic: config.BoardPublic{
-						Title:      msg.Title,
-						DefaultCSS: config.Get().DefaultCSS,
-					},
-					ID:        msg.ID,
-					Eightball: config.EightballDefaults,
-				},
+				Created:      time.Now().UTC(),
+				BoardConfigs: conf,
 			})
 			switch {
 			case err == nil:
@@ -318,22 +678,40 @@ func createBoard(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			return db.WriteStaff(tx, msg.ID, map[string][]string{
-				"owners": []string{creds.UserID},
-			})
+			return db.WriteStaff(tx, msg.ID, staff.toMap())
 		})
 		if err != nil {
 			return
 		}
 
 		err = db.WritePyu(msg.ID)
-		return
+		if err != nil {
+			return
+		}
+
+		// Make the board available immediately without waiting for the
+		// database notification
+		return db.ReloadBoardConfigs(msg.ID)
 	}()
 	if err != nil {
 		httpError(w, r, err)
 	}
 }
 
+// Returns, if an account is allowed to create boards
+func canCreateBoards(userID string) bool {
+	conf := config.Get()
+	if userID == "admin" || !conf.DisableUserBoards {
+		return true
+	}
+	for _, u := range conf.BoardCreators {
+		if u == userID {
+			return true
+		}
+	}
+	return false
+}
+
 // Set the server configuration to match the one sent from the admin account
 // user
 func configureServer(w http.ResponseWriter, r *http.Request) {
@@ -348,12 +726,40 @@ func configureServer(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if len(msg.CaptchaTags) < 3 {
-			err = common.StatusError{errors.New("too few captcha tags"), 400}
-			return
+		err = msg.Validate()
+		if err != nil {
+			return common.ErrInvalidInput(err.Error())
 		}
 		err = db.WriteConfigs(msg)
-		return
+		if err != nil {
+			return
+		}
+		return db.LogModeration("all", common.ModerationEntry{
+			Type: common.ConfigureServer,
+			By:   "admin",
+		})
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Re-read the global server configuration from the database and apply it
+// without restarting. Available only to the "admin" account.
+func reloadServerConfigs(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		err = isAdmin(w, r)
+		if err != nil {
+			return
+		}
+		err = db.ReloadConfigs()
+		if err != nil {
+			return
+		}
+		return db.LogModeration("all", common.ModerationEntry{
+			Type: common.ConfigureServer,
+			By:   "admin",
+		})
 	}()
 	if err != nil {
 		httpError(w, r, err)
@@ -462,9 +868,9 @@ func purgePost(w http.ResponseWriter, r *http.Request) {
 func ban(w http.ResponseWriter, r *http.Request) {
 	err := func() (err error) {
 		var msg struct {
-			Global       bool
-			ID, Duration uint64
-			Reason       string
+			Global, Shadow bool
+			ID, Duration   uint64
+			Reason         string
 		}
 		err = decodeJSON(w, r, &msg)
 		if err != nil {
@@ -500,7 +906,11 @@ func ban(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Apply ban
-		return db.Ban(board, msg.Reason, creds.UserID,
+		fn := db.Ban
+		if msg.Shadow {
+			fn = db.ShadowBan
+		}
+		return fn(board, msg.Reason, creds.UserID,
 			time.Minute*time.Duration(msg.Duration), msg.ID)
 	}()
 	if err != nil {
@@ -540,36 +950,35 @@ func assignStaff(w http.ResponseWriter, r *http.Request) {
 	err := func() (err error) {
 		var msg struct {
 			boardActionRequest
-			Owners, Moderators, Janitors []string
+			staffList
 		}
 		err = decodeJSON(w, r, &msg)
 		if err != nil {
 			return
 		}
-		_, err = canPerform(w, r, msg.Board, auth.BoardOwner, true)
+		creds, err := canPerform(w, r, msg.Board, auth.BoardOwner, true)
 		if err != nil {
 			return
 		}
 
-		// Ensure there always is at least one board owner
-		if len(msg.Owners) == 0 {
-			return common.ErrInvalidInput("no board owners set")
-		}
-		// Maximum of 100 staff per position
-		for _, s := range [...][]string{
-			msg.Owners, msg.Moderators, msg.Janitors,
-		} {
-			if len(s) > 100 {
-				return common.ErrInvalidInput("too many staff per position")
-			}
+		err = msg.staffList.validate()
+		if err != nil {
+			return
 		}
 
-		return db.InTransaction(false, func(tx *sql.Tx) error {
-			return db.WriteStaff(tx, msg.Board, map[string][]string{
-				"owners":     msg.Owners,
-				"moderators": msg.Moderators,
-				"janitors":   msg.Janitors,
-			})
+		err = db.InTransaction(false, func(tx *sql.Tx) error {
+			return db.WriteStaff(tx, msg.Board, msg.staffList.toMap())
+		})
+		if err != nil {
+			return
+		}
+		return db.LogModeration(msg.Board, common.ModerationEntry{
+			Type: common.AssignStaff,
+			By:   creds.UserID,
+			Data: fmt.Sprintf("owners: %s; moderators: %s; janitors: %s",
+				strings.Join(msg.Owners, ", "),
+				strings.Join(msg.Moderators, ", "),
+				strings.Join(msg.Janitors, ", ")),
 		})
 	}()
 	if err != nil {
@@ -605,9 +1014,7 @@ func getSameIPPosts(w http.ResponseWriter, r *http.Request) {
 
 // Set the sticky flag of a thread
 func setThreadSticky(w http.ResponseWriter, r *http.Request) {
-	handleBoolRequest(w, r, func(id uint64, val bool, _ string) error {
-		return db.SetThreadSticky(id, val)
-	})
+	handleBoolRequest(w, r, db.SetThreadSticky)
 }
 
 // Handle moderation request, that takes a boolean parameter,
@@ -642,10 +1049,115 @@ func setThreadLock(w http.ResponseWriter, r *http.Request) {
 	handleBoolRequest(w, r, db.SetThreadLock)
 }
 
-// Render list of bans on a board with unban links for authenticated staff
+// Move a thread to another board
+func moveThread(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var msg struct {
+			ID    uint64
+			Board string
+		}
+		err = decodeJSON(w, r, &msg)
+		if err != nil {
+			return
+		}
+		if !auth.IsNonMetaBoard(msg.Board) {
+			return errInvalidBoardName
+		}
+
+		_, userID, err := canModeratePost(w, r, msg.ID, auth.Moderator)
+		if err != nil {
+			return
+		}
+		_, err = canPerform(w, r, msg.Board, auth.Moderator, false)
+		if err != nil {
+			return
+		}
+
+		linking, err := db.MoveThread(msg.ID, msg.Board, userID)
+		if err != nil {
+			return
+		}
+		evictThreadCaches(linking...)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Merge a thread into another thread
+func mergeThread(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var msg struct {
+			Source, Target uint64
+		}
+		err = decodeJSON(w, r, &msg)
+		if err != nil {
+			return
+		}
+
+		_, userID, err := canModeratePost(w, r, msg.Source, auth.Moderator)
+		if err != nil {
+			return
+		}
+		_, _, err = canModeratePost(w, r, msg.Target, auth.Moderator)
+		if err != nil {
+			return
+		}
+
+		linking, err := db.MergeThread(msg.Source, msg.Target, userID)
+		if err != nil {
+			return
+		}
+		evictThreadCaches(linking...)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Serve posts of shadow banned posters on a board
+func serveHiddenPosts(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		_, err = canPerform(w, r, board, auth.Janitor, false)
+		if err != nil {
+			return
+		}
+		posts, err := db.GetHiddenPosts(board)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", posts)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Make closed posts of shadow banned posters visible to everyone
+func unhidePosts(w http.ResponseWriter, r *http.Request) {
+	moderatePosts(w, r, auth.Moderator, func(id uint64, userID string) error {
+		post, err := db.GetPost(id)
+		switch {
+		case err != nil:
+			return err
+		case !post.Shadowed:
+			return errNotHidden
+		case post.Editing:
+			return errHiddenPostOpen
+		}
+		return db.UnhidePost(id, userID)
+	})
+}
+
+// Render list of bans on a board with unban links for authenticated staff.
+// Staff identities are redacted for everyone else.
 func banList(w http.ResponseWriter, r *http.Request) {
 	board := extractParam(r, "board")
-	if !auth.IsBoard(board) {
+	if !canViewBoard(r, board) {
 		text404(w)
 		return
 	}
@@ -656,9 +1168,21 @@ func banList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	canUnban := detectCanPerform(r, board, auth.Moderator)
+	if !canUnban {
+		public := bans[:0]
+		for _, b := range bans {
+			if !b.Shadow {
+				b.By = ""
+				public = append(public, b)
+			}
+		}
+		bans = public
+	}
+
 	setHTMLHeaders(w)
-	templates.WriteBanList(w, bans, board,
-		detectCanPerform(r, board, auth.Moderator))
+	templates.WriteBanList(w, bans, board, canUnban,
+		lang.FromRequest(r, board))
 }
 
 // Detect, if a  client can perform moderation on a board. Unlike canPerform,
@@ -670,7 +1194,7 @@ func detectCanPerform(
 ) (
 	can bool,
 ) {
-	creds := extractLoginCreds(r)
+	creds := auth.ExtractLoginCreds(r)
 	if creds.UserID == "" || creds.Session == "" {
 		return
 	}
@@ -736,21 +1260,202 @@ func unban(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Serve moderation log for a specific board
+// Serve a page of the moderation log of a specific board
 func modLog(w http.ResponseWriter, r *http.Request) {
 	board := extractParam(r, "board")
-	if !auth.IsBoard(board) {
+	if !canViewBoard(r, board) {
 		text404(w)
 		return
 	}
 
-	log, err := db.GetModLog(board)
+	p, err := getModLogPage(r, board)
 	if err != nil {
 		httpError(w, r, err)
 		return
 	}
 	setHTMLHeaders(w)
-	templates.WriteModLog(w, log)
+	templates.WriteModLog(w, p.Entries, int(p.Page), int(p.Pages),
+		lang.FromRequest(r, board))
+}
+
+// Serve a page of the moderation log of a specific board as JSON
+func servePublicModLog(w http.ResponseWriter, r *http.Request) {
+	board := extractParam(r, "board")
+	if !canViewBoard(r, board) {
+		text404(w)
+		return
+	}
+
+	p, err := getModLogPage(r, board)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", p)
+}
+
+// Read the page of a board's moderation log set by the "page" query
+// parameter. Unless the client is staff of the board, entries of staff only
+// actions are omitted and the rest redacted.
+func getModLogPage(r *http.Request, board string) (p modLogPage, err error) {
+	if s := r.URL.Query().Get("page"); s != "" {
+		p.Page, err = strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			err = common.ErrInvalidInput("invalid page")
+			return
+		}
+	}
+
+	staff := detectCanPerform(r, board, auth.Janitor)
+	filter := db.ModLogFilter{
+		Public: !staff,
+		Limit:  modLogPageSize,
+		Offset: p.Page * modLogPageSize,
+	}
+	n, err := db.CountModLog(board, filter)
+	if err != nil {
+		return
+	}
+	p.Pages = (n + modLogPageSize - 1) / modLogPageSize
+	if p.Pages == 0 {
+		p.Pages = 1
+	}
+	if p.Page >= p.Pages {
+		err = common.StatusError{cache.ErrPageOverflow, 404}
+		return
+	}
+
+	p.Entries, err = db.FilterModLog(board, filter)
+	if err != nil {
+		return
+	}
+	if !staff {
+		redactModLog(p.Entries, config.Get().PublicModLogStaff)
+	}
+	return
+}
+
+// Strip information only staff may see from moderation log entries. Staff
+// identities are only stripped, if showStaff is false.
+func redactModLog(log []auth.ModLogEntry, showStaff bool) {
+	if showStaff {
+		return
+	}
+	for i := range log {
+		e := &log[i]
+		e.By = ""
+		if e.Type == common.AssignStaff {
+			// Lists the names of all assigned staff
+			e.Data = ""
+		}
+	}
+}
+
+// Serve the moderation log of a board to its staff as JSON. The log can be
+// filtered with the "type", "by", "since", "until" and "limit" query
+// parameters. "type" is a comma-separated list of moderation action types.
+// "since" and "until" are Unix timestamps.
+func serveModLog(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		_, err = canPerform(w, r, board, auth.Janitor, false)
+		if err != nil {
+			return
+		}
+		filter, err := parseModLogFilter(r)
+		if err != nil {
+			return
+		}
+		log, err := db.FilterModLog(board, filter)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", log)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Serve moderation statistics of a board and its staff members to the board
+// owners. The "since" query parameter sets the start of the covered period as
+// a Unix timestamp and defaults to the start of the moderation log retention
+// period.
+func serveModerationStats(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		_, err = canPerform(w, r, board, auth.BoardOwner, false)
+		if err != nil {
+			return
+		}
+
+		since := time.Now().AddDate(0, 0, -db.ModLogRetention)
+		if s := r.URL.Query().Get("since"); s != "" {
+			var n uint64
+			n, err = strconv.ParseUint(s, 10, 63)
+			if err != nil {
+				return common.ErrInvalidInput("invalid since")
+			}
+			since = time.Unix(int64(n), 0)
+		}
+
+		stats, err := db.GetModerationStats(board, since)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", stats)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Parse moderation log filter from request query parameters
+func parseModLogFilter(r *http.Request) (f db.ModLogFilter, err error) {
+	q := r.URL.Query()
+	parseUint := func(key string, bits int) (n uint64, err error) {
+		s := q.Get(key)
+		if s == "" {
+			return
+		}
+		n, err = strconv.ParseUint(s, 10, bits)
+		if err != nil {
+			err = common.ErrInvalidInput("invalid " + key)
+		}
+		return
+	}
+
+	if s := q.Get("type"); s != "" {
+		for _, t := range strings.Split(s, ",") {
+			var n uint64
+			n, err = strconv.ParseUint(t, 10, 8)
+			if err != nil {
+				err = common.ErrInvalidInput("invalid type")
+				return
+			}
+			f.Types = append(f.Types, common.ModerationAction(n))
+		}
+	}
+	f.By = q.Get("by")
+
+	since, err := parseUint("since", 63)
+	if err != nil {
+		return
+	}
+	if since != 0 {
+		f.Since = time.Unix(int64(since), 0)
+	}
+	until, err := parseUint("until", 63)
+	if err != nil {
+		return
+	}
+	if until != 0 {
+		f.Until = time.Unix(int64(until), 0)
+	}
+	f.Limit, err = parseUint("limit", 64)
+	return
 }
 
 // Decodes params for client forced redirection
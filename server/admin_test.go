@@ -192,6 +192,71 @@ func TestValidateBoardConfigs(t *testing.T) {
 			},
 			errTitleTooLong,
 		},
+		{
+			"webhook URL too long",
+			config.BoardConfigs{
+				WebhookURL: "https://" + GenString(common.MaxLenWebhookURL),
+			},
+			errWebhookTooLong,
+		},
+		{
+			"webhook secret too long",
+			config.BoardConfigs{
+				WebhookSecret: GenString(common.MaxLenWebhookSecret + 1),
+			},
+			errSecretTooLong,
+		},
+		{
+			"invalid webhook URL scheme",
+			config.BoardConfigs{
+				BoardPublic: config.BoardPublic{
+					DefaultCSS: "moe",
+				},
+				WebhookURL: "ftp://example.com/hook",
+			},
+			errInvalidWebhook,
+		},
+		{
+			"webhook URL without host",
+			config.BoardConfigs{
+				BoardPublic: config.BoardPublic{
+					DefaultCSS: "moe",
+				},
+				WebhookURL: "https:///hook",
+			},
+			errInvalidWebhook,
+		},
+		{
+			"invalid default language",
+			config.BoardConfigs{
+				BoardPublic: config.BoardPublic{
+					DefaultCSS:  "moe",
+					DefaultLang: "xx_XX",
+				},
+			},
+			errInvalidLang,
+		},
+		{
+			"valid default language",
+			config.BoardConfigs{
+				BoardPublic: config.BoardPublic{
+					DefaultCSS:  "moe",
+					DefaultLang: "ru_RU",
+				},
+			},
+			nil,
+		},
+		{
+			"valid webhook",
+			config.BoardConfigs{
+				BoardPublic: config.BoardPublic{
+					DefaultCSS: "moe",
+				},
+				WebhookURL:    "https://example.com/hook",
+				WebhookSecret: "foo",
+			},
+			nil,
+		},
 	}
 
 	for i := range cases {
@@ -208,6 +273,95 @@ func TestValidateBoardConfigs(t *testing.T) {
 	}
 }
 
+func TestValidateBoardLimits(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		boardLimits
+		valid bool
+	}{
+		{
+			name:  "no overrides",
+			valid: true,
+		},
+		{
+			name: "valid overrides",
+			boardLimits: boardLimits{
+				DisableCaptcha: true,
+				MaxBodyLength:  500,
+				PostCooldown:   30,
+				FileTypes:      []string{"png", "webm"},
+			},
+			valid: true,
+		},
+		{
+			name: "body length above global maximum",
+			boardLimits: boardLimits{
+				MaxBodyLength: common.MaxLenBody + 1,
+			},
+		},
+		{
+			name: "cooldown too long",
+			boardLimits: boardLimits{
+				PostCooldown: maxPostCooldown + 1,
+			},
+		},
+		{
+			name: "invalid file type",
+			boardLimits: boardLimits{
+				FileTypes: []string{"png", "exe"},
+			},
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := c.validate()
+			if c.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !c.valid && err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestConfigureBoardLimits(t *testing.T) {
+	test_db.ClearTables(t, "accounts", "boards")
+	writeSampleBoard(t)
+	writeAdminAccount(t)
+
+	std := boardLimits{
+		DisableCaptcha: true,
+		MaxBodyLength:  500,
+		PostCooldown:   30,
+		FileTypes:      []string{"png", "webm"},
+	}
+	rec, req := newJSONPair(t, "/api/configure-board-limits/a", std)
+	setLoginCookies(req, adminLoginCreds)
+	router.ServeHTTP(rec, req)
+	assertCode(t, rec, 200)
+
+	res, err := db.GetBoardConfigs("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, limitsOf(res), std)
+
+	t.Run("not admin", func(t *testing.T) {
+		writeSampleUser(t)
+		rec, req := newJSONPair(t, "/api/configure-board-limits/a", std)
+		setLoginCookies(req, sampleLoginCreds)
+		router.ServeHTTP(rec, req)
+		assertCode(t, rec, 403)
+	})
+}
+
 func disableCaptcha() {
 	conf := *config.Get()
 	conf.Captcha = false
@@ -342,6 +496,83 @@ func TestBoardCreation(t *testing.T) {
 	AssertDeepEquals(t, board, std)
 }
 
+func TestBoardCreationWithConfigsAndStaff(t *testing.T) {
+	test_db.ClearTables(t, "boards", "accounts")
+	writeSampleUser(t)
+	writeAdminAccount(t)
+	disableCaptcha()
+
+	const id = "b"
+	msg := boardCreationRequest{
+		ID:    id,
+		Title: "foo",
+		Configs: &config.BoardConfigs{
+			ID: "c", // Must be overridden
+			BoardPublic: config.BoardPublic{
+				DefaultCSS: common.Themes[0],
+				NSFW:       true,
+			},
+			Eightball: []string{"yes"},
+		},
+		Staff: staffList{
+			Moderators: []string{"admin"},
+		},
+	}
+	rec, req := newJSONPair(t, "/api/create-board", msg)
+	setLoginCookies(req, sampleLoginCreds)
+	router.ServeHTTP(rec, req)
+	assertCode(t, rec, 200)
+
+	board, err := db.GetBoardConfigs(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, board, config.BoardConfigs{
+		ID: id,
+		BoardPublic: config.BoardPublic{
+			Title:      "foo",
+			DefaultCSS: common.Themes[0],
+			NSFW:       true,
+		},
+		Eightball: []string{"yes"},
+	})
+	if !config.IsBoard(id) {
+		t.Fatal("board configs not propagated")
+	}
+
+	staff, err := db.GetStaff(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, staff, map[string][]string{
+		"owners":     {"user1"},
+		"moderators": {"admin"},
+	})
+}
+
+func TestCanCreateBoards(t *testing.T) {
+	conf := *config.Get()
+	defer config.Set(conf)
+
+	conf.DisableUserBoards = true
+	conf.BoardCreators = []string{"user1"}
+	config.Set(conf)
+
+	cases := [...]struct {
+		user string
+		can  bool
+	}{
+		{"admin", true},
+		{"user1", true},
+		{"user2", false},
+	}
+	for _, c := range cases {
+		if canCreateBoards(c.user) != c.can {
+			t.Errorf("unexpected board creation permission: %s", c.user)
+		}
+	}
+}
+
 func TestServePrivateServerConfigs(t *testing.T) {
 	test_db.ClearTables(t, "accounts")
 	writeSampleUser(t)
@@ -425,6 +656,23 @@ func TestServerConfigSetting(t *testing.T) {
 	AssertDeepEquals(t, conf, std)
 }
 
+func TestReloadServerConfigs(t *testing.T) {
+	test_db.ClearTables(t, "accounts")
+	std := config.Defaults
+	std.Mature = true
+	if err := db.WriteConfigs(std); err != nil {
+		t.Fatal(err)
+	}
+	writeAdminAccount(t)
+
+	rec, req := newJSONPair(t, "/api/reload-server-config", nil)
+	setLoginCookies(req, adminLoginCreds)
+	router.ServeHTTP(rec, req)
+	assertCode(t, rec, 200)
+
+	AssertDeepEquals(t, config.Get(), &std)
+}
+
 func TestDeleteBoard(t *testing.T) {
 	test_db.ClearTables(t, "accounts", "boards")
 	writeSampleUser(t)
@@ -596,3 +844,74 @@ func writeSampleThread(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseModLogFilter(t *testing.T) {
+	t.Parallel()
+
+	r := newRequest(
+		"/api/mod-log/a?type=2,9&by=admin&since=1&until=2&limit=10")
+	f, err := parseModLogFilter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, f, db.ModLogFilter{
+		Types: []common.ModerationAction{
+			common.DeletePost,
+			common.StickyThread,
+		},
+		By:    "admin",
+		Since: time.Unix(1, 0),
+		Until: time.Unix(2, 0),
+		Limit: 10,
+	})
+
+	r = newRequest("/api/mod-log/a?type=foo")
+	_, err = parseModLogFilter(r)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRedactModLog(t *testing.T) {
+	t.Parallel()
+
+	entries := func() []auth.ModLogEntry {
+		return []auth.ModLogEntry{
+			{
+				ModerationEntry: common.ModerationEntry{
+					Type: common.BanPost,
+					By:   "admin",
+					Data: "spam",
+				},
+				ID: 1,
+			},
+			{
+				ModerationEntry: common.ModerationEntry{
+					Type: common.AssignStaff,
+					By:   "admin",
+					Data: "owners: admin; moderators: ; janitors: ",
+				},
+			},
+		}
+	}
+
+	t.Run("hide staff", func(t *testing.T) {
+		t.Parallel()
+
+		log := entries()
+		redactModLog(log, false)
+		std := entries()
+		std[0].By = ""
+		std[1].By = ""
+		std[1].Data = ""
+		AssertDeepEquals(t, log, std)
+	})
+
+	t.Run("show staff", func(t *testing.T) {
+		t.Parallel()
+
+		log := entries()
+		redactModLog(log, true)
+		AssertDeepEquals(t, log, entries())
+	})
+}
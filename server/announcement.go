@@ -0,0 +1,264 @@
+package server
+
+import (
+	"errors"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/websockets"
+	"github.com/bakape/meguca/websockets/feeds"
+	"net/http"
+	"sync"
+
+	"github.com/go-playground/log"
+)
+
+// States of a board-wide announcement job
+const (
+	announcementRunning     = "running"
+	announcementDone        = "done"
+	announcementFailed      = "failed"
+	announcementRollingBack = "rollingBack"
+	announcementRolledBack  = "rolledBack"
+)
+
+var (
+	errAnnouncementRunning = common.StatusError{
+		Err:  errors.New("announcement already in progress"),
+		Code: 409,
+	}
+	errNoAnnouncement = common.StatusError{
+		Err:  errors.New("no announcement to roll back"),
+		Code: 404,
+	}
+
+	// Latest announcement job of each board
+	announcements   = make(map[string]*announcementJob)
+	announcementsMu sync.Mutex
+)
+
+// Posts a staff announcement reply into every thread on a board in the
+// background. Posted replies are deleted again, if the job fails or is rolled
+// back.
+type announcementJob struct {
+	mu sync.Mutex
+	announcementStatus
+	// IDs of posted announcement replies
+	posts []uint64
+
+	// Post an announcement reply into a thread and return its ID
+	post func(op uint64) (uint64, error)
+	// Remove a posted announcement reply
+	remove func(id uint64) error
+}
+
+// Progress of an announcement job as served to staff
+type announcementStatus struct {
+	State string `json:"state"`
+	By    string `json:"by"`
+	Total int    `json:"total"`
+	Done  int    `json:"done"`
+	Error string `json:"error,omitempty"`
+}
+
+// Return a snapshot of the job's progress
+func (j *announcementJob) status() announcementStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.announcementStatus
+}
+
+// Post the announcement into all threads. Rolls back all posted replies on
+// failure.
+func (j *announcementJob) run(threads []uint64) {
+	j.mu.Lock()
+	j.Total = len(threads)
+	j.mu.Unlock()
+
+	for _, op := range threads {
+		id, err := j.post(op)
+		if err != nil {
+			log.Errorf("announcement: thread %d: %s", op, err)
+			j.mu.Lock()
+			j.Error = err.Error()
+			j.mu.Unlock()
+			j.rollback(announcementFailed)
+			return
+		}
+
+		j.mu.Lock()
+		if id != 0 {
+			j.posts = append(j.posts, id)
+		}
+		j.Done++
+		j.mu.Unlock()
+	}
+
+	j.mu.Lock()
+	j.State = announcementDone
+	j.mu.Unlock()
+}
+
+// Delete all posted announcement replies and set the job to the final state
+func (j *announcementJob) rollback(final string) {
+	j.mu.Lock()
+	j.State = announcementRollingBack
+	posts := j.posts
+	j.mu.Unlock()
+
+	for i, id := range posts {
+		err := j.remove(id)
+		if err != nil {
+			log.Errorf("announcement: rollback: post %d: %s", id, err)
+		}
+		j.mu.Lock()
+		j.posts = posts[i+1:]
+		j.mu.Unlock()
+	}
+
+	j.mu.Lock()
+	j.State = final
+	j.mu.Unlock()
+}
+
+// Register a new announcement job for a board, unless one is already running
+// or rolling back
+func startAnnouncement(board string, j *announcementJob) error {
+	announcementsMu.Lock()
+	defer announcementsMu.Unlock()
+
+	if old := announcements[board]; old != nil {
+		switch old.status().State {
+		case announcementRunning, announcementRollingBack:
+			return errAnnouncementRunning
+		}
+	}
+	j.State = announcementRunning
+	announcements[board] = j
+	return nil
+}
+
+// Post a staff announcement reply into every unlocked thread on a board
+func announce(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var msg struct {
+			Body string
+		}
+		err = decodeJSON(w, r, &msg)
+		if err != nil {
+			return
+		}
+		board := extractParam(r, "board")
+		creds, err := canPerform(w, r, board, auth.BoardOwner, false)
+		if err != nil {
+			return
+		}
+		if msg.Body == "" {
+			return common.ErrInvalidInput("no text")
+		}
+		ip, err := auth.GetIP(r)
+		if err != nil {
+			return
+		}
+		pos, err := db.FindPosition(board, creds.UserID)
+		if err != nil {
+			return
+		}
+		threads, err := db.GetThreadIDs(board)
+		if err != nil {
+			return
+		}
+
+		j := &announcementJob{
+			post: func(op uint64) (id uint64, err error) {
+				locked, err := db.CheckThreadLocked(op)
+				if err != nil || locked {
+					return
+				}
+				encrypted, err := db.CheckThreadEncrypted(op)
+				if err != nil || encrypted {
+					return
+				}
+				post, m, err := websockets.CreateAnnouncement(op, board, ip,
+					msg.Body, pos)
+				if err != nil {
+					return
+				}
+				feeds.InsertPostInto(post.StandalonePost, m)
+				id = post.ID
+				return
+			},
+			remove: func(id uint64) error {
+				return db.DeletePost(id, creds.UserID)
+			},
+		}
+		j.By = creds.UserID
+		err = startAnnouncement(board, j)
+		if err != nil {
+			return
+		}
+		go j.run(threads)
+
+		serveJSON(w, r, "", j.status())
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Serve the progress of the latest announcement job on a board
+func serveAnnouncementStatus(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		_, err = canPerform(w, r, board, auth.BoardOwner, false)
+		if err != nil {
+			return
+		}
+
+		announcementsMu.Lock()
+		j := announcements[board]
+		announcementsMu.Unlock()
+		if j == nil {
+			return errNoAnnouncement
+		}
+		serveJSON(w, r, "", j.status())
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Delete all replies of the latest completed announcement job on a board
+func rollbackAnnouncement(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		_, err = canPerform(w, r, board, auth.BoardOwner, false)
+		if err != nil {
+			return
+		}
+
+		announcementsMu.Lock()
+		defer announcementsMu.Unlock()
+		j := announcements[board]
+		if j == nil {
+			return errNoAnnouncement
+		}
+		switch j.status().State {
+		case announcementRunning, announcementRollingBack:
+			return errAnnouncementRunning
+		case announcementDone:
+		default:
+			return errNoAnnouncement
+		}
+		j.mu.Lock()
+		j.State = announcementRollingBack
+		j.mu.Unlock()
+		go j.rollback(announcementRolledBack)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
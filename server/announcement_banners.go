@@ -0,0 +1,171 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/websockets/feeds"
+)
+
+var (
+	errNoBannerText        = common.ErrInvalidInput("no text")
+	errAnnouncementTooLong = common.ErrTooLong("announcement")
+	errInvalidBannerWindow = common.ErrInvalidInput(
+		"announcement ends before it starts")
+	errBannerEnded = common.ErrInvalidInput("announcement already ended")
+	errNoBanner    = common.StatusError{
+		Err:  errors.New("no such announcement banner"),
+		Code: 404,
+	}
+)
+
+// Request to create an announcement banner
+type announcementBannerRequest struct {
+	Body  string `json:"body"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+}
+
+func (req announcementBannerRequest) validate(now int64) error {
+	switch {
+	case req.Body == "":
+		return errNoBannerText
+	case len(req.Body) > common.MaxLenAnnouncement:
+		return errAnnouncementTooLong
+	case req.Start < 0 || req.End < 0:
+		return errInvalidBannerWindow
+	case req.End == 0:
+		return nil
+	case req.End <= req.Start:
+		return errInvalidBannerWindow
+	case req.End <= now:
+		return errBannerEnded
+	}
+	return nil
+}
+
+// Assert the client can manage the announcement banners of a board. Banners
+// of all boards can only be managed by the "admin" account.
+func canManageBanners(w http.ResponseWriter, r *http.Request,
+	board string,
+) error {
+	level := auth.BoardOwner
+	if board == "all" {
+		level = auth.Admin
+	}
+	_, err := canPerform(w, r, board, level, false)
+	return err
+}
+
+// Create an announcement banner shown on a board or all boards, if the board
+// is "all"
+func createAnnouncementBanner(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var req announcementBannerRequest
+		err = decodeJSON(w, r, &req)
+		if err != nil {
+			return
+		}
+		board := extractParam(r, "board")
+		err = canManageBanners(w, r, board)
+		if err != nil {
+			return
+		}
+		err = req.validate(time.Now().Unix())
+		if err != nil {
+			return
+		}
+
+		b := common.AnnouncementBanner{
+			Board: board,
+			Body:  req.Body,
+			Start: req.Start,
+			End:   req.End,
+		}
+		err = db.CreateAnnouncementBanner(&b)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", b)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Serve all announcement banners of a board, that have not yet ended,
+// including scheduled ones
+func serveAnnouncementBanners(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		err = canManageBanners(w, r, board)
+		if err != nil {
+			return
+		}
+		all, err := db.GetAnnouncementBanners()
+		if err != nil {
+			return
+		}
+		banners := make([]common.AnnouncementBanner, 0, len(all))
+		for _, b := range all {
+			if b.Board == board {
+				banners = append(banners, b)
+			}
+		}
+		serveJSON(w, r, "", banners)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Delete an announcement banner of a board
+func deleteAnnouncementBanner(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var id uint64
+		err = decodeJSON(w, r, &id)
+		if err != nil {
+			return
+		}
+		board := extractParam(r, "board")
+		err = canManageBanners(w, r, board)
+		if err != nil {
+			return
+		}
+		b, err := db.GetAnnouncementBanner(id)
+		switch {
+		case err == sql.ErrNoRows:
+			return errNoBanner
+		case err != nil:
+			return
+		case b.Board != board:
+			return errNoBanner
+		}
+		return db.DeleteAnnouncementBanner(id)
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Return the announcement banners shown on a board's pages and a string
+// identifying them for inclusion in the pages' etags
+func pageAnnouncementBanners(board string) (
+	banners []common.AnnouncementBanner, etag string,
+) {
+	banners = feeds.AnnouncementBanners(board)
+	ids := make([]string, len(banners))
+	for i, b := range banners {
+		ids[i] = strconv.FormatUint(b.ID, 10)
+	}
+	return banners, strings.Join(ids, ".")
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAnnouncementBanner(t *testing.T) {
+	t.Parallel()
+
+	const now = 1000
+	cases := [...]struct {
+		name string
+		req  announcementBannerRequest
+		err  error
+	}{
+		{
+			name: "unbound",
+			req:  announcementBannerRequest{Body: "foo"},
+		},
+		{
+			name: "scheduled",
+			req: announcementBannerRequest{
+				Body:  "foo",
+				Start: 2000,
+				End:   3000,
+			},
+		},
+		{
+			name: "no end",
+			req:  announcementBannerRequest{Body: "foo", Start: 500},
+		},
+		{
+			name: "no text",
+			req:  announcementBannerRequest{},
+			err:  errNoBannerText,
+		},
+		{
+			name: "too long",
+			req: announcementBannerRequest{
+				Body: strings.Repeat("a", 501),
+			},
+			err: errAnnouncementTooLong,
+		},
+		{
+			name: "negative time",
+			req:  announcementBannerRequest{Body: "foo", Start: -1},
+			err:  errInvalidBannerWindow,
+		},
+		{
+			name: "ends before start",
+			req: announcementBannerRequest{
+				Body:  "foo",
+				Start: 3000,
+				End:   2000,
+			},
+			err: errInvalidBannerWindow,
+		},
+		{
+			name: "ended",
+			req:  announcementBannerRequest{Body: "foo", End: 500},
+			err:  errBannerEnded,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if err := c.req.validate(now); err != c.err {
+				t.Fatalf("unexpected error: %v != %v", err, c.err)
+			}
+		})
+	}
+}
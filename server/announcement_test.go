@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	. "github.com/bakape/meguca/test"
+	"testing"
+)
+
+func TestAnnouncementJob(t *testing.T) {
+	t.Parallel()
+
+	newJob := func(fail uint64) (j *announcementJob, removed *[]uint64) {
+		removed = new([]uint64)
+		j = &announcementJob{
+			post: func(op uint64) (uint64, error) {
+				switch op {
+				case fail:
+					return 0, errors.New("foo")
+				case 2: // Skipped thread
+					return 0, nil
+				default:
+					return op * 10, nil
+				}
+			},
+			remove: func(id uint64) error {
+				*removed = append(*removed, id)
+				return nil
+			},
+		}
+		j.State = announcementRunning
+		return
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		j, removed := newJob(0)
+		j.run([]uint64{1, 2, 3})
+		AssertDeepEquals(t, j.status(), announcementStatus{
+			State: announcementDone,
+			Total: 3,
+			Done:  3,
+		})
+		AssertDeepEquals(t, j.posts, []uint64{10, 30})
+
+		j.rollback(announcementRolledBack)
+		AssertDeepEquals(t, j.status().State, announcementRolledBack)
+		AssertDeepEquals(t, *removed, []uint64{10, 30})
+		AssertDeepEquals(t, len(j.posts), 0)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		j, removed := newJob(3)
+		j.run([]uint64{1, 2, 3, 4})
+		AssertDeepEquals(t, j.status(), announcementStatus{
+			State: announcementFailed,
+			Total: 4,
+			Done:  2,
+			Error: "foo",
+		})
+		AssertDeepEquals(t, *removed, []uint64{10})
+	})
+}
+
+func TestStartAnnouncement(t *testing.T) {
+	const board = "announce"
+	j := new(announcementJob)
+	if err := startAnnouncement(board, j); err != nil {
+		t.Fatal(err)
+	}
+	if err := startAnnouncement(board, new(announcementJob)); err == nil {
+		t.Fatal("expected error")
+	}
+
+	j.mu.Lock()
+	j.State = announcementDone
+	j.mu.Unlock()
+	if err := startAnnouncement(board, new(announcementJob)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/websockets"
+	"github.com/go-playground/log"
+)
+
+const maxLenTokenName = 100
+
+var (
+	// Route prefixes mapped to the scope an API token needs to be accepted
+	// on them. Matched in order. Tokens are ignored on all other routes.
+	apiTokenRoutes = [...]struct {
+		prefix, scope string
+	}{
+		{"/api/socket", auth.ScopeRead},
+		{"/json/", auth.ScopeRead},
+		{"/api/upload", auth.ScopePost},
+		{"/api/create-thread", auth.ScopePost},
+		{"/api/create-reply", auth.ScopePost},
+		{"/api/report", auth.ScopePost},
+		{"/api/delete-post", auth.ScopeModerate},
+		{"/api/delete-image", auth.ScopeModerate},
+		{"/api/spoiler-image", auth.ScopeModerate},
+		{"/api/purge-post", auth.ScopeModerate},
+		{"/api/post-history/", auth.ScopeModerate},
+		{"/api/ban", auth.ScopeModerate},
+		{"/api/unban/", auth.ScopeModerate},
+		{"/api/sticky", auth.ScopeModerate},
+		{"/api/lock-thread", auth.ScopeModerate},
+		{"/api/move-thread", auth.ScopeModerate},
+		{"/api/merge-thread", auth.ScopeModerate},
+		{"/api/same-IP/", auth.ScopeModerate},
+		{"/api/hidden-posts/", auth.ScopeModerate},
+		{"/api/unhide-post", auth.ScopeModerate},
+		{"/api/redirect/", auth.ScopeModerate},
+		{"/api/mod-log/", auth.ScopeModerate},
+		{"/api/mod-stats/", auth.ScopeModerate},
+		{"/api/export/", auth.ScopeModerate},
+	}
+
+	// Usage of API tokens not yet written to the database
+	tokenUsage = struct {
+		sync.Mutex
+		tokens map[uint64]*apiTokenUsage
+	}{
+		tokens: make(map[uint64]*apiTokenUsage),
+	}
+
+	errInvalidAPIToken = common.StatusError{
+		Err:  errors.New("invalid API token"),
+		Code: 401,
+	}
+	errTokenScope = common.ErrAccessDenied(
+		"API token scope does not permit request")
+	errTokenNameTooLong = common.ErrTooLong("API token name")
+	errNoTokenName      = common.ErrInvalidInput("no API token name")
+	errNoTokenScopes    = common.ErrInvalidInput("no API token scopes")
+	errInvalidScope     = common.ErrInvalidInput("invalid API token scope")
+	errNoTokenRateLimit = common.ErrInvalidInput("no API token rate limit")
+)
+
+type apiTokenUsage struct {
+	requests, limited uint64
+	last              time.Time
+}
+
+// Request to issue a new API token
+type apiTokenRequest struct {
+	Name         string   `json:"name"`
+	Owner        string   `json:"owner"`
+	Scopes       []string `json:"scopes"`
+	RateLimit    uint     `json:"rateLimit"`
+	RateInterval uint     `json:"rateInterval"`
+}
+
+func (req apiTokenRequest) validate() error {
+	switch {
+	case req.Name == "":
+		return errNoTokenName
+	case len(req.Name) > maxLenTokenName:
+		return errTokenNameTooLong
+	case req.Owner == "" || len(req.Owner) > common.MaxLenUserID:
+		return common.ErrInvalidInput("invalid token owner")
+	case len(req.Scopes) == 0:
+		return errNoTokenScopes
+	case req.RateLimit == 0 || req.RateInterval == 0:
+		return errNoTokenRateLimit
+	}
+	for _, s := range req.Scopes {
+		if !auth.IsScope(s) {
+			return errInvalidScope
+		}
+	}
+	return nil
+}
+
+// Find the scope an API token needs for a request path, if tokens are
+// accepted on it
+func apiTokenScope(path string) string {
+	for _, r := range apiTokenRoutes {
+		if strings.HasPrefix(path, r.prefix) {
+			return r.scope
+		}
+	}
+	return ""
+}
+
+// Authenticate requests carrying an API token, assert the token's scope
+// permits the request and apply the token's rate limit. Authenticated
+// requests are exempt from per-IP rate limits.
+func apiTokenHandler(h http.Handler) http.Handler {
+	go func() {
+		for range time.Tick(time.Minute) {
+			flushAPITokenUsage()
+		}
+	}()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := apiTokenScope(r.URL.Path)
+		token := auth.ExtractAPIToken(r)
+		if scope == "" || token == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		t, err := db.GetAPIToken(auth.HashAPIToken(token))
+		switch err {
+		case nil:
+		case sql.ErrNoRows:
+			httpError(w, r, errInvalidAPIToken)
+			return
+		default:
+			httpError(w, r, err)
+			return
+		}
+		if !t.HasScope(scope) {
+			httpError(w, r, errTokenScope)
+			return
+		}
+
+		ok, wait, err := takeToken("token", strconv.FormatUint(t.ID, 10),
+			rateLimit{Requests: t.RateLimit, Interval: t.RateInterval})
+		if err != nil {
+			httpError(w, r, err)
+			return
+		}
+		recordAPITokenUse(t.ID, !ok, time.Now())
+		if !ok {
+			rejectRateLimited(w, r, wait)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(auth.WithAPIToken(r.Context(), t)))
+	})
+}
+
+// Count a request authenticated with an API token towards its usage metrics
+func recordAPITokenUse(id uint64, limited bool, now time.Time) {
+	tokenUsage.Lock()
+	defer tokenUsage.Unlock()
+
+	u := tokenUsage.tokens[id]
+	if u == nil {
+		u = new(apiTokenUsage)
+		tokenUsage.tokens[id] = u
+	}
+	u.requests++
+	if limited {
+		u.limited++
+	}
+	u.last = now
+}
+
+// Write buffered API token usage metrics to the database
+func flushAPITokenUsage() {
+	tokenUsage.Lock()
+	tokens := tokenUsage.tokens
+	tokenUsage.tokens = make(map[uint64]*apiTokenUsage, len(tokens))
+	tokenUsage.Unlock()
+
+	for id, u := range tokens {
+		err := db.RecordAPITokenUsage(id, u.requests, u.limited, u.last)
+		if err != nil {
+			log.Errorf("api token usage: %s", err)
+		}
+	}
+}
+
+// Issue a new API token. The token is only returned once and only its hash is
+// stored. Available only to the "admin" account.
+func createAPIToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		req   apiTokenRequest
+		token string
+		t     auth.APIToken
+	)
+	err := func() (err error) {
+		err = decodeJSON(w, r, &req)
+		if err != nil {
+			return
+		}
+		err = isAdmin(w, r)
+		if err != nil {
+			return
+		}
+		err = req.validate()
+		if err != nil {
+			return
+		}
+
+		token, err = auth.NewAPIToken()
+		if err != nil {
+			return
+		}
+		t = auth.APIToken{
+			Name:         req.Name,
+			Owner:        req.Owner,
+			Scopes:       req.Scopes,
+			RateLimit:    req.RateLimit,
+			RateInterval: req.RateInterval,
+		}
+		err = db.CreateAPIToken(&t, auth.HashAPIToken(token))
+		if err == db.ErrNoTokenOwner {
+			err = common.StatusError{err, 400}
+		}
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", struct {
+		auth.APIToken
+		Token string `json:"token"`
+	}{t, token})
+}
+
+// Serve all API tokens and their usage metrics. Available only to the "admin"
+// account.
+func serveAPITokens(w http.ResponseWriter, r *http.Request) {
+	err := isAdmin(w, r)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	flushAPITokenUsage()
+	tokens, err := db.GetAPITokens()
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", tokens)
+}
+
+// Revoke an API token and disconnect any websocket clients authenticated with
+// it. Available only to the "admin" account.
+func revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		var id uint64
+		err = decodeJSON(w, r, &id)
+		if err != nil {
+			return
+		}
+		err = isAdmin(w, r)
+		if err != nil {
+			return
+		}
+		err = db.RevokeAPIToken(id)
+		if err != nil {
+			return
+		}
+		websockets.DisconnectAPIToken(id)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
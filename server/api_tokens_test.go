@@ -0,0 +1,93 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bakape/meguca/auth"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestAPITokenScope(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		path, scope string
+	}{
+		{"/api/socket", auth.ScopeRead},
+		{"/json/boards/a/", auth.ScopeRead},
+		{"/api/upload-hash", auth.ScopePost},
+		{"/api/create-reply", auth.ScopePost},
+		{"/api/ban", auth.ScopeModerate},
+		{"/api/mod-log/a", auth.ScopeModerate},
+		{"/api/configure-server", ""},
+		{"/a/", ""},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.path, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, apiTokenScope(c.path), c.scope)
+		})
+	}
+}
+
+func TestValidateAPITokenRequest(t *testing.T) {
+	t.Parallel()
+
+	valid := apiTokenRequest{
+		Name:         "bot",
+		Owner:        "foo",
+		Scopes:       []string{auth.ScopeRead},
+		RateLimit:    10,
+		RateInterval: 60,
+	}
+	cases := [...]struct {
+		name string
+		fn   func(*apiTokenRequest)
+		err  error
+	}{
+		{"valid", func(*apiTokenRequest) {}, nil},
+		{
+			"no name",
+			func(r *apiTokenRequest) { r.Name = "" },
+			errNoTokenName,
+		},
+		{
+			"name too long",
+			func(r *apiTokenRequest) {
+				r.Name = GenString(maxLenTokenName + 1)
+			},
+			errTokenNameTooLong,
+		},
+		{
+			"no scopes",
+			func(r *apiTokenRequest) { r.Scopes = nil },
+			errNoTokenScopes,
+		},
+		{
+			"invalid scope",
+			func(r *apiTokenRequest) { r.Scopes = []string{"admin"} },
+			errInvalidScope,
+		},
+		{
+			"no rate limit",
+			func(r *apiTokenRequest) { r.RateInterval = 0 },
+			errNoTokenRateLimit,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := valid
+			req.Scopes = append([]string(nil), valid.Scopes...)
+			c.fn(&req)
+			if err := req.validate(); err != c.err {
+				UnexpectedError(t, err)
+			}
+		})
+	}
+}
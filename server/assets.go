@@ -13,23 +13,10 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/bakape/thumbnailer"
 )
 
-var (
-	// Set of headers for serving images (and other uploaded files)
-	imageHeaders = map[string]string{
-		// max-age set to 350 days. Some caches and browsers ignore max-age, if
-		// it is a year or greater, so keep it a little below.
-		"Cache-Control": "max-age=30240000, public, immutable",
-	}
-
-	// For overriding during tests
-	imageWebRoot = "images"
-)
-
 type fileError struct {
 	name, msg string
 }
@@ -44,25 +31,6 @@ func newFileError(h *multipart.FileHeader, msg string) error {
 	}
 }
 
-// More performant handler for serving image assets. These are immutable
-// (except deletion), so we can also set separate caching policies for them.
-func serveImages(w http.ResponseWriter, r *http.Request) {
-	path := extractParam(r, "path")
-	file, err := os.Open(cleanJoin(imageWebRoot, path))
-	if err != nil {
-		text404(w)
-		return
-	}
-	defer file.Close()
-
-	head := w.Header()
-	for key, val := range imageHeaders {
-		head.Set(key, val)
-	}
-
-	http.ServeContent(w, r, path, time.Time{}, file)
-}
-
 func cleanJoin(a, b string) string {
 	return filepath.Clean(filepath.Join(a, b))
 }
@@ -95,6 +63,17 @@ func serveFile(w http.ResponseWriter, r *http.Request, path string) {
 	modTime := stats.ModTime()
 	etag := strconv.FormatInt(modTime.Unix(), 10)
 
+	// Serve a precompressed version of the file, if any
+	if p, encoding := findPrecompressed(r, path); p != "" {
+		compressed, err := os.Open(p)
+		if err == nil {
+			defer compressed.Close()
+			file = compressed
+			setPrecompressedHeaders(w, path, encoding)
+			etag += "-" + encoding
+		}
+	}
+
 	head := w.Header()
 	head.Set("Cache-Control", "no-cache")
 	head.Set("ETag", etag)
@@ -32,19 +32,30 @@ func boardCacheArgs(r *http.Request, board string, catalog bool) (
 
 // Start cache upkeep proccesses. Requires a ready DB connection.
 func listenToThreadDeletion() error {
-	return db.Listen("thread_deleted", func(msg string) (err error) {
+	return db.ListenResync("thread_deleted", func(msg string) (err error) {
 		board, id, err := db.SplitBoardAndID(msg)
 		if err != nil {
 			return
 		}
 
 		// Clear all cache records associated with a thread
-		for _, i := range [...]int{0, 5, 100} {
-			cache.Delete(cache.ThreadKey(id, i))
-		}
+		evictThreadCaches(id)
 		cache.DeleteByBoard(board)
 		cache.DeleteByBoard("all")
 
+		return nil
+	}, func() error {
+		// Deletions might have been missed
+		cache.Clear()
 		return nil
 	})
 }
+
+// Clear all cached pages of threads
+func evictThreadCaches(ids ...uint64) {
+	for _, id := range ids {
+		for _, i := range [...]int{0, 5, 50, 100} {
+			cache.Delete(cache.ThreadKey(id, i))
+		}
+	}
+}
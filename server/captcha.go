@@ -5,6 +5,7 @@ import (
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
 	"net/http"
 )
@@ -73,5 +74,5 @@ func serveNewCaptcha(w http.ResponseWriter, r *http.Request) {
 // Render a form with nothing but captcha and confirmation buttons
 func renderCaptchaConfirmation(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.WriteCaptchaConfirmation(w)
+	templates.WriteCaptchaConfirmation(w, lang.FromRequest(r, ""))
 }
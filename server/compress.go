@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// Compression level of gzip-compressed responses
+	gzipLevel = gzip.DefaultCompression
+
+	// Responses smaller than this number of bytes are not compressed
+	gzipMinSize = 1 << 10
+
+	// MIME types of responses eligible for compression
+	compressibleTypes = map[string]bool{
+		"text/html":                 true,
+		"text/css":                  true,
+		"text/plain":                true,
+		"text/javascript":           true,
+		"application/javascript":    true,
+		"application/json":          true,
+		"application/manifest+json": true,
+		"image/svg+xml":             true,
+	}
+
+	// Encodings of precompressed static assets in order of preference and
+	// their file extensions
+	precompressedEncodings = [...]struct {
+		encoding, ext string
+	}{
+		{"br", ".br"},
+		{"gzip", ".gz"},
+	}
+
+	gzipWriterPool = sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(nil, gzipLevel)
+			return w
+		},
+	}
+)
+
+func validateGzipLevel(l int) error {
+	if l < gzip.HuffmanOnly || l > gzip.BestCompression {
+		return errors.New("invalid gzip compression level")
+	}
+	return nil
+}
+
+// Returns, if the client accepts the passed content encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, s := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(s, ";")
+		if strings.TrimSpace(params[0]) != encoding {
+			continue
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Add Accept-Encoding to the Vary header, if not already present
+func varyOnEncoding(head http.Header) {
+	for _, v := range head["Vary"] {
+		if v == "Accept-Encoding" {
+			return
+		}
+	}
+	head.Add("Vary", "Accept-Encoding")
+}
+
+// Transparently gzip-compress textual responses, that are larger than
+// gzipMinSize, if the client supports it
+func compressHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Websocket connections are compressed by the websocket library
+		if r.Header.Get("Upgrade") != "" || !acceptsEncoding(r, "gzip") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		varyOnEncoding(w.Header())
+		cw := &compressWriter{
+			ResponseWriter: w,
+			code:           200,
+		}
+		defer cw.close()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// Buffers the start of a response to decide, if it should be compressed
+type compressWriter struct {
+	http.ResponseWriter
+	decided bool
+	code    int
+	buf     []byte
+	gz      *gzip.Writer
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.code = code
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		return len(p), w.decide()
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Decide, if the response is to be compressed, and write out the headers and
+// buffered response start
+func (w *compressWriter) decide() (err error) {
+	w.decided = true
+	head := w.Header()
+	if head.Get("Content-Type") == "" && len(w.buf) != 0 {
+		head.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	typ, _, _ := mime.ParseMediaType(head.Get("Content-Type"))
+
+	compress := len(w.buf) >= gzipMinSize &&
+		w.code == 200 &&
+		head.Get("Content-Encoding") == "" &&
+		compressibleTypes[typ]
+	if compress {
+		head.Del("Content-Length")
+		head.Set("Content-Encoding", "gzip")
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return
+	}
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return
+}
+
+// Flush any buffered and compressed data to the client
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	return h.Hijack()
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Find a precompressed version of a static file, that the client accepts.
+// Returns the path to the file and its content encoding or empty strings, if
+// none found.
+func findPrecompressed(r *http.Request, path string) (string, string) {
+	for _, e := range precompressedEncodings {
+		if !acceptsEncoding(r, e.encoding) {
+			continue
+		}
+		stats, err := os.Stat(path + e.ext)
+		if err == nil && !stats.IsDir() {
+			return path + e.ext, e.encoding
+		}
+	}
+	return "", ""
+}
+
+// Set headers for serving a precompressed version of a static file
+func setPrecompressedHeaders(w http.ResponseWriter, path, encoding string) {
+	head := w.Header()
+	varyOnEncoding(head)
+	head.Set("Content-Encoding", encoding)
+	if typ := mime.TypeByExtension(filepath.Ext(path)); typ != "" {
+		head.Set("Content-Type", typ)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressHandler(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("a", gzipMinSize)
+
+	cases := [...]struct {
+		name, typ, body, acceptEncoding string
+		compressed                      bool
+	}{
+		{"large HTML", "text/html", large, "gzip, deflate", true},
+		{"large JSON", "application/json", large, "gzip", true},
+		{"too small", "text/html", "a", "gzip", false},
+		{"binary", "image/png", large, "gzip", false},
+		{"not accepted", "text/html", large, "deflate", false},
+		{"rejected", "text/html", large, "gzip;q=0", false},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := compressHandler(http.HandlerFunc(func(
+				w http.ResponseWriter,
+				r *http.Request,
+			) {
+				w.Header().Set("Content-Type", c.typ)
+				w.Write([]byte(c.body))
+			}))
+			rec, req := newPair("/")
+			req.Header.Set("Accept-Encoding", c.acceptEncoding)
+			h.ServeHTTP(rec, req)
+			assertCode(t, rec, 200)
+
+			if !c.compressed {
+				assertHeaders(t, rec, map[string]string{
+					"Content-Encoding": "",
+				})
+				assertBody(t, rec, c.body)
+				return
+			}
+
+			assertHeaders(t, rec, map[string]string{
+				"Content-Encoding": "gzip",
+				"Vary":             "Accept-Encoding",
+			})
+			r, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != c.body {
+				t.Fatal("decompressed body does not match")
+			}
+		})
+	}
+}
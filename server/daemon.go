@@ -6,6 +6,7 @@ package server
 
 import (
 	"os"
+	"os/signal"
 	"syscall"
 	"time"
 
@@ -13,10 +14,12 @@ import (
 	"github.com/sevlyar/go-daemon"
 
 	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
 	"github.com/bakape/meguca/log"
 )
 
 func init() {
+	listenForReloads = reloadOnSIGHUP
 	handleDaemon = func(arg string) {
 		if config.ImagerMode == config.ImagerOnly {
 			daemonContext.PidFileName = ".imager.pid"
@@ -46,6 +49,18 @@ func init() {
 	}
 }
 
+// Reload the global server configuration from the database on each SIGHUP
+func reloadOnSIGHUP() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		log.Info("reloading configuration")
+		if err := db.ReloadConfigs(); err != nil {
+			log.Errorf("reloading configuration: %s", err)
+		}
+	}
+}
+
 // Configuration variables for handling daemons
 var daemonContext = &daemon.Context{
 	PidFileName: ".pid",
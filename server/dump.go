@@ -0,0 +1,319 @@
+package server
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/imager/assets"
+)
+
+// Name of the archive entry describing the dump
+const dumpMetaName = "meta.json"
+
+// Include media files in database dumps
+var dumpMedia bool
+
+// Describes the contents of a dump archive
+type dumpMeta struct {
+	// Database schema version
+	Version int   `json:"version"`
+	Created int64 `json:"created"`
+	Media   bool  `json:"media"`
+}
+
+// Open the output or input file of a dump. "-" or an empty path denote
+// stdout or stdin.
+func openDumpFile(p string, write bool) (*os.File, error) {
+	switch {
+	case p == "" || p == "-":
+		if write {
+			return os.Stdout, nil
+		}
+		return os.Stdin, nil
+	case write:
+		return os.Create(p)
+	default:
+		return os.Open(p)
+	}
+}
+
+// Write a gzipped tar archive of all boards, threads, accounts and image
+// metadata and optionally media files to the file at path p from the command
+// line
+func dumpCLI(p string) (err error) {
+	err = db.Connect()
+	if err != nil {
+		return
+	}
+	f, err := openDumpFile(p, true)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	err = writeDump(w, dumpMedia)
+	if err != nil {
+		return
+	}
+	return w.Flush()
+}
+
+// Write a dump archive to w. The archive starts with meta.json followed by
+// each table as newline-delimited JSON in tables/TABLE.ndjson and optionally
+// media files in images/.
+func writeDump(w io.Writer, media bool) (err error) {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+
+	// Table rows are spooled to a temporary file, as tar headers require the
+	// file size
+	tmp, err := ioutil.TempFile("", "meguca_dump")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var (
+		table string
+		size  int64
+		buf   = bufio.NewWriter(tmp)
+	)
+	flush := func() (err error) {
+		if table == "" {
+			return
+		}
+		err = buf.Flush()
+		if err != nil {
+			return
+		}
+		_, err = tmp.Seek(0, io.SeekStart)
+		if err != nil {
+			return
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    path.Join("tables", table+".ndjson"),
+			Mode:    0600,
+			Size:    size,
+			ModTime: now,
+		})
+		if err != nil {
+			return
+		}
+		_, err = io.CopyN(tw, tmp, size)
+		if err != nil {
+			return
+		}
+		size = 0
+		err = tmp.Truncate(0)
+		if err != nil {
+			return
+		}
+		_, err = tmp.Seek(0, io.SeekStart)
+		return
+	}
+
+	err = db.Dump(
+		func(v int) error {
+			data, err := json.Marshal(dumpMeta{
+				Version: v,
+				Created: now.Unix(),
+				Media:   media,
+			})
+			if err != nil {
+				return err
+			}
+			err = tw.WriteHeader(&tar.Header{
+				Name:    dumpMetaName,
+				Mode:    0600,
+				Size:    int64(len(data)),
+				ModTime: now,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = tw.Write(data)
+			return err
+		},
+		func(t string, row []byte) error {
+			if t != table {
+				if err := flush(); err != nil {
+					return err
+				}
+				table = t
+			}
+			n, err := buf.Write(row)
+			size += int64(n)
+			if err != nil {
+				return err
+			}
+			size++
+			return buf.WriteByte('\n')
+		},
+	)
+	if err != nil {
+		return
+	}
+	err = flush()
+	if err != nil {
+		return
+	}
+
+	if media {
+		err = writeDumpMedia(tw)
+		if err != nil {
+			return
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return
+	}
+	return gw.Close()
+}
+
+// Write all uploaded files and thumbnails from storage to a dump archive
+func writeDumpMedia(tw *tar.Writer) (err error) {
+	s := assets.GetStorage()
+	for _, prefix := range [...]string{"src/", "thumb/"} {
+		err = s.Walk(prefix, func(f assets.FileInfo) (err error) {
+			name := "images/" + f.Key
+			if !isDumpMediaPath(name) {
+				return
+			}
+
+			r, info, err := s.Open(f.Key)
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			err = tw.WriteHeader(&tar.Header{
+				Name:    name,
+				Mode:    0600,
+				Size:    info.Size,
+				ModTime: f.ModTime,
+			})
+			if err != nil {
+				return
+			}
+			_, err = io.Copy(tw, r)
+			return
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Returns, if an archive entry name is a valid media file path. Guards
+// against writing outside the image directories on restore.
+func isDumpMediaPath(name string) bool {
+	dir, file := path.Split(name)
+	if dir != "images/src/" && dir != "images/thumb/" {
+		return false
+	}
+	return file != "" && file[0] != '.' && !strings.ContainsAny(file, `/\`)
+}
+
+// Restore a dump archive from the file at path p into an uninitialized
+// database from the command line
+func restoreCLI(p string) (err error) {
+	err = db.Connect()
+	if err != nil {
+		return
+	}
+	err = assets.CreateDirs()
+	if err != nil {
+		return
+	}
+	f, err := openDumpFile(p, false)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return readDump(bufio.NewReader(f))
+}
+
+// Read a dump archive written by writeDump and restore it into the database
+func readDump(r io.Reader) (err error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	h, err := tr.Next()
+	if err != nil {
+		return
+	}
+	if h.Name != dumpMetaName {
+		return common.ErrInvalidInput("dump: expected " + dumpMetaName)
+	}
+	var meta dumpMeta
+	err = json.NewDecoder(tr).Decode(&meta)
+	if err != nil {
+		return
+	}
+
+	return db.Restore(meta.Version, func(
+		insert func(table string, row []byte) error,
+	) (err error) {
+		for {
+			h, err = tr.Next()
+			switch err {
+			case nil:
+			case io.EOF:
+				return nil
+			default:
+				return
+			}
+
+			switch {
+			case isDumpMediaPath(h.Name):
+				err = assets.WriteFile(strings.TrimPrefix(h.Name, "images/"),
+					tr, h.Size)
+			case path.Dir(h.Name) == "tables" &&
+				path.Ext(h.Name) == ".ndjson":
+				table := strings.TrimSuffix(path.Base(h.Name), ".ndjson")
+				err = restoreTable(tr, table, insert)
+			default:
+				err = common.ErrInvalidInput(
+					"dump: unexpected file: " + h.Name)
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+// Insert all newline-delimited JSON rows of a table from r
+func restoreTable(r io.Reader, table string,
+	insert func(table string, row []byte) error,
+) error {
+	s := bufio.NewScanner(r)
+	// Banners and loading animations can be large
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		if err := insert(table, s.Bytes()); err != nil {
+			return fmt.Errorf("dump: %s: %s", table, err)
+		}
+	}
+	return s.Err()
+}
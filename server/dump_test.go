@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestIsDumpMediaPath(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name  string
+		valid bool
+	}{
+		{"images/src/012a2f912c9ee93ceb0ccb8684a29ec571990a94.jpg", true},
+		{"images/thumb/012a2f912c9ee93ceb0ccb8684a29ec571990a94.webp", true},
+		{"images/src/", false},
+		{"images/src/.write_test", false},
+		{"images/src/../../etc/passwd", false},
+		{"/images/src/foo.jpg", false},
+		{"tables/posts.ndjson", false},
+	}
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, isDumpMediaPath(c.name), c.valid)
+		})
+	}
+}
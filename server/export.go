@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Maximum number of posts per board export page
+const exportPageSize = 1000
+
+// Serve a page of a board's public post data as newline-delimited JSON to
+// logged in users, that can view the board. Posts are ordered by ascending
+// ID. The "cursor" query parameter specifies the ID of the last post of the
+// previous page. The cursor for the next page is set in the X-Next-Cursor
+// header, if there are more posts.
+func exportBoard(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		board := extractParam(r, "board")
+		if !auth.IsNonMetaBoard(board) {
+			return errInvalidBoardName
+		}
+		_, err = isLoggedIn(w, r)
+		if err != nil {
+			return
+		}
+		if !canViewBoard(r, board) {
+			return common.ErrInvalidBoard(board)
+		}
+
+		var cursor uint64
+		if s := r.URL.Query().Get("cursor"); s != "" {
+			cursor, err = strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return common.ErrInvalidInput("invalid cursor")
+			}
+		}
+
+		posts, err := db.ExportBoard(board, cursor, exportPageSize)
+		if err != nil {
+			return
+		}
+
+		head := w.Header()
+		head.Set("Content-Type", "application/x-ndjson")
+		head.Set("Cache-Control", "no-store")
+		if len(posts) == exportPageSize {
+			head.Set("X-Next-Cursor",
+				strconv.FormatUint(posts[len(posts)-1].ID, 10))
+		}
+		return writeNDJSON(w, posts)
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Write posts as newline-delimited JSON
+func writeNDJSON(w io.Writer, posts []common.StandalonePost) (err error) {
+	enc := json.NewEncoder(w)
+	for _, p := range posts {
+		err = enc.Encode(p)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Export all public post data of a board as newline-delimited JSON from the
+// command line
+func exportBoardCLI(board string, w io.Writer) (err error) {
+	// Board configurations are not loaded, so only basic validation is
+	// possible
+	if board == "" || board == "all" {
+		return errors.New("invalid board: " + board)
+	}
+	err = db.Connect()
+	if err != nil {
+		return
+	}
+
+	var cursor uint64
+	for {
+		var posts []common.StandalonePost
+		posts, err = db.ExportBoard(board, cursor, exportPageSize)
+		if err != nil {
+			return
+		}
+		err = writeNDJSON(w, posts)
+		if err != nil || len(posts) < exportPageSize {
+			return
+		}
+		cursor = posts[len(posts)-1].ID
+	}
+}
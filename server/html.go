@@ -6,8 +6,10 @@ import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
 	"net/http"
+	"sort"
 )
 
 func setHTMLHeaders(w http.ResponseWriter) {
@@ -16,6 +18,9 @@ func setHTMLHeaders(w http.ResponseWriter) {
 		head.Set(key, val)
 	}
 	head.Set("Content-Type", "text/html")
+
+	// Pages are rendered in the language negotiated from this header
+	head.Set("Vary", "Accept-Language")
 }
 
 // Client is requesting the new wasm page
@@ -25,7 +30,7 @@ func isWasm(r *http.Request) bool {
 
 // Serves board HTML to regular or noscript clients
 func boardHTML(w http.ResponseWriter, r *http.Request, b string, catalog bool) {
-	if !auth.IsBoard(b) {
+	if !canViewBoard(r, b) {
 		text404(w)
 		return
 	}
@@ -34,13 +39,15 @@ func boardHTML(w http.ResponseWriter, r *http.Request, b string, catalog bool) {
 	}
 
 	theme := resolveTheme(r, b)
+	ln := lang.FromRequest(r, b)
 	if isWasm(r) {
 		setHTMLHeaders(w)
-		templates.WriteIndexWasm(w, theme)
+		templates.WriteIndexWasm(w, theme, ln)
 		return
 	}
 
-	html, data, ctr, err := cache.GetHTML(boardCacheArgs(r, b, catalog))
+	k, f := boardCacheArgs(r, b, catalog)
+	html, data, ctr, err := cache.GetHTML(k, f, ln)
 	switch err {
 	case nil:
 	case cache.ErrPageOverflow:
@@ -56,8 +63,9 @@ func boardHTML(w http.ResponseWriter, r *http.Request, b string, catalog bool) {
 		return
 	}
 
+	banners, bannersEtag := pageAnnouncementBanners(b)
 	_, hash := config.GetClient()
-	etag := formatEtag(ctr, hash, pos)
+	etag := formatEtag(ctr, hash+"-"+ln.ID+"-"+bannersEtag, pos)
 	if checkClientEtag(w, r, etag) {
 		return
 	}
@@ -77,6 +85,8 @@ func boardHTML(w http.ResponseWriter, r *http.Request, b string, catalog bool) {
 		pos,
 		r.URL.Query().Get("minimal") == "true", catalog,
 		html,
+		banners,
+		ln,
 	)
 }
 
@@ -105,15 +115,16 @@ func threadHTML(w http.ResponseWriter, r *http.Request) {
 
 	b := extractParam(r, "board")
 	theme := resolveTheme(r, b)
+	ln := lang.FromRequest(r, b)
 	if isWasm(r) {
 		setHTMLHeaders(w)
-		templates.WriteIndexWasm(w, theme)
+		templates.WriteIndexWasm(w, theme, ln)
 		return
 	}
 
 	lastN := detectLastN(r)
 	k := cache.ThreadKey(id, lastN)
-	html, data, ctr, err := cache.GetHTML(k, cache.ThreadFE)
+	html, data, ctr, err := cache.GetHTML(k, cache.ThreadFE, ln)
 	if err != nil {
 		httpError(w, r, err)
 		return
@@ -124,8 +135,9 @@ func threadHTML(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	banners, bannersEtag := pageAnnouncementBanners(b)
 	_, hash := config.GetClient()
-	etag := formatEtag(ctr, hash, pos)
+	etag := formatEtag(ctr, hash+"-"+ln.ID+"-"+bannersEtag, pos)
 	if checkClientEtag(w, r, etag) {
 		return
 	}
@@ -139,6 +151,8 @@ func threadHTML(w http.ResponseWriter, r *http.Request) {
 		lastN != 0, thread.Locked,
 		pos,
 		html,
+		banners,
+		ln,
 	)
 }
 
@@ -149,7 +163,7 @@ func extractPosition(w http.ResponseWriter, r *http.Request) (
 ) {
 	ok = true
 	pos = auth.NotLoggedIn
-	creds := extractLoginCreds(r)
+	creds := auth.ExtractLoginCreds(r)
 	if creds.UserID == "" {
 		return
 	}
@@ -180,7 +194,7 @@ func extractPosition(w http.ResponseWriter, r *http.Request) (
 // Render a board selection and navigation panel and write HTML to client
 func boardNavigation(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.WriteBoardNavigation(w)
+	templates.WriteBoardNavigation(w, lang.FromRequest(r, ""))
 }
 
 // Serve a form for selecting one of several boards owned by the user
@@ -192,19 +206,21 @@ func ownedBoardSelection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Retrieve titles of boards
+	// Retrieve titles of boards. Unlisted boards are not included in
+	// config.GetBoardTitles(), so look up each board individually.
 	ownedTitles := make(config.BoardTitles, 0, len(owned))
-	for _, b := range config.GetBoardTitles() {
-		for _, o := range owned {
-			if b.ID == o {
-				ownedTitles = append(ownedTitles, b)
-				break
-			}
+	for _, o := range owned {
+		if conf := config.GetBoardConfigs(o); conf.ID != "" {
+			ownedTitles = append(ownedTitles, config.BoardTitle{
+				ID:    o,
+				Title: conf.Title,
+			})
 		}
 	}
+	sort.Sort(ownedTitles)
 
 	setHTMLHeaders(w)
-	templates.WriteOwnedBoard(w, ownedTitles)
+	templates.WriteOwnedBoard(w, ownedTitles, lang.FromRequest(r, ""))
 }
 
 // Renders a form for configuring a board owned by the user
@@ -216,7 +232,7 @@ func boardConfigurationForm(w http.ResponseWriter, r *http.Request) {
 		}
 
 		setHTMLHeaders(w)
-		templates.ConfigureBoard(w, conf)
+		templates.ConfigureBoard(w, conf, lang.FromRequest(r, conf.ID))
 		return
 	}()
 	if err != nil {
@@ -233,13 +249,14 @@ func staffAssignmentForm(w http.ResponseWriter, r *http.Request) {
 	}
 	setHTMLHeaders(w)
 	templates.StaffAssignment(w,
-		[...][]string{s["owners"], s["moderators"], s["janitors"]})
+		[...][]string{s["owners"], s["moderators"], s["janitors"]},
+		lang.FromRequest(r, extractParam(r, "board")))
 }
 
 // Renders a form for creating new boards
 func boardCreationForm(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.WriteCreateBoard(w)
+	templates.WriteCreateBoard(w, lang.FromRequest(r, ""))
 }
 
 // Render the form for configuring the server
@@ -251,7 +268,7 @@ func serverConfigurationForm(w http.ResponseWriter, r *http.Request) {
 		}
 
 		setHTMLHeaders(w)
-		templates.ConfigureServer(w, (*config.Get()))
+		templates.ConfigureServer(w, (*config.Get()), lang.FromRequest(r, ""))
 		return
 
 	}()
@@ -263,15 +280,15 @@ func serverConfigurationForm(w http.ResponseWriter, r *http.Request) {
 // Render a form to change an account password
 func changePasswordForm(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.ChangePassword(w)
+	templates.ChangePassword(w, lang.FromRequest(r, ""))
 }
 
 func bannerSettingForm(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.WriteBannerForm(w)
+	templates.WriteBannerForm(w, lang.FromRequest(r, ""))
 }
 
 func loadingAnimationForm(w http.ResponseWriter, r *http.Request) {
 	setHTMLHeaders(w)
-	templates.WriteLoadingAnimationForm(w)
+	templates.WriteLoadingAnimationForm(w, lang.FromRequest(r, ""))
 }
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/bakape/meguca/imager/assets"
+)
+
+// Set of headers for serving images (and other uploaded files)
+var imageHeaders = map[string]string{
+	// max-age set to 350 days. Some caches and browsers ignore max-age, if
+	// it is a year or greater, so keep it a little below.
+	"Cache-Control": "max-age=30240000, public, immutable",
+}
+
+// More performant handler for serving image assets. These are immutable
+// (except deletion), so we can also set separate caching policies for them.
+// Files in remote storage are either served through or redirected to.
+func serveImages(w http.ResponseWriter, r *http.Request) {
+	key := path.Clean("/" + extractParam(r, "path"))[1:]
+
+	u, err := assets.URL(key)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	if u != "" {
+		// Redirects must not outlive the validity of presigned URLs
+		if s, ok := assets.GetStorage().(*assets.S3Storage); ok {
+			w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(
+				int(s.PresignExpiry()/time.Second/2)))
+		}
+		http.Redirect(w, r, u, 302)
+		return
+	}
+
+	file, info, err := assets.Open(key)
+	if err != nil {
+		text404(w)
+		return
+	}
+	defer file.Close()
+
+	head := w.Header()
+	for key, val := range imageHeaders {
+		head.Set(key, val)
+	}
+
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, key, time.Time{}, rs)
+		return
+	}
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		head.Set("Content-Type", t)
+	}
+	if info.Size >= 0 {
+		head.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	io.Copy(w, file)
+}
@@ -4,6 +4,7 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -14,14 +15,19 @@ import (
 	"github.com/bakape/meguca/cache"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/dnsbl"
 	"github.com/bakape/meguca/geoip"
 	"github.com/bakape/meguca/imager/assets"
 	"github.com/bakape/meguca/lang"
+	mlog "github.com/bakape/meguca/log"
 	"github.com/bakape/meguca/templates"
+	"github.com/bakape/meguca/topics"
 	"github.com/bakape/meguca/util"
 	"github.com/bakape/meguca/websockets/feeds"
+	"net"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -40,6 +46,13 @@ var (
 	// is never compiled on Windows and this function is never called.
 	handleDaemon func(string)
 
+	// Ship logs to the local journald
+	journald bool
+
+	// Is assigned in ./daemon.go to reload the server configuration on SIGHUP.
+	// Nil on Windows.
+	listenForReloads func()
+
 	// CLI mode arguments and descriptions
 	arguments = map[string]string{
 		"start":   "start the meguca server",
@@ -47,16 +60,33 @@ var (
 		"restart": "combination of stop + start",
 		"debug":   "start server in debug mode without daemonizing (default)",
 		"help":    "print this help text",
+		"export": "write all public post data of board BOARD to stdout " +
+			"as newline-delimited JSON",
+		"dump": "write all boards, threads, accounts and image metadata to " +
+			"the gzipped tar archive FILE or stdout, if omitted",
+		"restore": "restore the archive FILE or stdin, if omitted, written " +
+			"by dump into an uninitialized database",
+		"migrate-media": "copy all uploaded files from storage backend " +
+			"BACKEND to the configured storage backend",
+		"migrate": "migrate the database schema to VERSION or the latest " +
+			"version, if omitted",
 	}
 )
 
 // Configs, that can be optionally passed through a JSON configuration file.
 // Flags override this. All fields are optional.
 type serverConfigs struct {
-	SSL, ReverseProxied, Gzip                            *bool
-	ImagerMode                                           *uint
+	SSL, ReverseProxied, Gzip, RateLimit, LogCompress    *bool
+	LogJSON, Journald, Cluster                           *bool
+	ImagerMode, LogMaxSize, LogMaxAge, LogMaxBackups     *uint
+	GzipLevel, GzipMinSize                               *int
 	CacheSize                                            *float64
 	Address, Database, CertPath, KeyPath, ReverseProxyIP *string
+	Journal, RateLimitAllowlist, LogFile, AccessLog      *string
+	MediaGC, Storage                                     *string
+	S3                                                   *assets.S3Config
+	Syslog, SyslogFacility, SyslogTag, LogLevels         *string
+	RateLimits                                           map[string]rateLimit
 }
 
 func validateImagerMode(m *uint) {
@@ -65,6 +95,29 @@ func validateImagerMode(m *uint) {
 	}
 }
 
+// Assert the listening address has a valid port
+func validateAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listening address: %s", err)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return fmt.Errorf("invalid listening port: %s", port)
+	}
+	return nil
+}
+
+func validateMediaGCMode(m string) error {
+	switch m {
+	case db.MediaGCDelete, db.MediaGCQuarantine, db.MediaGCReport,
+		db.MediaGCOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid media garbage collection mode: %s", m)
+	}
+}
+
 // Iterate struct fields and assign defaults to missing fields
 func setConfigDefaults(c *serverConfigs) {
 	if c.SSL == nil {
@@ -76,6 +129,17 @@ func setConfigDefaults(c *serverConfigs) {
 	if c.Gzip == nil {
 		c.Gzip = new(bool)
 	}
+	if c.RateLimit == nil {
+		c.RateLimit = new(bool)
+	}
+	if c.GzipLevel == nil {
+		c.GzipLevel = new(int)
+		*c.GzipLevel = gzip.DefaultCompression
+	}
+	if c.GzipMinSize == nil {
+		c.GzipMinSize = new(int)
+		*c.GzipMinSize = 1 << 10
+	}
 	if c.ImagerMode == nil {
 		c.ImagerMode = new(uint)
 	} else {
@@ -102,6 +166,64 @@ func setConfigDefaults(c *serverConfigs) {
 	if c.ReverseProxyIP == nil {
 		c.ReverseProxyIP = new(string)
 	}
+	if c.Journal == nil {
+		c.Journal = new(string)
+	}
+	if c.RateLimitAllowlist == nil {
+		c.RateLimitAllowlist = new(string)
+	}
+	if c.LogFile == nil {
+		c.LogFile = new(string)
+	}
+	if c.LogMaxSize == nil {
+		c.LogMaxSize = new(uint)
+		*c.LogMaxSize = 100
+	}
+	if c.LogMaxAge == nil {
+		c.LogMaxAge = new(uint)
+		*c.LogMaxAge = 24 * 7
+	}
+	if c.LogMaxBackups == nil {
+		c.LogMaxBackups = new(uint)
+		*c.LogMaxBackups = 10
+	}
+	if c.LogCompress == nil {
+		c.LogCompress = new(bool)
+	}
+	if c.LogJSON == nil {
+		c.LogJSON = new(bool)
+	}
+	if c.Journald == nil {
+		c.Journald = new(bool)
+	}
+	if c.Cluster == nil {
+		c.Cluster = new(bool)
+	}
+	if c.Syslog == nil {
+		c.Syslog = new(string)
+	}
+	if c.SyslogFacility == nil {
+		c.SyslogFacility = new(string)
+		*c.SyslogFacility = "daemon"
+	}
+	if c.LogLevels == nil {
+		c.LogLevels = new(string)
+	}
+	if c.AccessLog == nil {
+		c.AccessLog = new(string)
+	}
+	if c.Storage == nil {
+		c.Storage = new(string)
+		*c.Storage = assets.StorageLocal
+	}
+	if c.MediaGC == nil {
+		c.MediaGC = new(string)
+		*c.MediaGC = db.MediaGCDelete
+	}
+	if c.SyslogTag == nil {
+		c.SyslogTag = new(string)
+		*c.SyslogTag = "meguca"
+	}
 }
 
 // Start parses command line arguments and initializes the server.
@@ -158,7 +280,141 @@ func Start() error {
 		*conf.ReverseProxyIP,
 		"IP of the reverse proxy. Only needed, when reverse proxy is not on localhost.",
 	)
-	flag.BoolVar(&enableGzip, "g", *conf.Gzip, "compress all traffic with gzip")
+	flag.BoolVar(&enableGzip, "g", *conf.Gzip, "compress textual responses with gzip")
+	flag.IntVar(
+		&gzipLevel,
+		"gl",
+		*conf.GzipLevel,
+		"gzip compression level from 1 (fastest) to 9 (smallest). -1 for default.",
+	)
+	flag.IntVar(
+		&gzipMinSize,
+		"gm",
+		*conf.GzipMinSize,
+		"minimum response size in bytes to compress with gzip",
+	)
+	flag.BoolVar(
+		&enableRateLimits,
+		"rl",
+		*conf.RateLimit,
+		"apply per-IP rate limits to the HTTP API",
+	)
+	var rateLimitAllowlist string
+	flag.StringVar(
+		&rateLimitAllowlist,
+		"ra",
+		*conf.RateLimitAllowlist,
+		"comma-separated list of IPs and CIDR networks exempt from rate limits",
+	)
+	flag.BoolVar(
+		&config.Clustered,
+		"cl",
+		*conf.Cluster,
+		"share live updates and rate limits with other instances using the same database",
+	)
+	flag.StringVar(
+		&feeds.JournalPath,
+		"j",
+		*conf.Journal,
+		"path to open post journal for crash recovery. Disabled, if empty.",
+	)
+	flag.StringVar(
+		&mlog.FileConfig.Path,
+		"lf",
+		*conf.LogFile,
+		"path to log file with rotation. Disabled, if empty.",
+	)
+	flag.UintVar(
+		&mlog.FileConfig.MaxSize,
+		"ls",
+		*conf.LogMaxSize,
+		"size in MB to rotate the log file at. 0 to disable.",
+	)
+	flag.UintVar(
+		&mlog.FileConfig.MaxAge,
+		"la",
+		*conf.LogMaxAge,
+		"age in hours to rotate the log file at. 0 to disable.",
+	)
+	flag.UintVar(
+		&mlog.FileConfig.MaxBackups,
+		"lb",
+		*conf.LogMaxBackups,
+		"number of rotated log files to retain. 0 to retain all.",
+	)
+	flag.BoolVar(
+		&mlog.FileConfig.Compress,
+		"lc",
+		*conf.LogCompress,
+		"compress rotated log files with gzip",
+	)
+	flag.BoolVar(
+		&mlog.JSONOutput,
+		"lj",
+		*conf.LogJSON,
+		"write logs as newline-delimited JSON",
+	)
+	flag.StringVar(
+		&mlog.SyslogConfig.Address,
+		"sl",
+		*conf.Syslog,
+		`ship logs to syslog. Either "local" or of the form "udp://host:port", "tcp://host:port" or "tls://host:port". Disabled, if empty.`,
+	)
+	flag.StringVar(
+		&mlog.SyslogConfig.Facility,
+		"sf",
+		*conf.SyslogFacility,
+		"syslog facility to log as",
+	)
+	flag.StringVar(
+		&mlog.SyslogConfig.Tag,
+		"st",
+		*conf.SyslogTag,
+		"tag identifying the server in syslog and journald",
+	)
+	flag.BoolVar(
+		&journald,
+		"jd",
+		*conf.Journald,
+		"ship logs to the local journald",
+	)
+	flag.StringVar(
+		&accessLogFormat,
+		"al",
+		*conf.AccessLog,
+		`write HTTP access logs in "combined" log format or as "json". Disabled, if empty.`,
+	)
+	flag.StringVar(
+		&db.MediaGCMode,
+		"gc",
+		*conf.MediaGC,
+		`handling of uploaded files not referenced by any image: "delete", "quarantine" to move them to images/quarantine, "report" to only log them or "off"`,
+	)
+	flag.StringVar(
+		&storageBackend,
+		"ms",
+		*conf.Storage,
+		`storage backend of uploaded files: "local" or "s3" configured in config.json`,
+	)
+	var logLevels string
+	flag.StringVar(
+		&logLevels,
+		"ll",
+		*conf.LogLevels,
+		`comma-separated list of minimum log levels per module like "websockets=info,db=warn". "*" sets the level of all other modules.`,
+	)
+	flag.BoolVar(
+		&dumpMedia,
+		"dm",
+		false,
+		"include uploaded media files in dumps",
+	)
+	flag.BoolVar(
+		&migrateDryRun,
+		"md",
+		false,
+		"only test pending database migrations in a rolled back transaction and exit",
+	)
 	flag.UintVar(conf.ImagerMode, "i", *conf.ImagerMode,
 		`image processing and serving mode for this instance
 0	handle image processing and serving and all other functionality (default)
@@ -171,12 +427,57 @@ func Start() error {
 	if cache.Size < 0 {
 		return errors.New("cache size must be a positive number")
 	}
+	if err := validateAddress(address); err != nil {
+		return err
+	}
+	if err := validateGzipLevel(gzipLevel); err != nil {
+		return err
+	}
+	if err := validateAccessLogFormat(accessLogFormat); err != nil {
+		return err
+	}
+	if err := validateMediaGCMode(db.MediaGCMode); err != nil {
+		return err
+	}
+	if err := configureStorage(storageBackend, conf.S3); err != nil {
+		return err
+	}
+	if err := validateRateLimits(conf.RateLimits); err != nil {
+		return err
+	}
+	if err := parseRateLimitAllowlist(rateLimitAllowlist); err != nil {
+		return err
+	}
+	levels, err := mlog.ParseLevels(logLevels)
+	if err != nil {
+		return err
+	}
+	if err := mlog.SetLevels(levels); err != nil {
+		return err
+	}
 	validateImagerMode(conf.ImagerMode)
 	config.ImagerMode = config.ImagerModeType(*conf.ImagerMode)
 	arg := flag.Arg(0)
 	if arg == "" {
 		arg = "debug"
 	}
+	switch arg {
+	case "export":
+		return exportBoardCLI(flag.Arg(1), os.Stdout)
+	case "dump":
+		return dumpCLI(flag.Arg(1))
+	case "restore":
+		return restoreCLI(flag.Arg(1))
+	case "migrate-media":
+		return migrateMediaCLI(flag.Arg(1), conf.S3, os.Stdout)
+	}
+	if arg == "migrate" || migrateDryRun {
+		var to string
+		if arg == "migrate" {
+			to = flag.Arg(1)
+		}
+		return migrateCLI(to, migrateDryRun, os.Stdout)
+	}
 
 	// Can't daemonize in windows, so only args they have is "start" and "help"
 	if isWindows {
@@ -218,7 +519,8 @@ func printUsage() {
 	} else {
 		arguments["debug"] = `alias of "start"`
 	}
-	toPrint = append(toPrint, []string{"debug", "help"}...)
+	toPrint = append(toPrint, []string{"debug", "export", "dump", "restore", "migrate",
+		"migrate-media", "help"}...)
 
 	help := new(bytes.Buffer)
 	for _, arg := range toPrint {
@@ -242,6 +544,15 @@ func startServer() {
 		}
 	}
 
+	if mlog.FileConfig.Path != "" {
+		mlog.Init(mlog.File)
+	}
+	if mlog.SyslogConfig.Address != "" {
+		mlog.Init(mlog.Syslog)
+	}
+	if journald {
+		mlog.Init(mlog.Journald)
+	}
 	load(db.LoadDB, assets.CreateDirs)
 
 	// Depend on configs
@@ -256,7 +567,8 @@ func startServer() {
 			load(lang.Load)
 			load(templates.Compile) // Depends on language packs
 		}()
-		tasks = append(tasks, geoip.Load, listenToThreadDeletion)
+		tasks = append(tasks, geoip.Load, listenToThreadDeletion, topics.Load,
+			dnsbl.Init)
 		go ass.WatchVideoDir()
 	}
 	if config.ImagerMode != config.NoImager {
@@ -266,6 +578,9 @@ func startServer() {
 	load(tasks...)
 	wg.Wait()
 
+	if listenForReloads != nil {
+		go listenForReloads()
+	}
 	if err := startWebServer(); err != nil {
 		log.Fatal(err)
 	}
@@ -1,20 +1,45 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/cache"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/topics"
 	"github.com/bakape/meguca/util"
 	"github.com/bakape/meguca/websockets/feeds"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
-var errNoImage = errors.New("post has no image")
+// Maximum number of posts served by an image hash search
+const imageSearchLimit = 100
+
+var (
+	errNoImage          = errors.New("post has no image")
+	errInvalidImageHash = common.ErrInvalidInput("invalid image hash")
+	errNoImageHash      = common.ErrInvalidInput("no image hash")
+
+	// Image hash query parameters accepted by image hash searches with their
+	// encoded length and alphabet
+	imageHashFormats = [...]struct {
+		param    string
+		length   int
+		alphabet string
+	}{
+		{"md5", 22, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz" +
+			"0123456789-_"},
+		{"sha1", 40, "0123456789abcdef"},
+		{"phash", 16, "0123456789abcdef"},
+	}
+)
 
 // Request to spoiler an already allocated image that the sender has created
 type spoilerRequest struct {
@@ -64,12 +89,12 @@ func writeJSON(
 }
 
 // Validate the client's last N posts to display setting. To allow for better
-// caching the only valid values are 5 and 50. 5 is for index-like thread
-// previews and 50 is for short threads.
+// caching the only valid values are 5, 50 and 100. 5 is for index-like thread
+// previews and the others for shortened views of long threads.
 func detectLastN(r *http.Request) int {
 	if q := r.URL.Query().Get("last"); q != "" {
 		n, err := strconv.Atoi(q)
-		if err == nil && (n == 100 || n == 5) {
+		if err == nil && common.IsLastN(n) {
 			return n
 		}
 	}
@@ -95,16 +120,68 @@ func servePost(w http.ResponseWriter, r *http.Request) {
 		httpError(w, r, err)
 		return
 	}
+	if !canViewBoard(r, post.Board) ||
+		isHidden(post) && !canSeeHidden(r, post) {
+		text404(w)
+		return
+	}
 	serveJSON(w, r, "", post)
 }
 
+// Serve the strokes of a post's oekaki drawing for replaying
+func serveDrawing(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(extractParam(r, "post"), 10, 64)
+	if err != nil {
+		httpError(w, r, common.StatusError{err, 400})
+		return
+	}
+
+	post, err := db.GetPost(id)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	if !canViewBoard(r, post.Board) ||
+		isHidden(post) && !canSeeHidden(r, post) {
+		text404(w)
+		return
+	}
+	buf, err := db.GetDrawing(id)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", json.RawMessage(buf))
+}
+
+// Returns, if a post is hidden from other clients by a shadow ban or not yet
+// being published
+func isHidden(post common.StandalonePost) bool {
+	return post.Shadowed ||
+		post.Editing && config.GetBoardConfigs(post.Board).HideOpenPosts
+}
+
+// Returns, if the requester can see a post hidden by a shadow ban or not yet
+// published. Only staff and the post's author can.
+func canSeeHidden(r *http.Request, post common.StandalonePost) bool {
+	if detectCanPerform(r, post.Board, auth.Janitor) {
+		return true
+	}
+	ip, err := auth.GetIP(r)
+	if err != nil {
+		return false
+	}
+	stored, err := db.GetPostIP(post.ID)
+	return err == nil && stored == db.HashIP(ip)
+}
+
 // Serve board-specific configuration JSON
 func serveBoardConfigs(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
 	board := extractParam(r, "board")
-	if !auth.IsBoard(board) {
+	if !canViewBoard(r, board) {
 		text404(w)
 		return
 	}
@@ -138,7 +215,10 @@ func threadJSON(w http.ResponseWriter, r *http.Request) {
 // occurred and the calling function should return, ok = false.
 func validateThread(w http.ResponseWriter, r *http.Request) (uint64, bool) {
 	board := extractParam(r, "board")
-
+	if !canViewBoard(r, board) {
+		text404(w)
+		return 0, false
+	}
 	if !assertNotBanned(w, r, board) {
 		return 0, false
 	}
@@ -155,17 +235,44 @@ func validateThread(w http.ResponseWriter, r *http.Request) (uint64, bool) {
 		return 0, false
 	}
 	if !valid {
-		text404(w)
+		redirectThread(w, r, board, id)
 		return 0, false
 	}
 
 	return id, true
 }
 
+// Redirect requests for a moved or merged thread to its current location
+func redirectThread(w http.ResponseWriter, r *http.Request, board string,
+	id uint64,
+) {
+	newBoard, op, err := db.GetThreadRedirect(board, id)
+	switch err {
+	case nil:
+	case sql.ErrNoRows:
+		text404(w)
+		return
+	default:
+		httpError(w, r, err)
+		return
+	}
+
+	old := fmt.Sprintf("/%s/%d", board, id)
+	if !strings.HasSuffix(r.URL.Path, old) {
+		text404(w)
+		return
+	}
+	u := *r.URL
+	u.Path = strings.TrimSuffix(u.Path, old) +
+		fmt.Sprintf("/%s/%d", newBoard, op)
+	u.RawPath = ""
+	http.Redirect(w, r, u.String(), 302)
+}
+
 // Serves board page JSON
 func boardJSON(w http.ResponseWriter, r *http.Request, catalog bool) {
 	b := extractParam(r, "board")
-	if !auth.IsBoard(b) {
+	if !canViewBoard(r, b) {
 		text404(w)
 		return
 	}
@@ -200,6 +307,68 @@ func serveIPCount(w http.ResponseWriter, r *http.Request) {
 	serveJSON(w, r, "", feeds.IPCount())
 }
 
+// Serve links to the newest posts across all boards with an image matching
+// the hash in the "md5", "sha1" or "phash" query parameter. Perceptual hashes
+// also match visually similar images. Available to global staff or everyone,
+// if enabled in the server configuration.
+func serveImageSearch(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		param, hash, err := parseImageSearch(r.URL.Query())
+		if err != nil {
+			return
+		}
+		staff := detectCanPerform(r, "all", auth.Janitor)
+		if !staff && !config.Get().PublicImageSearch {
+			return errAccessDenied
+		}
+		posts, err := db.FindImagePosts(param, hash, staff, imageSearchLimit)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", posts)
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Read the type and value of the image hash to search for from the query
+// parameters of a request
+func parseImageSearch(q url.Values) (param, hash string, err error) {
+	for _, f := range imageHashFormats {
+		hash = q.Get(f.param)
+		if hash == "" {
+			continue
+		}
+		if len(hash) != f.length {
+			err = errInvalidImageHash
+			return
+		}
+		for _, r := range hash {
+			if !strings.ContainsRune(f.alphabet, r) {
+				err = errInvalidImageHash
+				return
+			}
+		}
+		param = f.param
+		return
+	}
+	err = errNoImageHash
+	return
+}
+
+// Serve the most frequent keywords of recent posts on a board
+func serveTopics(w http.ResponseWriter, r *http.Request) {
+	board := extractParam(r, "board")
+	if !canViewBoard(r, board) {
+		text404(w)
+		return
+	}
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	serveJSON(w, r, "", topics.Get(board, n))
+}
+
 func serveThreadUpdates(w http.ResponseWriter, r *http.Request) {
 	err := func() (err error) {
 		var data map[uint64]uint64
@@ -6,6 +6,7 @@ import (
 	"github.com/bakape/meguca/db"
 	. "github.com/bakape/meguca/test"
 	"github.com/bakape/meguca/test/test_db"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -43,6 +44,7 @@ func TestDetectLastN(t *testing.T) {
 		{"no query string", "/a/1", 0},
 		{"unparsable", "/a/1?last=addsa", 0},
 		{"5", "/a/1?last=5", 5},
+		{"50", "/a/1?last=50", 50},
 		{"100", "/a/1?last=100", 100},
 		{"invalid number", "/a/1?last=1000", 0},
 	}
@@ -95,6 +97,11 @@ func TestPostJSON(t *testing.T) {
 			code:   304,
 			etag:   "",
 		},
+		{
+			name: "post without drawing",
+			url:  "/drawing/1",
+			code: 404,
+		},
 		{
 			name: "invalid thread board",
 			url:  "/boards/nope/1",
@@ -226,3 +233,67 @@ func TestServeExtensionMap(t *testing.T) {
 	router.ServeHTTP(rec, req)
 	assertCode(t, rec, 200)
 }
+
+func TestParseImageSearch(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name, query, param, hash string
+		err                      error
+	}{
+		{
+			name:  "md5",
+			query: "md5=YOQQklgfezKbBXuEAsqopw",
+			param: "md5",
+			hash:  "YOQQklgfezKbBXuEAsqopw",
+		},
+		{
+			name:  "sha1",
+			query: "sha1=012a2f912c9ee93ceb0ccb8684a29ec571990a94",
+			param: "sha1",
+			hash:  "012a2f912c9ee93ceb0ccb8684a29ec571990a94",
+		},
+		{
+			name:  "phash",
+			query: "phash=c3e1f0f8e0c08181",
+			param: "phash",
+			hash:  "c3e1f0f8e0c08181",
+		},
+		{
+			name:  "invalid length",
+			query: "sha1=012a2f",
+			err:   errInvalidImageHash,
+		},
+		{
+			name:  "invalid characters",
+			query: "phash=c3e1f0f8e0c0818%27",
+			err:   errInvalidImageHash,
+		},
+		{
+			name:  "no hash",
+			query: "foo=bar",
+			err:   errNoImageHash,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := url.ParseQuery(c.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			param, hash, err := parseImageSearch(q)
+			if err != c.err {
+				t.Fatalf("unexpected error: %v != %v", err, c.err)
+			}
+			if c.err != nil {
+				return
+			}
+			AssertDeepEquals(t, param, c.param)
+			AssertDeepEquals(t, hash, c.hash)
+		})
+	}
+}
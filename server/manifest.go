@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/bakape/meguca/common"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Subdirectories of webRoot containing client code and styles
+var manifestDirs = [...]string{"js", "css"}
+
+// Cache of client asset hashes. Files are only rehashed, if they changed.
+var assetHashes = assetHashCache{
+	files: make(map[string]assetHash),
+}
+
+// Hash of a single client asset file
+type assetHash struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+type assetHashCache struct {
+	mu    sync.Mutex
+	files map[string]assetHash
+}
+
+// Manifest of current client assets and compatible client versions
+type assetManifest struct {
+	Version            string            `json:"version"`
+	ProtocolVersion    uint              `json:"protocolVersion"`
+	MinProtocolVersion uint              `json:"minProtocolVersion"`
+	Assets             map[string]string `json:"assets"`
+}
+
+// Return hashes of all current client assets by their path relative to root
+func (c *assetHashCache) get(root string) (hashes map[string]string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hashes = make(map[string]string, len(c.files))
+	seen := make(map[string]assetHash, len(c.files))
+	for _, dir := range manifestDirs {
+		err = filepath.Walk(filepath.Join(root, dir),
+			func(path string, info os.FileInfo, err error) error {
+				switch {
+				case os.IsNotExist(err):
+					return nil
+				case err != nil:
+					return err
+				case info.IsDir():
+					return nil
+				}
+
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				rel = filepath.ToSlash(rel)
+
+				h, ok := c.files[rel]
+				if !ok || !h.modTime.Equal(info.ModTime()) ||
+					h.size != info.Size() {
+					h.hash, err = hashFile(path)
+					if err != nil {
+						return err
+					}
+					h.modTime = info.ModTime()
+					h.size = info.Size()
+				}
+				seen[rel] = h
+				hashes[rel] = h.hash
+				return nil
+			},
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	// Also drops deleted files from the cache
+	c.files = seen
+	return
+}
+
+// Compute the content hash of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// Serve the client asset manifest
+func serveManifest(w http.ResponseWriter, r *http.Request) {
+	hashes, err := assetHashes.get(webRoot)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", assetManifest{
+		Version:            common.Version,
+		ProtocolVersion:    common.ProtocolVersion,
+		MinProtocolVersion: common.MinProtocolVersion,
+		Assets:             hashes,
+	})
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/bakape/meguca/common"
+	"testing"
+)
+
+func TestServeManifest(t *testing.T) {
+	t.Parallel()
+
+	rec, req := newPair("/json/manifest")
+	router.ServeHTTP(rec, req)
+	assertCode(t, rec, 200)
+
+	var res assetManifest
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != common.Version ||
+		res.ProtocolVersion != common.ProtocolVersion ||
+		res.MinProtocolVersion != common.MinProtocolVersion {
+		t.Fatalf("unexpected versions: %#v", res)
+	}
+	hash := res.Assets["js/scripts/worker.js"]
+	if hash == "" {
+		t.Fatalf("asset not listed: %#v", res.Assets)
+	}
+
+	// Cached hash
+	hashes, err := assetHashes.get(webRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashes["js/scripts/worker.js"] != hash {
+		t.Fatal("hash changed")
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+)
+
+// Test migrations in a rolled back transaction instead of applying them
+var migrateDryRun bool
+
+// Migrate the database schema to version `to` from the command line. An empty
+// `to` migrates to the latest version.
+func migrateCLI(to string, dryRun bool, w io.Writer) (err error) {
+	target := -1
+	if to != "" {
+		target, err = strconv.Atoi(to)
+		if err != nil || target < 1 {
+			return common.ErrInvalidInput("invalid database version: " + to)
+		}
+	}
+	err = db.Connect()
+	if err != nil {
+		return
+	}
+
+	from, latest, err := db.SchemaVersion()
+	if err != nil {
+		return
+	}
+	if target == -1 {
+		target = latest
+	}
+	if from == target {
+		fmt.Fprintf(w, "database is at version %d: no migrations pending\n",
+			from)
+		return
+	}
+	err = db.Migrate(target, dryRun)
+	if err != nil {
+		return
+	}
+	if dryRun {
+		fmt.Fprintf(w, "dry run: database can be migrated from %d to %d\n",
+			from, target)
+	} else {
+		fmt.Fprintf(w, "database migrated from version %d to %d\n",
+			from, target)
+	}
+	return
+}
@@ -31,22 +31,16 @@ func createThread(w http.ResponseWriter, r *http.Request) {
 		req := websockets.ThreadCreationRequest{
 			Subject:              f.Get("subject"),
 			Board:                f.Get("board"),
+			Encrypted:            f.Get("encrypted") == "on",
 			ReplyCreationRequest: repReq,
 		}
 
-		post, err := websockets.CreateThread(req, ip)
+		post, err := websockets.CreateThread(r.Context(), req, ip)
 		if err != nil {
-			// TODO: Not all codes are actually 400. Need to differentiate
-			return common.StatusError{err, 400}
+			return postCreationError(err)
 		}
 
-		// Let the JS add the ID of the post to "mine"
-		http.SetCookie(w, &http.Cookie{
-			Name:  "addMine",
-			Value: strconv.FormatUint(post.ID, 10),
-			Path:  "/",
-		})
-
+		setAddMine(w, post.ID)
 		http.Redirect(w, r, fmt.Sprintf(`/%s/%d`, req.Board, post.ID), 303)
 		incrementSpamscore(ip, req.Body, true)
 
@@ -74,9 +68,17 @@ func parsePostCreationForm(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	if conf.Captcha {
+	board := r.Form.Get("board")
+	if config.IsStaffOnly(board) && !canViewBoard(r, board) {
+		err = common.ErrInvalidBoard(board)
+		return
+	}
+
+	// Bots authenticating with API tokens can not solve captchas
+	_, isBot := auth.APITokenFromContext(r.Context())
+	if conf.Captcha && !config.GetBoardConfigs(board).DisableCaptcha && !isBot {
 		var need, has bool
-		need, err = db.NeedCaptcha(ip)
+		need, err = db.NeedBoardCaptcha(board, ip)
 		if err != nil {
 			return
 		}
@@ -98,12 +100,14 @@ func parsePostCreationForm(w http.ResponseWriter, r *http.Request,
 	req = websockets.ReplyCreationRequest{
 		// HTTP uses "\r\n" for newlines, but "\r" is considered non-printable
 		// and raises parser.ErrContainsNonPrintable during parsing.
-		Body: strings.Replace(f.Get("body"), "\r", "", -1),
-		Name: f.Get("name"),
-		Sage: f.Get("sage") == "on",
+		Body:      strings.Replace(f.Get("body"), "\r", "", -1),
+		Name:      f.Get("name"),
+		Sage:      f.Get("sage") == "on",
+		Challenge: f.Get("powChallenge"),
+		Nonce:     f.Get("powNonce"),
 	}
 	if f.Get("staffTitle") == "on" {
-		req.SessionCreds = extractLoginCreds(r)
+		req.SessionCreds = auth.ExtractLoginCreds(r)
 	}
 
 	// Handle image, if any, and extract file name
@@ -154,13 +158,15 @@ func createReply(w http.ResponseWriter, r *http.Request) {
 			return common.ErrInvalidThread(op, board)
 		}
 
-		post, msg, err := websockets.CreatePost(op, board, ip, req)
+		post, msg, err := websockets.CreatePost(r.Context(), op, board, ip, req)
 		if err != nil {
-			// TODO: Not all codes are actually 400. Need to differentiate
-			return common.StatusError{err, 400}
+			return postCreationError(err)
 		}
 
-		feeds.InsertPostInto(post.StandalonePost, msg)
+		if !post.Shadowed {
+			feeds.InsertPostInto(post.StandalonePost, msg)
+		}
+		setAddMine(w, post.ID)
 		http.Redirect(w, r,
 			fmt.Sprintf(`/%s/%d?last100=true#bottom`, board, op), 303)
 		incrementSpamscore(ip, req.Body, false)
@@ -172,6 +178,25 @@ func createReply(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Errors, that already carry a status code, are passed through as is. Post
+// validation and parsing errors without one are client errors.
+func postCreationError(err error) error {
+	if _, ok := err.(common.StatusError); ok {
+		return err
+	}
+	return common.StatusError{err, 400}
+}
+
+// Let the JS add the ID of a post created without it to "mine", once a page is
+// loaded with JS enabled
+func setAddMine(w http.ResponseWriter, id uint64) {
+	http.SetCookie(w, &http.Cookie{
+		Name:  "addMine",
+		Value: strconv.FormatUint(id, 10),
+		Path:  "/",
+	})
+}
+
 func incrementSpamscore(ip, body string, isOP bool) {
 	conf := config.Get()
 	s := conf.CharScore * uint(utf8.RuneCountInString(body))
@@ -181,3 +206,23 @@ func incrementSpamscore(ip, body string, isOP bool) {
 	}
 	db.IncrementSpamScore(ip, time.Duration(s))
 }
+
+// Serve a new proof of work challenge for creating a post through the HTTP
+// API
+func servePowChallenge(w http.ResponseWriter, r *http.Request) {
+	if !config.Get().ProofOfWork {
+		text404(w)
+		return
+	}
+	ip, err := auth.GetIP(r)
+	if err != nil {
+		httpError(w, r, common.StatusError{err, 400})
+		return
+	}
+	ch, err := websockets.IssuePowChallenge(ip)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	serveJSON(w, r, "", ch)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bakape/meguca/common"
+)
+
+func TestPostCreationError(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		err  error
+		code int
+		body string
+	}{
+		{
+			"plain error",
+			errors.New("no subject"),
+			400, "400 invalid input: no subject\n",
+		},
+		{
+			"invalid input",
+			common.ErrNameTooLong,
+			400, "400 invalid input: name too long\n",
+		},
+		{
+			"access denied",
+			common.ErrBanned,
+			403, "403 access denied: you are banned from this board\n",
+		},
+		{
+			"not found",
+			common.ErrInvalidBoard("x"),
+			404, "404 not found: board `x` does not exist\n",
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, req := newPair("/api/create-reply")
+			httpError(rec, req, postCreationError(c.err))
+			assertCode(t, rec, c.code)
+			assertBody(t, rec, c.body)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+)
+
+var (
+	errDeletionDisabled = common.ErrAccessDenied("post deletion disabled")
+	errDeletionExpired  = common.ErrAccessDenied("post deletion window expired")
+	errWrongPassword    = common.ErrAccessDenied("wrong password")
+)
+
+// Request to delete one's own post or its image
+type ownPostDeletionRequest struct {
+	ID        uint64 `json:"id"`
+	Password  string `json:"password"`
+	ImageOnly bool   `json:"imageOnly"`
+}
+
+// Delete a post or just its image by its author within the configured
+// deletion window. The author is authenticated by the password provided at
+// post creation. The deletion is logged with an empty moderator ID.
+func deleteOwnPost(w http.ResponseWriter, r *http.Request) {
+	var req ownPostDeletionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		httpError(w, r, err)
+		return
+	}
+	if err := authorDeletion(w, r, req); err != nil {
+		httpError(w, r, err)
+	}
+}
+
+func authorDeletion(w http.ResponseWriter, r *http.Request,
+	req ownPostDeletionRequest,
+) (err error) {
+	window := config.Get().DeletionWindow
+	if window == 0 {
+		return errDeletionDisabled
+	}
+
+	post, err := db.GetPost(req.ID)
+	if err != nil {
+		return
+	}
+	if !assertNotBanned(w, r, post.Board) {
+		return
+	}
+	if time.Now().Unix()-post.Time > int64(window)*60 {
+		return errDeletionExpired
+	}
+
+	hash, err := db.GetPostPassword(req.ID)
+	if err != nil {
+		return
+	}
+	if hash == nil || auth.BcryptCompare(req.Password, hash) != nil {
+		return errWrongPassword
+	}
+
+	switch {
+	case req.ImageOnly && post.Image == nil:
+		return common.StatusError{errNoImage, 400}
+	case req.ImageOnly:
+		return db.DeleteImage(req.ID, "")
+	case post.IsDeleted():
+		return
+	default:
+		return db.DeletePost(req.ID, "")
+	}
+}
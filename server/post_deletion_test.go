@@ -0,0 +1,143 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/test/test_db"
+)
+
+func TestDeleteOwnPost(t *testing.T) {
+	test_db.ClearTables(t, "boards")
+	writeSampleBoard(t)
+
+	hash, err := auth.BcryptHash("123", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePost := func(id uint64, created time.Time, editing bool) {
+		t.Helper()
+		err := db.WriteThread(
+			db.Thread{
+				ID:    id,
+				Board: "a",
+			},
+			db.Post{
+				StandalonePost: common.StandalonePost{
+					Post: common.Post{
+						ID:      id,
+						Time:    created.Unix(),
+						Editing: editing,
+					},
+					OP:    id,
+					Board: "a",
+				},
+				Password: hash,
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePost(1, time.Now(), false)
+	writePost(2, time.Now().Add(-time.Hour), false)
+
+	// Closing a post must retain its password for the deletion window
+	writePost(3, time.Now(), true)
+	err = db.ClosePost(3, 3, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Get()
+	defer config.Set(*conf)
+	setWindow := func(min uint) {
+		c := *conf
+		c.DeletionWindow = min
+		config.Set(c)
+	}
+
+	cases := [...]struct {
+		name, password string
+		id             uint64
+		window         uint
+		imageOnly      bool
+		code           int
+		err            error
+	}{
+		{
+			name:     "disabled",
+			id:       1,
+			password: "123",
+			code:     403,
+			err:      errDeletionDisabled,
+		},
+		{
+			name:     "wrong password",
+			id:       1,
+			password: "1234",
+			window:   15,
+			code:     403,
+			err:      errWrongPassword,
+		},
+		{
+			name:     "window expired",
+			id:       2,
+			password: "123",
+			window:   15,
+			code:     403,
+			err:      errDeletionExpired,
+		},
+		{
+			name:      "no image",
+			id:        1,
+			password:  "123",
+			window:    15,
+			imageOnly: true,
+			code:      400,
+			err:       errNoImage,
+		},
+		{
+			name:     "success",
+			id:       1,
+			password: "123",
+			window:   15,
+			code:     200,
+		},
+		{
+			name:     "closed after opening",
+			id:       3,
+			password: "123",
+			window:   15,
+			code:     200,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setWindow(c.window)
+			rec, req := newJSONPair(t, "/api/delete-own-post",
+				ownPostDeletionRequest{
+					ID:        c.id,
+					Password:  c.password,
+					ImageOnly: c.imageOnly,
+				})
+			router.ServeHTTP(rec, req)
+			assertError(t, rec, c.code, c.err)
+		})
+	}
+
+	for _, id := range [...]uint64{1, 3} {
+		post, err := db.GetPost(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !post.IsDeleted() {
+			t.Fatalf("post %d not deleted", id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/util"
+)
+
+// Modification of a post's body. Diff transforms Body into the body of the
+// next revision or the current body of the post, if this is the last one.
+type postHistoryEntry struct {
+	common.PostRevision
+	Diff []util.DiffChunk `json:"diff"`
+}
+
+// Serve the timeline of all modifications of a closed post's body with diffs
+// between consecutive versions. Available only to admins, as the snapshots
+// contain the content of purged posts.
+func servePostHistory(w http.ResponseWriter, r *http.Request) {
+	err := func() (err error) {
+		id, err := strconv.ParseUint(extractParam(r, "id"), 10, 64)
+		if err != nil {
+			err = common.StatusError{err, 400}
+			return
+		}
+
+		_, _, err = canModeratePost(w, r, id, auth.Admin)
+		if err != nil {
+			return
+		}
+
+		post, err := db.GetPost(id)
+		if err != nil {
+			return
+		}
+		revs, err := db.GetPostRevisions(id)
+		if err != nil {
+			return
+		}
+		serveJSON(w, r, "", postHistory(revs, post.Body))
+		return
+	}()
+	if err != nil {
+		httpError(w, r, err)
+	}
+}
+
+// Build a diff timeline from chronological snapshots of a post's body and its
+// current body
+func postHistory(revs []common.PostRevision, current string,
+) []postHistoryEntry {
+	entries := make([]postHistoryEntry, len(revs))
+	for i, rev := range revs {
+		next := current
+		if i+1 < len(revs) {
+			next = revs[i+1].Body
+		}
+		entries[i] = postHistoryEntry{
+			PostRevision: rev,
+			Diff:         util.DiffWords(rev.Body, next),
+		}
+	}
+	return entries
+}
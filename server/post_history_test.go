@@ -0,0 +1,38 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+	"github.com/bakape/meguca/util"
+)
+
+func TestPostHistory(t *testing.T) {
+	t.Parallel()
+
+	revs := []common.PostRevision{
+		{ID: 1, Reason: common.RevisionRedaction, By: "admin", Body: "foo"},
+		{
+			ID:     2,
+			Reason: common.RevisionRedaction,
+			By:     "admin",
+			Body:   "foo bar",
+		},
+	}
+	AssertDeepEquals(t, postHistory(revs, ""), []postHistoryEntry{
+		{
+			PostRevision: revs[0],
+			Diff: []util.DiffChunk{
+				{Type: util.DiffEqual, Text: "foo"},
+				{Type: util.DiffInsert, Text: " bar"},
+			},
+		},
+		{
+			PostRevision: revs[1],
+			Diff: []util.DiffChunk{
+				{Type: util.DiffDelete, Text: "foo bar"},
+			},
+		},
+	})
+}
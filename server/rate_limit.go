@@ -0,0 +1,261 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// Defines, if per-IP rate limits should be applied to the HTTP API
+	enableRateLimits bool
+
+	// Rate limits for each route class. Can be overridden through config.json.
+	rateLimits = map[string]rateLimit{
+		"upload": {Requests: 20, Interval: 60},
+		"post":   {Requests: 20, Interval: 60},
+		"auth":   {Requests: 10, Interval: 60},
+		"json":   {Requests: 300, Interval: 60},
+		"api":    {Requests: 120, Interval: 60},
+		"export": {Requests: 10, Interval: 60},
+	}
+
+	// Route prefixes mapped to their rate limit class. Matched in order.
+	rateLimitRoutes = [...]struct {
+		prefix, class string
+	}{
+		{"/api/upload", "upload"},
+		{"/api/create-thread", "post"},
+		{"/api/create-reply", "post"},
+		{"/api/report", "post"},
+		{"/api/delete-own-post", "auth"},
+		{"/api/register", "auth"},
+		{"/api/login", "auth"},
+		{"/api/change-password", "auth"},
+		{"/api/captcha/", "auth"},
+		{"/api/export/", "export"},
+		{"/json/", "json"},
+		{"/api/", "api"},
+	}
+
+	// IPs and networks exempt from rate limiting, like trusted reverse proxies
+	// and crawlers
+	rateLimitAllowlist []*net.IPNet
+
+	// Shared store of all rate limiting buckets
+	limiters = limiterStore{
+		buckets: make(map[limiterKey]*tokenBucket, 1<<10),
+	}
+
+	errRateLimited = common.StatusError{
+		Err:  errors.New("rate limit exceeded"),
+		Code: 429,
+	}
+)
+
+// Rate limit of a route class. Allows bursts of up to Requests requests and
+// refills at a rate of Requests per Interval seconds.
+type rateLimit struct {
+	Requests uint `json:"requests"`
+	Interval uint `json:"interval"`
+}
+
+type limiterKey struct {
+	class, ip string
+}
+
+// Token bucket of a single IP and route class
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	interval time.Duration // Time to refill completely
+}
+
+// Stores rate limiting buckets of all IPs and route classes
+type limiterStore struct {
+	mu      sync.Mutex
+	buckets map[limiterKey]*tokenBucket
+}
+
+// Consume a token from the bucket of an IP. Returns, if the request is
+// allowed and, if not, the time after which it can be retried.
+func (s *limiterStore) take(class, ip string, lim rateLimit, now time.Time) (
+	bool, time.Duration,
+) {
+	capacity := float64(lim.Requests)
+	rate := capacity / float64(lim.Interval) // Tokens per second
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := limiterKey{class, ip}
+	b := s.buckets[k]
+	if b == nil {
+		b = &tokenBucket{
+			tokens:   capacity,
+			last:     now,
+			interval: time.Duration(lim.Interval) * time.Second,
+		}
+		s.buckets[k] = b
+	} else {
+		b.tokens = math.Min(capacity,
+			b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Consume a token from the bucket of key in a route class. In clustered mode
+// buckets are shared with other instances through the database.
+func takeToken(class, key string, lim rateLimit) (
+	bool, time.Duration, error,
+) {
+	if config.Clustered {
+		capacity := float64(lim.Requests)
+		return db.TakeRateLimitToken(class, key, capacity,
+			capacity/float64(lim.Interval))
+	}
+	ok, wait := limiters.take(class, key, lim, time.Now())
+	return ok, wait, nil
+}
+
+// Remove buckets, that have been refilled completely and thus carry no
+// state
+func (s *limiterStore) cleanUp(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, b := range s.buckets {
+		if now.Sub(b.last) >= b.interval {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+// Parse a comma-separated list of IPs and CIDR networks
+func parseRateLimitAllowlist(s string) (err error) {
+	rateLimitAllowlist = rateLimitAllowlist[:0]
+	for _, addr := range strings.Split(s, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, "/") {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+				addr += "/32"
+			} else {
+				addr += "/128"
+			}
+		}
+		var n *net.IPNet
+		_, n, err = net.ParseCIDR(addr)
+		if err != nil {
+			return fmt.Errorf("rate limit allowlist: %s", err)
+		}
+		rateLimitAllowlist = append(rateLimitAllowlist, n)
+	}
+	return
+}
+
+// Validate rate limits overridden through config.json
+func validateRateLimits(limits map[string]rateLimit) error {
+	for class, lim := range limits {
+		if _, ok := rateLimits[class]; !ok {
+			return fmt.Errorf("unknown rate limit class: %s", class)
+		}
+		if lim.Requests == 0 || lim.Interval == 0 {
+			return fmt.Errorf("invalid rate limit: %s", class)
+		}
+		rateLimits[class] = lim
+	}
+	return nil
+}
+
+// Returns, if an IP is exempt from rate limiting
+func isRateLimitExempt(ip string) bool {
+	if auth.ReverseProxyIP != "" && ip == auth.ReverseProxyIP {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	for _, n := range rateLimitAllowlist {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find the rate limit class of a request path, if any
+func rateLimitClass(path string) string {
+	for _, r := range rateLimitRoutes {
+		if strings.HasPrefix(path, r.prefix) {
+			return r.class
+		}
+	}
+	return ""
+}
+
+// Apply per-IP rate limits to API routes
+func rateLimitHandler(h http.Handler) http.Handler {
+	go func() {
+		for range time.Tick(time.Minute) {
+			limiters.cleanUp(time.Now())
+		}
+	}()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := rateLimitClass(r.URL.Path)
+		if class == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ip, err := auth.GetIP(r)
+		if err != nil {
+			httpError(w, r, common.StatusError{err, 400})
+			return
+		}
+		_, hasToken := auth.APITokenFromContext(r.Context())
+		if hasToken || isRateLimitExempt(ip) {
+			// Requests authenticated with API tokens are limited per token
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait, err := takeToken(class, ip, rateLimits[class])
+		if err != nil {
+			httpError(w, r, err)
+			return
+		}
+		if !ok {
+			rejectRateLimited(w, r, wait)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Respond to a rate limited request with the time after which it can be
+// retried
+func rejectRateLimited(w http.ResponseWriter, r *http.Request,
+	wait time.Duration,
+) {
+	sec := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(sec))
+	httpError(w, r, errRateLimited)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	s := limiterStore{
+		buckets: make(map[limiterKey]*tokenBucket),
+	}
+	lim := rateLimit{Requests: 2, Interval: 10}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := s.take("test", "::1", lim, now); !ok {
+			t.Fatalf("request %d rate limited", i)
+		}
+	}
+	ok, wait := s.take("test", "::1", lim, now)
+	if ok {
+		t.Fatal("burst not rate limited")
+	}
+	if wait != 5*time.Second {
+		t.Fatalf("unexpected retry delay: %s", wait)
+	}
+
+	// Other IPs are not affected
+	if ok, _ := s.take("test", "::2", lim, now); !ok {
+		t.Fatal("other IP rate limited")
+	}
+
+	// Refilled after waiting
+	if ok, _ := s.take("test", "::1", lim, now.Add(wait)); !ok {
+		t.Fatal("rate limited after refill")
+	}
+}
+
+func TestLimiterCleanUp(t *testing.T) {
+	t.Parallel()
+
+	s := limiterStore{
+		buckets: make(map[limiterKey]*tokenBucket),
+	}
+	now := time.Now()
+	s.take("short", "::1", rateLimit{Requests: 1, Interval: 10}, now)
+	s.take("long", "::1", rateLimit{Requests: 1, Interval: 600}, now)
+
+	s.cleanUp(now.Add(time.Minute))
+	if _, ok := s.buckets[limiterKey{"short", "::1"}]; ok {
+		t.Fatal("refilled bucket not removed")
+	}
+	if _, ok := s.buckets[limiterKey{"long", "::1"}]; !ok {
+		t.Fatal("bucket removed before refilling")
+	}
+}
+
+func TestRateLimitClass(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		path, class string
+	}{
+		{"/api/upload", "upload"},
+		{"/api/upload-hash", "upload"},
+		{"/api/create-reply", "post"},
+		{"/json/boards/a/", "json"},
+		{"/api/ban", "api"},
+		{"/a/", ""},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.path, func(t *testing.T) {
+			t.Parallel()
+			if s := rateLimitClass(c.path); s != c.class {
+				t.Fatalf("unexpected class: %s : %s", c.class, s)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitAllowlist(t *testing.T) {
+	err := parseRateLimitAllowlist("10.0.0.1, 192.168.0.0/16,::2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parseRateLimitAllowlist("")
+
+	cases := [...]struct {
+		ip     string
+		exempt bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.2", false},
+		{"192.168.4.20", true},
+		{"::2", true},
+		{"::3", false},
+	}
+	for _, c := range cases {
+		if e := isRateLimitExempt(c.ip); e != c.exempt {
+			t.Errorf("unexpected exemption for %s: %v", c.ip, e)
+		}
+	}
+}
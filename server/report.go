@@ -4,7 +4,9 @@ import (
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
+	"github.com/bakape/meguca/websockets/feeds"
 	"net/http"
 	"strconv"
 	"time"
@@ -47,7 +49,7 @@ func report(w http.ResponseWriter, r *http.Request) {
 		httpError(w, r, err)
 		return
 	}
-	if !auth.IsNonMetaBoard(board) {
+	if !auth.IsNonMetaBoard(board) || !canViewBoard(r, board) {
 		httpError(w, r, errInvalidBoardName)
 		return
 	}
@@ -66,6 +68,10 @@ func report(w http.ResponseWriter, r *http.Request) {
 		httpError(w, r, err)
 		return
 	}
+	err = feeds.SendReportToStaff(target, reason)
+	if err != nil {
+		requestLog(r).Errorf("staff feed: %s", err)
+	}
 }
 
 // Render post reporting form
@@ -76,7 +82,7 @@ func reportForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	setHTMLHeaders(w)
-	templates.WriteReportForm(w, id)
+	templates.WriteReportForm(w, id, lang.FromRequest(r, ""))
 }
 
 // Render a list of reports for the board
@@ -93,5 +99,5 @@ func reportList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	setHTMLHeaders(w)
-	templates.WriteReportList(w, rep)
+	templates.WriteReportList(w, rep, lang.FromRequest(r, board))
 }
@@ -2,7 +2,6 @@ package server
 
 import (
 	"bytes"
-	"compress/gzip"
 	"fmt"
 	"net/http"
 	"runtime/debug"
@@ -17,7 +16,6 @@ import (
 
 	"github.com/dimfeld/httptreemux"
 	"github.com/go-playground/log"
-	"github.com/gorilla/handlers"
 )
 
 var (
@@ -34,8 +32,7 @@ var (
 	// Path to SSL key
 	sslKey string
 
-	// Defines, if all traffic should be piped through a gzip compression
-	// -decompression handler
+	// Defines, if textual responses should be compressed with gzip
 	enableGzip bool
 
 	isTest bool
@@ -74,7 +71,7 @@ func handlePanic(w http.ResponseWriter, r *http.Request, err interface{}) {
 	if ipErr != nil {
 		ip = "invalid IP"
 	}
-	log.Errorf("server: %s: %#v\n%s\n", ip, err, debug.Stack())
+	requestLog(r).Errorf("server: %s: %#v\n%s\n", ip, err, debug.Stack())
 }
 
 // Create the monolithic router for routing HTTP requests. Separated into own
@@ -97,6 +94,8 @@ func createRouter() http.Handler {
 		api.POST("/upload-hash", imager.UploadImageHash)
 		api.POST("/create-thread", createThread)
 		api.POST("/create-reply", createReply)
+		api.GET("/pow-challenge", servePowChallenge)
+		api.POST("/delete-own-post", deleteOwnPost)
 
 		assets.GET("/images/*path", serveImages)
 
@@ -151,11 +150,15 @@ func createRouter() http.Handler {
 		})
 		boards.GET("/:board/:thread", threadJSON)
 		json.GET("/post/:post", servePost)
+		json.GET("/drawing/:post", serveDrawing)
 		json.GET("/config", serveConfigs)
 		json.GET("/extensions", serveExtensionMap)
 		json.GET("/board-config/:board", serveBoardConfigs)
 		json.GET("/board-list", serveBoardList)
+		json.GET("/mod-log/:board", servePublicModLog)
+		json.GET("/image-search", serveImageSearch)
 		json.GET("/ip-count", serveIPCount)
+		json.GET("/manifest", serveManifest)
 		json.POST("/thread-updates", serveThreadUpdates)
 
 		// Internal API
@@ -165,6 +168,7 @@ func createRouter() http.Handler {
 				httpError(w, r, err)
 			}
 		})
+		api.GET("/:board/topics", serveTopics)
 		api.GET("/youtube-data/:id", youTubeData)
 		api.GET("/bitchute-title/:id", bitChuteTitle)
 		api.POST("/register", register)
@@ -174,8 +178,22 @@ func createRouter() http.Handler {
 		api.POST("/change-password", changePassword)
 		api.POST("/board-config/:board", servePrivateBoardConfigs)
 		api.POST("/configure-board/:board", configureBoard)
+		api.POST("/configure-board-limits/:board", configureBoardLimits)
+		api.GET("/mod-log/:board", serveModLog)
+		api.GET("/mod-stats/:board", serveModerationStats)
+		api.GET("/export/:board", exportBoard)
 		api.POST("/config", servePrivateServerConfigs)
 		api.POST("/configure-server", configureServer)
+		api.POST("/reload-server-config", reloadServerConfigs)
+		api.POST("/audit-samples", serveAuditSamples)
+		api.POST("/transaction-stats", serveTransactionStats)
+		api.POST("/listener-status", serveListenerStatus)
+		api.POST("/media-gc-stats", serveMediaGCStats)
+		api.POST("/log-levels", serveLogLevels)
+		api.POST("/api-tokens", serveAPITokens)
+		api.POST("/create-api-token", createAPIToken)
+		api.POST("/revoke-api-token", revokeAPIToken)
+		api.POST("/set-log-levels", setLogLevels)
 		api.POST("/create-board", createBoard)
 		api.POST("/delete-board", deleteBoard)
 		api.POST("/delete-post", deletePost)
@@ -183,15 +201,28 @@ func createRouter() http.Handler {
 		api.POST("/spoiler-image", modSpoilerImage)
 		api.POST("/ban", ban)
 		api.POST("/notification", sendNotification)
+		api.POST("/announce/:board", announce)
+		api.GET("/announcement/:board", serveAnnouncementStatus)
+		api.POST("/rollback-announcement/:board", rollbackAnnouncement)
+		api.POST("/create-announcement-banner/:board",
+			createAnnouncementBanner)
+		api.GET("/announcement-banners/:board", serveAnnouncementBanners)
+		api.POST("/delete-announcement-banner/:board",
+			deleteAnnouncementBanner)
 		api.POST("/assign-staff", assignStaff)
 		api.POST("/same-IP/:id", getSameIPPosts)
 		api.POST("/sticky", setThreadSticky)
 		api.POST("/lock-thread", setThreadLock)
+		api.POST("/move-thread", moveThread)
+		api.POST("/merge-thread", mergeThread)
+		api.GET("/hidden-posts/:board", serveHiddenPosts)
+		api.POST("/unhide-post", unhidePosts)
 		api.POST("/unban/:board", unban)
 		api.POST("/set-banners", setBanners)
 		api.POST("/set-loading", setLoadingAnimation)
 		api.POST("/report", report)
 		api.POST("/purge-post", purgePost)
+		api.GET("/post-history/:id", servePostHistory)
 
 		redir := api.NewGroup("/redirect")
 		redir.POST("/by-ip", redirectByIP)
@@ -204,11 +235,15 @@ func createRouter() http.Handler {
 	}
 
 	h := http.Handler(r)
+	if enableRateLimits {
+		h = rateLimitHandler(h)
+	}
+	h = apiTokenHandler(h)
 	if enableGzip {
-		h = handlers.CompressHandlerLevel(h, gzip.DefaultCompression)
+		h = compressHandler(h)
 	}
 
-	return h
+	return accessLogHandler(h)
 }
 
 // Redirects to / requests to /all/ board
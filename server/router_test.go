@@ -9,6 +9,7 @@ import (
 
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/imager/assets"
 	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
 	"github.com/bakape/meguca/util"
@@ -32,7 +33,7 @@ func TestMain(m *testing.M) {
 	isTest = true
 	router = createRouter()
 	webRoot = "testdata"
-	imageWebRoot = "testdata"
+	assets.SetStorage(assets.NewLocalStorage("testdata"))
 
 	config.Set(config.Configs{
 		Public: config.Public{
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bakape/meguca/imager/assets"
+)
+
+// Storage backend of uploaded files
+var storageBackend string
+
+// Create a storage backend by name
+func newStorage(backend string, conf *assets.S3Config) (assets.Storage, error) {
+	switch backend {
+	case assets.StorageLocal:
+		return assets.NewLocalStorage("images"), nil
+	case assets.StorageS3:
+		if conf == nil {
+			return nil, errors.New("s3: not configured")
+		}
+		return assets.NewS3Storage(*conf)
+	default:
+		return nil, fmt.Errorf("invalid storage backend: %s", backend)
+	}
+}
+
+// Set the storage backend of uploaded files
+func configureStorage(backend string, conf *assets.S3Config) error {
+	s, err := newStorage(backend, conf)
+	if err != nil {
+		return err
+	}
+	assets.SetStorage(s)
+	return nil
+}
+
+// Copy all uploaded files from storage backend `from` to the configured
+// backend from the command line. Files are not deleted from `from`.
+func migrateMediaCLI(from string, conf *assets.S3Config, w io.Writer,
+) (err error) {
+	if from == storageBackend {
+		return errors.New("source and destination storage are the same")
+	}
+	src, err := newStorage(from, conf)
+	if err != nil {
+		return
+	}
+	n, err := assets.Copy(src, assets.GetStorage(), func(f assets.FileInfo) {
+		fmt.Fprintf(w, "copied %s (%d bytes)\n", f.Key, f.Size)
+	})
+	fmt.Fprintf(w, "copied %d files from %s to %s storage\n", n, from,
+		storageBackend)
+	return
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/templates"
 	"net/http"
 	"strconv"
@@ -73,7 +75,7 @@ func logError(r *http.Request, err interface{}) {
 	if ipErr != nil {
 		ip = "invalid IP"
 	}
-	log.Errorf("server: by %s: %s: %#v", ip, err, err)
+	requestLog(r).Errorf("server: by %s: %s: %#v", ip, err, err)
 }
 
 // Text-only 404 response
@@ -93,7 +95,8 @@ func httpError(w http.ResponseWriter, r *http.Request, err error) {
 		}
 	}
 
-	http.Error(w, fmt.Sprintf("%d %s", code, err), code)
+	msg := lang.FromRequest(r, extractParam(r, "board")).Error(err)
+	http.Error(w, fmt.Sprintf("%d %s", code, msg), code)
 	if code >= 500 && code < 600 {
 		logError(r, err)
 	}
@@ -128,7 +131,7 @@ func assertNotBanned(w http.ResponseWriter, r *http.Request, board string,
 		}
 		head.Set("Content-Type", "text/html")
 		head.Set("Cache-Control", "no-store")
-		templates.WriteBanPage(w, rec)
+		templates.WriteBanPage(w, rec, lang.FromRequest(r, board))
 		return false
 	case sql.ErrNoRows:
 		// If there is no row, that means the ban cache has not been updated
@@ -143,6 +146,16 @@ func assertNotBanned(w http.ResponseWriter, r *http.Request, board string,
 	}
 }
 
+// Returns, if a board exists and the client can access it. Staff-only boards
+// are only accessible to the board's staff.
+func canViewBoard(r *http.Request, board string) bool {
+	if !auth.IsBoard(board) {
+		return false
+	}
+	return !config.IsStaffOnly(board) ||
+		detectCanPerform(r, board, auth.Janitor)
+}
+
 // Extract URL paramater from request context
 func extractParam(r *http.Request, id string) string {
 	return httptreemux.ContextParams(r.Context())[id]
@@ -23,6 +23,7 @@ type articleContext struct {
 	op                                 uint64
 	board, subject, root               string
 	backlinks                          backlinks
+	lang                               lang.Pack
 }
 
 // Map of all backlinks on a page
@@ -39,8 +40,8 @@ func imageName(fileType uint8, name string) string {
 }
 
 // Renders the post creation time field
-func formatTime(sec int64) string {
-	ln := lang.Get().Common.Time
+func formatTime(sec int64, pack lang.Pack) string {
+	ln := pack.Common.Time
 
 	t := time.Unix(sec, 0)
 	year, m, day := t.Date()
@@ -124,13 +125,18 @@ func extractBacklinks(cap int, threads ...common.Thread) backlinks {
 }
 
 // Write on-post moderation to template
-func streampostModeration(qw *quicktemplate.Writer, e common.ModerationEntry) {
+func streampostModeration(
+	qw *quicktemplate.Writer,
+	e common.ModerationEntry,
+	pack lang.Pack,
+) {
 	w := qw.E()
-	ln := lang.Get().Common
+	ln := pack.Common
 	f := ln.Format
 	switch e.Type {
 	case common.BanPost:
-		fmt.Fprintf(w, f["banned"], e.By, strings.ToUpper(secondsToTime(e.Length)), e.Data)
+		fmt.Fprintf(w, f["banned"], e.By,
+			strings.ToUpper(secondsToTime(e.Length, pack)), e.Data)
 	case common.DeletePost:
 		fmt.Fprintf(w, f["deleted"], e.By)
 	case common.DeleteImage:
@@ -145,6 +151,8 @@ func streampostModeration(qw *quicktemplate.Writer, e common.ModerationEntry) {
 			action = ln.Posts["unlocked"]
 		}
 		fmt.Fprintf(w, f["threadLockToggled"], action, e.By)
+	case common.StickyThread:
+		fmt.Fprintf(w, f["threadStickyToggled"], e.By)
 	case common.MeidoVision:
 		fmt.Fprintf(w, f["viewedSameIP"], e.By)
 	case common.PurgePost:
@@ -153,20 +161,20 @@ func streampostModeration(qw *quicktemplate.Writer, e common.ModerationEntry) {
 }
 
 // Returns human readable time
-func secondsToTime(s uint64) string {
+func secondsToTime(s uint64, ln lang.Pack) string {
 	divide := [5]float64{60, 60, 24, 30, 12}
 	unit := [5]string{"second", "minute", "hour", "day", "month"}
 	time := float64(s)
 
 	format := func(key string) string {
 		tmp := fmt.Sprintf("%.1f", time)
-		plural := lang.Get().Common.Plurals[key][1]
+		plural := ln.Common.Plurals[key][1]
 
 		if strings.Contains(tmp, ".0") {
 			tmp = tmp[:len(tmp) - 2]
 
 			if tmp == "1" {
-				plural = lang.Get().Common.Plurals[key][0]
+				plural = ln.Common.Plurals[key][0]
 			}
 		}
 
@@ -186,8 +194,8 @@ func secondsToTime(s uint64) string {
 
 // Returns the stringified n + the plural or singular word
 // from the language by index word
-func pluralize(n int, word string) string {
-	ln := lang.Get().Common.Plurals[word]
+func pluralize(n int, word string, pack lang.Pack) string {
+	ln := pack.Common.Plurals[word]
 	b := make([]byte, 0, 32)
 	b = strconv.AppendInt(b, int64(n), 10)
 	b = append(b, ' ')
@@ -14,574 +14,571 @@ import "strconv"
 import "github.com/bakape/meguca/common"
 
 //line article.qtpl:4
-import "github.com/bakape/meguca/lang"
-
-//line article.qtpl:5
 import "github.com/bakape/meguca/imager/assets"
 
-//line article.qtpl:6
+//line article.qtpl:5
 import "github.com/bakape/meguca/util"
 
-//line article.qtpl:8
+//line article.qtpl:7
 import (
 	qtio422016 "io"
 
 	qt422016 "github.com/valyala/quicktemplate"
 )
 
-//line article.qtpl:8
+//line article.qtpl:7
 var (
 	_ = qtio422016.Copy
 	_ = qt422016.AcquireByteBuffer
 )
 
-//line article.qtpl:8
+//line article.qtpl:7
 func streamrenderArticle(qw422016 *qt422016.Writer, p common.Post, c articleContext) {
-	//line article.qtpl:9
+	//line article.qtpl:8
 	id := strconv.FormatUint(p.ID, 10)
 
-	//line article.qtpl:10
-	ln := lang.Get()
+	//line article.qtpl:9
+	ln := c.lang
 
-	//line article.qtpl:10
+	//line article.qtpl:9
 	qw422016.N().S(`<article id="p`)
-	//line article.qtpl:11
+	//line article.qtpl:10
 	qw422016.N().S(id)
-	//line article.qtpl:11
+	//line article.qtpl:10
 	qw422016.N().S(`"`)
-	//line article.qtpl:11
+	//line article.qtpl:10
 	qw422016.N().S(` `)
-	//line article.qtpl:11
+	//line article.qtpl:10
 	streampostClass(qw422016, p, c.op)
-	//line article.qtpl:11
+	//line article.qtpl:10
 	qw422016.N().S(`>`)
-	//line article.qtpl:12
+	//line article.qtpl:11
 	streamdeletedToggle(qw422016)
-	//line article.qtpl:12
+	//line article.qtpl:11
 	qw422016.N().S(`<header class="spaced"><input type="checkbox" class="mod-checkbox hidden">`)
-	//line article.qtpl:15
+	//line article.qtpl:14
 	streamrenderSticky(qw422016, c.sticky)
-	//line article.qtpl:16
+	//line article.qtpl:15
 	streamrenderLocked(qw422016, c.locked)
-	//line article.qtpl:17
+	//line article.qtpl:16
 	if c.subject != "" {
-		//line article.qtpl:18
+		//line article.qtpl:17
 		if c.board != "" {
-			//line article.qtpl:18
+			//line article.qtpl:17
 			qw422016.N().S(`<b class="board">/`)
-			//line article.qtpl:20
+			//line article.qtpl:19
 			qw422016.N().S(c.board)
-			//line article.qtpl:20
+			//line article.qtpl:19
 			qw422016.N().S(`/</b>`)
-			//line article.qtpl:22
+			//line article.qtpl:21
 		}
-		//line article.qtpl:22
+		//line article.qtpl:21
 		qw422016.N().S(`<h3>「`)
-		//line article.qtpl:24
+		//line article.qtpl:23
 		qw422016.E().S(c.subject)
-		//line article.qtpl:24
+		//line article.qtpl:23
 		qw422016.N().S(`」</h3>`)
-		//line article.qtpl:26
+		//line article.qtpl:25
 	}
-	//line article.qtpl:26
+	//line article.qtpl:25
 	qw422016.N().S(`<b class="name spaced`)
-	//line article.qtpl:27
+	//line article.qtpl:26
 	if p.Auth != "" {
-		//line article.qtpl:27
+		//line article.qtpl:26
 		qw422016.N().S(` `)
-		//line article.qtpl:27
+		//line article.qtpl:26
 		qw422016.N().S(`admin`)
-		//line article.qtpl:27
+		//line article.qtpl:26
 	}
-	//line article.qtpl:27
+	//line article.qtpl:26
 	if p.Sage {
-		//line article.qtpl:27
+		//line article.qtpl:26
 		qw422016.N().S(` `)
-		//line article.qtpl:27
+		//line article.qtpl:26
 		qw422016.N().S(`sage`)
-		//line article.qtpl:27
+		//line article.qtpl:26
 	}
-	//line article.qtpl:27
+	//line article.qtpl:26
 	qw422016.N().S(`">`)
-	//line article.qtpl:28
+	//line article.qtpl:27
 	if p.Name != "" || p.Trip == "" {
-		//line article.qtpl:28
+		//line article.qtpl:27
 		qw422016.N().S(`<span>`)
-		//line article.qtpl:30
+		//line article.qtpl:29
 		if p.Name != "" {
-			//line article.qtpl:31
+			//line article.qtpl:30
 			qw422016.E().S(p.Name)
-			//line article.qtpl:32
+			//line article.qtpl:31
 		} else {
-			//line article.qtpl:33
+			//line article.qtpl:32
 			qw422016.N().S(ln.Common.Posts["anon"])
-			//line article.qtpl:34
+			//line article.qtpl:33
 		}
-		//line article.qtpl:34
+		//line article.qtpl:33
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:36
+		//line article.qtpl:35
 	}
-	//line article.qtpl:37
+	//line article.qtpl:36
 	if p.Trip != "" {
-		//line article.qtpl:37
+		//line article.qtpl:36
 		qw422016.N().S(`<code>!`)
-		//line article.qtpl:39
+		//line article.qtpl:38
 		qw422016.E().S(p.Trip)
-		//line article.qtpl:39
+		//line article.qtpl:38
 		qw422016.N().S(`</code>`)
-		//line article.qtpl:41
+		//line article.qtpl:40
 	}
-	//line article.qtpl:42
+	//line article.qtpl:41
 	if p.Auth != "" {
-		//line article.qtpl:42
+		//line article.qtpl:41
 		qw422016.N().S(`<span>##`)
-		//line article.qtpl:44
+		//line article.qtpl:43
 		qw422016.N().S(` `)
-		//line article.qtpl:44
+		//line article.qtpl:43
 		qw422016.N().S(ln.Common.Posts[p.Auth])
-		//line article.qtpl:44
+		//line article.qtpl:43
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:46
+		//line article.qtpl:45
 	}
-	//line article.qtpl:46
+	//line article.qtpl:45
 	qw422016.N().S(`</b>`)
-	//line article.qtpl:48
+	//line article.qtpl:47
 	if p.Flag != "" {
-		//line article.qtpl:49
+		//line article.qtpl:48
 		title, ok := countryMap[p.Flag]
 
-		//line article.qtpl:50
+		//line article.qtpl:49
 		if !ok {
-			//line article.qtpl:51
+			//line article.qtpl:50
 			title = p.Flag
 
-			//line article.qtpl:52
+			//line article.qtpl:51
 		}
-		//line article.qtpl:52
+		//line article.qtpl:51
 		qw422016.N().S(`<img class="flag" src="/assets/flags/`)
-		//line article.qtpl:53
+		//line article.qtpl:52
 		qw422016.N().S(p.Flag)
-		//line article.qtpl:53
+		//line article.qtpl:52
 		qw422016.N().S(`.svg" title="`)
-		//line article.qtpl:53
+		//line article.qtpl:52
 		qw422016.N().S(title)
-		//line article.qtpl:53
+		//line article.qtpl:52
 		qw422016.N().S(`">`)
-		//line article.qtpl:54
+		//line article.qtpl:53
 	}
-	//line article.qtpl:54
+	//line article.qtpl:53
 	qw422016.N().S(`<time>`)
-	//line article.qtpl:56
-	qw422016.N().S(formatTime(p.Time))
-	//line article.qtpl:56
+	//line article.qtpl:55
+	qw422016.N().S(formatTime(p.Time, ln))
+	//line article.qtpl:55
 	qw422016.N().S(`</time><nav>`)
-	//line article.qtpl:59
+	//line article.qtpl:58
 	url := "#p" + id
 
-	//line article.qtpl:60
+	//line article.qtpl:59
 	if c.index {
-		//line article.qtpl:61
+		//line article.qtpl:60
 		url = util.ConcatStrings("/all/", id, "?last=100", url)
 
-		//line article.qtpl:62
+		//line article.qtpl:61
 	}
-	//line article.qtpl:62
+	//line article.qtpl:61
 	qw422016.N().S(`<a href="`)
-	//line article.qtpl:63
+	//line article.qtpl:62
 	qw422016.N().S(url)
-	//line article.qtpl:63
+	//line article.qtpl:62
 	qw422016.N().S(`">No.</a><a class="quote" href="`)
-	//line article.qtpl:66
+	//line article.qtpl:65
 	qw422016.N().S(url)
-	//line article.qtpl:66
+	//line article.qtpl:65
 	qw422016.N().S(`">`)
-	//line article.qtpl:67
+	//line article.qtpl:66
 	qw422016.N().S(id)
-	//line article.qtpl:67
+	//line article.qtpl:66
 	qw422016.N().S(`</a></nav>`)
-	//line article.qtpl:70
+	//line article.qtpl:69
 	if c.index && c.subject != "" {
-		//line article.qtpl:70
+		//line article.qtpl:69
 		qw422016.N().S(`<span>`)
+		//line article.qtpl:71
+		streamexpandLink(qw422016, "all", id, ln)
+		//line article.qtpl:72
+		streamlast100Link(qw422016, "all", id, ln)
 		//line article.qtpl:72
-		streamexpandLink(qw422016, "all", id)
-		//line article.qtpl:73
-		streamlast100Link(qw422016, "all", id)
-		//line article.qtpl:73
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:75
+		//line article.qtpl:74
 	}
-	//line article.qtpl:76
+	//line article.qtpl:75
 	streamcontrolLink(qw422016)
-	//line article.qtpl:77
+	//line article.qtpl:76
 	if c.op == p.ID {
+		//line article.qtpl:77
+		streamthreadWatcherToggle(qw422016, p.ID, ln)
 		//line article.qtpl:78
-		streamthreadWatcherToggle(qw422016, p.ID)
-		//line article.qtpl:79
 	}
-	//line article.qtpl:79
+	//line article.qtpl:78
 	qw422016.N().S(`</header>`)
-	//line article.qtpl:81
+	//line article.qtpl:80
 	var src string
 
-	//line article.qtpl:82
+	//line article.qtpl:81
 	if p.Image != nil {
-		//line article.qtpl:83
+		//line article.qtpl:82
 		img := *p.Image
 
-		//line article.qtpl:84
+		//line article.qtpl:83
 		src = assets.SourcePath(img.FileType, img.SHA1)
 
-		//line article.qtpl:84
+		//line article.qtpl:83
 		qw422016.N().S(`<figcaption class="spaced"><a class="image-toggle act" hidden></a><span class="spaced image-search-container">`)
-		//line article.qtpl:88
+		//line article.qtpl:87
 		streamimageSearch(qw422016, c.root, img)
-		//line article.qtpl:88
+		//line article.qtpl:87
 		qw422016.N().S(`</span><span class="fileinfo">`)
-		//line article.qtpl:91
+		//line article.qtpl:90
 		if img.Artist != "" {
-			//line article.qtpl:91
+			//line article.qtpl:90
 			qw422016.N().S(`<span class="media-artist">`)
-			//line article.qtpl:93
+			//line article.qtpl:92
 			qw422016.E().S(img.Artist)
-			//line article.qtpl:93
+			//line article.qtpl:92
 			qw422016.N().S(`</span>`)
-			//line article.qtpl:95
+			//line article.qtpl:94
 		}
-		//line article.qtpl:96
+		//line article.qtpl:95
 		if img.Title != "" {
-			//line article.qtpl:96
+			//line article.qtpl:95
 			qw422016.N().S(`<span class="media-title">`)
-			//line article.qtpl:98
+			//line article.qtpl:97
 			qw422016.E().S(img.Title)
-			//line article.qtpl:98
+			//line article.qtpl:97
 			qw422016.N().S(`</span>`)
-			//line article.qtpl:100
+			//line article.qtpl:99
 		}
-		//line article.qtpl:101
+		//line article.qtpl:100
 		if img.Audio {
-			//line article.qtpl:101
+			//line article.qtpl:100
 			qw422016.N().S(`<span class="has-audio">♫</span>`)
-			//line article.qtpl:105
+			//line article.qtpl:104
 		}
-		//line article.qtpl:106
+		//line article.qtpl:105
 		if img.Length != 0 {
-			//line article.qtpl:106
+			//line article.qtpl:105
 			qw422016.N().S(`<span class="media-length">`)
-			//line article.qtpl:108
+			//line article.qtpl:107
 			l := img.Length
 
-			//line article.qtpl:109
+			//line article.qtpl:108
 			if l < 60 {
-				//line article.qtpl:110
+				//line article.qtpl:109
 				qw422016.N().S(fmt.Sprintf("0:%02d", l))
-				//line article.qtpl:111
+				//line article.qtpl:110
 			} else {
-				//line article.qtpl:112
+				//line article.qtpl:111
 				min := l / 60
 
-				//line article.qtpl:113
+				//line article.qtpl:112
 				qw422016.N().S(fmt.Sprintf("%02d:%02d", min, l-min*60))
-				//line article.qtpl:114
+				//line article.qtpl:113
 			}
-			//line article.qtpl:114
+			//line article.qtpl:113
 			qw422016.N().S(`</span>`)
-			//line article.qtpl:116
+			//line article.qtpl:115
 		}
-		//line article.qtpl:116
+		//line article.qtpl:115
 		qw422016.N().S(`<span class="filesize">`)
-		//line article.qtpl:118
+		//line article.qtpl:117
 		qw422016.N().S(readableFileSize(img.Size))
-		//line article.qtpl:118
+		//line article.qtpl:117
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:120
+		//line article.qtpl:119
 		if img.Dims != [4]uint16{} {
-			//line article.qtpl:120
+			//line article.qtpl:119
 			qw422016.N().S(`<span class="dims">`)
-			//line article.qtpl:122
+			//line article.qtpl:121
 			qw422016.N().S(strconv.FormatUint(uint64(img.Dims[0]), 10))
-			//line article.qtpl:122
+			//line article.qtpl:121
 			qw422016.N().S(`x`)
-			//line article.qtpl:124
+			//line article.qtpl:123
 			qw422016.N().S(strconv.FormatUint(uint64(img.Dims[1]), 10))
-			//line article.qtpl:124
+			//line article.qtpl:123
 			qw422016.N().S(`</span>`)
-			//line article.qtpl:126
+			//line article.qtpl:125
 		}
-		//line article.qtpl:126
+		//line article.qtpl:125
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:128
+		//line article.qtpl:127
 		name := imageName(img.FileType, img.Name)
 
-		//line article.qtpl:128
+		//line article.qtpl:127
 		qw422016.N().S(`<a href="`)
-		//line article.qtpl:129
+		//line article.qtpl:128
 		qw422016.N().S(assets.RelativeSourcePath(img.FileType, img.SHA1))
-		//line article.qtpl:129
+		//line article.qtpl:128
 		qw422016.N().S(`" download="`)
-		//line article.qtpl:129
+		//line article.qtpl:128
 		qw422016.N().S(name)
-		//line article.qtpl:129
+		//line article.qtpl:128
 		qw422016.N().S(`">`)
-		//line article.qtpl:130
+		//line article.qtpl:129
 		qw422016.N().S(name)
-		//line article.qtpl:130
+		//line article.qtpl:129
 		qw422016.N().S(`</a></figcaption>`)
-		//line article.qtpl:133
+		//line article.qtpl:132
 	}
-	//line article.qtpl:133
+	//line article.qtpl:132
 	qw422016.N().S(`<div class="post-container">`)
-	//line article.qtpl:135
+	//line article.qtpl:134
 	if p.Image != nil {
-		//line article.qtpl:136
+		//line article.qtpl:135
 		img := *p.Image
 
-		//line article.qtpl:136
+		//line article.qtpl:135
 		qw422016.N().S(`<figure><a target="_blank" href="`)
-		//line article.qtpl:138
+		//line article.qtpl:137
 		qw422016.N().S(src)
-		//line article.qtpl:138
+		//line article.qtpl:137
 		qw422016.N().S(`">`)
-		//line article.qtpl:139
+		//line article.qtpl:138
 		switch {
-		//line article.qtpl:140
+		//line article.qtpl:139
 		case img.ThumbType == common.NoFile:
-			//line article.qtpl:141
+			//line article.qtpl:140
 			var file string
 
-			//line article.qtpl:142
+			//line article.qtpl:141
 			switch img.FileType {
-			//line article.qtpl:143
+			//line article.qtpl:142
 			case common.MP4, common.MP3, common.OGG, common.FLAC:
-				//line article.qtpl:144
+				//line article.qtpl:143
 				file = "audio"
 
-			//line article.qtpl:145
+			//line article.qtpl:144
 			default:
-				//line article.qtpl:146
+				//line article.qtpl:145
 				file = "file"
 
-				//line article.qtpl:147
+				//line article.qtpl:146
 			}
-			//line article.qtpl:147
+			//line article.qtpl:146
 			qw422016.N().S(`<img src="/assets/`)
-			//line article.qtpl:148
+			//line article.qtpl:147
 			qw422016.N().S(file)
-			//line article.qtpl:148
+			//line article.qtpl:147
 			qw422016.N().S(`.png" width="150" height="150">`)
-		//line article.qtpl:149
+		//line article.qtpl:148
 		case img.Spoiler:
-			//line article.qtpl:152
+			//line article.qtpl:151
 			qw422016.N().S(`<img src="/assets/spoil/default.jpg" width="150" height="150">`)
-		//line article.qtpl:154
+		//line article.qtpl:153
 		default:
-			//line article.qtpl:154
+			//line article.qtpl:153
 			qw422016.N().S(`<img src="`)
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().S(assets.ThumbPath(img.ThumbType, img.SHA1))
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().S(`" width="`)
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().D(int(img.Dims[2]))
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().S(`" height="`)
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().D(int(img.Dims[3]))
-			//line article.qtpl:155
+			//line article.qtpl:154
 			qw422016.N().S(`">`)
-			//line article.qtpl:156
+			//line article.qtpl:155
 		}
-		//line article.qtpl:156
+		//line article.qtpl:155
 		qw422016.N().S(`</a></figure>`)
-		//line article.qtpl:159
+		//line article.qtpl:158
 	}
-	//line article.qtpl:159
+	//line article.qtpl:158
 	qw422016.N().S(`<blockquote>`)
-	//line article.qtpl:161
+	//line article.qtpl:160
 	streambody(qw422016, p, c.op, c.board, c.index, c.rbText, c.pyu)
-	//line article.qtpl:161
+	//line article.qtpl:160
 	qw422016.N().S(`</blockquote>`)
-	//line article.qtpl:163
+	//line article.qtpl:162
 	for _, e := range p.Moderation {
-		//line article.qtpl:163
+		//line article.qtpl:162
 		qw422016.N().S(`<b class="admin post-moderation">`)
-		//line article.qtpl:165
-		streampostModeration(qw422016, e)
-		//line article.qtpl:165
+		//line article.qtpl:164
+		streampostModeration(qw422016, e, ln)
+		//line article.qtpl:164
 		qw422016.N().S(`<br></b>`)
-		//line article.qtpl:168
+		//line article.qtpl:167
 	}
-	//line article.qtpl:168
+	//line article.qtpl:167
 	qw422016.N().S(`</div>`)
-	//line article.qtpl:170
+	//line article.qtpl:169
 	if c.omit != 0 {
-		//line article.qtpl:170
+		//line article.qtpl:169
 		qw422016.N().S(`<span class="omit" data-omit="`)
-		//line article.qtpl:171
+		//line article.qtpl:170
 		qw422016.N().D(c.omit)
-		//line article.qtpl:171
+		//line article.qtpl:170
 		qw422016.N().S(`" data-image-omit="`)
-		//line article.qtpl:171
+		//line article.qtpl:170
 		qw422016.N().D(c.imageOmit)
-		//line article.qtpl:171
+		//line article.qtpl:170
 		qw422016.N().S(`">`)
+		//line article.qtpl:171
+		qw422016.N().S(pluralize(c.omit, "post", ln))
 		//line article.qtpl:172
-		qw422016.N().S(pluralize(c.omit, "post"))
-		//line article.qtpl:173
 		qw422016.N().S(` `)
-		//line article.qtpl:173
+		//line article.qtpl:172
 		qw422016.N().S(ln.Common.Posts["and"])
-		//line article.qtpl:173
+		//line article.qtpl:172
 		qw422016.N().S(` `)
+		//line article.qtpl:173
+		qw422016.N().S(pluralize(c.imageOmit, "image", ln))
 		//line article.qtpl:174
-		qw422016.N().S(pluralize(c.imageOmit, "image"))
-		//line article.qtpl:175
 		qw422016.N().S(` `)
-		//line article.qtpl:175
-		qw422016.N().S(`omitted`)
-		//line article.qtpl:175
+		//line article.qtpl:174
+		qw422016.N().S(ln.Common.Posts["omitted"])
+		//line article.qtpl:174
 		qw422016.N().S(` `)
-		//line article.qtpl:175
+		//line article.qtpl:174
 		qw422016.N().S(`<span class="act"><a href="`)
-		//line article.qtpl:177
+		//line article.qtpl:176
 		qw422016.N().S(strconv.FormatUint(c.op, 10))
-		//line article.qtpl:177
+		//line article.qtpl:176
 		qw422016.N().S(`">`)
-		//line article.qtpl:178
+		//line article.qtpl:177
 		qw422016.N().S(ln.Common.Posts["seeAll"])
-		//line article.qtpl:178
+		//line article.qtpl:177
 		qw422016.N().S(`</a></span></span>`)
-		//line article.qtpl:182
+		//line article.qtpl:181
 	}
-	//line article.qtpl:183
+	//line article.qtpl:182
 	if bls := c.backlinks[p.ID]; len(bls) != 0 {
-		//line article.qtpl:183
+		//line article.qtpl:182
 		qw422016.N().S(`<span class="backlinks spaced">`)
-		//line article.qtpl:185
+		//line article.qtpl:184
 		for _, l := range bls {
-			//line article.qtpl:185
+			//line article.qtpl:184
 			qw422016.N().S(`<em>`)
-			//line article.qtpl:187
+			//line article.qtpl:186
 			streampostLink(qw422016, l, c.index || l.OP != c.op, c.index)
-			//line article.qtpl:187
+			//line article.qtpl:186
 			qw422016.N().S(`</em>`)
-			//line article.qtpl:189
+			//line article.qtpl:188
 		}
-		//line article.qtpl:189
+		//line article.qtpl:188
 		qw422016.N().S(`</span>`)
-		//line article.qtpl:191
+		//line article.qtpl:190
 	}
-	//line article.qtpl:191
+	//line article.qtpl:190
 	qw422016.N().S(`</article>`)
-//line article.qtpl:193
+//line article.qtpl:192
 }
 
-//line article.qtpl:193
+//line article.qtpl:192
 func writerenderArticle(qq422016 qtio422016.Writer, p common.Post, c articleContext) {
-	//line article.qtpl:193
+	//line article.qtpl:192
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line article.qtpl:193
+	//line article.qtpl:192
 	streamrenderArticle(qw422016, p, c)
-	//line article.qtpl:193
+	//line article.qtpl:192
 	qt422016.ReleaseWriter(qw422016)
-//line article.qtpl:193
+//line article.qtpl:192
 }
 
-//line article.qtpl:193
+//line article.qtpl:192
 func renderArticle(p common.Post, c articleContext) string {
-	//line article.qtpl:193
+	//line article.qtpl:192
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line article.qtpl:193
+	//line article.qtpl:192
 	writerenderArticle(qb422016, p, c)
-	//line article.qtpl:193
+	//line article.qtpl:192
 	qs422016 := string(qb422016.B)
-	//line article.qtpl:193
+	//line article.qtpl:192
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line article.qtpl:193
+	//line article.qtpl:192
 	return qs422016
-//line article.qtpl:193
+//line article.qtpl:192
 }
 
 // Render image search links according to file type
 
-//line article.qtpl:196
+//line article.qtpl:195
 func streamimageSearch(qw422016 *qt422016.Writer, root string, img common.Image) {
-	//line article.qtpl:197
+	//line article.qtpl:196
 	if img.ThumbType == common.NoFile || img.FileType == common.PDF {
-		//line article.qtpl:198
+		//line article.qtpl:197
 		return
-		//line article.qtpl:199
+		//line article.qtpl:198
 	}
-	//line article.qtpl:201
+	//line article.qtpl:200
 	url := root + assets.ImageSearchPath(img.ImageCommon)
 
-	//line article.qtpl:201
+	//line article.qtpl:200
 	qw422016.N().S(`<a class="image-search google" target="_blank" rel="nofollow" href="https://www.google.com/searchbyimage?image_url=`)
-	//line article.qtpl:202
+	//line article.qtpl:201
 	qw422016.N().S(url)
-	//line article.qtpl:202
+	//line article.qtpl:201
 	qw422016.N().S(`">G</a><a class="image-search iqdb" target="_blank" rel="nofollow" href="http://iqdb.org/?url=`)
-	//line article.qtpl:205
+	//line article.qtpl:204
 	qw422016.N().S(url)
-	//line article.qtpl:205
+	//line article.qtpl:204
 	qw422016.N().S(`">Iq</a><a class="image-search saucenao" target="_blank" rel="nofollow" href="http://saucenao.com/search.php?db=999&url=`)
-	//line article.qtpl:208
+	//line article.qtpl:207
 	qw422016.N().S(url)
-	//line article.qtpl:208
+	//line article.qtpl:207
 	qw422016.N().S(`">Sn</a><a class="image-search whatAnime" target="_blank" rel="nofollow" href="https://trace.moe/?url=`)
-	//line article.qtpl:211
+	//line article.qtpl:210
 	qw422016.N().S(url)
-	//line article.qtpl:211
+	//line article.qtpl:210
 	qw422016.N().S(`">Wa</a>`)
-	//line article.qtpl:214
+	//line article.qtpl:213
 	switch img.FileType {
-	//line article.qtpl:215
+	//line article.qtpl:214
 	case common.JPEG, common.PNG, common.GIF, common.WEBM:
-		//line article.qtpl:215
+		//line article.qtpl:214
 		qw422016.N().S(`<a class="image-search desustorage" target="_blank" rel="nofollow" href="https://desuarchive.org/_/search/image/`)
-		//line article.qtpl:216
+		//line article.qtpl:215
 		qw422016.N().S(img.MD5)
-		//line article.qtpl:216
+		//line article.qtpl:215
 		qw422016.N().S(`">Ds</a>`)
-		//line article.qtpl:219
+		//line article.qtpl:218
 	}
-	//line article.qtpl:220
+	//line article.qtpl:219
 	switch img.FileType {
-	//line article.qtpl:221
+	//line article.qtpl:220
 	case common.JPEG, common.PNG:
-		//line article.qtpl:221
+		//line article.qtpl:220
 		qw422016.N().S(`<a class="image-search exhentai" target="_blank" rel="nofollow" href="http://exhentai.org/?fs_similar=1&fs_exp=1&f_shash=`)
-		//line article.qtpl:222
+		//line article.qtpl:221
 		qw422016.N().S(img.SHA1)
-		//line article.qtpl:222
+		//line article.qtpl:221
 		qw422016.N().S(`">Ex</a>`)
-		//line article.qtpl:225
+		//line article.qtpl:224
 	}
-//line article.qtpl:226
+//line article.qtpl:225
 }
 
-//line article.qtpl:226
+//line article.qtpl:225
 func writeimageSearch(qq422016 qtio422016.Writer, root string, img common.Image) {
-	//line article.qtpl:226
+	//line article.qtpl:225
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line article.qtpl:226
+	//line article.qtpl:225
 	streamimageSearch(qw422016, root, img)
-	//line article.qtpl:226
+	//line article.qtpl:225
 	qt422016.ReleaseWriter(qw422016)
-//line article.qtpl:226
+//line article.qtpl:225
 }
 
-//line article.qtpl:226
+//line article.qtpl:225
 func imageSearch(root string, img common.Image) string {
-	//line article.qtpl:226
+	//line article.qtpl:225
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line article.qtpl:226
+	//line article.qtpl:225
 	writeimageSearch(qb422016, root, img)
-	//line article.qtpl:226
+	//line article.qtpl:225
 	qs422016 := string(qb422016.B)
-	//line article.qtpl:226
+	//line article.qtpl:225
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line article.qtpl:226
+	//line article.qtpl:225
 	return qs422016
-//line article.qtpl:226
+//line article.qtpl:225
 }
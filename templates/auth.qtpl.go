@@ -114,11 +114,11 @@ func htmlEnd() string {
 // BanPage renders a ban page for a banned user
 
 //line auth.qtpl:27
-func StreamBanPage(qw422016 *qt422016.Writer, rec auth.BanRecord) {
+func StreamBanPage(qw422016 *qt422016.Writer, rec auth.BanRecord, pack lang.Pack) {
 	//line auth.qtpl:28
 	streamhtmlHeader(qw422016)
 	//line auth.qtpl:29
-	ln := lang.Get().Templates["banPage"]
+	ln := pack.Templates["banPage"]
 
 	//line auth.qtpl:30
 	if len(ln) < 3 {
@@ -160,22 +160,22 @@ func StreamBanPage(qw422016 *qt422016.Writer, rec auth.BanRecord) {
 }
 
 //line auth.qtpl:52
-func WriteBanPage(qq422016 qtio422016.Writer, rec auth.BanRecord) {
+func WriteBanPage(qq422016 qtio422016.Writer, rec auth.BanRecord, pack lang.Pack) {
 	//line auth.qtpl:52
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line auth.qtpl:52
-	StreamBanPage(qw422016, rec)
+	StreamBanPage(qw422016, rec, pack)
 	//line auth.qtpl:52
 	qt422016.ReleaseWriter(qw422016)
 //line auth.qtpl:52
 }
 
 //line auth.qtpl:52
-func BanPage(rec auth.BanRecord) string {
+func BanPage(rec auth.BanRecord, pack lang.Pack) string {
 	//line auth.qtpl:52
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line auth.qtpl:52
-	WriteBanPage(qb422016, rec)
+	WriteBanPage(qb422016, rec, pack)
 	//line auth.qtpl:52
 	qs422016 := string(qb422016.B)
 	//line auth.qtpl:52
@@ -188,7 +188,7 @@ func BanPage(rec auth.BanRecord) string {
 // Renders a list of bans for a specific page with optional unbanning API links
 
 //line auth.qtpl:55
-func StreamBanList(qw422016 *qt422016.Writer, bans []auth.BanRecord, board string, canUnban bool) {
+func StreamBanList(qw422016 *qt422016.Writer, bans []auth.BanRecord, board string, canUnban bool, ln lang.Pack) {
 	//line auth.qtpl:56
 	streamhtmlHeader(qw422016)
 	//line auth.qtpl:57
@@ -200,302 +200,363 @@ func StreamBanList(qw422016 *qt422016.Writer, bans []auth.BanRecord, board strin
 	//line auth.qtpl:58
 	qw422016.N().S(`"><table>`)
 	//line auth.qtpl:60
-	headers := []string{
-		"reason", "by", "post", "posterID", "expires",
+	headers := []string{"reason"}
+
+	//line auth.qtpl:61
+	if canUnban {
+		//line auth.qtpl:62
+		headers = append(headers, "by")
+
+		//line auth.qtpl:63
 	}
+	//line auth.qtpl:64
+	headers = append(headers, "post", "posterID", "expires")
 
-	//line auth.qtpl:63
+	//line auth.qtpl:65
 	if canUnban {
-		//line auth.qtpl:64
-		headers = append(headers, "unban")
+		//line auth.qtpl:66
+		headers = append(headers, "shadowBan", "unban")
 
-		//line auth.qtpl:65
+		//line auth.qtpl:67
 	}
-	//line auth.qtpl:66
-	streamtableHeaders(qw422016, headers...)
-	//line auth.qtpl:67
+	//line auth.qtpl:68
+	streamtableHeaders(qw422016, ln, headers...)
+	//line auth.qtpl:69
 	salt := config.Get().Salt
 
-	//line auth.qtpl:68
+	//line auth.qtpl:70
 	for _, b := range bans {
-		//line auth.qtpl:68
-		qw422016.N().S(`<tr><td>`)
 		//line auth.qtpl:70
-		qw422016.E().S(b.Reason)
-		//line auth.qtpl:70
-		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:71
-		qw422016.E().S(b.By)
-		//line auth.qtpl:71
-		qw422016.N().S(`</td><td>`)
+		qw422016.N().S(`<tr><td>`)
 		//line auth.qtpl:72
-		streamstaticPostLink(qw422016, b.ForPost)
+		qw422016.E().S(b.Reason)
 		//line auth.qtpl:72
 		qw422016.N().S(`</td>`)
 		//line auth.qtpl:73
+		if canUnban {
+			//line auth.qtpl:73
+			qw422016.N().S(`<td>`)
+			//line auth.qtpl:74
+			qw422016.E().S(b.By)
+			//line auth.qtpl:74
+			qw422016.N().S(`</td>`)
+			//line auth.qtpl:75
+		}
+		//line auth.qtpl:75
+		qw422016.N().S(`<td>`)
+		//line auth.qtpl:76
+		streamstaticPostLink(qw422016, b.ForPost)
+		//line auth.qtpl:76
+		qw422016.N().S(`</td>`)
+		//line auth.qtpl:77
 		buf := make([]byte, 0, len(salt)+len(b.IP))
 
-		//line auth.qtpl:74
+		//line auth.qtpl:78
 		buf = append(buf, salt...)
 
-		//line auth.qtpl:75
+		//line auth.qtpl:79
 		buf = append(buf, b.IP...)
 
-		//line auth.qtpl:75
+		//line auth.qtpl:79
 		qw422016.N().S(`<td>`)
-		//line auth.qtpl:76
+		//line auth.qtpl:80
 		qw422016.E().S(mnemonic.FantasyName(buf))
-		//line auth.qtpl:76
+		//line auth.qtpl:80
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:77
+		//line auth.qtpl:81
 		qw422016.E().S(b.Expires.Format(time.UnixDate))
-		//line auth.qtpl:77
+		//line auth.qtpl:81
 		qw422016.N().S(`</td>`)
-		//line auth.qtpl:78
+		//line auth.qtpl:82
 		if canUnban {
-			//line auth.qtpl:78
-			qw422016.N().S(`<td><input type="checkbox" name="`)
-			//line auth.qtpl:80
+			//line auth.qtpl:82
+			qw422016.N().S(`<td><input type="checkbox" disabled`)
+			//line auth.qtpl:84
+			if b.Shadow {
+				//line auth.qtpl:84
+				qw422016.N().S(` `)
+				//line auth.qtpl:84
+				qw422016.N().S(`checked`)
+				//line auth.qtpl:84
+			}
+			//line auth.qtpl:84
+			qw422016.N().S(`></td><td><input type="checkbox" name="`)
+			//line auth.qtpl:87
 			qw422016.E().S(strconv.FormatUint(b.ForPost, 10))
-			//line auth.qtpl:80
+			//line auth.qtpl:87
 			qw422016.N().S(`"></td>`)
-			//line auth.qtpl:82
+			//line auth.qtpl:89
 		}
-		//line auth.qtpl:82
+		//line auth.qtpl:89
 		qw422016.N().S(`</tr>`)
-		//line auth.qtpl:84
+		//line auth.qtpl:91
 	}
-	//line auth.qtpl:84
+	//line auth.qtpl:91
 	qw422016.N().S(`</table>`)
-	//line auth.qtpl:86
+	//line auth.qtpl:93
 	if canUnban {
-		//line auth.qtpl:87
-		streamsubmit(qw422016, false)
-		//line auth.qtpl:88
+		//line auth.qtpl:94
+		streamsubmit(qw422016, false, ln)
+		//line auth.qtpl:95
 	}
-	//line auth.qtpl:88
+	//line auth.qtpl:95
 	qw422016.N().S(`</form>`)
-	//line auth.qtpl:90
+	//line auth.qtpl:97
 	streamhtmlEnd(qw422016)
-//line auth.qtpl:91
+//line auth.qtpl:98
 }
 
-//line auth.qtpl:91
-func WriteBanList(qq422016 qtio422016.Writer, bans []auth.BanRecord, board string, canUnban bool) {
-	//line auth.qtpl:91
+//line auth.qtpl:98
+func WriteBanList(qq422016 qtio422016.Writer, bans []auth.BanRecord, board string, canUnban bool, ln lang.Pack) {
+	//line auth.qtpl:98
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line auth.qtpl:91
-	StreamBanList(qw422016, bans, board, canUnban)
-	//line auth.qtpl:91
+	//line auth.qtpl:98
+	StreamBanList(qw422016, bans, board, canUnban, ln)
+	//line auth.qtpl:98
 	qt422016.ReleaseWriter(qw422016)
-//line auth.qtpl:91
+//line auth.qtpl:98
 }
 
-//line auth.qtpl:91
-func BanList(bans []auth.BanRecord, board string, canUnban bool) string {
-	//line auth.qtpl:91
+//line auth.qtpl:98
+func BanList(bans []auth.BanRecord, board string, canUnban bool, ln lang.Pack) string {
+	//line auth.qtpl:98
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line auth.qtpl:91
-	WriteBanList(qb422016, bans, board, canUnban)
-	//line auth.qtpl:91
+	//line auth.qtpl:98
+	WriteBanList(qb422016, bans, board, canUnban, ln)
+	//line auth.qtpl:98
 	qs422016 := string(qb422016.B)
-	//line auth.qtpl:91
+	//line auth.qtpl:98
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line auth.qtpl:91
+	//line auth.qtpl:98
 	return qs422016
-//line auth.qtpl:91
+//line auth.qtpl:98
 }
 
 // Common style for plain html tables
 
-//line auth.qtpl:94
+//line auth.qtpl:101
 func streamtableStyle(qw422016 *qt422016.Writer) {
-	//line auth.qtpl:94
+	//line auth.qtpl:101
 	qw422016.N().S(`<style>table, th, td {border: 1px solid black;}.hash-link {display: none;}</style>`)
-//line auth.qtpl:103
+//line auth.qtpl:110
 }
 
-//line auth.qtpl:103
+//line auth.qtpl:110
 func writetableStyle(qq422016 qtio422016.Writer) {
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	streamtableStyle(qw422016)
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	qt422016.ReleaseWriter(qw422016)
-//line auth.qtpl:103
+//line auth.qtpl:110
 }
 
-//line auth.qtpl:103
+//line auth.qtpl:110
 func tableStyle() string {
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	writetableStyle(qb422016)
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	qs422016 := string(qb422016.B)
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line auth.qtpl:103
+	//line auth.qtpl:110
 	return qs422016
-//line auth.qtpl:103
+//line auth.qtpl:110
 }
 
 // Post link, that will redirect to the post from any page
 
-//line auth.qtpl:106
+//line auth.qtpl:113
 func streamstaticPostLink(qw422016 *qt422016.Writer, id uint64) {
-	//line auth.qtpl:107
+	//line auth.qtpl:114
 	streampostLink(qw422016, common.Link{id, id, "all"}, true, true)
-//line auth.qtpl:108
+//line auth.qtpl:115
 }
 
-//line auth.qtpl:108
+//line auth.qtpl:115
 func writestaticPostLink(qq422016 qtio422016.Writer, id uint64) {
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	streamstaticPostLink(qw422016, id)
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	qt422016.ReleaseWriter(qw422016)
-//line auth.qtpl:108
+//line auth.qtpl:115
 }
 
-//line auth.qtpl:108
+//line auth.qtpl:115
 func staticPostLink(id uint64) string {
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	writestaticPostLink(qb422016, id)
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	qs422016 := string(qb422016.B)
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line auth.qtpl:108
+	//line auth.qtpl:115
 	return qs422016
-//line auth.qtpl:108
+//line auth.qtpl:115
 }
 
-// Renders a moderation log page
+// Renders a page of a board's moderation log
 
-//line auth.qtpl:111
-func StreamModLog(qw422016 *qt422016.Writer, log []auth.ModLogEntry) {
-	//line auth.qtpl:112
+//line auth.qtpl:118
+func StreamModLog(qw422016 *qt422016.Writer, log []auth.ModLogEntry, page, pages int, ln lang.Pack) {
+	//line auth.qtpl:119
 	streamhtmlHeader(qw422016)
-	//line auth.qtpl:113
-	ln := lang.Get()
-
-	//line auth.qtpl:114
+	//line auth.qtpl:120
 	streamtableStyle(qw422016)
-	//line auth.qtpl:114
+	//line auth.qtpl:120
 	qw422016.N().S(`<table>`)
-	//line auth.qtpl:116
-	streamtableHeaders(qw422016, "type", "by", "post", "time", "data", "duration")
-	//line auth.qtpl:117
+	//line auth.qtpl:122
+	streamtableHeaders(qw422016, ln, "type", "by", "post", "time", "data", "duration")
+	//line auth.qtpl:123
 	for _, l := range log {
-		//line auth.qtpl:117
+		//line auth.qtpl:123
 		qw422016.N().S(`<tr><td>`)
-		//line auth.qtpl:120
+		//line auth.qtpl:126
 		switch l.Type {
-		//line auth.qtpl:121
+		//line auth.qtpl:127
 		case common.BanPost:
-			//line auth.qtpl:122
+			//line auth.qtpl:128
 			qw422016.E().S(ln.UI["ban"])
-		//line auth.qtpl:123
+		//line auth.qtpl:129
 		case common.UnbanPost:
-			//line auth.qtpl:124
+			//line auth.qtpl:130
 			qw422016.E().S(ln.UI["unban"])
-		//line auth.qtpl:125
+		//line auth.qtpl:131
 		case common.DeletePost:
-			//line auth.qtpl:126
+			//line auth.qtpl:132
 			qw422016.E().S(ln.UI["deletePost"])
-		//line auth.qtpl:127
+		//line auth.qtpl:133
 		case common.DeleteImage:
-			//line auth.qtpl:128
+			//line auth.qtpl:134
 			qw422016.E().S(ln.UI["deleteImage"])
-		//line auth.qtpl:129
+		//line auth.qtpl:135
 		case common.SpoilerImage:
-			//line auth.qtpl:130
+			//line auth.qtpl:136
 			qw422016.E().S(ln.UI["spoilerImage"])
-		//line auth.qtpl:131
+		//line auth.qtpl:137
 		case common.LockThread:
-			//line auth.qtpl:132
+			//line auth.qtpl:138
 			qw422016.E().S(ln.Common.UI["lockThread"])
-		//line auth.qtpl:133
+		//line auth.qtpl:139
 		case common.DeleteBoard:
-			//line auth.qtpl:134
+			//line auth.qtpl:140
 			qw422016.E().S(ln.Common.UI["deleteBoard"])
-		//line auth.qtpl:135
+		//line auth.qtpl:141
 		case common.MeidoVision:
-			//line auth.qtpl:136
+			//line auth.qtpl:142
 			qw422016.E().S(ln.Common.UI["meidoVisionPost"])
-		//line auth.qtpl:137
+		//line auth.qtpl:143
 		case common.PurgePost:
-			//line auth.qtpl:138
+			//line auth.qtpl:144
 			qw422016.E().S(ln.UI["purgePost"])
-			//line auth.qtpl:139
+		//line auth.qtpl:145
+		case common.StickyThread:
+			//line auth.qtpl:146
+			qw422016.E().S(ln.UI["stickyThread"])
+		//line auth.qtpl:147
+		case common.ConfigureBoard:
+			//line auth.qtpl:148
+			qw422016.E().S(ln.UI["configureBoard"])
+		//line auth.qtpl:149
+		case common.ConfigureServer:
+			//line auth.qtpl:150
+			qw422016.E().S(ln.UI["configureServer"])
+		//line auth.qtpl:151
+		case common.AssignStaff:
+			//line auth.qtpl:152
+			qw422016.E().S(ln.UI["assignStaff"])
+		//line auth.qtpl:153
+		case common.MoveThread:
+			//line auth.qtpl:154
+			qw422016.E().S(ln.UI["moveThread"])
+		//line auth.qtpl:155
+		case common.MergeThread:
+			//line auth.qtpl:156
+			qw422016.E().S(ln.UI["mergeThread"])
+		//line auth.qtpl:157
+		case common.ShadowBanPost:
+			//line auth.qtpl:158
+			qw422016.E().S(ln.UI["shadowBan"])
+		//line auth.qtpl:159
+		case common.UnhidePost:
+			//line auth.qtpl:160
+			qw422016.E().S(ln.UI["unhidePost"])
+			//line auth.qtpl:161
 		}
-		//line auth.qtpl:139
+		//line auth.qtpl:161
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:141
+		//line auth.qtpl:163
 		qw422016.E().S(l.By)
-		//line auth.qtpl:141
+		//line auth.qtpl:163
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:143
+		//line auth.qtpl:165
 		if l.ID != 0 {
-			//line auth.qtpl:144
+			//line auth.qtpl:166
 			streamstaticPostLink(qw422016, l.ID)
-			//line auth.qtpl:145
+			//line auth.qtpl:167
 		}
-		//line auth.qtpl:145
+		//line auth.qtpl:167
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:147
+		//line auth.qtpl:169
 		qw422016.E().S(l.Created.Format(time.UnixDate))
-		//line auth.qtpl:147
+		//line auth.qtpl:169
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:148
+		//line auth.qtpl:170
 		qw422016.E().S(l.Data)
-		//line auth.qtpl:148
+		//line auth.qtpl:170
 		qw422016.N().S(`</td><td>`)
-		//line auth.qtpl:150
+		//line auth.qtpl:172
 		if l.Length != 0 {
-			//line auth.qtpl:151
+			//line auth.qtpl:173
 			qw422016.E().S((time.Second * time.Duration(l.Length)).String())
-			//line auth.qtpl:152
+			//line auth.qtpl:174
 		}
-		//line auth.qtpl:152
+		//line auth.qtpl:174
 		qw422016.N().S(`</td></tr>`)
-		//line auth.qtpl:155
+		//line auth.qtpl:177
 	}
-	//line auth.qtpl:155
+	//line auth.qtpl:177
 	qw422016.N().S(`</table>`)
-	//line auth.qtpl:157
+	//line auth.qtpl:179
+	if pages > 1 {
+		//line auth.qtpl:180
+		streampagination(qw422016, page, pages)
+		//line auth.qtpl:181
+	}
+	//line auth.qtpl:182
 	streamhtmlEnd(qw422016)
-//line auth.qtpl:158
+//line auth.qtpl:183
 }
 
-//line auth.qtpl:158
-func WriteModLog(qq422016 qtio422016.Writer, log []auth.ModLogEntry) {
-	//line auth.qtpl:158
+//line auth.qtpl:183
+func WriteModLog(qq422016 qtio422016.Writer, log []auth.ModLogEntry, page, pages int, ln lang.Pack) {
+	//line auth.qtpl:183
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line auth.qtpl:158
-	StreamModLog(qw422016, log)
-	//line auth.qtpl:158
+	//line auth.qtpl:183
+	StreamModLog(qw422016, log, page, pages, ln)
+	//line auth.qtpl:183
 	qt422016.ReleaseWriter(qw422016)
-//line auth.qtpl:158
+//line auth.qtpl:183
 }
 
-//line auth.qtpl:158
-func ModLog(log []auth.ModLogEntry) string {
-	//line auth.qtpl:158
+//line auth.qtpl:183
+func ModLog(log []auth.ModLogEntry, page, pages int, ln lang.Pack) string {
+	//line auth.qtpl:183
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line auth.qtpl:158
-	WriteModLog(qb422016, log)
-	//line auth.qtpl:158
+	//line auth.qtpl:183
+	WriteModLog(qb422016, log, page, pages, ln)
+	//line auth.qtpl:183
 	qs422016 := string(qb422016.B)
-	//line auth.qtpl:158
+	//line auth.qtpl:183
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line auth.qtpl:158
+	//line auth.qtpl:183
 	return qs422016
-//line auth.qtpl:158
+//line auth.qtpl:183
 }
@@ -42,10 +42,9 @@ var (
 )
 
 //line board.qtpl:10
-func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool) {
+func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool, banners []common.AnnouncementBanner, ln lang.Pack) {
 	//line board.qtpl:11
-	ln := lang.Get()
-
+	streamannouncementBanners(qw422016, banners)
 	//line board.qtpl:12
 	bannerID, mime, ok := ass.Banners.Random(conf.ID)
 
@@ -112,17 +111,17 @@ func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title s
 	//line board.qtpl:44
 	qw422016.N().S(`" required type="text" maxlength="100"><br>`)
 	//line board.qtpl:46
-	streamnoscriptPostCreationFields(qw422016, pos)
+	streamnoscriptPostCreationFields(qw422016, pos, ln)
 	//line board.qtpl:47
 	if id == "all" || !conf.TextOnly {
 		//line board.qtpl:48
-		streamuploadForm(qw422016)
+		streamuploadForm(qw422016, ln)
 		//line board.qtpl:49
 	}
 	//line board.qtpl:50
 	streamcaptcha(qw422016, id)
 	//line board.qtpl:51
-	streamsubmit(qw422016, false)
+	streamsubmit(qw422016, false, ln)
 	//line board.qtpl:51
 	qw422016.N().S(`</form></aside><aside id="refresh" class="act glass noscript-hide"><a>`)
 	//line board.qtpl:56
@@ -130,7 +129,7 @@ func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title s
 	//line board.qtpl:56
 	qw422016.N().S(`</a></aside>`)
 	//line board.qtpl:59
-	streamcatalogLink(qw422016, catalog)
+	streamcatalogLink(qw422016, catalog, ln)
 	//line board.qtpl:60
 	if !catalog {
 		//line board.qtpl:61
@@ -184,7 +183,7 @@ func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title s
 	//line board.qtpl:81
 	qw422016.N().S(`</script><hr><span class="aside-container">`)
 	//line board.qtpl:85
-	streamcatalogLink(qw422016, catalog)
+	streamcatalogLink(qw422016, catalog, ln)
 	//line board.qtpl:86
 	if !catalog {
 		//line board.qtpl:87
@@ -199,22 +198,22 @@ func streamrenderBoard(qw422016 *qt422016.Writer, threadHTML []byte, id, title s
 }
 
 //line board.qtpl:91
-func writerenderBoard(qq422016 qtio422016.Writer, threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool) {
+func writerenderBoard(qq422016 qtio422016.Writer, threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool, banners []common.AnnouncementBanner, ln lang.Pack) {
 	//line board.qtpl:91
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line board.qtpl:91
-	streamrenderBoard(qw422016, threadHTML, id, title, conf, page, total, pos, catalog)
+	streamrenderBoard(qw422016, threadHTML, id, title, conf, page, total, pos, catalog, banners, ln)
 	//line board.qtpl:91
 	qt422016.ReleaseWriter(qw422016)
 //line board.qtpl:91
 }
 
 //line board.qtpl:91
-func renderBoard(threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool) string {
+func renderBoard(threadHTML []byte, id, title string, conf config.BoardConfContainer, page, total int, pos auth.ModerationLevel, catalog bool, banners []common.AnnouncementBanner, ln lang.Pack) string {
 	//line board.qtpl:91
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line board.qtpl:91
-	writerenderBoard(qb422016, threadHTML, id, title, conf, page, total, pos, catalog)
+	writerenderBoard(qb422016, threadHTML, id, title, conf, page, total, pos, catalog, banners, ln)
 	//line board.qtpl:91
 	qs422016 := string(qb422016.B)
 	//line board.qtpl:91
@@ -228,7 +227,7 @@ func renderBoard(threadHTML []byte, id, title string, conf config.BoardConfConta
 // allow caching of generated posts.
 
 //line board.qtpl:95
-func StreamCatalogThreads(qw422016 *qt422016.Writer, b []common.Thread, json []byte) {
+func StreamCatalogThreads(qw422016 *qt422016.Writer, b []common.Thread, json []byte, ln lang.Pack) {
 	//line board.qtpl:95
 	qw422016.N().S(`<div id="catalog">`)
 	//line board.qtpl:97
@@ -322,13 +321,13 @@ func StreamCatalogThreads(qw422016 *qt422016.Writer, b []common.Thread, json []b
 		//line board.qtpl:124
 		if !hasImage {
 			//line board.qtpl:125
-			streamexpandLink(qw422016, t.Board, idStr)
+			streamexpandLink(qw422016, t.Board, idStr, ln)
 			//line board.qtpl:126
 		}
 		//line board.qtpl:127
-		streamlast100Link(qw422016, t.Board, idStr)
+		streamlast100Link(qw422016, t.Board, idStr, ln)
 		//line board.qtpl:128
-		streamthreadWatcherToggle(qw422016, t.ID)
+		streamthreadWatcherToggle(qw422016, t.ID, ln)
 		//line board.qtpl:128
 		qw422016.N().S(`</span><br><h3>「`)
 		//line board.qtpl:132
@@ -351,22 +350,22 @@ func StreamCatalogThreads(qw422016 *qt422016.Writer, b []common.Thread, json []b
 }
 
 //line board.qtpl:143
-func WriteCatalogThreads(qq422016 qtio422016.Writer, b []common.Thread, json []byte) {
+func WriteCatalogThreads(qq422016 qtio422016.Writer, b []common.Thread, json []byte, ln lang.Pack) {
 	//line board.qtpl:143
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line board.qtpl:143
-	StreamCatalogThreads(qw422016, b, json)
+	StreamCatalogThreads(qw422016, b, json, ln)
 	//line board.qtpl:143
 	qt422016.ReleaseWriter(qw422016)
 //line board.qtpl:143
 }
 
 //line board.qtpl:143
-func CatalogThreads(b []common.Thread, json []byte) string {
+func CatalogThreads(b []common.Thread, json []byte, ln lang.Pack) string {
 	//line board.qtpl:143
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line board.qtpl:143
-	WriteCatalogThreads(qb422016, b, json)
+	WriteCatalogThreads(qb422016, b, json, ln)
 	//line board.qtpl:143
 	qs422016 := string(qb422016.B)
 	//line board.qtpl:143
@@ -379,7 +378,7 @@ func CatalogThreads(b []common.Thread, json []byte) string {
 // IndexThreads renders abbreviated threads for display on board index pages
 
 //line board.qtpl:146
-func StreamIndexThreads(qw422016 *qt422016.Writer, threads []common.Thread, json []byte) {
+func StreamIndexThreads(qw422016 *qt422016.Writer, threads []common.Thread, json []byte, ln lang.Pack) {
 	//line board.qtpl:147
 	root := config.Get().RootURL
 
@@ -412,7 +411,7 @@ func StreamIndexThreads(qw422016 *qt422016.Writer, threads []common.Thread, json
 		//line board.qtpl:153
 		streamdeletedToggle(qw422016)
 		//line board.qtpl:154
-		streamrenderThreadPosts(qw422016, t, bls, root, true)
+		streamrenderThreadPosts(qw422016, t, bls, root, true, ln)
 		//line board.qtpl:154
 		qw422016.N().S(`<hr></section>`)
 		//line board.qtpl:157
@@ -431,22 +430,22 @@ func StreamIndexThreads(qw422016 *qt422016.Writer, threads []common.Thread, json
 }
 
 //line board.qtpl:163
-func WriteIndexThreads(qq422016 qtio422016.Writer, threads []common.Thread, json []byte) {
+func WriteIndexThreads(qq422016 qtio422016.Writer, threads []common.Thread, json []byte, ln lang.Pack) {
 	//line board.qtpl:163
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line board.qtpl:163
-	StreamIndexThreads(qw422016, threads, json)
+	StreamIndexThreads(qw422016, threads, json, ln)
 	//line board.qtpl:163
 	qt422016.ReleaseWriter(qw422016)
 //line board.qtpl:163
 }
 
 //line board.qtpl:163
-func IndexThreads(threads []common.Thread, json []byte) string {
+func IndexThreads(threads []common.Thread, json []byte, ln lang.Pack) string {
 	//line board.qtpl:163
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line board.qtpl:163
-	WriteIndexThreads(qb422016, threads, json)
+	WriteIndexThreads(qb422016, threads, json, ln)
 	//line board.qtpl:163
 	qs422016 := string(qb422016.B)
 	//line board.qtpl:163
@@ -459,266 +458,260 @@ func IndexThreads(threads []common.Thread, json []byte) string {
 // Render noscript-specific post creation fields
 
 //line board.qtpl:166
-func streamnoscriptPostCreationFields(qw422016 *qt422016.Writer, pos auth.ModerationLevel) {
+func streamnoscriptPostCreationFields(qw422016 *qt422016.Writer, pos auth.ModerationLevel, ln lang.Pack) {
 	//line board.qtpl:167
-	ln := lang.Get()
-
-	//line board.qtpl:168
 	if pos > auth.NotStaff {
-		//line board.qtpl:169
+		//line board.qtpl:168
 		streaminput(qw422016, staffTitleSpec.wrap(), ln)
-		//line board.qtpl:170
+		//line board.qtpl:169
 	}
-	//line board.qtpl:171
+	//line board.qtpl:170
 	for _, s := range specs["noscriptPostCreation"] {
-		//line board.qtpl:172
+		//line board.qtpl:171
 		streaminput(qw422016, s, ln)
-		//line board.qtpl:173
+		//line board.qtpl:172
 	}
-//line board.qtpl:174
+//line board.qtpl:173
 }
 
-//line board.qtpl:174
-func writenoscriptPostCreationFields(qq422016 qtio422016.Writer, pos auth.ModerationLevel) {
-	//line board.qtpl:174
+//line board.qtpl:173
+func writenoscriptPostCreationFields(qq422016 qtio422016.Writer, pos auth.ModerationLevel, ln lang.Pack) {
+	//line board.qtpl:173
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line board.qtpl:174
-	streamnoscriptPostCreationFields(qw422016, pos)
-	//line board.qtpl:174
+	//line board.qtpl:173
+	streamnoscriptPostCreationFields(qw422016, pos, ln)
+	//line board.qtpl:173
 	qt422016.ReleaseWriter(qw422016)
-//line board.qtpl:174
+//line board.qtpl:173
 }
 
-//line board.qtpl:174
-func noscriptPostCreationFields(pos auth.ModerationLevel) string {
-	//line board.qtpl:174
+//line board.qtpl:173
+func noscriptPostCreationFields(pos auth.ModerationLevel, ln lang.Pack) string {
+	//line board.qtpl:173
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line board.qtpl:174
-	writenoscriptPostCreationFields(qb422016, pos)
-	//line board.qtpl:174
+	//line board.qtpl:173
+	writenoscriptPostCreationFields(qb422016, pos, ln)
+	//line board.qtpl:173
 	qs422016 := string(qb422016.B)
-	//line board.qtpl:174
+	//line board.qtpl:173
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line board.qtpl:174
+	//line board.qtpl:173
 	return qs422016
-//line board.qtpl:174
+//line board.qtpl:173
 }
 
 // Render image upload form
 
-//line board.qtpl:177
-func streamuploadForm(qw422016 *qt422016.Writer) {
-	//line board.qtpl:177
+//line board.qtpl:176
+func streamuploadForm(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line board.qtpl:176
 	qw422016.N().S(`<span class="upload-container"><span data-id="spoiler"><label><input type="checkbox" name="spoiler">`)
-	//line board.qtpl:182
-	qw422016.N().S(lang.Get().Common.Posts["spoiler"])
-	//line board.qtpl:182
+	//line board.qtpl:181
+	qw422016.N().S(ln.Common.Posts["spoiler"])
+	//line board.qtpl:181
 	qw422016.N().S(`</label></span><br><input type="file" name="image" accept="image/png, image/gif, image/jpeg, video/webm, video/ogg, audio/ogg, application/ogg, video/mp4, audio/mp4, audio/mp3, application/zip, application/x-7z-compressed, application/x-xz, application/x-gzip, audio/x-flac, text/plain, application/pdf, video/quicktime, audio/x-flac"><br></span>`)
-//line board.qtpl:189
+//line board.qtpl:188
 }
 
-//line board.qtpl:189
-func writeuploadForm(qq422016 qtio422016.Writer) {
-	//line board.qtpl:189
+//line board.qtpl:188
+func writeuploadForm(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line board.qtpl:188
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line board.qtpl:189
-	streamuploadForm(qw422016)
-	//line board.qtpl:189
+	//line board.qtpl:188
+	streamuploadForm(qw422016, ln)
+	//line board.qtpl:188
 	qt422016.ReleaseWriter(qw422016)
-//line board.qtpl:189
+//line board.qtpl:188
 }
 
-//line board.qtpl:189
-func uploadForm() string {
-	//line board.qtpl:189
+//line board.qtpl:188
+func uploadForm(ln lang.Pack) string {
+	//line board.qtpl:188
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line board.qtpl:189
-	writeuploadForm(qb422016)
-	//line board.qtpl:189
+	//line board.qtpl:188
+	writeuploadForm(qb422016, ln)
+	//line board.qtpl:188
 	qs422016 := string(qb422016.B)
-	//line board.qtpl:189
+	//line board.qtpl:188
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line board.qtpl:189
+	//line board.qtpl:188
 	return qs422016
-//line board.qtpl:189
+//line board.qtpl:188
 }
 
 // Link to catalog or board page
 
-//line board.qtpl:192
-func streamcatalogLink(qw422016 *qt422016.Writer, catalog bool) {
-	//line board.qtpl:193
-	ln := lang.Get().Common.UI
-
-	//line board.qtpl:193
+//line board.qtpl:191
+func streamcatalogLink(qw422016 *qt422016.Writer, catalog bool, ln lang.Pack) {
+	//line board.qtpl:191
 	qw422016.N().S(`<aside class="act glass">`)
-	//line board.qtpl:195
+	//line board.qtpl:193
 	if catalog {
-		//line board.qtpl:195
+		//line board.qtpl:193
 		qw422016.N().S(`<a href=".">`)
+		//line board.qtpl:195
+		qw422016.N().S(ln.Common.UI["return"])
+		//line board.qtpl:195
+		qw422016.N().S(`</a>`)
 		//line board.qtpl:197
-		qw422016.N().S(ln["return"])
+	} else {
 		//line board.qtpl:197
-		qw422016.N().S(`</a>`)
+		qw422016.N().S(`<a href="catalog">`)
 		//line board.qtpl:199
-	} else {
+		qw422016.N().S(ln.Common.UI["catalog"])
 		//line board.qtpl:199
-		qw422016.N().S(`<a href="catalog">`)
-		//line board.qtpl:201
-		qw422016.N().S(ln["catalog"])
-		//line board.qtpl:201
 		qw422016.N().S(`</a>`)
-		//line board.qtpl:203
+		//line board.qtpl:201
 	}
-	//line board.qtpl:203
+	//line board.qtpl:201
 	qw422016.N().S(`</aside>`)
-//line board.qtpl:205
+//line board.qtpl:203
 }
 
-//line board.qtpl:205
-func writecatalogLink(qq422016 qtio422016.Writer, catalog bool) {
-	//line board.qtpl:205
+//line board.qtpl:203
+func writecatalogLink(qq422016 qtio422016.Writer, catalog bool, ln lang.Pack) {
+	//line board.qtpl:203
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line board.qtpl:205
-	streamcatalogLink(qw422016, catalog)
-	//line board.qtpl:205
+	//line board.qtpl:203
+	streamcatalogLink(qw422016, catalog, ln)
+	//line board.qtpl:203
 	qt422016.ReleaseWriter(qw422016)
-//line board.qtpl:205
+//line board.qtpl:203
 }
 
-//line board.qtpl:205
-func catalogLink(catalog bool) string {
-	//line board.qtpl:205
+//line board.qtpl:203
+func catalogLink(catalog bool, ln lang.Pack) string {
+	//line board.qtpl:203
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line board.qtpl:205
-	writecatalogLink(qb422016, catalog)
-	//line board.qtpl:205
+	//line board.qtpl:203
+	writecatalogLink(qb422016, catalog, ln)
+	//line board.qtpl:203
 	qs422016 := string(qb422016.B)
-	//line board.qtpl:205
+	//line board.qtpl:203
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line board.qtpl:205
+	//line board.qtpl:203
 	return qs422016
-//line board.qtpl:205
+//line board.qtpl:203
 }
 
 // Links to different pages of the board index
 
-//line board.qtpl:208
+//line board.qtpl:206
 func streampagination(qw422016 *qt422016.Writer, page, total int) {
-	//line board.qtpl:208
+	//line board.qtpl:206
 	qw422016.N().S(`<aside class="glass spaced">`)
-	//line board.qtpl:210
+	//line board.qtpl:208
 	if page != 0 {
-		//line board.qtpl:211
+		//line board.qtpl:209
 		if page-1 != 0 {
-			//line board.qtpl:212
+			//line board.qtpl:210
 			streampageLink(qw422016, 0, "<<")
-			//line board.qtpl:213
+			//line board.qtpl:211
 		}
-		//line board.qtpl:214
+		//line board.qtpl:212
 		streampageLink(qw422016, page-1, "<")
-		//line board.qtpl:215
+		//line board.qtpl:213
 	}
-	//line board.qtpl:216
+	//line board.qtpl:214
 	for i := 0; i < total; i++ {
-		//line board.qtpl:217
+		//line board.qtpl:215
 		if i != page {
-			//line board.qtpl:218
+			//line board.qtpl:216
 			streampageLink(qw422016, i, strconv.Itoa(i))
-			//line board.qtpl:219
+			//line board.qtpl:217
 		} else {
-			//line board.qtpl:219
+			//line board.qtpl:217
 			qw422016.N().S(`<b>`)
-			//line board.qtpl:221
+			//line board.qtpl:219
 			qw422016.N().D(i)
-			//line board.qtpl:221
+			//line board.qtpl:219
 			qw422016.N().S(`</b>`)
-			//line board.qtpl:223
+			//line board.qtpl:221
 		}
-		//line board.qtpl:224
+		//line board.qtpl:222
 	}
-	//line board.qtpl:225
+	//line board.qtpl:223
 	if page != total-1 {
-		//line board.qtpl:226
+		//line board.qtpl:224
 		streampageLink(qw422016, page+1, ">")
-		//line board.qtpl:227
+		//line board.qtpl:225
 		if page+1 != total-1 {
-			//line board.qtpl:228
+			//line board.qtpl:226
 			streampageLink(qw422016, total-1, ">>")
-			//line board.qtpl:229
+			//line board.qtpl:227
 		}
-		//line board.qtpl:230
+		//line board.qtpl:228
 	}
-	//line board.qtpl:230
+	//line board.qtpl:228
 	qw422016.N().S(`</aside>`)
-//line board.qtpl:232
+//line board.qtpl:230
 }
 
-//line board.qtpl:232
+//line board.qtpl:230
 func writepagination(qq422016 qtio422016.Writer, page, total int) {
-	//line board.qtpl:232
+	//line board.qtpl:230
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line board.qtpl:232
+	//line board.qtpl:230
 	streampagination(qw422016, page, total)
-	//line board.qtpl:232
+	//line board.qtpl:230
 	qt422016.ReleaseWriter(qw422016)
-//line board.qtpl:232
+//line board.qtpl:230
 }
 
-//line board.qtpl:232
+//line board.qtpl:230
 func pagination(page, total int) string {
-	//line board.qtpl:232
+	//line board.qtpl:230
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line board.qtpl:232
+	//line board.qtpl:230
 	writepagination(qb422016, page, total)
-	//line board.qtpl:232
+	//line board.qtpl:230
 	qs422016 := string(qb422016.B)
-	//line board.qtpl:232
+	//line board.qtpl:230
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line board.qtpl:232
+	//line board.qtpl:230
 	return qs422016
-//line board.qtpl:232
+//line board.qtpl:230
 }
 
 // Link to a different paginated board page
 
-//line board.qtpl:235
+//line board.qtpl:233
 func streampageLink(qw422016 *qt422016.Writer, i int, text string) {
-	//line board.qtpl:235
+	//line board.qtpl:233
 	qw422016.N().S(`<a href="?page=`)
-	//line board.qtpl:236
+	//line board.qtpl:234
 	qw422016.N().D(i)
-	//line board.qtpl:236
+	//line board.qtpl:234
 	qw422016.N().S(`">`)
-	//line board.qtpl:237
+	//line board.qtpl:235
 	qw422016.N().S(text)
-	//line board.qtpl:237
+	//line board.qtpl:235
 	qw422016.N().S(`</a>`)
-//line board.qtpl:239
+//line board.qtpl:237
 }
 
-//line board.qtpl:239
+//line board.qtpl:237
 func writepageLink(qq422016 qtio422016.Writer, i int, text string) {
-	//line board.qtpl:239
+	//line board.qtpl:237
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line board.qtpl:239
+	//line board.qtpl:237
 	streampageLink(qw422016, i, text)
-	//line board.qtpl:239
+	//line board.qtpl:237
 	qt422016.ReleaseWriter(qw422016)
-//line board.qtpl:239
+//line board.qtpl:237
 }
 
-//line board.qtpl:239
+//line board.qtpl:237
 func pageLink(i int, text string) string {
-	//line board.qtpl:239
+	//line board.qtpl:237
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line board.qtpl:239
+	//line board.qtpl:237
 	writepageLink(qb422016, i, text)
-	//line board.qtpl:239
+	//line board.qtpl:237
 	qs422016 := string(qb422016.B)
-	//line board.qtpl:239
+	//line board.qtpl:237
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line board.qtpl:239
+	//line board.qtpl:237
 	return qs422016
-//line board.qtpl:239
+//line board.qtpl:237
 }
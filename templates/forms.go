@@ -6,18 +6,19 @@ import (
 	"fmt"
 	"io"
 	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/lang"
 	"reflect"
 	"sort"
 	"strings"
 )
 
 // ConfigureBoard renders a form for setting board configurations
-func ConfigureBoard(w io.Writer, conf config.BoardConfigs) {
-	configurationTable(w, reflect.ValueOf(conf), "configureBoard", true)
+func ConfigureBoard(w io.Writer, conf config.BoardConfigs, ln lang.Pack) {
+	configurationTable(w, reflect.ValueOf(conf), "configureBoard", true, ln)
 }
 
 func configurationTable(w io.Writer, v reflect.Value, key string,
-	needCaptcha bool,
+	needCaptcha bool, ln lang.Pack,
 ) {
 	// Copy over all spec structs, so the mutations don't affect them
 	noValues := specs[key]
@@ -39,22 +40,22 @@ func configurationTable(w io.Writer, v reflect.Value, key string,
 		withValues[i].Val = v.Interface()
 	}
 
-	writetableForm(w, withValues, needCaptcha)
+	writetableForm(w, withValues, needCaptcha, ln)
 }
 
 // ConfigureServer renders the form for changing server configurations
-func ConfigureServer(w io.Writer, conf config.Configs) {
-	configurationTable(w, reflect.ValueOf(conf), "configureServer", false)
+func ConfigureServer(w io.Writer, conf config.Configs, ln lang.Pack) {
+	configurationTable(w, reflect.ValueOf(conf), "configureServer", false, ln)
 }
 
 // ChangePassword renders a form for changing an account's password
-func ChangePassword(w io.Writer) {
-	writetableForm(w, specs["changePassword"], true)
+func ChangePassword(w io.Writer, ln lang.Pack) {
+	writetableForm(w, specs["changePassword"], true, ln)
 }
 
 // StaffAssignment renders a staff assignment form with the current staff
 // already filled in
-func StaffAssignment(w io.Writer, staff [3][]string) {
+func StaffAssignment(w io.Writer, staff [3][]string, ln lang.Pack) {
 	var specs [3]inputSpec
 	for i, id := range [3]string{"owners", "moderators", "janitors"} {
 		sort.Strings(staff[i])
@@ -65,5 +66,5 @@ func StaffAssignment(w io.Writer, staff [3][]string) {
 		}
 	}
 
-	writetableForm(w, specs[:], true)
+	writetableForm(w, specs[:], true, ln)
 }
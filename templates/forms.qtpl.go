@@ -26,7 +26,7 @@ var (
 )
 
 //line forms.qtpl:5
-func StreamOwnedBoard(qw422016 *qt422016.Writer, boards config.BoardTitles) {
+func StreamOwnedBoard(qw422016 *qt422016.Writer, boards config.BoardTitles, ln lang.Pack) {
 	//line forms.qtpl:6
 	if len(boards) != 0 {
 		//line forms.qtpl:6
@@ -48,15 +48,15 @@ func StreamOwnedBoard(qw422016 *qt422016.Writer, boards config.BoardTitles) {
 		//line forms.qtpl:12
 		qw422016.N().S(`</select><br>`)
 		//line forms.qtpl:15
-		streamsubmit(qw422016, true)
+		streamsubmit(qw422016, true, ln)
 		//line forms.qtpl:16
 	} else {
 		//line forms.qtpl:17
-		qw422016.N().S(lang.Get().UI["ownNoBoards"])
+		qw422016.N().S(ln.UI["ownNoBoards"])
 		//line forms.qtpl:17
 		qw422016.N().S(`<br><br>`)
 		//line forms.qtpl:20
-		streamcancel(qw422016)
+		streamcancel(qw422016, ln)
 		//line forms.qtpl:20
 		qw422016.N().S(`<div class="form-response admin"></div>`)
 		//line forms.qtpl:22
@@ -65,22 +65,22 @@ func StreamOwnedBoard(qw422016 *qt422016.Writer, boards config.BoardTitles) {
 }
 
 //line forms.qtpl:23
-func WriteOwnedBoard(qq422016 qtio422016.Writer, boards config.BoardTitles) {
+func WriteOwnedBoard(qq422016 qtio422016.Writer, boards config.BoardTitles, ln lang.Pack) {
 	//line forms.qtpl:23
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line forms.qtpl:23
-	StreamOwnedBoard(qw422016, boards)
+	StreamOwnedBoard(qw422016, boards, ln)
 	//line forms.qtpl:23
 	qt422016.ReleaseWriter(qw422016)
 //line forms.qtpl:23
 }
 
 //line forms.qtpl:23
-func OwnedBoard(boards config.BoardTitles) string {
+func OwnedBoard(boards config.BoardTitles, ln lang.Pack) string {
 	//line forms.qtpl:23
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line forms.qtpl:23
-	WriteOwnedBoard(qb422016, boards)
+	WriteOwnedBoard(qb422016, boards, ln)
 	//line forms.qtpl:23
 	qs422016 := string(qb422016.B)
 	//line forms.qtpl:23
@@ -138,638 +138,629 @@ func formatTitle(id, title string) string {
 // BoardNavigation renders a board selection and search form
 
 //line forms.qtpl:30
-func StreamBoardNavigation(qw422016 *qt422016.Writer) {
+func StreamBoardNavigation(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:30
+	qw422016.N().S(`<input type="text" class="full-width" name="search" placeholder="`)
 	//line forms.qtpl:31
-	ln := lang.Get().Common.UI
-
+	qw422016.N().S(ln.Common.UI["search"])
 	//line forms.qtpl:31
-	qw422016.N().S(`<input type="text" class="full-width" name="search" placeholder="`)
-	//line forms.qtpl:32
-	qw422016.N().S(ln["search"])
-	//line forms.qtpl:32
 	qw422016.N().S(`"><br><form><span class="flex">`)
-	//line forms.qtpl:36
-	streamsubmit(qw422016, true)
-	//line forms.qtpl:36
+	//line forms.qtpl:35
+	streamsubmit(qw422016, true, ln)
+	//line forms.qtpl:35
 	qw422016.N().S(`<label><input type="checkbox" name="pointToCatalog">`)
-	//line forms.qtpl:39
-	qw422016.N().S(ln["pointToCatalog"])
-	//line forms.qtpl:39
+	//line forms.qtpl:38
+	qw422016.N().S(ln.Common.UI["pointToCatalog"])
+	//line forms.qtpl:38
 	qw422016.N().S(`</label></span><div class="board-list">`)
-	//line forms.qtpl:43
+	//line forms.qtpl:42
 	for _, b := range config.GetBoardTitles() {
-		//line forms.qtpl:43
+		//line forms.qtpl:42
 		qw422016.N().S(`<label class="board"><input type="checkbox" name="`)
-		//line forms.qtpl:45
+		//line forms.qtpl:44
 		qw422016.N().S(b.ID)
-		//line forms.qtpl:45
+		//line forms.qtpl:44
 		qw422016.N().S(`"><a href="/`)
-		//line forms.qtpl:46
+		//line forms.qtpl:45
 		qw422016.N().S(b.ID)
-		//line forms.qtpl:46
+		//line forms.qtpl:45
 		qw422016.N().S(`/">`)
-		//line forms.qtpl:47
+		//line forms.qtpl:46
 		streamformatTitle(qw422016, b.ID, b.Title)
-		//line forms.qtpl:47
+		//line forms.qtpl:46
 		qw422016.N().S(`</a><br></label>`)
-		//line forms.qtpl:51
+		//line forms.qtpl:50
 	}
-	//line forms.qtpl:51
+	//line forms.qtpl:50
 	qw422016.N().S(`</div></form>`)
-//line forms.qtpl:54
+//line forms.qtpl:53
 }
 
-//line forms.qtpl:54
-func WriteBoardNavigation(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:54
+//line forms.qtpl:53
+func WriteBoardNavigation(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:53
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:54
-	StreamBoardNavigation(qw422016)
-	//line forms.qtpl:54
+	//line forms.qtpl:53
+	StreamBoardNavigation(qw422016, ln)
+	//line forms.qtpl:53
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:54
+//line forms.qtpl:53
 }
 
-//line forms.qtpl:54
-func BoardNavigation() string {
-	//line forms.qtpl:54
+//line forms.qtpl:53
+func BoardNavigation(ln lang.Pack) string {
+	//line forms.qtpl:53
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:54
-	WriteBoardNavigation(qb422016)
-	//line forms.qtpl:54
+	//line forms.qtpl:53
+	WriteBoardNavigation(qb422016, ln)
+	//line forms.qtpl:53
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:54
+	//line forms.qtpl:53
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:54
+	//line forms.qtpl:53
 	return qs422016
-//line forms.qtpl:54
+//line forms.qtpl:53
 }
 
 // CreateBoard renders a the form for creating new boards
 
-//line forms.qtpl:57
-func StreamCreateBoard(qw422016 *qt422016.Writer) {
+//line forms.qtpl:56
+func StreamCreateBoard(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:57
+	streamtable(qw422016, specs["createBoard"], ln)
 	//line forms.qtpl:58
-	streamtable(qw422016, specs["createBoard"])
-	//line forms.qtpl:59
-	StreamCaptchaConfirmation(qw422016)
-//line forms.qtpl:60
+	StreamCaptchaConfirmation(qw422016, ln)
+//line forms.qtpl:59
 }
 
-//line forms.qtpl:60
-func WriteCreateBoard(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:60
+//line forms.qtpl:59
+func WriteCreateBoard(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:59
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:60
-	StreamCreateBoard(qw422016)
-	//line forms.qtpl:60
+	//line forms.qtpl:59
+	StreamCreateBoard(qw422016, ln)
+	//line forms.qtpl:59
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:60
+//line forms.qtpl:59
 }
 
-//line forms.qtpl:60
-func CreateBoard() string {
-	//line forms.qtpl:60
+//line forms.qtpl:59
+func CreateBoard(ln lang.Pack) string {
+	//line forms.qtpl:59
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:60
-	WriteCreateBoard(qb422016)
-	//line forms.qtpl:60
+	//line forms.qtpl:59
+	WriteCreateBoard(qb422016, ln)
+	//line forms.qtpl:59
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:60
+	//line forms.qtpl:59
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:60
+	//line forms.qtpl:59
 	return qs422016
-//line forms.qtpl:60
+//line forms.qtpl:59
 }
 
 // CaptchaConfirmation renders a confirmation form with an optional captcha
 
-//line forms.qtpl:63
-func StreamCaptchaConfirmation(qw422016 *qt422016.Writer) {
-	//line forms.qtpl:64
+//line forms.qtpl:62
+func StreamCaptchaConfirmation(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:63
 	streamcaptcha(qw422016, "all")
-	//line forms.qtpl:65
-	streamsubmit(qw422016, true)
-//line forms.qtpl:66
+	//line forms.qtpl:64
+	streamsubmit(qw422016, true, ln)
+//line forms.qtpl:65
 }
 
-//line forms.qtpl:66
-func WriteCaptchaConfirmation(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:66
+//line forms.qtpl:65
+func WriteCaptchaConfirmation(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:65
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:66
-	StreamCaptchaConfirmation(qw422016)
-	//line forms.qtpl:66
+	//line forms.qtpl:65
+	StreamCaptchaConfirmation(qw422016, ln)
+	//line forms.qtpl:65
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:66
+//line forms.qtpl:65
 }
 
-//line forms.qtpl:66
-func CaptchaConfirmation() string {
-	//line forms.qtpl:66
+//line forms.qtpl:65
+func CaptchaConfirmation(ln lang.Pack) string {
+	//line forms.qtpl:65
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:66
-	WriteCaptchaConfirmation(qb422016)
-	//line forms.qtpl:66
+	//line forms.qtpl:65
+	WriteCaptchaConfirmation(qb422016, ln)
+	//line forms.qtpl:65
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:66
+	//line forms.qtpl:65
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:66
+	//line forms.qtpl:65
 	return qs422016
-//line forms.qtpl:66
+//line forms.qtpl:65
 }
 
-//line forms.qtpl:68
+//line forms.qtpl:67
 func streamcaptcha(qw422016 *qt422016.Writer, board string) {
-	//line forms.qtpl:69
+	//line forms.qtpl:68
 	if !config.Get().Captcha {
-		//line forms.qtpl:70
+		//line forms.qtpl:69
 		return
-		//line forms.qtpl:71
+		//line forms.qtpl:70
 	}
-	//line forms.qtpl:71
+	//line forms.qtpl:70
 	qw422016.N().S(`<div class="captcha-container full-width"><noscript><iframe width="462" height="525" scrolling="no" marginwidth="0" marginheight="0" src="/api/captcha/`)
-	//line forms.qtpl:74
+	//line forms.qtpl:73
 	qw422016.N().S(board)
-	//line forms.qtpl:74
+	//line forms.qtpl:73
 	qw422016.N().S(`"></iframe></noscript></div>`)
-//line forms.qtpl:77
+//line forms.qtpl:76
 }
 
-//line forms.qtpl:77
+//line forms.qtpl:76
 func writecaptcha(qq422016 qtio422016.Writer, board string) {
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	streamcaptcha(qw422016, board)
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:77
+//line forms.qtpl:76
 }
 
-//line forms.qtpl:77
+//line forms.qtpl:76
 func captcha(board string) string {
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	writecaptcha(qb422016, board)
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:77
+	//line forms.qtpl:76
 	return qs422016
-//line forms.qtpl:77
+//line forms.qtpl:76
 }
 
 // Form for inputting key-value map-like data
 
-//line forms.qtpl:80
+//line forms.qtpl:79
 func streamkeyValueForm(qw422016 *qt422016.Writer, k, v string) {
-	//line forms.qtpl:80
+	//line forms.qtpl:79
 	qw422016.N().S(`<span><input type="text" class="map-field" value="`)
-	//line forms.qtpl:82
+	//line forms.qtpl:81
 	qw422016.E().S(k)
-	//line forms.qtpl:82
+	//line forms.qtpl:81
 	qw422016.N().S(`"><input type="text" class="map-field" value="`)
-	//line forms.qtpl:83
+	//line forms.qtpl:82
 	qw422016.E().S(v)
-	//line forms.qtpl:83
+	//line forms.qtpl:82
 	qw422016.N().S(`"><a class="map-remove">[X]</a><br></span>`)
-//line forms.qtpl:89
+//line forms.qtpl:88
 }
 
-//line forms.qtpl:89
+//line forms.qtpl:88
 func writekeyValueForm(qq422016 qtio422016.Writer, k, v string) {
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	streamkeyValueForm(qw422016, k, v)
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:89
+//line forms.qtpl:88
 }
 
-//line forms.qtpl:89
+//line forms.qtpl:88
 func keyValueForm(k, v string) string {
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	writekeyValueForm(qb422016, k, v)
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:89
+	//line forms.qtpl:88
 	return qs422016
-//line forms.qtpl:89
+//line forms.qtpl:88
 }
 
 // Form for inputting one array-like form item
 
-//line forms.qtpl:92
+//line forms.qtpl:91
 func streamarrayItemForm(qw422016 *qt422016.Writer, v string) {
-	//line forms.qtpl:92
+	//line forms.qtpl:91
 	qw422016.N().S(`<span><input type="text" class="array-field" value="`)
-	//line forms.qtpl:94
+	//line forms.qtpl:93
 	qw422016.E().S(v)
-	//line forms.qtpl:94
+	//line forms.qtpl:93
 	qw422016.N().S(`"><a class="array-remove">[X]</a><br></span>`)
-//line forms.qtpl:100
+//line forms.qtpl:99
 }
 
-//line forms.qtpl:100
+//line forms.qtpl:99
 func writearrayItemForm(qq422016 qtio422016.Writer, v string) {
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	streamarrayItemForm(qw422016, v)
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:100
+//line forms.qtpl:99
 }
 
-//line forms.qtpl:100
+//line forms.qtpl:99
 func arrayItemForm(v string) string {
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	writearrayItemForm(qb422016, v)
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:100
+	//line forms.qtpl:99
 	return qs422016
-//line forms.qtpl:100
+//line forms.qtpl:99
 }
 
 // Form formatted as a table, with cancel and submit buttons
 
-//line forms.qtpl:103
-func streamtableForm(qw422016 *qt422016.Writer, specs []inputSpec, needCaptcha bool) {
+//line forms.qtpl:102
+func streamtableForm(qw422016 *qt422016.Writer, specs []inputSpec, needCaptcha bool, ln lang.Pack) {
+	//line forms.qtpl:103
+	streamtable(qw422016, specs, ln)
 	//line forms.qtpl:104
-	streamtable(qw422016, specs)
-	//line forms.qtpl:105
 	if needCaptcha {
-		//line forms.qtpl:106
+		//line forms.qtpl:105
 		streamcaptcha(qw422016, "all")
-		//line forms.qtpl:107
+		//line forms.qtpl:106
 	}
-	//line forms.qtpl:108
-	streamsubmit(qw422016, true)
-//line forms.qtpl:109
+	//line forms.qtpl:107
+	streamsubmit(qw422016, true, ln)
+//line forms.qtpl:108
 }
 
-//line forms.qtpl:109
-func writetableForm(qq422016 qtio422016.Writer, specs []inputSpec, needCaptcha bool) {
-	//line forms.qtpl:109
+//line forms.qtpl:108
+func writetableForm(qq422016 qtio422016.Writer, specs []inputSpec, needCaptcha bool, ln lang.Pack) {
+	//line forms.qtpl:108
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:109
-	streamtableForm(qw422016, specs, needCaptcha)
-	//line forms.qtpl:109
+	//line forms.qtpl:108
+	streamtableForm(qw422016, specs, needCaptcha, ln)
+	//line forms.qtpl:108
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:109
+//line forms.qtpl:108
 }
 
-//line forms.qtpl:109
-func tableForm(specs []inputSpec, needCaptcha bool) string {
-	//line forms.qtpl:109
+//line forms.qtpl:108
+func tableForm(specs []inputSpec, needCaptcha bool, ln lang.Pack) string {
+	//line forms.qtpl:108
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:109
-	writetableForm(qb422016, specs, needCaptcha)
-	//line forms.qtpl:109
+	//line forms.qtpl:108
+	writetableForm(qb422016, specs, needCaptcha, ln)
+	//line forms.qtpl:108
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:109
+	//line forms.qtpl:108
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:109
+	//line forms.qtpl:108
 	return qs422016
-//line forms.qtpl:109
+//line forms.qtpl:108
 }
 
 // Render a map form for inputting map-like data
 
-//line forms.qtpl:112
-func streamrenderMap(qw422016 *qt422016.Writer, spec inputSpec) {
-	//line forms.qtpl:113
-	ln := lang.Get()
-
-	//line forms.qtpl:113
+//line forms.qtpl:111
+func streamrenderMap(qw422016 *qt422016.Writer, spec inputSpec, ln lang.Pack) {
+	//line forms.qtpl:111
 	qw422016.N().S(`<div class="map-form" name="`)
-	//line forms.qtpl:114
+	//line forms.qtpl:112
 	qw422016.N().S(spec.ID)
-	//line forms.qtpl:114
+	//line forms.qtpl:112
 	qw422016.N().S(`" title="`)
-	//line forms.qtpl:114
+	//line forms.qtpl:112
 	qw422016.N().S(ln.Forms[spec.ID][1])
-	//line forms.qtpl:114
+	//line forms.qtpl:112
 	qw422016.N().S(`">`)
-	//line forms.qtpl:115
+	//line forms.qtpl:113
 	for k, v := range spec.Val.(map[string]string) {
-		//line forms.qtpl:116
+		//line forms.qtpl:114
 		streamkeyValueForm(qw422016, k, v)
-		//line forms.qtpl:117
+		//line forms.qtpl:115
 	}
-	//line forms.qtpl:117
+	//line forms.qtpl:115
 	qw422016.N().S(`<a class="map-add">`)
-	//line forms.qtpl:119
+	//line forms.qtpl:117
 	qw422016.N().S(ln.UI["add"])
-	//line forms.qtpl:119
+	//line forms.qtpl:117
 	qw422016.N().S(`</a><br></div>`)
-//line forms.qtpl:123
+//line forms.qtpl:121
 }
 
-//line forms.qtpl:123
-func writerenderMap(qq422016 qtio422016.Writer, spec inputSpec) {
-	//line forms.qtpl:123
+//line forms.qtpl:121
+func writerenderMap(qq422016 qtio422016.Writer, spec inputSpec, ln lang.Pack) {
+	//line forms.qtpl:121
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:123
-	streamrenderMap(qw422016, spec)
-	//line forms.qtpl:123
+	//line forms.qtpl:121
+	streamrenderMap(qw422016, spec, ln)
+	//line forms.qtpl:121
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:123
+//line forms.qtpl:121
 }
 
-//line forms.qtpl:123
-func renderMap(spec inputSpec) string {
-	//line forms.qtpl:123
+//line forms.qtpl:121
+func renderMap(spec inputSpec, ln lang.Pack) string {
+	//line forms.qtpl:121
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:123
-	writerenderMap(qb422016, spec)
-	//line forms.qtpl:123
+	//line forms.qtpl:121
+	writerenderMap(qb422016, spec, ln)
+	//line forms.qtpl:121
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:123
+	//line forms.qtpl:121
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:123
+	//line forms.qtpl:121
 	return qs422016
-//line forms.qtpl:123
+//line forms.qtpl:121
 }
 
 // Render form for inputting array-like data
 
-//line forms.qtpl:126
-func streamrenderArray(qw422016 *qt422016.Writer, spec inputSpec) {
-	//line forms.qtpl:127
-	ln := lang.Get()
-
-	//line forms.qtpl:127
+//line forms.qtpl:124
+func streamrenderArray(qw422016 *qt422016.Writer, spec inputSpec, ln lang.Pack) {
+	//line forms.qtpl:124
 	qw422016.N().S(`<div class="array-form" name="`)
-	//line forms.qtpl:128
+	//line forms.qtpl:125
 	qw422016.N().S(spec.ID)
-	//line forms.qtpl:128
+	//line forms.qtpl:125
 	qw422016.N().S(`" title="`)
-	//line forms.qtpl:128
+	//line forms.qtpl:125
 	qw422016.N().S(ln.Forms[spec.ID][1])
-	//line forms.qtpl:128
+	//line forms.qtpl:125
 	qw422016.N().S(`">`)
-	//line forms.qtpl:129
+	//line forms.qtpl:126
 	for _, v := range spec.Val.([]string) {
-		//line forms.qtpl:130
+		//line forms.qtpl:127
 		streamarrayItemForm(qw422016, v)
-		//line forms.qtpl:131
+		//line forms.qtpl:128
 	}
-	//line forms.qtpl:131
+	//line forms.qtpl:128
 	qw422016.N().S(`<a class="array-add">`)
-	//line forms.qtpl:133
+	//line forms.qtpl:130
 	qw422016.N().S(ln.UI["add"])
-	//line forms.qtpl:133
+	//line forms.qtpl:130
 	qw422016.N().S(`</a><br></div>`)
-//line forms.qtpl:137
+//line forms.qtpl:134
 }
 
-//line forms.qtpl:137
-func writerenderArray(qq422016 qtio422016.Writer, spec inputSpec) {
-	//line forms.qtpl:137
+//line forms.qtpl:134
+func writerenderArray(qq422016 qtio422016.Writer, spec inputSpec, ln lang.Pack) {
+	//line forms.qtpl:134
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:137
-	streamrenderArray(qw422016, spec)
-	//line forms.qtpl:137
+	//line forms.qtpl:134
+	streamrenderArray(qw422016, spec, ln)
+	//line forms.qtpl:134
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:137
+//line forms.qtpl:134
 }
 
-//line forms.qtpl:137
-func renderArray(spec inputSpec) string {
-	//line forms.qtpl:137
+//line forms.qtpl:134
+func renderArray(spec inputSpec, ln lang.Pack) string {
+	//line forms.qtpl:134
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:137
-	writerenderArray(qb422016, spec)
-	//line forms.qtpl:137
+	//line forms.qtpl:134
+	writerenderArray(qb422016, spec, ln)
+	//line forms.qtpl:134
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:137
+	//line forms.qtpl:134
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:137
+	//line forms.qtpl:134
 	return qs422016
-//line forms.qtpl:137
+//line forms.qtpl:134
 }
 
 // Render submit and cancel buttons
 
-//line forms.qtpl:140
-func streamsubmit(qw422016 *qt422016.Writer, cancel bool) {
-	//line forms.qtpl:140
+//line forms.qtpl:137
+func streamsubmit(qw422016 *qt422016.Writer, cancel bool, ln lang.Pack) {
+	//line forms.qtpl:137
 	qw422016.N().S(`<input type="submit" value="`)
-	//line forms.qtpl:141
-	qw422016.N().S(lang.Get().Common.UI["submit"])
-	//line forms.qtpl:141
+	//line forms.qtpl:138
+	qw422016.N().S(ln.Common.UI["submit"])
+	//line forms.qtpl:138
 	qw422016.N().S(`">`)
-	//line forms.qtpl:142
+	//line forms.qtpl:139
 	if cancel {
-		//line forms.qtpl:143
-		streamcancel(qw422016)
-		//line forms.qtpl:144
+		//line forms.qtpl:140
+		streamcancel(qw422016, ln)
+		//line forms.qtpl:141
 	}
-	//line forms.qtpl:144
+	//line forms.qtpl:141
 	qw422016.N().S(`<div class="form-response admin"></div>`)
-//line forms.qtpl:146
+//line forms.qtpl:143
 }
 
-//line forms.qtpl:146
-func writesubmit(qq422016 qtio422016.Writer, cancel bool) {
-	//line forms.qtpl:146
+//line forms.qtpl:143
+func writesubmit(qq422016 qtio422016.Writer, cancel bool, ln lang.Pack) {
+	//line forms.qtpl:143
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:146
-	streamsubmit(qw422016, cancel)
-	//line forms.qtpl:146
+	//line forms.qtpl:143
+	streamsubmit(qw422016, cancel, ln)
+	//line forms.qtpl:143
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:146
+//line forms.qtpl:143
 }
 
-//line forms.qtpl:146
-func submit(cancel bool) string {
-	//line forms.qtpl:146
+//line forms.qtpl:143
+func submit(cancel bool, ln lang.Pack) string {
+	//line forms.qtpl:143
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:146
-	writesubmit(qb422016, cancel)
-	//line forms.qtpl:146
+	//line forms.qtpl:143
+	writesubmit(qb422016, cancel, ln)
+	//line forms.qtpl:143
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:146
+	//line forms.qtpl:143
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:146
+	//line forms.qtpl:143
 	return qs422016
-//line forms.qtpl:146
+//line forms.qtpl:143
 }
 
 // Renders a cancel button
 
-//line forms.qtpl:149
-func streamcancel(qw422016 *qt422016.Writer) {
-	//line forms.qtpl:149
+//line forms.qtpl:146
+func streamcancel(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:146
 	qw422016.N().S(`<input type="button" name="cancel" value="`)
-	//line forms.qtpl:150
-	qw422016.N().S(lang.Get().Common.UI["cancel"])
-	//line forms.qtpl:150
+	//line forms.qtpl:147
+	qw422016.N().S(ln.Common.UI["cancel"])
+	//line forms.qtpl:147
 	qw422016.N().S(`">`)
-//line forms.qtpl:151
+//line forms.qtpl:148
 }
 
-//line forms.qtpl:151
-func writecancel(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:151
+//line forms.qtpl:148
+func writecancel(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:148
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:151
-	streamcancel(qw422016)
-	//line forms.qtpl:151
+	//line forms.qtpl:148
+	streamcancel(qw422016, ln)
+	//line forms.qtpl:148
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:151
+//line forms.qtpl:148
 }
 
-//line forms.qtpl:151
-func cancel() string {
-	//line forms.qtpl:151
+//line forms.qtpl:148
+func cancel(ln lang.Pack) string {
+	//line forms.qtpl:148
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:151
-	writecancel(qb422016)
-	//line forms.qtpl:151
+	//line forms.qtpl:148
+	writecancel(qb422016, ln)
+	//line forms.qtpl:148
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:151
+	//line forms.qtpl:148
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:151
+	//line forms.qtpl:148
 	return qs422016
-//line forms.qtpl:151
+//line forms.qtpl:148
 }
 
 // Render link to request new noscript captcha
 
-//line forms.qtpl:154
-func StreamNoscriptCaptchaLink(qw422016 *qt422016.Writer, board string) {
-	//line forms.qtpl:154
+//line forms.qtpl:151
+func StreamNoscriptCaptchaLink(qw422016 *qt422016.Writer, board string, ln lang.Pack) {
+	//line forms.qtpl:151
 	qw422016.N().S(`<a href="/api/captcha/`)
-	//line forms.qtpl:155
+	//line forms.qtpl:152
 	qw422016.E().S(board)
-	//line forms.qtpl:155
+	//line forms.qtpl:152
 	qw422016.N().S(`" style="display: flex; width: 100%; height: 100%;"><span style="align-self: center; margin: auto;">`)
-	//line forms.qtpl:157
-	qw422016.N().S(lang.Get().UI["loadCaptcha"])
-	//line forms.qtpl:157
+	//line forms.qtpl:154
+	qw422016.N().S(ln.UI["loadCaptcha"])
+	//line forms.qtpl:154
 	qw422016.N().S(`</span></a>`)
-//line forms.qtpl:160
+//line forms.qtpl:157
 }
 
-//line forms.qtpl:160
-func WriteNoscriptCaptchaLink(qq422016 qtio422016.Writer, board string) {
-	//line forms.qtpl:160
+//line forms.qtpl:157
+func WriteNoscriptCaptchaLink(qq422016 qtio422016.Writer, board string, ln lang.Pack) {
+	//line forms.qtpl:157
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:160
-	StreamNoscriptCaptchaLink(qw422016, board)
-	//line forms.qtpl:160
+	//line forms.qtpl:157
+	StreamNoscriptCaptchaLink(qw422016, board, ln)
+	//line forms.qtpl:157
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:160
+//line forms.qtpl:157
 }
 
-//line forms.qtpl:160
-func NoscriptCaptchaLink(board string) string {
-	//line forms.qtpl:160
+//line forms.qtpl:157
+func NoscriptCaptchaLink(board string, ln lang.Pack) string {
+	//line forms.qtpl:157
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:160
-	WriteNoscriptCaptchaLink(qb422016, board)
-	//line forms.qtpl:160
+	//line forms.qtpl:157
+	WriteNoscriptCaptchaLink(qb422016, board, ln)
+	//line forms.qtpl:157
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:160
+	//line forms.qtpl:157
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:160
+	//line forms.qtpl:157
 	return qs422016
-//line forms.qtpl:160
+//line forms.qtpl:157
 }
 
-//line forms.qtpl:162
-func StreamBannerForm(qw422016 *qt422016.Writer) {
-	//line forms.qtpl:162
+//line forms.qtpl:159
+func StreamBannerForm(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:159
 	qw422016.N().S(`<div style="white-space: normal;">`)
-	//line forms.qtpl:164
-	qw422016.N().S(lang.Get().UI["bannerSpecs"])
-	//line forms.qtpl:164
+	//line forms.qtpl:161
+	qw422016.N().S(ln.UI["bannerSpecs"])
+	//line forms.qtpl:161
 	qw422016.N().S(`</div><br><input type="file" name="banners" multiple accept="image/png, image/gif, image/jpeg, video/webm"><br>`)
-	//line forms.qtpl:169
+	//line forms.qtpl:166
 	streamcaptcha(qw422016, "all")
-	//line forms.qtpl:170
-	streamsubmit(qw422016, true)
-//line forms.qtpl:171
+	//line forms.qtpl:167
+	streamsubmit(qw422016, true, ln)
+//line forms.qtpl:168
 }
 
-//line forms.qtpl:171
-func WriteBannerForm(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:171
+//line forms.qtpl:168
+func WriteBannerForm(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:168
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:171
-	StreamBannerForm(qw422016)
-	//line forms.qtpl:171
+	//line forms.qtpl:168
+	StreamBannerForm(qw422016, ln)
+	//line forms.qtpl:168
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:171
+//line forms.qtpl:168
 }
 
-//line forms.qtpl:171
-func BannerForm() string {
-	//line forms.qtpl:171
+//line forms.qtpl:168
+func BannerForm(ln lang.Pack) string {
+	//line forms.qtpl:168
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:171
-	WriteBannerForm(qb422016)
-	//line forms.qtpl:171
+	//line forms.qtpl:168
+	WriteBannerForm(qb422016, ln)
+	//line forms.qtpl:168
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:171
+	//line forms.qtpl:168
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:171
+	//line forms.qtpl:168
 	return qs422016
-//line forms.qtpl:171
+//line forms.qtpl:168
 }
 
-//line forms.qtpl:173
-func StreamLoadingAnimationForm(qw422016 *qt422016.Writer) {
-	//line forms.qtpl:173
+//line forms.qtpl:170
+func StreamLoadingAnimationForm(qw422016 *qt422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:170
 	qw422016.N().S(`<div style="white-space: normal;">`)
-	//line forms.qtpl:175
-	qw422016.N().S(lang.Get().UI["loadingSpecs"])
-	//line forms.qtpl:175
+	//line forms.qtpl:172
+	qw422016.N().S(ln.UI["loadingSpecs"])
+	//line forms.qtpl:172
 	qw422016.N().S(`</div><br><input type="file" name="image" accept="image/gif, video/webm"><br>`)
-	//line forms.qtpl:180
+	//line forms.qtpl:177
 	streamcaptcha(qw422016, "all")
-	//line forms.qtpl:181
-	streamsubmit(qw422016, true)
-//line forms.qtpl:182
+	//line forms.qtpl:178
+	streamsubmit(qw422016, true, ln)
+//line forms.qtpl:179
 }
 
-//line forms.qtpl:182
-func WriteLoadingAnimationForm(qq422016 qtio422016.Writer) {
-	//line forms.qtpl:182
+//line forms.qtpl:179
+func WriteLoadingAnimationForm(qq422016 qtio422016.Writer, ln lang.Pack) {
+	//line forms.qtpl:179
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line forms.qtpl:182
-	StreamLoadingAnimationForm(qw422016)
-	//line forms.qtpl:182
+	//line forms.qtpl:179
+	StreamLoadingAnimationForm(qw422016, ln)
+	//line forms.qtpl:179
 	qt422016.ReleaseWriter(qw422016)
-//line forms.qtpl:182
+//line forms.qtpl:179
 }
 
-//line forms.qtpl:182
-func LoadingAnimationForm() string {
-	//line forms.qtpl:182
+//line forms.qtpl:179
+func LoadingAnimationForm(ln lang.Pack) string {
+	//line forms.qtpl:179
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line forms.qtpl:182
-	WriteLoadingAnimationForm(qb422016)
-	//line forms.qtpl:182
+	//line forms.qtpl:179
+	WriteLoadingAnimationForm(qb422016, ln)
+	//line forms.qtpl:179
 	qs422016 := string(qb422016.B)
-	//line forms.qtpl:182
+	//line forms.qtpl:179
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line forms.qtpl:182
+	//line forms.qtpl:179
 	return qs422016
-//line forms.qtpl:182
+//line forms.qtpl:179
 }
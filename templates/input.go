@@ -75,9 +75,9 @@ func (w *formWriter) input(spec inputSpec) {
 	case _textarea:
 		w.textArea(spec)
 	case _map:
-		streamrenderMap(&w.Writer, spec)
+		streamrenderMap(&w.Writer, spec, w.lang)
 	case _array:
-		streamrenderArray(&w.Writer, spec)
+		streamrenderArray(&w.Writer, spec, w.lang)
 	case _shortcut:
 		w.N().S("Alt+")
 		cont = true
@@ -224,10 +224,10 @@ func (w *formWriter) label(spec inputSpec, inside *func()) {
 }
 
 // Render a table containing {label input_element} pairs
-func streamtable(qw *quicktemplate.Writer, specs []inputSpec) {
+func streamtable(qw *quicktemplate.Writer, specs []inputSpec, ln lang.Pack) {
 	w := formWriter{
 		Writer: *qw,
-		lang:   lang.Get(),
+		lang:   ln,
 	}
 	w.N().S("<table>")
 
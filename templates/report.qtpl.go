@@ -35,120 +35,117 @@ var (
 )
 
 //line report.qtpl:8
-func StreamReportForm(qw422016 *qt422016.Writer, id uint64) {
+func StreamReportForm(qw422016 *qt422016.Writer, id uint64, ln lang.Pack) {
+	//line report.qtpl:8
+	qw422016.N().S(`<input type=text name=target value="`)
 	//line report.qtpl:9
-	ln := lang.Get().UI
-
+	qw422016.N().S(strconv.FormatUint(id, 10))
 	//line report.qtpl:9
-	qw422016.N().S(`<input type=text name=target value="`)
+	qw422016.N().S(`" hidden><input type=text name=reason placeholder="`)
 	//line report.qtpl:10
-	qw422016.N().S(strconv.FormatUint(id, 10))
+	qw422016.N().S(ln.UI["reason"])
 	//line report.qtpl:10
-	qw422016.N().S(`" hidden><input type=text name=reason placeholder="`)
-	//line report.qtpl:11
-	qw422016.N().S(ln["reason"])
-	//line report.qtpl:11
 	qw422016.N().S(`" maxlength="`)
-	//line report.qtpl:11
+	//line report.qtpl:10
 	qw422016.N().D(common.MaxLenReason)
-	//line report.qtpl:11
+	//line report.qtpl:10
 	qw422016.N().S(`"><br><label><input type=checkbox name=illegal>`)
-	//line report.qtpl:15
-	qw422016.N().S(ln["illegal"])
-	//line report.qtpl:15
+	//line report.qtpl:14
+	qw422016.N().S(ln.UI["illegal"])
+	//line report.qtpl:14
 	qw422016.N().S(`<br></label>`)
-	//line report.qtpl:18
+	//line report.qtpl:17
 	streamcaptcha(qw422016, "all")
-	//line report.qtpl:19
-	streamsubmit(qw422016, true)
-//line report.qtpl:20
+	//line report.qtpl:18
+	streamsubmit(qw422016, true, ln)
+//line report.qtpl:19
 }
 
-//line report.qtpl:20
-func WriteReportForm(qq422016 qtio422016.Writer, id uint64) {
-	//line report.qtpl:20
+//line report.qtpl:19
+func WriteReportForm(qq422016 qtio422016.Writer, id uint64, ln lang.Pack) {
+	//line report.qtpl:19
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line report.qtpl:20
-	StreamReportForm(qw422016, id)
-	//line report.qtpl:20
+	//line report.qtpl:19
+	StreamReportForm(qw422016, id, ln)
+	//line report.qtpl:19
 	qt422016.ReleaseWriter(qw422016)
-//line report.qtpl:20
+//line report.qtpl:19
 }
 
-//line report.qtpl:20
-func ReportForm(id uint64) string {
-	//line report.qtpl:20
+//line report.qtpl:19
+func ReportForm(id uint64, ln lang.Pack) string {
+	//line report.qtpl:19
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line report.qtpl:20
-	WriteReportForm(qb422016, id)
-	//line report.qtpl:20
+	//line report.qtpl:19
+	WriteReportForm(qb422016, id, ln)
+	//line report.qtpl:19
 	qs422016 := string(qb422016.B)
-	//line report.qtpl:20
+	//line report.qtpl:19
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line report.qtpl:20
+	//line report.qtpl:19
 	return qs422016
-//line report.qtpl:20
+//line report.qtpl:19
 }
 
 // Render list of all reports on board
 
-//line report.qtpl:23
-func StreamReportList(qw422016 *qt422016.Writer, reports []auth.Report) {
-	//line report.qtpl:24
+//line report.qtpl:22
+func StreamReportList(qw422016 *qt422016.Writer, reports []auth.Report, ln lang.Pack) {
+	//line report.qtpl:23
 	streamtableStyle(qw422016)
-	//line report.qtpl:24
+	//line report.qtpl:23
 	qw422016.N().S(`<table>`)
+	//line report.qtpl:25
+	streamtableHeaders(qw422016, ln, "id", "post", "reason", "time")
 	//line report.qtpl:26
-	streamtableHeaders(qw422016, "id", "post", "reason", "time")
-	//line report.qtpl:27
 	for _, r := range reports {
-		//line report.qtpl:27
+		//line report.qtpl:26
 		qw422016.N().S(`<tr><td>`)
-		//line report.qtpl:29
+		//line report.qtpl:28
 		qw422016.N().S(strconv.FormatUint(r.ID, 10))
+		//line report.qtpl:28
+		qw422016.N().S(`</td><td>`)
+		//line report.qtpl:29
+		streamstaticPostLink(qw422016, r.Target)
 		//line report.qtpl:29
 		qw422016.N().S(`</td><td>`)
 		//line report.qtpl:30
-		streamstaticPostLink(qw422016, r.Target)
+		qw422016.E().S(r.Reason)
 		//line report.qtpl:30
 		qw422016.N().S(`</td><td>`)
 		//line report.qtpl:31
-		qw422016.E().S(r.Reason)
-		//line report.qtpl:31
-		qw422016.N().S(`</td><td>`)
-		//line report.qtpl:32
 		qw422016.E().S(r.Created.Format(time.UnixDate))
-		//line report.qtpl:32
+		//line report.qtpl:31
 		qw422016.N().S(`</td></tr>`)
-		//line report.qtpl:34
+		//line report.qtpl:33
 	}
-	//line report.qtpl:34
+	//line report.qtpl:33
 	qw422016.N().S(`</table>`)
-//line report.qtpl:36
+//line report.qtpl:35
 }
 
-//line report.qtpl:36
-func WriteReportList(qq422016 qtio422016.Writer, reports []auth.Report) {
-	//line report.qtpl:36
+//line report.qtpl:35
+func WriteReportList(qq422016 qtio422016.Writer, reports []auth.Report, ln lang.Pack) {
+	//line report.qtpl:35
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line report.qtpl:36
-	StreamReportList(qw422016, reports)
-	//line report.qtpl:36
+	//line report.qtpl:35
+	StreamReportList(qw422016, reports, ln)
+	//line report.qtpl:35
 	qt422016.ReleaseWriter(qw422016)
-//line report.qtpl:36
+//line report.qtpl:35
 }
 
-//line report.qtpl:36
-func ReportList(reports []auth.Report) string {
-	//line report.qtpl:36
+//line report.qtpl:35
+func ReportList(reports []auth.Report, ln lang.Pack) string {
+	//line report.qtpl:35
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line report.qtpl:36
-	WriteReportList(qb422016, reports)
-	//line report.qtpl:36
+	//line report.qtpl:35
+	WriteReportList(qb422016, reports, ln)
+	//line report.qtpl:35
 	qs422016 := string(qb422016.B)
-	//line report.qtpl:36
+	//line report.qtpl:35
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line report.qtpl:36
+	//line report.qtpl:35
 	return qs422016
-//line report.qtpl:36
+//line report.qtpl:35
 }
@@ -123,6 +123,8 @@ var specs = map[string][]inputSpec{
 		{ID: "NSFW"},
 		{ID: "rbText"},
 		{ID: "pyu"},
+		{ID: "posterIDs"},
+		{ID: "hideOpenPosts"},
 		{
 			ID:        "title",
 			Type:      _string,
@@ -141,11 +143,54 @@ var specs = map[string][]inputSpec{
 			MaxLength: common.MaxLenRules,
 		},
 		defaultThemeSpec,
+		{
+			ID:      "defaultLang",
+			Type:    _select,
+			Options: append([]string{""}, common.Langs...),
+		},
+		{ID: "radioBanner"},
+		{
+			ID:      "visibility",
+			Type:    _select,
+			Options: common.BoardVisibilities,
+		},
 		{
 			ID:        "eightball",
 			Type:      _array,
 			MaxLength: common.MaxLenEightball,
 		},
+		{
+			ID:      "proxyPolicy",
+			Type:    _select,
+			Options: common.ProxyPolicies,
+		},
+		{
+			ID:   "duplicateLimit",
+			Type: _number,
+			Min:  0,
+		},
+		{
+			ID:   "duplicateWindow",
+			Type: _number,
+			Min:  1,
+			Max:  common.MaxDuplicateWindow,
+		},
+		{
+			ID:      "duplicatePolicy",
+			Type:    _select,
+			Options: common.DuplicatePolicies,
+		},
+		{
+			ID:        "webhookURL",
+			Type:      _string,
+			MaxLength: common.MaxLenWebhookURL,
+		},
+		{
+			ID:           "webhookSecret",
+			Type:         _string,
+			MaxLength:    common.MaxLenWebhookSecret,
+			Autocomplete: "off",
+		},
 	},
 	"createBoard": {
 		{
@@ -165,6 +210,18 @@ var specs = map[string][]inputSpec{
 	"configureServer": {
 		{ID: "mature"},
 		{ID: "disableUserBoards"},
+		{
+			ID:   "boardCreators",
+			Type: _array,
+		},
+		{
+			ID:   "deletionWindow",
+			Type: _number,
+		},
+		{
+			ID:   "reclaimWindow",
+			Type: _number,
+		},
 		{ID: "pruneThreads"},
 		{
 			ID:       "threadExpiryMin",
@@ -186,6 +243,7 @@ var specs = map[string][]inputSpec{
 			Required: true,
 		},
 		{ID: "hideNSFW"},
+		{ID: "animatedThumbs"},
 		{
 			ID:   "rootURL",
 			Type: _string,
@@ -198,7 +256,9 @@ var specs = map[string][]inputSpec{
 			ID:   "salt",
 			Type: _string,
 		},
+		{ID: "encryptedThreads"},
 		{ID: "captcha"},
+		{ID: "proofOfWork"},
 		{
 			ID:   "captchaTags",
 			Type: _array,
@@ -207,6 +267,14 @@ var specs = map[string][]inputSpec{
 			ID:   "overrideCaptchaTags",
 			Type: _map,
 		},
+		{
+			ID:   "DNSBLs",
+			Type: _array,
+		},
+		{
+			ID:   "torExitList",
+			Type: _string,
+		},
 		{
 			ID:       "charScore",
 			Type:     _number,
@@ -225,6 +293,26 @@ var specs = map[string][]inputSpec{
 			Min:      0,
 			Required: true,
 		},
+		{ID: "hashIPs"},
+		{ID: "publicModLogStaff"},
+		{ID: "publicImageSearch"},
+		{
+			ID:       "ipRetention",
+			Type:     _number,
+			Min:      1,
+			Required: true,
+		},
+		{
+			ID:   "auditSampling",
+			Type: _number,
+			Min:  0,
+			Max:  100,
+		},
+		{
+			ID:   "resyncThreshold",
+			Type: _number,
+			Min:  0,
+		},
 		{
 			ID:       "sessionExpiry",
 			Type:     _number,
@@ -257,10 +345,37 @@ var specs = map[string][]inputSpec{
 			Min:      0,
 			Required: true,
 		},
+		{
+			ID:   "emailErrCap",
+			Type: _number,
+			Min:  1,
+		},
+		{ID: "webhookErr"},
+		{
+			ID:           "webhookErrURL",
+			Type:         _string,
+			Autocomplete: "off",
+		},
+		{
+			ID:   "webhookErrCap",
+			Type: _number,
+			Min:  1,
+		},
+		{ID: "postWebhooks"},
+		{
+			ID:           "sentryDSN",
+			Type:         _string,
+			Autocomplete: "off",
+		},
 		{
 			ID:   "feedbackEmail",
 			Type: _string,
 		},
+		{
+			ID:           "radioAPI",
+			Type:         _string,
+			Autocomplete: "off",
+		},
 		{
 			ID:      "defaultLang",
 			Type:    _select,
@@ -13,6 +13,7 @@ import (
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/lang"
 	"github.com/bakape/meguca/util"
 )
 
@@ -22,7 +23,8 @@ func init() {
 }
 
 var (
-	indexTemplates map[auth.ModerationLevel][4][]byte
+	// Compiled index templates by language pack ID and moderation level
+	indexTemplates map[string]map[auth.ModerationLevel][4][]byte
 	mu             sync.RWMutex
 )
 
@@ -33,11 +35,16 @@ func Compile() error {
 		auth.BoardOwner, auth.Admin,
 	}
 
-	t := make(map[auth.ModerationLevel][4][]byte, len(levels))
+	t := make(map[string]map[auth.ModerationLevel][4][]byte, len(common.Langs))
 
-	for _, pos := range levels {
-		split := bytes.Split([]byte(renderIndex(pos)), []byte("$$$"))
-		t[pos] = [4][]byte{split[0], split[1], split[2], split[3]}
+	for _, id := range common.Langs {
+		ln := lang.GetByID(id)
+		byPos := make(map[auth.ModerationLevel][4][]byte, len(levels))
+		for _, pos := range levels {
+			split := bytes.Split([]byte(renderIndex(pos, ln)), []byte("$$$"))
+			byPos[pos] = [4][]byte{split[0], split[1], split[2], split[3]}
+		}
+		t[id] = byPos
 	}
 
 	mu.Lock()
@@ -59,44 +66,51 @@ func Recompile() error {
 // Board writes board HTML to w
 func Board(w io.Writer, b, theme string, page, total int,
 	pos auth.ModerationLevel, minimal, catalog bool, threadHTML []byte,
+	banners []common.AnnouncementBanner, ln lang.Pack,
 ) {
 	conf := config.GetBoardConfigs(b)
 	title := html.EscapeString(fmt.Sprintf("/%s/ - %s", b, conf.Title))
 	write := func(w io.Writer) {
 		writerenderBoard(w, threadHTML, b, title, conf, page, total, pos,
-			catalog)
+			catalog, banners, ln)
 	}
 
 	if minimal {
 		write(w)
 	} else {
-		execIndex(w, title, theme, pos, write)
+		execIndex(w, ln, title, theme, pos, write)
 	}
 }
 
 // Thread writes thread page HTML
 func Thread(w io.Writer, id uint64, board, title, theme string, abbrev,
 	locked bool, pos auth.ModerationLevel, postHTML []byte,
+	banners []common.AnnouncementBanner, ln lang.Pack,
 ) {
 	title = html.EscapeString(fmt.Sprintf("/%s/ - %s", board, title))
-	execIndex(w, title, theme, pos, func(w io.Writer) {
-		writerenderThread(w, postHTML, id, board, abbrev, locked, pos)
+	execIndex(w, ln, title, theme, pos, func(w io.Writer) {
+		writerenderThread(w, postHTML, id, board, abbrev, locked, pos,
+			banners, ln)
 	})
 }
 
 // Execute and index template in the second pass
-func execIndex(w io.Writer, title, theme string, pos auth.ModerationLevel,
-	fn func(w io.Writer),
+func execIndex(w io.Writer, ln lang.Pack, title, theme string,
+	pos auth.ModerationLevel, fn func(w io.Writer),
 ) {
 	mu.RLock()
-	t := indexTemplates[pos]
+	t, ok := indexTemplates[ln.ID]
+	if !ok {
+		t = indexTemplates[config.Get().DefaultLang]
+	}
 	mu.RUnlock()
 
-	w.Write(t[0])
+	parts := t[pos]
+	w.Write(parts[0])
 	w.Write([]byte(title))
-	w.Write(t[1])
+	w.Write(parts[1])
 	w.Write([]byte(theme))
-	w.Write(t[2])
+	w.Write(parts[2])
 	fn(w)
-	w.Write(t[3])
+	w.Write(parts[3])
 }
@@ -36,12 +36,11 @@ var (
 )
 
 //line thread.qtpl:8
-func streamrenderThread(qw422016 *qt422016.Writer, postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel) {
+func streamrenderThread(qw422016 *qt422016.Writer, postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel, banners []common.AnnouncementBanner, ln lang.Pack) {
 	//line thread.qtpl:9
-	conf := config.GetBoardConfigs(board)
-
+	streamannouncementBanners(qw422016, banners)
 	//line thread.qtpl:10
-	ln := lang.Get()
+	conf := config.GetBoardConfigs(board)
 
 	//line thread.qtpl:11
 	if !locked {
@@ -58,17 +57,17 @@ func streamrenderThread(qw422016 *qt422016.Writer, postHTML []byte, id uint64, b
 		//line thread.qtpl:15
 		streaminput(qw422016, sageSpec.wrap(), ln)
 		//line thread.qtpl:16
-		streamnoscriptPostCreationFields(qw422016, pos)
+		streamnoscriptPostCreationFields(qw422016, pos, ln)
 		//line thread.qtpl:17
 		if !conf.TextOnly {
 			//line thread.qtpl:18
-			streamuploadForm(qw422016)
+			streamuploadForm(qw422016, ln)
 			//line thread.qtpl:19
 		}
 		//line thread.qtpl:20
 		streamcaptcha(qw422016, board)
 		//line thread.qtpl:21
-		streamsubmit(qw422016, true)
+		streamsubmit(qw422016, true, ln)
 		//line thread.qtpl:21
 		qw422016.N().S(`</form>`)
 		//line thread.qtpl:23
@@ -149,22 +148,22 @@ func streamrenderThread(qw422016 *qt422016.Writer, postHTML []byte, id uint64, b
 }
 
 //line thread.qtpl:87
-func writerenderThread(qq422016 qtio422016.Writer, postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel) {
+func writerenderThread(qq422016 qtio422016.Writer, postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel, banners []common.AnnouncementBanner, ln lang.Pack) {
 	//line thread.qtpl:87
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line thread.qtpl:87
-	streamrenderThread(qw422016, postHTML, id, board, abbrev, locked, pos)
+	streamrenderThread(qw422016, postHTML, id, board, abbrev, locked, pos, banners, ln)
 	//line thread.qtpl:87
 	qt422016.ReleaseWriter(qw422016)
 //line thread.qtpl:87
 }
 
 //line thread.qtpl:87
-func renderThread(postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel) string {
+func renderThread(postHTML []byte, id uint64, board string, abbrev, locked bool, pos auth.ModerationLevel, banners []common.AnnouncementBanner, ln lang.Pack) string {
 	//line thread.qtpl:87
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line thread.qtpl:87
-	writerenderThread(qb422016, postHTML, id, board, abbrev, locked, pos)
+	writerenderThread(qb422016, postHTML, id, board, abbrev, locked, pos, banners, ln)
 	//line thread.qtpl:87
 	qs422016 := string(qb422016.B)
 	//line thread.qtpl:87
@@ -178,7 +177,7 @@ func renderThread(postHTML []byte, id uint64, board string, abbrev, locked bool,
 // caching of generated posts.
 
 //line thread.qtpl:91
-func StreamThreadPosts(qw422016 *qt422016.Writer, t common.Thread, json []byte) {
+func StreamThreadPosts(qw422016 *qt422016.Writer, t common.Thread, json []byte, ln lang.Pack) {
 	//line thread.qtpl:91
 	qw422016.N().S(`<section id="thread-container" data-id="`)
 	//line thread.qtpl:92
@@ -189,7 +188,7 @@ func StreamThreadPosts(qw422016 *qt422016.Writer, t common.Thread, json []byte)
 	bls := extractBacklinks(1<<10, t)
 
 	//line thread.qtpl:94
-	streamrenderThreadPosts(qw422016, t, bls, config.Get().RootURL, false)
+	streamrenderThreadPosts(qw422016, t, bls, config.Get().RootURL, false, ln)
 	//line thread.qtpl:94
 	qw422016.N().S(`<script id="post-data" type="application/json">`)
 	//line thread.qtpl:96
@@ -208,22 +207,22 @@ func StreamThreadPosts(qw422016 *qt422016.Writer, t common.Thread, json []byte)
 }
 
 //line thread.qtpl:103
-func WriteThreadPosts(qq422016 qtio422016.Writer, t common.Thread, json []byte) {
+func WriteThreadPosts(qq422016 qtio422016.Writer, t common.Thread, json []byte, ln lang.Pack) {
 	//line thread.qtpl:103
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line thread.qtpl:103
-	StreamThreadPosts(qw422016, t, json)
+	StreamThreadPosts(qw422016, t, json, ln)
 	//line thread.qtpl:103
 	qt422016.ReleaseWriter(qw422016)
 //line thread.qtpl:103
 }
 
 //line thread.qtpl:103
-func ThreadPosts(t common.Thread, json []byte) string {
+func ThreadPosts(t common.Thread, json []byte, ln lang.Pack) string {
 	//line thread.qtpl:103
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line thread.qtpl:103
-	WriteThreadPosts(qb422016, t, json)
+	WriteThreadPosts(qb422016, t, json, ln)
 	//line thread.qtpl:103
 	qs422016 := string(qb422016.B)
 	//line thread.qtpl:103
@@ -236,7 +235,7 @@ func ThreadPosts(t common.Thread, json []byte) string {
 // Common functionality between index board pages and threads pages
 
 //line thread.qtpl:106
-func streamrenderThreadPosts(qw422016 *qt422016.Writer, t common.Thread, bls backlinks, root string, index bool) {
+func streamrenderThreadPosts(qw422016 *qt422016.Writer, t common.Thread, bls backlinks, root string, index bool, ln lang.Pack) {
 	//line thread.qtpl:107
 	boardConfig := config.GetBoardConfigs(t.Board)
 
@@ -252,96 +251,97 @@ func streamrenderThreadPosts(qw422016 *qt422016.Writer, t common.Thread, bls bac
 		subject:   t.Subject,
 		root:      root,
 		backlinks: bls,
+		lang:      ln,
 	}
 
-	//line thread.qtpl:120
+	//line thread.qtpl:121
 	c.omit, c.imageOmit = CalculateOmit(t)
 
-	//line thread.qtpl:121
+	//line thread.qtpl:122
 	streamrenderArticle(qw422016, t.Post, c)
-	//line thread.qtpl:123
+	//line thread.qtpl:124
 	c.sticky = false
 
-	//line thread.qtpl:124
+	//line thread.qtpl:125
 	c.locked = false
 
-	//line thread.qtpl:125
+	//line thread.qtpl:126
 	c.omit, c.imageOmit = 0, 0
 
-	//line thread.qtpl:126
+	//line thread.qtpl:127
 	c.subject = ""
 
-	//line thread.qtpl:127
+	//line thread.qtpl:128
 	for _, p := range t.Posts {
-		//line thread.qtpl:128
-		streamrenderArticle(qw422016, p, c)
 		//line thread.qtpl:129
+		streamrenderArticle(qw422016, p, c)
+		//line thread.qtpl:130
 	}
-//line thread.qtpl:130
+//line thread.qtpl:131
 }
 
-//line thread.qtpl:130
-func writerenderThreadPosts(qq422016 qtio422016.Writer, t common.Thread, bls backlinks, root string, index bool) {
-	//line thread.qtpl:130
+//line thread.qtpl:131
+func writerenderThreadPosts(qq422016 qtio422016.Writer, t common.Thread, bls backlinks, root string, index bool, ln lang.Pack) {
+	//line thread.qtpl:131
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line thread.qtpl:130
-	streamrenderThreadPosts(qw422016, t, bls, root, index)
-	//line thread.qtpl:130
+	//line thread.qtpl:131
+	streamrenderThreadPosts(qw422016, t, bls, root, index, ln)
+	//line thread.qtpl:131
 	qt422016.ReleaseWriter(qw422016)
-//line thread.qtpl:130
+//line thread.qtpl:131
 }
 
-//line thread.qtpl:130
-func renderThreadPosts(t common.Thread, bls backlinks, root string, index bool) string {
-	//line thread.qtpl:130
+//line thread.qtpl:131
+func renderThreadPosts(t common.Thread, bls backlinks, root string, index bool, ln lang.Pack) string {
+	//line thread.qtpl:131
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line thread.qtpl:130
-	writerenderThreadPosts(qb422016, t, bls, root, index)
-	//line thread.qtpl:130
+	//line thread.qtpl:131
+	writerenderThreadPosts(qb422016, t, bls, root, index, ln)
+	//line thread.qtpl:131
 	qs422016 := string(qb422016.B)
-	//line thread.qtpl:130
+	//line thread.qtpl:131
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line thread.qtpl:130
+	//line thread.qtpl:131
 	return qs422016
-//line thread.qtpl:130
+//line thread.qtpl:131
 }
 
-//line thread.qtpl:132
+//line thread.qtpl:133
 func streamencodeBacklinks(qw422016 *qt422016.Writer, bls backlinks) {
-	//line thread.qtpl:132
+	//line thread.qtpl:133
 	qw422016.N().S(`<script id="backlink-data" type="application/json">`)
-	//line thread.qtpl:134
+	//line thread.qtpl:135
 	buf, _ := json.Marshal(bls)
 
-	//line thread.qtpl:135
+	//line thread.qtpl:136
 	qw422016.N().Z(buf)
-	//line thread.qtpl:135
+	//line thread.qtpl:136
 	qw422016.N().S(`</script>`)
-//line thread.qtpl:137
+//line thread.qtpl:138
 }
 
-//line thread.qtpl:137
+//line thread.qtpl:138
 func writeencodeBacklinks(qq422016 qtio422016.Writer, bls backlinks) {
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	streamencodeBacklinks(qw422016, bls)
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	qt422016.ReleaseWriter(qw422016)
-//line thread.qtpl:137
+//line thread.qtpl:138
 }
 
-//line thread.qtpl:137
+//line thread.qtpl:138
 func encodeBacklinks(bls backlinks) string {
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	writeencodeBacklinks(qb422016, bls)
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	qs422016 := string(qb422016.B)
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line thread.qtpl:137
+	//line thread.qtpl:138
 	return qs422016
-//line thread.qtpl:137
+//line thread.qtpl:138
 }
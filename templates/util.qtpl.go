@@ -181,7 +181,7 @@ func postLink(link common.Link, cross, boardPage bool) string {
 }
 
 //line util.qtpl:41
-func streamexpandLink(qw422016 *qt422016.Writer, board, id string) {
+func streamexpandLink(qw422016 *qt422016.Writer, board, id string, ln lang.Pack) {
 	//line util.qtpl:41
 	qw422016.N().S(`<span class="act"><a href="/`)
 	//line util.qtpl:43
@@ -193,29 +193,29 @@ func streamexpandLink(qw422016 *qt422016.Writer, board, id string) {
 	//line util.qtpl:43
 	qw422016.N().S(`">`)
 	//line util.qtpl:44
-	qw422016.N().S(lang.Get().Common.Posts["expand"])
+	qw422016.N().S(ln.Common.Posts["expand"])
 	//line util.qtpl:44
 	qw422016.N().S(`</a></span>`)
 //line util.qtpl:47
 }
 
 //line util.qtpl:47
-func writeexpandLink(qq422016 qtio422016.Writer, board, id string) {
+func writeexpandLink(qq422016 qtio422016.Writer, board, id string, ln lang.Pack) {
 	//line util.qtpl:47
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line util.qtpl:47
-	streamexpandLink(qw422016, board, id)
+	streamexpandLink(qw422016, board, id, ln)
 	//line util.qtpl:47
 	qt422016.ReleaseWriter(qw422016)
 //line util.qtpl:47
 }
 
 //line util.qtpl:47
-func expandLink(board, id string) string {
+func expandLink(board, id string, ln lang.Pack) string {
 	//line util.qtpl:47
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line util.qtpl:47
-	writeexpandLink(qb422016, board, id)
+	writeexpandLink(qb422016, board, id, ln)
 	//line util.qtpl:47
 	qs422016 := string(qb422016.B)
 	//line util.qtpl:47
@@ -226,7 +226,7 @@ func expandLink(board, id string) string {
 }
 
 //line util.qtpl:49
-func streamlast100Link(qw422016 *qt422016.Writer, board, id string) {
+func streamlast100Link(qw422016 *qt422016.Writer, board, id string, ln lang.Pack) {
 	//line util.qtpl:49
 	qw422016.N().S(`<span class="act"><a href="/`)
 	//line util.qtpl:51
@@ -238,7 +238,7 @@ func streamlast100Link(qw422016 *qt422016.Writer, board, id string) {
 	//line util.qtpl:51
 	qw422016.N().S(`?last=100#bottom">`)
 	//line util.qtpl:52
-	qw422016.N().S(lang.Get().Common.UI["last"])
+	qw422016.N().S(ln.Common.UI["last"])
 	//line util.qtpl:52
 	qw422016.N().S(` `)
 	//line util.qtpl:52
@@ -247,22 +247,22 @@ func streamlast100Link(qw422016 *qt422016.Writer, board, id string) {
 }
 
 //line util.qtpl:55
-func writelast100Link(qq422016 qtio422016.Writer, board, id string) {
+func writelast100Link(qq422016 qtio422016.Writer, board, id string, ln lang.Pack) {
 	//line util.qtpl:55
 	qw422016 := qt422016.AcquireWriter(qq422016)
 	//line util.qtpl:55
-	streamlast100Link(qw422016, board, id)
+	streamlast100Link(qw422016, board, id, ln)
 	//line util.qtpl:55
 	qt422016.ReleaseWriter(qw422016)
 //line util.qtpl:55
 }
 
 //line util.qtpl:55
-func last100Link(board, id string) string {
+func last100Link(board, id string, ln lang.Pack) string {
 	//line util.qtpl:55
 	qb422016 := qt422016.AcquireByteBuffer()
 	//line util.qtpl:55
-	writelast100Link(qb422016, board, id)
+	writelast100Link(qb422016, board, id, ln)
 	//line util.qtpl:55
 	qs422016 := string(qb422016.B)
 	//line util.qtpl:55
@@ -445,296 +445,344 @@ func hoverReveal(tag, text, label string) string {
 //line util.qtpl:94
 }
 
-// Render pin signifying a thread is sticky
+// Announcement banners shown on top of board and thread pages
 
 //line util.qtpl:97
+func streamannouncementBanners(qw422016 *qt422016.Writer, banners []common.AnnouncementBanner) {
+	//line util.qtpl:97
+	qw422016.N().S(`<div id="announcement-banners" class="hide-empty">`)
+	//line util.qtpl:99
+	for _, b := range banners {
+		//line util.qtpl:99
+		qw422016.N().S(`<div class="announcement-banner glass" data-id="`)
+		//line util.qtpl:100
+		qw422016.N().S(strconv.FormatUint(b.ID, 10))
+		//line util.qtpl:100
+		qw422016.N().S(`">`)
+		//line util.qtpl:101
+		qw422016.E().S(b.Body)
+		//line util.qtpl:101
+		qw422016.N().S(`</div>`)
+		//line util.qtpl:103
+	}
+	//line util.qtpl:103
+	qw422016.N().S(`</div>`)
+//line util.qtpl:105
+}
+
+//line util.qtpl:105
+func writeannouncementBanners(qq422016 qtio422016.Writer, banners []common.AnnouncementBanner) {
+	//line util.qtpl:105
+	qw422016 := qt422016.AcquireWriter(qq422016)
+	//line util.qtpl:105
+	streamannouncementBanners(qw422016, banners)
+	//line util.qtpl:105
+	qt422016.ReleaseWriter(qw422016)
+//line util.qtpl:105
+}
+
+//line util.qtpl:105
+func announcementBanners(banners []common.AnnouncementBanner) string {
+	//line util.qtpl:105
+	qb422016 := qt422016.AcquireByteBuffer()
+	//line util.qtpl:105
+	writeannouncementBanners(qb422016, banners)
+	//line util.qtpl:105
+	qs422016 := string(qb422016.B)
+	//line util.qtpl:105
+	qt422016.ReleaseByteBuffer(qb422016)
+	//line util.qtpl:105
+	return qs422016
+//line util.qtpl:105
+}
+
+// Render pin signifying a thread is sticky
+
+//line util.qtpl:108
 func streamrenderSticky(qw422016 *qt422016.Writer, sticky bool) {
-	//line util.qtpl:98
+	//line util.qtpl:109
 	if !sticky {
-		//line util.qtpl:99
+		//line util.qtpl:110
 		return
-		//line util.qtpl:100
+		//line util.qtpl:111
 	}
-	//line util.qtpl:100
+	//line util.qtpl:111
 	qw422016.N().S(`<svg class="sticky" xmlns="http://www.w3.org/2000/svg" width="8" height="8" viewBox="0 0 8 8"><path d="M1.34 0a.5.5 0 0 0 .16 1h.5v2h-1c-.55 0-1 .45-1 1h3v3l.44 1 .56-1v-3h3c0-.55-.45-1-1-1h-1v-2h.5a.5.5 0 1 0 0-1h-4a.5.5 0 0 0-.09 0 .5.5 0 0 0-.06 0z" /></svg>`)
-//line util.qtpl:104
+//line util.qtpl:115
 }
 
-//line util.qtpl:104
+//line util.qtpl:115
 func writerenderSticky(qq422016 qtio422016.Writer, sticky bool) {
-	//line util.qtpl:104
+	//line util.qtpl:115
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:104
+	//line util.qtpl:115
 	streamrenderSticky(qw422016, sticky)
-	//line util.qtpl:104
+	//line util.qtpl:115
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:104
+//line util.qtpl:115
 }
 
-//line util.qtpl:104
+//line util.qtpl:115
 func renderSticky(sticky bool) string {
-	//line util.qtpl:104
+	//line util.qtpl:115
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:104
+	//line util.qtpl:115
 	writerenderSticky(qb422016, sticky)
-	//line util.qtpl:104
+	//line util.qtpl:115
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:104
+	//line util.qtpl:115
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:104
+	//line util.qtpl:115
 	return qs422016
-//line util.qtpl:104
+//line util.qtpl:115
 }
 
 // Render lock signifying a thread has posting disabled
 
-//line util.qtpl:107
+//line util.qtpl:118
 func streamrenderLocked(qw422016 *qt422016.Writer, locked bool) {
-	//line util.qtpl:108
+	//line util.qtpl:119
 	if !locked {
-		//line util.qtpl:109
+		//line util.qtpl:120
 		return
-		//line util.qtpl:110
+		//line util.qtpl:121
 	}
-	//line util.qtpl:110
+	//line util.qtpl:121
 	qw422016.N().S(`<svg class="locked" xmlns="http://www.w3.org/2000/svg" width="8" height="8" viewBox="0 0 8 8"><path d="M3 0c-1.1 0-2 .9-2 2v1h-1v4h6v-4h-1v-1c0-1.1-.9-2-2-2zm0 1c.56 0 1 .44 1 1v1h-2v-1c0-.56.44-1 1-1z" transform="translate(1)" /></svg>`)
-//line util.qtpl:114
+//line util.qtpl:125
 }
 
-//line util.qtpl:114
+//line util.qtpl:125
 func writerenderLocked(qq422016 qtio422016.Writer, locked bool) {
-	//line util.qtpl:114
+	//line util.qtpl:125
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:114
+	//line util.qtpl:125
 	streamrenderLocked(qw422016, locked)
-	//line util.qtpl:114
+	//line util.qtpl:125
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:114
+//line util.qtpl:125
 }
 
-//line util.qtpl:114
+//line util.qtpl:125
 func renderLocked(locked bool) string {
-	//line util.qtpl:114
+	//line util.qtpl:125
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:114
+	//line util.qtpl:125
 	writerenderLocked(qb422016, locked)
-	//line util.qtpl:114
+	//line util.qtpl:125
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:114
+	//line util.qtpl:125
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:114
+	//line util.qtpl:125
 	return qs422016
-//line util.qtpl:114
+//line util.qtpl:125
 }
 
 // Render an image or video asset
 
-//line util.qtpl:117
+//line util.qtpl:128
 func streamasset(qw422016 *qt422016.Writer, url, mime string) {
-	//line util.qtpl:118
+	//line util.qtpl:129
 	if mime == "video/webm" {
-		//line util.qtpl:118
+		//line util.qtpl:129
 		qw422016.N().S(`<video src="`)
-		//line util.qtpl:119
+		//line util.qtpl:130
 		qw422016.N().S(url)
-		//line util.qtpl:119
+		//line util.qtpl:130
 		qw422016.N().S(`" autoplay loop>`)
-		//line util.qtpl:120
+		//line util.qtpl:131
 	} else {
-		//line util.qtpl:120
+		//line util.qtpl:131
 		qw422016.N().S(`<img src="`)
-		//line util.qtpl:121
+		//line util.qtpl:132
 		qw422016.N().S(url)
-		//line util.qtpl:121
+		//line util.qtpl:132
 		qw422016.N().S(`">`)
-		//line util.qtpl:122
+		//line util.qtpl:133
 	}
-//line util.qtpl:123
+//line util.qtpl:134
 }
 
-//line util.qtpl:123
+//line util.qtpl:134
 func writeasset(qq422016 qtio422016.Writer, url, mime string) {
-	//line util.qtpl:123
+	//line util.qtpl:134
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:123
+	//line util.qtpl:134
 	streamasset(qw422016, url, mime)
-	//line util.qtpl:123
+	//line util.qtpl:134
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:123
+//line util.qtpl:134
 }
 
-//line util.qtpl:123
+//line util.qtpl:134
 func asset(url, mime string) string {
-	//line util.qtpl:123
+	//line util.qtpl:134
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:123
+	//line util.qtpl:134
 	writeasset(qb422016, url, mime)
-	//line util.qtpl:123
+	//line util.qtpl:134
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:123
+	//line util.qtpl:134
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:123
+	//line util.qtpl:134
 	return qs422016
-//line util.qtpl:123
+//line util.qtpl:134
 }
 
-//line util.qtpl:125
+//line util.qtpl:136
 func streamloadingImage(qw422016 *qt422016.Writer, board string) {
-	//line util.qtpl:125
+	//line util.qtpl:136
 	qw422016.N().S(`<div id="loading-image" class="noscript-hide">`)
-	//line util.qtpl:127
+	//line util.qtpl:138
 	streamasset(qw422016, fmt.Sprintf("/assets/loading/%s", board), assets.Loading.Get(board).Mime)
-	//line util.qtpl:127
+	//line util.qtpl:138
 	qw422016.N().S(`</div>`)
-//line util.qtpl:129
+//line util.qtpl:140
 }
 
-//line util.qtpl:129
+//line util.qtpl:140
 func writeloadingImage(qq422016 qtio422016.Writer, board string) {
-	//line util.qtpl:129
+	//line util.qtpl:140
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:129
+	//line util.qtpl:140
 	streamloadingImage(qw422016, board)
-	//line util.qtpl:129
+	//line util.qtpl:140
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:129
+//line util.qtpl:140
 }
 
-//line util.qtpl:129
+//line util.qtpl:140
 func loadingImage(board string) string {
-	//line util.qtpl:129
+	//line util.qtpl:140
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:129
+	//line util.qtpl:140
 	writeloadingImage(qb422016, board)
-	//line util.qtpl:129
+	//line util.qtpl:140
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:129
+	//line util.qtpl:140
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:129
+	//line util.qtpl:140
 	return qs422016
-//line util.qtpl:129
+//line util.qtpl:140
 }
 
 // Render localized table headers by UI translation ID
 
-//line util.qtpl:132
-func streamtableHeaders(qw422016 *qt422016.Writer, ids ...string) {
-	//line util.qtpl:133
-	ln := lang.Get().UI
-
-	//line util.qtpl:133
+//line util.qtpl:143
+func streamtableHeaders(qw422016 *qt422016.Writer, ln lang.Pack, ids ...string) {
+	//line util.qtpl:143
 	qw422016.N().S(`<tr>`)
-	//line util.qtpl:135
+	//line util.qtpl:145
 	for _, id := range ids {
-		//line util.qtpl:135
+		//line util.qtpl:145
 		qw422016.N().S(`<th>`)
-		//line util.qtpl:136
-		qw422016.N().S(ln[id])
-		//line util.qtpl:136
+		//line util.qtpl:146
+		qw422016.N().S(ln.UI[id])
+		//line util.qtpl:146
 		qw422016.N().S(`</th>`)
-		//line util.qtpl:137
+		//line util.qtpl:147
 	}
-	//line util.qtpl:137
+	//line util.qtpl:147
 	qw422016.N().S(`</tr>`)
-//line util.qtpl:139
+//line util.qtpl:149
 }
 
-//line util.qtpl:139
-func writetableHeaders(qq422016 qtio422016.Writer, ids ...string) {
-	//line util.qtpl:139
+//line util.qtpl:149
+func writetableHeaders(qq422016 qtio422016.Writer, ln lang.Pack, ids ...string) {
+	//line util.qtpl:149
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:139
-	streamtableHeaders(qw422016, ids...)
-	//line util.qtpl:139
+	//line util.qtpl:149
+	streamtableHeaders(qw422016, ln, ids...)
+	//line util.qtpl:149
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:139
+//line util.qtpl:149
 }
 
-//line util.qtpl:139
-func tableHeaders(ids ...string) string {
-	//line util.qtpl:139
+//line util.qtpl:149
+func tableHeaders(ln lang.Pack, ids ...string) string {
+	//line util.qtpl:149
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:139
-	writetableHeaders(qb422016, ids...)
-	//line util.qtpl:139
+	//line util.qtpl:149
+	writetableHeaders(qb422016, ln, ids...)
+	//line util.qtpl:149
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:139
+	//line util.qtpl:149
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:139
+	//line util.qtpl:149
 	return qs422016
-//line util.qtpl:139
+//line util.qtpl:149
 }
 
-//line util.qtpl:141
-func streamthreadWatcherToggle(qw422016 *qt422016.Writer, id uint64) {
-	//line util.qtpl:141
+//line util.qtpl:151
+func streamthreadWatcherToggle(qw422016 *qt422016.Writer, id uint64, ln lang.Pack) {
+	//line util.qtpl:151
 	qw422016.N().S(`<a class="watcher-toggle svg-link noscript-hide" title="`)
-	//line util.qtpl:142
-	qw422016.N().S(lang.Get().Common.UI["watchThread"])
-	//line util.qtpl:142
+	//line util.qtpl:152
+	qw422016.N().S(ln.Common.UI["watchThread"])
+	//line util.qtpl:152
 	qw422016.N().S(`" data-id="`)
-	//line util.qtpl:142
+	//line util.qtpl:152
 	qw422016.N().S(strconv.FormatUint(id, 10))
-	//line util.qtpl:142
+	//line util.qtpl:152
 	qw422016.N().S(`"><svg xmlns="http://www.w3.org/2000/svg" width="8" height="8" viewBox="0 0 8 8"><path d="M4.03 0c-2.53 0-4.03 3-4.03 3s1.5 3 4.03 3c2.47 0 3.97-3 3.97-3s-1.5-3-3.97-3zm-.03 1c1.11 0 2 .9 2 2 0 1.11-.89 2-2 2-1.1 0-2-.89-2-2 0-1.1.9-2 2-2zm0 1c-.55 0-1 .45-1 1s.45 1 1 1 1-.45 1-1c0-.1-.04-.19-.06-.28-.08.16-.24.28-.44.28-.28 0-.5-.22-.5-.5 0-.2.12-.36.28-.44-.09-.03-.18-.06-.28-.06z" transform="translate(0 1)" /></svg></a>`)
-//line util.qtpl:147
+//line util.qtpl:157
 }
 
-//line util.qtpl:147
-func writethreadWatcherToggle(qq422016 qtio422016.Writer, id uint64) {
-	//line util.qtpl:147
+//line util.qtpl:157
+func writethreadWatcherToggle(qq422016 qtio422016.Writer, id uint64, ln lang.Pack) {
+	//line util.qtpl:157
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:147
-	streamthreadWatcherToggle(qw422016, id)
-	//line util.qtpl:147
+	//line util.qtpl:157
+	streamthreadWatcherToggle(qw422016, id, ln)
+	//line util.qtpl:157
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:147
+//line util.qtpl:157
 }
 
-//line util.qtpl:147
-func threadWatcherToggle(id uint64) string {
-	//line util.qtpl:147
+//line util.qtpl:157
+func threadWatcherToggle(id uint64, ln lang.Pack) string {
+	//line util.qtpl:157
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:147
-	writethreadWatcherToggle(qb422016, id)
-	//line util.qtpl:147
+	//line util.qtpl:157
+	writethreadWatcherToggle(qb422016, id, ln)
+	//line util.qtpl:157
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:147
+	//line util.qtpl:157
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:147
+	//line util.qtpl:157
 	return qs422016
-//line util.qtpl:147
+//line util.qtpl:157
 }
 
-//line util.qtpl:149
+//line util.qtpl:159
 func streamcontrolLink(qw422016 *qt422016.Writer) {
-	//line util.qtpl:149
+	//line util.qtpl:159
 	qw422016.N().S(`<a class="control svg-link noscript-hide"><svg xmlns="http://www.w3.org/2000/svg" width="8" height="8" viewBox="0 0 8 8"><path d="M1.5 0l-1.5 1.5 4 4 4-4-1.5-1.5-2.5 2.5-2.5-2.5z" transform="translate(0 1)" /></svg></a>`)
-//line util.qtpl:155
+//line util.qtpl:165
 }
 
-//line util.qtpl:155
+//line util.qtpl:165
 func writecontrolLink(qq422016 qtio422016.Writer) {
-	//line util.qtpl:155
+	//line util.qtpl:165
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	//line util.qtpl:155
+	//line util.qtpl:165
 	streamcontrolLink(qw422016)
-	//line util.qtpl:155
+	//line util.qtpl:165
 	qt422016.ReleaseWriter(qw422016)
-//line util.qtpl:155
+//line util.qtpl:165
 }
 
-//line util.qtpl:155
+//line util.qtpl:165
 func controlLink() string {
-	//line util.qtpl:155
+	//line util.qtpl:165
 	qb422016 := qt422016.AcquireByteBuffer()
-	//line util.qtpl:155
+	//line util.qtpl:165
 	writecontrolLink(qb422016)
-	//line util.qtpl:155
+	//line util.qtpl:165
 	qs422016 := string(qb422016.B)
-	//line util.qtpl:155
+	//line util.qtpl:165
 	qt422016.ReleaseByteBuffer(qb422016)
-	//line util.qtpl:155
+	//line util.qtpl:165
 	return qs422016
-//line util.qtpl:155
+//line util.qtpl:165
 }
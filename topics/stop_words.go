@@ -0,0 +1,31 @@
+package topics
+
+// Common words not carrying any topical meaning
+var stopWords = make(map[string]bool, len(stopWordList))
+
+var stopWordList = [...]string{
+	"about", "above", "after", "again", "against", "all", "also", "and",
+	"any", "are", "aren", "because", "been", "before", "being", "below",
+	"between", "both", "but", "can", "cannot", "could", "couldn", "did",
+	"didn", "does", "doesn", "doing", "don", "down", "during", "each", "even",
+	"every", "few", "for", "from", "further", "get", "gets", "got", "had",
+	"hadn", "has", "hasn", "have", "haven", "having", "her", "here", "hers",
+	"herself", "him", "himself", "his", "how", "into", "isn", "its",
+	"itself", "just", "let", "like", "more", "most", "much", "must", "mustn",
+	"myself", "never", "nor", "not", "now", "off", "once", "one", "only",
+	"other", "ought", "our", "ours", "ourselves", "out", "over", "own",
+	"really", "same", "say", "said", "she", "should", "shouldn", "some",
+	"still", "such", "than", "that", "the", "their", "theirs", "them",
+	"themselves", "then", "there", "these", "they", "thing", "things",
+	"think", "this", "those", "through", "too", "under", "until", "very",
+	"want", "was", "wasn", "way", "well", "were", "weren", "what", "when",
+	"where", "which", "while", "who", "whom", "why", "will", "with", "won",
+	"would", "wouldn", "yes", "yet", "you", "your", "yours", "yourself",
+	"yourselves", "www", "com", "http", "https",
+}
+
+func init() {
+	for _, w := range stopWordList {
+		stopWords[w] = true
+	}
+}
@@ -0,0 +1,203 @@
+// Package topics aggregates rolling keyword frequencies of recent posts per
+// board for word clouds and moderation situational awareness
+package topics
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Time span of a single frequency bucket
+	bucketSpan = time.Hour
+
+	// Number of buckets making up the rolling aggregation window
+	bucketCount = 24
+
+	// Window of time covered by the aggregation
+	window = bucketSpan * bucketCount
+
+	// Shortest word length in runes to consider a topic
+	minWordLen = 3
+
+	// MaxTopics is the maximum number of topics returned per board
+	MaxTopics = 100
+)
+
+var (
+	mu     sync.RWMutex
+	boards = make(map[string]*boardTopics, 32)
+
+	// Post bodies queued for aggregation
+	queue = make(chan post, 1<<10)
+)
+
+// Topic is a single keyword and its frequency in recent posts of a board
+type Topic struct {
+	Word  string `json:"word"`
+	Count uint   `json:"count"`
+}
+
+type post struct {
+	board, body string
+	time        time.Time
+}
+
+// Word frequencies of posts created during a bucketSpan starting with start
+type bucket struct {
+	start  int64
+	counts map[string]uint
+}
+
+// Ring buffer of frequency buckets of a board
+type boardTopics [bucketCount]bucket
+
+func init() {
+	common.RecordTopics = Add
+
+	go func() {
+		for p := range queue {
+			record(p.board, p.body, p.time)
+		}
+	}()
+}
+
+// Load aggregates word frequencies of posts created during the aggregation
+// window from the database. Should be called once on server start.
+func Load() error {
+	since := time.Now().Add(-window)
+	return db.ForEachPostSince(since.Unix(),
+		func(board, body string, t int64) {
+			record(board, body, time.Unix(t, 0))
+		},
+	)
+}
+
+// Add queues the body of a closed post for aggregation into the board's
+// topics. If the queue is full, the post is skipped, as the aggregation
+// only needs to be representative.
+func Add(board, body string) {
+	select {
+	case queue <- post{board, body, time.Now()}:
+	default:
+	}
+}
+
+// Aggregate post body words into the bucket of the post's creation time
+func record(board, body string, t time.Time) {
+	words := Words(body)
+	if len(words) == 0 {
+		return
+	}
+	start := t.Truncate(bucketSpan).Unix()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	b := boards[board]
+	if b == nil {
+		b = new(boardTopics)
+		boards[board] = b
+	}
+	buc := &b[(start/int64(bucketSpan/time.Second))%bucketCount]
+	switch {
+	case buc.start == start:
+	case buc.start > start:
+		return // Outdated
+	default:
+		buc.start = start
+		buc.counts = make(map[string]uint, 256)
+	}
+	for _, w := range words {
+		buc.counts[w]++
+	}
+}
+
+// Get returns the most frequent topics of a board during the aggregation
+// window sorted by descending frequency. Specifying "all" as board aggregates
+// all listed boards.
+func Get(board string, n int) []Topic {
+	if n <= 0 || n > MaxTopics {
+		n = MaxTopics
+	}
+	min := time.Now().Add(-window).Truncate(bucketSpan).Unix()
+	counts := make(map[string]uint, 1<<10)
+
+	mu.RLock()
+	for id, b := range boards {
+		if board == "all" {
+			if config.IsUnlisted(id) {
+				continue
+			}
+		} else if id != board {
+			continue
+		}
+		for _, buc := range b {
+			if buc.start < min {
+				continue
+			}
+			for w, c := range buc.counts {
+				counts[w] += c
+			}
+		}
+	}
+	mu.RUnlock()
+
+	topics := make([]Topic, 0, len(counts))
+	for w, c := range counts {
+		topics = append(topics, Topic{w, c})
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].Count != topics[j].Count {
+			return topics[i].Count > topics[j].Count
+		}
+		return topics[i].Word < topics[j].Word
+	})
+	if len(topics) > n {
+		topics = topics[:n]
+	}
+	return topics
+}
+
+// Words splits a post body into lowercase keywords eligible for aggregation.
+// Links, hash commands, numbers, short words and stop words are omitted.
+func Words(body string) (words []string) {
+	for _, f := range strings.Fields(body) {
+		switch {
+		case strings.HasPrefix(f, ">>"),
+			strings.HasPrefix(f, "#"),
+			strings.Contains(f, "://"):
+			continue
+		}
+		isSeparator := func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}
+		for _, w := range strings.FieldsFunc(f, isSeparator) {
+			if utf8.RuneCountInString(w) < minWordLen {
+				continue
+			}
+			w = strings.ToLower(w)
+			if stopWords[w] || isNumeric(w) {
+				continue
+			}
+			words = append(words, w)
+		}
+	}
+	return
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
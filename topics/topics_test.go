@@ -0,0 +1,56 @@
+package topics
+
+import (
+	. "github.com/bakape/meguca/test"
+	"testing"
+	"time"
+)
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name, in string
+		out      []string
+	}{
+		{"empty", "", nil},
+		{"stop words", "this is what they said", nil},
+		{"short words", "a bc de", nil},
+		{"lowercase", "Touhou TOUHOU", []string{"touhou", "touhou"}},
+		{"punctuation", "cirno, baka!", []string{"cirno", "baka"}},
+		{"links", ">>12345 >>>/a/ https://example.com/foo", nil},
+		{"commands", "#flip #d100", nil},
+		{"numbers", "12345 2hu", []string{"2hu"}},
+		{"unicode", "東方 チルノ", []string{"チルノ"}},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, Words(c.in), c.out)
+		})
+	}
+}
+
+func TestAggregation(t *testing.T) {
+	now := time.Now()
+	record("a", "cirno cirno marisa", now)
+	record("a", "cirno", now.Add(-time.Hour))
+	record("a", "expired", now.Add(-window*2))
+	record("c", "reimu marisa", now)
+
+	AssertDeepEquals(t, Get("a", 0), []Topic{
+		{"cirno", 3},
+		{"marisa", 1},
+	})
+	AssertDeepEquals(t, Get("a", 1), []Topic{
+		{"cirno", 3},
+	})
+	AssertDeepEquals(t, Get("all", 0), []Topic{
+		{"cirno", 3},
+		{"marisa", 2},
+		{"reimu", 1},
+	})
+	AssertDeepEquals(t, Get("b", 0), []Topic{})
+}
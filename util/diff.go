@@ -0,0 +1,110 @@
+package util
+
+import "unicode"
+
+// Types of DiffChunk
+const (
+	DiffEqual uint8 = iota
+	DiffInsert
+	DiffDelete
+)
+
+// DiffChunk is a continuous run of text, that is either retained, inserted or
+// deleted between two versions of a text
+type DiffChunk struct {
+	Type uint8  `json:"type"`
+	Text string `json:"text"`
+}
+
+// DiffWords computes a word-level diff transforming text a into b.
+// Whitespace is retained, so concatenating all DiffEqual and DiffDelete
+// chunks yields a and all DiffEqual and DiffInsert chunks yields b.
+func DiffWords(a, b string) []DiffChunk {
+	x, y := splitWords(a), splitWords(b)
+
+	// Trim common prefix and suffix to reduce the size of the LCS table
+	var pre, suf int
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	for suf < len(x)-pre && suf < len(y)-pre &&
+		x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	chunks := make([]DiffChunk, 0, 8)
+	push := func(typ uint8, word string) {
+		if l := len(chunks); l != 0 && chunks[l-1].Type == typ {
+			chunks[l-1].Text += word
+		} else {
+			chunks = append(chunks, DiffChunk{typ, word})
+		}
+	}
+
+	for _, w := range x[:pre] {
+		push(DiffEqual, w)
+	}
+
+	// Longest common subsequence lengths of all suffix pairs of the
+	// differing middle sections
+	mx, my := x[pre:len(x)-suf], y[pre:len(y)-suf]
+	w := len(my) + 1
+	lcs := make([]int, (len(mx)+1)*w)
+	for i := len(mx) - 1; i >= 0; i-- {
+		for j := len(my) - 1; j >= 0; j-- {
+			if mx[i] == my[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else if lcs[(i+1)*w+j] >= lcs[i*w+j+1] {
+				lcs[i*w+j] = lcs[(i+1)*w+j]
+			} else {
+				lcs[i*w+j] = lcs[i*w+j+1]
+			}
+		}
+	}
+
+	var i, j int
+	for i < len(mx) && j < len(my) {
+		switch {
+		case mx[i] == my[j]:
+			push(DiffEqual, mx[i])
+			i++
+			j++
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			push(DiffDelete, mx[i])
+			i++
+		default:
+			push(DiffInsert, my[j])
+			j++
+		}
+	}
+	for ; i < len(mx); i++ {
+		push(DiffDelete, mx[i])
+	}
+	for ; j < len(my); j++ {
+		push(DiffInsert, my[j])
+	}
+
+	for _, w := range x[len(x)-suf:] {
+		push(DiffEqual, w)
+	}
+	return chunks
+}
+
+// Split text into alternating runs of whitespace and non-whitespace
+func splitWords(s string) []string {
+	words := make([]string, 0, 16)
+	start := 0
+	var prevSpace bool
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i != 0 && space != prevSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		prevSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
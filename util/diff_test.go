@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestDiffWords(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name, a, b string
+		diff       []DiffChunk
+	}{
+		{"empty", "", "", []DiffChunk{}},
+		{
+			"equal", "foo bar", "foo bar",
+			[]DiffChunk{{DiffEqual, "foo bar"}},
+		},
+		{
+			"cleared", "foo bar", "",
+			[]DiffChunk{{DiffDelete, "foo bar"}},
+		},
+		{
+			"from empty", "", "foo",
+			[]DiffChunk{{DiffInsert, "foo"}},
+		},
+		{
+			"replaced word", "I like cats a lot", "I like dogs a lot",
+			[]DiffChunk{
+				{DiffEqual, "I like "},
+				{DiffDelete, "cats"},
+				{DiffInsert, "dogs"},
+				{DiffEqual, " a lot"},
+			},
+		},
+		{
+			"inserted line", "foo\nbaz", "foo\nbar\nbaz",
+			[]DiffChunk{
+				{DiffEqual, "foo\n"},
+				{DiffInsert, "bar\n"},
+				{DiffEqual, "baz"},
+			},
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			diff := DiffWords(c.a, c.b)
+			AssertDeepEquals(t, diff, c.diff)
+
+			var a, b string
+			for _, ch := range diff {
+				if ch.Type != DiffInsert {
+					a += ch.Text
+				}
+				if ch.Type != DiffDelete {
+					b += ch.Text
+				}
+			}
+			if a != c.a || b != c.b {
+				t.Fatalf("diff does not reproduce texts: `%s` `%s`", a, b)
+			}
+		})
+	}
+}
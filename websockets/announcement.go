@@ -0,0 +1,64 @@
+package websockets
+
+import (
+	"database/sql"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/parser"
+	"unicode/utf8"
+)
+
+// CreateAnnouncement creates a closed staff announcement reply in a thread
+// and writes it to the database. Unlike CreatePost, the board's anti-spam
+// limits are not applied, as the same announcement is posted into many
+// threads in quick succession.
+func CreateAnnouncement(
+	op uint64,
+	board, ip, body string,
+	pos auth.ModerationLevel,
+) (
+	post db.Post, msg []byte, err error,
+) {
+	if body == "" {
+		err = errNoTextOrImage
+		return
+	}
+	if utf8.RuneCountInString(body) > common.MaxLenBody {
+		err = common.ErrBodyTooLong
+		return
+	}
+
+	post = db.Post{
+		StandalonePost: common.StandalonePost{
+			Post: common.Post{
+				Body: body,
+				Auth: pos.String(),
+			},
+			OP:    op,
+			Board: board,
+		},
+		IP: ip,
+	}
+	post.Links, post.Commands, err = parser.ParseBody(
+		[]byte(body),
+		board,
+		op,
+		0,
+		ip,
+		false,
+	)
+	if err != nil {
+		return
+	}
+
+	err = db.InTransaction(false, func(tx *sql.Tx) error {
+		return db.InsertPost(tx, &post)
+	})
+	if err != nil {
+		return
+	}
+
+	msg, err = common.EncodeMessage(common.MessageInsertPost, post.Post)
+	return
+}
@@ -0,0 +1,70 @@
+package websockets
+
+import (
+	"sync"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+)
+
+var (
+	// Clients authenticated with API tokens by token ID
+	tokenClients = struct {
+		sync.Mutex
+		clients map[uint64]map[*Client]struct{}
+	}{
+		clients: make(map[uint64]map[*Client]struct{}),
+	}
+
+	errTokenRevoked = common.ErrAccessDenied("API token revoked")
+	errNoPostScope  = common.ErrAccessDenied("API token can not post")
+)
+
+// Register a client authenticated with an API token for disconnection on
+// token revocation
+func registerTokenClient(c *Client) {
+	tokenClients.Lock()
+	defer tokenClients.Unlock()
+
+	m := tokenClients.clients[c.token.ID]
+	if m == nil {
+		m = make(map[*Client]struct{})
+		tokenClients.clients[c.token.ID] = m
+	}
+	m[c] = struct{}{}
+}
+
+func unregisterTokenClient(c *Client) {
+	tokenClients.Lock()
+	defer tokenClients.Unlock()
+
+	m := tokenClients.clients[c.token.ID]
+	delete(m, c)
+	if len(m) == 0 {
+		delete(tokenClients.clients, c.token.ID)
+	}
+}
+
+// DisconnectAPIToken disconnects all clients authenticated with an API token
+func DisconnectAPIToken(id uint64) {
+	tokenClients.Lock()
+	defer tokenClients.Unlock()
+
+	for c := range tokenClients.clients[id] {
+		c.Close(errTokenRevoked)
+	}
+}
+
+// Returns, if the client must solve a captcha before posting on board.
+// Bots authenticated with API tokens can not solve captchas and are only
+// permitted to post, if the token has the post scope.
+func (c *Client) needCaptcha(board string) (bool, error) {
+	if c.token.ID != 0 {
+		if !c.token.HasScope(auth.ScopePost) {
+			return false, errNoPostScope
+		}
+		return false, nil
+	}
+	return db.NeedBoardCaptcha(board, c.ip)
+}
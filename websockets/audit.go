@@ -0,0 +1,107 @@
+package websockets
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Number of audit samples retained. Older samples are overwritten.
+const auditCapacity = 1 << 14
+
+var audit = auditStore{
+	samples: make([]AuditSample, auditCapacity),
+}
+
+// AuditSample contains metadata of a single message received from a client.
+// Message contents are never recorded.
+type AuditSample struct {
+	Type common.MessageType `json:"type"`
+	Size int                `json:"size"`
+
+	// Unix timestamp of receipt in milliseconds
+	Time int64 `json:"time"`
+
+	// Milliseconds since the previous message of the client. 0 for the first
+	// message.
+	Interval int64 `json:"interval"`
+
+	// Anonymised identifier of the client's IP and user agent
+	Client string `json:"client"`
+}
+
+// Ring buffer of sampled message metadata
+type auditStore struct {
+	mu      sync.Mutex
+	samples []AuditSample
+	pos     int
+	full    bool
+}
+
+func (s *auditStore) add(sample AuditSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.pos] = sample
+	s.pos++
+	if s.pos == len(s.samples) {
+		s.pos = 0
+		s.full = true
+	}
+}
+
+// Return all retained samples from oldest to newest
+func (s *auditStore) get() []AuditSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]AuditSample(nil), s.samples[:s.pos]...)
+	}
+	res := make([]AuditSample, 0, len(s.samples))
+	res = append(res, s.samples[s.pos:]...)
+	return append(res, s.samples[:s.pos]...)
+}
+
+// AuditSamples returns the retained sampled websocket message metadata from
+// oldest to newest
+func AuditSamples() []AuditSample {
+	return audit.get()
+}
+
+// Generate an identifier for correlating samples of the same client, that can
+// not be reversed to the client's IP
+func clientFingerprint(ip, userAgent string) string {
+	h := sha256.New()
+	h.Write([]byte(config.Get().Salt))
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:9])
+}
+
+// Record the metadata of a received message with the configured probability
+func (c *Client) sampleMessage(typ common.MessageType, size int, now time.Time,
+) {
+	var interval int64
+	if !c.lastMessage.IsZero() {
+		interval = int64(now.Sub(c.lastMessage) / time.Millisecond)
+	}
+	c.lastMessage = now
+
+	rate := config.Get().AuditSampling
+	if rate == 0 || uint(rand.Intn(100)) >= rate {
+		return
+	}
+	audit.add(AuditSample{
+		Type:     typ,
+		Size:     size,
+		Time:     now.UnixNano() / int64(time.Millisecond),
+		Interval: interval,
+		Client:   c.fingerprint,
+	})
+}
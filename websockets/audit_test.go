@@ -0,0 +1,58 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"testing"
+	"time"
+)
+
+func TestAuditStore(t *testing.T) {
+	s := auditStore{
+		samples: make([]AuditSample, 3),
+	}
+	if len(s.get()) != 0 {
+		t.Fatal("store not empty")
+	}
+	for i := 0; i < 5; i++ {
+		s.add(AuditSample{Size: i})
+	}
+
+	res := s.get()
+	if len(res) != 3 {
+		t.Fatalf("unexpected sample count: %d", len(res))
+	}
+	for i, smp := range res {
+		if smp.Size != i+2 {
+			t.Fatalf("unexpected sample order: %v", res)
+		}
+	}
+}
+
+func TestSampleMessage(t *testing.T) {
+	config.Set(config.Configs{
+		AuditSampling: 100,
+	})
+	defer config.Set(config.Configs{})
+
+	audit = auditStore{
+		samples: make([]AuditSample, auditCapacity),
+	}
+	cl := Client{
+		fingerprint: clientFingerprint("::1", "foo"),
+	}
+	now := time.Now()
+	cl.sampleMessage(common.MessageInsertPost, 10, now)
+	cl.sampleMessage(common.MessageAppend, 3, now.Add(time.Second))
+
+	res := AuditSamples()
+	if len(res) != 2 {
+		t.Fatalf("unexpected sample count: %d", len(res))
+	}
+	if res[0].Interval != 0 || res[1].Interval != 1000 {
+		t.Fatalf("unexpected intervals: %d %d", res[0].Interval, res[1].Interval)
+	}
+	if res[1].Client != cl.fingerprint || res[1].Type != common.MessageAppend {
+		t.Fatalf("unexpected sample: %#v", res[1])
+	}
+}
@@ -0,0 +1,64 @@
+package websockets
+
+import (
+	"errors"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"time"
+)
+
+var (
+	errPostCooldown = common.StatusError{
+		Err:  errors.New("posting too fast"),
+		Code: 429,
+	}
+	errFileTypeNotAllowed = common.StatusError{
+		Err:  errors.New("file type not allowed on this board"),
+		Code: 400,
+	}
+)
+
+// Returns the maximum post body length on a board
+func maxBodyLen(conf config.BoardConfigs) int {
+	if conf.MaxBodyLength != 0 && conf.MaxBodyLength < common.MaxLenBody {
+		return int(conf.MaxBodyLength)
+	}
+	return common.MaxLenBody
+}
+
+// Returns the maximum post body length on the board of the open post
+func (o *openPost) maxLen() int {
+	return maxBodyLen(config.GetBoardConfigs(o.board).BoardConfigs)
+}
+
+// Assert an IP has not posted on the board within the board's post cooldown
+func checkCooldown(conf config.BoardConfigs, ip string) error {
+	if conf.PostCooldown == 0 {
+		return nil
+	}
+	has, err := db.HasPostedWithin(conf.ID, ip,
+		time.Duration(conf.PostCooldown)*time.Second)
+	switch {
+	case err != nil:
+		return err
+	case has:
+		return errPostCooldown
+	default:
+		return nil
+	}
+}
+
+// Assert an uploaded file type is allowed on the board
+func checkFileType(conf config.BoardConfigs, fileType uint8) error {
+	if len(conf.FileTypes) == 0 {
+		return nil
+	}
+	ext := common.Extensions[fileType]
+	for _, t := range conf.FileTypes {
+		if t == ext {
+			return nil
+		}
+	}
+	return errFileTypeNotAllowed
+}
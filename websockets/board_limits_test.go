@@ -0,0 +1,51 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"testing"
+)
+
+func TestMaxBodyLen(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name     string
+		override uint
+		max      int
+	}{
+		{"no override", 0, common.MaxLenBody},
+		{"lower", 100, 100},
+		{"above global", common.MaxLenBody + 1, common.MaxLenBody},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var conf config.BoardConfigs
+			conf.MaxBodyLength = c.override
+			if l := maxBodyLen(conf); l != c.max {
+				t.Fatalf("unexpected length: %d != %d", l, c.max)
+			}
+		})
+	}
+}
+
+func TestCheckFileType(t *testing.T) {
+	t.Parallel()
+
+	var conf config.BoardConfigs
+	if err := checkFileType(conf, common.WEBM); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.FileTypes = []string{"png", "jpg"}
+	if err := checkFileType(conf, common.PNG); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFileType(conf, common.WEBM); err != errFileTypeNotAllowed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
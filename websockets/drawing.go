@@ -0,0 +1,72 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/imager"
+)
+
+var errDrawingDisabled = common.ErrInvalidInput(
+	"drawing not supported by server")
+
+// Add a stroke to the oekaki drawing of the open post. The drawing is
+// rendered and attached as the post's image, when the post is closed.
+func (c *Client) drawStroke(data []byte) (err error) {
+	has, err := c.hasPost()
+	switch {
+	case err != nil:
+		return
+	case !has:
+		return errNoPostOpen
+	case c.post.hasImage:
+		return errHasImage
+	case config.ImagerMode == config.NoImager:
+		return errDrawingDisabled
+	case config.GetBoardConfigs(c.post.board).TextOnly:
+		return errTextOnly
+	}
+
+	var s common.Stroke
+	err = decodeMessage(data, &s)
+	if err != nil {
+		return
+	}
+	err = s.Validate()
+	if err != nil {
+		return
+	}
+	l := s.Length()
+	if c.post.drawingPoints+len(s.Points) > common.MaxDrawingPoints ||
+		c.post.drawingLength+l > common.MaxDrawingLength {
+		return common.ErrDrawingTooLong
+	}
+
+	c.post.strokes = append(c.post.strokes, s)
+	c.post.drawingPoints += len(s.Points)
+	c.post.drawingLength += l
+	c.incrementSpamScore(config.Get().CharScore)
+	return
+}
+
+// Render the open post's drawing, if any, and attach it as the post's image.
+// The strokes are stored for replaying the drawing.
+func (c *Client) attachDrawing() (err error) {
+	if len(c.post.strokes) == 0 || c.post.hasImage {
+		return
+	}
+
+	token, err := imager.UploadDrawing(c.post.strokes)
+	if err != nil {
+		return
+	}
+	err = c.attachImage(config.GetBoardConfigs(c.post.board).BoardConfigs,
+		ImageRequest{
+			Token: token,
+			Name:  "oekaki.png",
+		})
+	if err != nil {
+		return
+	}
+	return db.WriteDrawing(c.post.id, c.post.strokes)
+}
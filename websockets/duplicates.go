@@ -0,0 +1,139 @@
+package websockets
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	mlog "github.com/bakape/meguca/log"
+	"github.com/bakape/meguca/websockets/feeds"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalized bodies shorter than this are too generic to be considered
+// duplicate spam
+const minDuplicateLen = 16
+
+var (
+	// Fingerprints of recent post bodies across all boards
+	fingerprints = fingerprintStore{
+		posts: make(map[[sha1.Size]byte][]time.Time, 1<<10),
+	}
+
+	errDuplicatePost = common.StatusError{
+		Err:  errors.New("post duplicates recent posts"),
+		Code: 403,
+	}
+)
+
+func init() {
+	go func() {
+		for range time.Tick(time.Minute) {
+			fingerprints.clean(time.Now())
+		}
+	}()
+}
+
+// Stores creation times of recent post bodies by their fingerprints
+type fingerprintStore struct {
+	mu    sync.Mutex
+	posts map[[sha1.Size]byte][]time.Time
+}
+
+// Record a post body fingerprint and return the number of previous posts with
+// the same fingerprint within window
+func (s *fingerprintStore) add(fp [sha1.Size]byte, window time.Duration,
+	now time.Time,
+) (n uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	times := s.posts[fp]
+	min := now.Add(-window)
+	for _, t := range times {
+		if t.After(min) {
+			n++
+		}
+	}
+	s.posts[fp] = append(times, now)
+	return
+}
+
+// Remove fingerprints older than the maximum detection window
+func (s *fingerprintStore) clean(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	min := now.Add(-common.MaxDuplicateWindow * time.Second)
+	for fp, times := range s.posts {
+		i := 0
+		for i < len(times) && !times[i].After(min) {
+			i++
+		}
+		if i == len(times) {
+			delete(s.posts, fp)
+		} else if i != 0 {
+			s.posts[fp] = append(times[:0], times[i:]...)
+		}
+	}
+}
+
+// Normalize a post body for fingerprinting, so trivial variations in case,
+// punctuation and whitespace do not evade detection. Returns false, if the
+// body is too short to fingerprint.
+func fingerprint(body string) (fp [sha1.Size]byte, ok bool) {
+	isSeparator := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	norm := strings.ToLower(strings.Join(strings.FieldsFunc(body, isSeparator),
+		" "))
+	if utf8.RuneCountInString(norm) < minDuplicateLen {
+		return
+	}
+	return sha1.Sum([]byte(norm)), true
+}
+
+// Record a post body and apply the board's duplicate post policy. Returns, if
+// the post is to be flagged for moderation.
+func checkDuplicate(conf config.BoardConfigs, body string) (
+	flag bool, err error,
+) {
+	fp, ok := fingerprint(body)
+	if !ok {
+		return
+	}
+	window := conf.DuplicateWindow
+	if window == 0 || window > common.MaxDuplicateWindow {
+		window = common.MaxDuplicateWindow
+	}
+	n := fingerprints.add(fp, time.Duration(window)*time.Second, time.Now())
+	if conf.DuplicateLimit == 0 || n < conf.DuplicateLimit {
+		return
+	}
+
+	if conf.DuplicatePolicy == common.DuplicatePolicyReject {
+		err = errDuplicatePost
+	} else {
+		flag = true
+	}
+	return
+}
+
+// Report a post duplicating recent posts to the board's moderators
+func flagDuplicate(ctx context.Context, id uint64, board, ip string) {
+	const reason = "duplicate post spam"
+	err := db.Report(id, board, reason, ip, false)
+	if err == nil {
+		err = feeds.SendReportToStaff(id, reason)
+	}
+	if err != nil {
+		mlog.FromContext(ctx, "websockets").
+			Errorf("duplicate post report: %s", err)
+	}
+}
@@ -0,0 +1,69 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	a, ok := fingerprint("Buy cheap watches at example!")
+	if !ok {
+		t.Fatal("not fingerprinted")
+	}
+	b, _ := fingerprint("  buy CHEAP watches\nat example ")
+	if a != b {
+		t.Fatal("normalized bodies differ")
+	}
+	if _, ok := fingerprint("lol"); ok {
+		t.Fatal("short body fingerprinted")
+	}
+}
+
+func TestFingerprintStore(t *testing.T) {
+	s := fingerprintStore{
+		posts: make(map[[20]byte][]time.Time),
+	}
+	fp, _ := fingerprint("this is a long enough post body")
+	now := time.Now()
+
+	s.add(fp, time.Minute, now.Add(-time.Hour))
+	if n := s.add(fp, time.Minute, now.Add(-time.Second)); n != 0 {
+		t.Fatalf("unexpected count: %d", n)
+	}
+	if n := s.add(fp, time.Minute, now); n != 1 {
+		t.Fatalf("unexpected count: %d", n)
+	}
+
+	s.clean(now.Add(common.MaxDuplicateWindow * time.Second))
+	if len(s.posts) != 0 {
+		t.Fatal("fingerprints not cleaned")
+	}
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	conf := config.BoardConfigs{
+		DuplicateLimit:  2,
+		DuplicateWindow: 60,
+		DuplicatePolicy: common.DuplicatePolicyReject,
+	}
+	const body = "spam spam spam spam spam spam"
+	for i := 0; i < 2; i++ {
+		if _, err := checkDuplicate(conf, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := checkDuplicate(conf, body); err != errDuplicatePost {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conf.DuplicatePolicy = common.DuplicatePolicyFlag
+	flag, err := checkDuplicate(conf, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag {
+		t.Fatal("post not flagged")
+	}
+}
@@ -0,0 +1,131 @@
+// Announcement banners shown on top of board and thread pages
+
+package feeds
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/go-playground/log"
+)
+
+// Interval between checks for announcement banners reaching the start or end
+// of their time window
+const announcementBannerInterval = 10 * time.Second
+
+var announcementBanners struct {
+	sync.Mutex
+	// All banners, that have not yet ended
+	banners []common.AnnouncementBanner
+	// IDs of the banners shown at the time clients were last sent banners
+	shown string
+}
+
+// Load announcement banners, keep them up to date with the database and send
+// them to clients, whenever the shown banners change
+func initAnnouncementBanners() (err error) {
+	err = loadAnnouncementBanners()
+	if err != nil {
+		return
+	}
+	go func() {
+		for range time.Tick(announcementBannerInterval) {
+			sendAnnouncementBanners()
+		}
+	}()
+	return db.ListenAnnouncementBanners(loadAnnouncementBanners)
+}
+
+// Read all announcement banners, that have not yet ended, from the database
+func loadAnnouncementBanners() (err error) {
+	banners, err := db.GetAnnouncementBanners()
+	if err != nil {
+		return
+	}
+	setAnnouncementBanners(banners)
+	return
+}
+
+// Store announcement banners and send them to clients, if the shown banners
+// changed
+func setAnnouncementBanners(banners []common.AnnouncementBanner) {
+	announcementBanners.Lock()
+	announcementBanners.banners = banners
+	announcementBanners.Unlock()
+	sendAnnouncementBanners()
+}
+
+// Return the banners, that are shown on board at Unix time now
+func filterAnnouncementBanners(banners []common.AnnouncementBanner,
+	board string, now int64,
+) []common.AnnouncementBanner {
+	shown := make([]common.AnnouncementBanner, 0, len(banners))
+	for _, b := range banners {
+		if b.ShownOn(board) && b.Active(now) {
+			shown = append(shown, b)
+		}
+	}
+	return shown
+}
+
+// AnnouncementBanners returns the announcement banners currently shown on
+// board
+func AnnouncementBanners(board string) []common.AnnouncementBanner {
+	announcementBanners.Lock()
+	banners := announcementBanners.banners
+	announcementBanners.Unlock()
+	return filterAnnouncementBanners(banners, board, time.Now().Unix())
+}
+
+// AnnouncementBannersMessage returns the message with the announcement banners
+// currently shown on board to send to a client synchronising to it
+func AnnouncementBannersMessage(board string) ([]byte, error) {
+	return common.EncodeMessage(common.MessageAnnouncementBanners,
+		AnnouncementBanners(board))
+}
+
+// Send all clients the announcement banners shown on their boards, if the
+// banners shown on any board changed since last sent
+func sendAnnouncementBanners() {
+	announcementBanners.Lock()
+	defer announcementBanners.Unlock()
+
+	var (
+		now     = time.Now().Unix()
+		banners = announcementBanners.banners
+		ids     = make([]string, 0, len(banners))
+	)
+	for _, b := range banners {
+		if b.Active(now) {
+			ids = append(ids, strconv.FormatUint(b.ID, 10))
+		}
+	}
+	shown := strings.Join(ids, ",")
+	if shown == announcementBanners.shown {
+		return
+	}
+	announcementBanners.shown = shown
+
+	clients.RLock()
+	defer clients.RUnlock()
+
+	msgs := make(map[string][]byte)
+	for cl, s := range clients.clients {
+		msg, ok := msgs[s.board]
+		if !ok {
+			var err error
+			msg, err = common.EncodeMessage(common.MessageAnnouncementBanners,
+				filterAnnouncementBanners(banners, s.board, now))
+			if err != nil {
+				log.Errorf("announcement banners: %s", err)
+				return
+			}
+			msgs[s.board] = msg
+		}
+		cl.Send(msg)
+	}
+}
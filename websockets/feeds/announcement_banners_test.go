@@ -0,0 +1,66 @@
+package feeds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestSetAnnouncementBanners(t *testing.T) {
+	var onX, onY staffClient
+	clients.Lock()
+	clients.clients[&onX] = syncID{board: "x"}
+	clients.clients[&onY] = syncID{board: "y"}
+	clients.Unlock()
+	defer func() {
+		clients.Lock()
+		delete(clients.clients, &onX)
+		delete(clients.clients, &onY)
+		clients.Unlock()
+		setAnnouncementBanners(nil)
+	}()
+
+	now := time.Now().Unix()
+	global := common.AnnouncementBanner{ID: 1, Board: "all", Body: "global"}
+	onlyX := common.AnnouncementBanner{ID: 2, Board: "x", Body: "x"}
+	scheduled := common.AnnouncementBanner{
+		ID:    3,
+		Board: "y",
+		Body:  "scheduled",
+		Start: now + 3600,
+	}
+
+	encode := func(banners ...common.AnnouncementBanner) []byte {
+		t.Helper()
+		if banners == nil {
+			banners = []common.AnnouncementBanner{}
+		}
+		msg, err := common.EncodeMessage(common.MessageAnnouncementBanners,
+			banners)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	// Unchanged banners are not resent
+	banners := []common.AnnouncementBanner{global, onlyX, scheduled}
+	setAnnouncementBanners(banners)
+	setAnnouncementBanners(banners)
+	AssertDeepEquals(t, onX.msgs, [][]byte{encode(global, onlyX)})
+	AssertDeepEquals(t, onY.msgs, [][]byte{encode(global)})
+
+	t.Run("new client", func(t *testing.T) {
+		msg, err := AnnouncementBannersMessage("y")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, msg, encode(global))
+	})
+
+	setAnnouncementBanners([]common.AnnouncementBanner{onlyX})
+	AssertDeepEquals(t, onX.msgs[1:], [][]byte{encode(onlyX)})
+	AssertDeepEquals(t, onY.msgs[1:], [][]byte{encode()})
+}
@@ -3,6 +3,7 @@ package feeds
 import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/db"
+	"sort"
 	"time"
 )
 
@@ -12,7 +13,15 @@ const retentionTime = 16 * time.Minute
 // Persists thread state for syncing clients to server feed
 type threadCache struct {
 	syncMessage
-	memoized []byte
+	// Encoded sync messages by number of last posts requested
+	memoized map[int][]byte
+	// IDs of all replies in the thread in ascending order
+	replies []uint64
+	// Log of post modification messages
+	log messageLog
+	// Highest log position of any post evicted from Recent. Delta syncs
+	// from before this position would miss the evicted post's changes.
+	evictedPos uint64
 }
 
 func retentionThreshold() int64 {
@@ -25,14 +34,21 @@ func newThreadCache(id uint64) (c threadCache, err error) {
 			Recent:     make(map[uint64]cachedPost, 16),
 			Moderation: make(map[uint64][]common.ModerationEntry, 16),
 		},
+		log: messageLog{
+			// Microseconds keep the epoch within the precision of JS numbers
+			epoch: time.Now().UnixNano() / int64(time.Microsecond),
+			tail:  make([]string, 0, 64),
+		},
 	}
 	thread, err := db.GetThread(id, 0)
 	if err != nil {
 		return
 	}
 
+	c.replies = make([]uint64, 0, len(thread.Posts)+16)
 	threshold := retentionThreshold()
 	for _, p := range thread.Posts {
+		c.replies = append(c.replies, p.ID)
 		if p.Time > threshold {
 			c.Recent[p.ID] = cachedPost{
 				HasImage:  p.Image != nil,
@@ -57,6 +73,9 @@ func (c *threadCache) evict() {
 	threshold := retentionThreshold()
 	for id, p := range c.Recent {
 		if p.Time < threshold {
+			if p.pos > c.evictedPos {
+				c.evictedPos = p.pos
+			}
 			delete(c.Recent, id)
 		}
 	}
@@ -64,8 +83,18 @@ func (c *threadCache) evict() {
 
 // Message used for synchronizing clients to the feed state.
 type syncMessage struct {
+	LogPosition
 	Recent     map[uint64]cachedPost               `json:"recent"`
 	Moderation map[uint64][]common.ModerationEntry `json:"moderation"`
+
+	// Set, if the client is resynced from its last log position. Recent then
+	// only contains posts modified since that position.
+	Delta bool `json:"delta,omitempty"`
+	// Messages to apply before syncing to Recent
+	Log []string `json:"log,omitempty"`
+	// Number of replies left out, if the client requested only the last N
+	// posts of the thread
+	Omitted int `json:"omitted,omitempty"`
 }
 
 type cachedPost struct {
@@ -74,22 +103,140 @@ type cachedPost struct {
 	Closed    bool   `json:"closed"`
 	Time      int64  `json:"-"`
 	Body      string `json:"body"`
+	// Log position of the last modification
+	pos uint64
+}
+
+// LogPosition is a position in the message log of a thread feed. Positions
+// are only comparable within the same epoch.
+type LogPosition struct {
+	Epoch    int64  `json:"epoch"`
+	Position uint64 `json:"position"`
+}
+
+// Record a reply inserted into the thread
+func (c *threadCache) addReply(id uint64) {
+	i := sort.Search(len(c.replies), func(i int) bool {
+		return c.replies[i] >= id
+	})
+	if i < len(c.replies) && c.replies[i] == id {
+		return
+	}
+	c.replies = append(c.replies, 0)
+	copy(c.replies[i+1:], c.replies[i:])
+	c.replies[i] = id
+}
+
+// Strip posts not displayed by clients showing only the last N posts of the
+// thread from msg
+func (c *threadCache) abbreviate(msg *syncMessage, lastN int) {
+	if lastN == 0 || len(c.replies) <= lastN {
+		return
+	}
+
+	msg.Omitted = len(c.replies) - lastN
+	omitted := c.replies[:msg.Omitted]
+	isOmitted := func(id uint64) bool {
+		i := sort.Search(len(omitted), func(i int) bool {
+			return omitted[i] >= id
+		})
+		return i < len(omitted) && omitted[i] == id
+	}
+
+	recent := make(map[uint64]cachedPost, len(msg.Recent))
+	for id, p := range msg.Recent {
+		if !isOmitted(id) {
+			recent[id] = p
+		}
+	}
+	msg.Recent = recent
+
+	mod := make(map[uint64][]common.ModerationEntry, len(msg.Moderation))
+	for id, e := range msg.Moderation {
+		if !isOmitted(id) {
+			mod[id] = e
+		}
+	}
+	msg.Moderation = mod
 }
 
 // Generate a message for synchronizing to the current status of the update
 // feed. The client has to compare this state to it's own and resolve any
-// missing entries or conflicts.
+// missing entries or conflicts. If lastN is not 0, only the state of the last
+// N posts is sent.
 //
 // Returned buffer must not be modified.
-func (c *threadCache) getSyncMessage() ([]byte, error) {
-	if c.memoized != nil {
-		return c.memoized, nil
+func (c *threadCache) getSyncMessage(lastN int) ([]byte, error) {
+	if buf, ok := c.memoized[lastN]; ok {
+		return buf, nil
+	}
+
+	c.LogPosition = c.log.position()
+	msg := c.syncMessage
+	c.abbreviate(&msg, lastN)
+	buf, err := common.EncodeMessage(common.MessageSynchronise, msg)
+	if err != nil {
+		return nil, err
+	}
+	if c.memoized == nil {
+		c.memoized = make(map[int][]byte, 3)
+	}
+	c.memoized[lastN] = buf
+	return buf, nil
+}
+
+// Generate a message for resyncing a client from its last known log
+// position. If few enough messages were missed, they are replayed from the
+// log. Otherwise a delta snapshot of the bodies of posts modified since pos
+// and the log tail of inserted posts is sent. Falls back to a full sync
+// message, if pos is not from the current log or posts modified since pos
+// have been evicted.
+func (c *threadCache) getResyncMessage(pos LogPosition, lastN int) (
+	[]byte, error,
+) {
+	if !c.log.contains(pos.Epoch, pos.Position) {
+		return c.getSyncMessage(lastN)
+	}
+
+	msg := syncMessage{
+		LogPosition: c.log.position(),
+		Recent:      make(map[uint64]cachedPost),
+		Moderation:  make(map[uint64][]common.ModerationEntry),
+		Delta:       true,
+	}
+	if c.log.pos-pos.Position <= replayThreshold(len(c.replies)) {
+		if missed, ok := c.log.since(pos.Position); ok {
+			msg.Log = missed
+			return common.EncodeMessage(common.MessageSynchronise, msg)
+		}
+	}
+	if pos.Position < c.evictedPos {
+		return c.getSyncMessage(lastN)
+	}
+
+	for id, p := range c.Recent {
+		if p.pos > pos.Position {
+			msg.Recent[id] = p
+		}
+	}
+	msg.Moderation = c.Moderation
+
+	tail, ok := c.log.since(pos.Position)
+	if !ok {
+		tail = c.log.tail
+	}
+	for i := len(tail) - 1; i >= 0 && len(msg.Log) < deltaTailSize; i-- {
+		if messageType(tail[i]) == common.MessageInsertPost {
+			msg.Log = append(msg.Log, tail[i])
+		}
+	}
+	// Restore chronological order
+	for i, j := 0, len(msg.Log)-1; i < j; i, j = i+1, j-1 {
+		msg.Log[i], msg.Log[j] = msg.Log[j], msg.Log[i]
 	}
+	c.abbreviate(&msg, lastN)
 
-	var err error
-	c.memoized, err = common.EncodeMessage(common.MessageSynchronise,
-		c.syncMessage)
-	return c.memoized, err
+	return common.EncodeMessage(common.MessageSynchronise, msg)
 }
 
 // Clear memoized sync message JSON, if any
@@ -2,6 +2,7 @@ package feeds
 
 import (
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
 	"sync"
 )
 
@@ -68,8 +69,18 @@ func IPCount() int {
 
 // SyncClient adds a client to a the global client map and synchronizes to an
 // update feed, if any. If the client was already synced to another feed, it is
-// automatically unsubscribed.
-func SyncClient(cl common.Client, op uint64, board string) (*Feed, error) {
+// automatically unsubscribed. pos is the client's last known position in the
+// feed's message log, if reconnecting to the same thread. lastN is the number
+// of last posts the client displays or 0 for all of them.
+func SyncClient(
+	cl common.Client,
+	op uint64,
+	board string,
+	pos LogPosition,
+	lastN int,
+) (
+	*Feed, error,
+) {
 	clients.Lock()
 	old, ok := clients.clients[cl]
 	clients.clients[cl] = syncID{op, board}
@@ -78,7 +89,7 @@ func SyncClient(cl common.Client, op uint64, board string) (*Feed, error) {
 	if ok {
 		removeFromFeed(old.op, old.board, cl)
 	}
-	return addToFeed(op, board, cl)
+	return addToFeed(op, board, cl, pos, lastN)
 }
 
 // RemoveClient removes a client from the global client map and any subscribed
@@ -94,6 +105,7 @@ func RemoveClient(cl common.Client) {
 	if ok {
 		removeFromFeed(old.op, old.board, cl)
 	}
+	unsubscribeFromStaff(cl)
 }
 
 // GetSync returns if the client is synced and the thread and board it is
@@ -108,28 +120,30 @@ func GetSync(cl common.Client) (synced bool, op uint64, board string) {
 	return
 }
 
-// GetByIPAndBoard retrieves all Clients that match the passed IP on a board
+// GetByIPAndBoard retrieves all Clients that match the passed IP, as stored in
+// the database, on a board
 func GetByIPAndBoard(ip, board string) []common.Client {
 	clients.RLock()
 	defer clients.RUnlock()
 
 	cls := make([]common.Client, 0, 16)
 	for cl, sync := range clients.clients {
-		if cl.IP() == ip && (board == "all" || sync.board == board) {
+		if db.MatchIP(cl.IP(), ip) && (board == "all" || sync.board == board) {
 			cls = append(cls, cl)
 		}
 	}
 	return cls
 }
 
-// GetByIP returns all clients matching the specified IP
+// GetByIP returns all clients matching the specified IP as stored in the
+// database
 func GetByIP(ip string) []common.Client {
 	clients.RLock()
 	defer clients.RUnlock()
 
 	cls := make([]common.Client, 0, 16)
 	for cl := range clients.clients {
-		if cl.IP() == ip {
+		if db.MatchIP(cl.IP(), ip) {
 			cls = append(cls, cl)
 		}
 	}
@@ -0,0 +1,170 @@
+package feeds
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+	"github.com/go-playground/log"
+)
+
+// Kinds of feed updates shared with other instances
+type clusterKind uint8
+
+const (
+	clusterInsertPost clusterKind = iota
+	clusterInsertImage
+	clusterSpoilerImage
+	clusterClosePost
+	clusterPublishPost
+	clusterSetOpenBody
+	clusterSend
+)
+
+// Interval at which queued feed updates are sent to other instances as one
+// notification. Open post bodies change on every keystroke, so sending each
+// update separately would flood the database with notifications.
+const clusterBatchInterval = 50 * time.Millisecond
+
+var (
+	// Identifies messages sent by this instance
+	instanceID string
+
+	// Messages pending sending to other instances. Sent from a single
+	// goroutine to preserve their order.
+	clusterQueue = make(chan clusterMessage, 1<<10)
+)
+
+// Feed update applied by one instance, that the others must apply to their
+// own feeds
+type clusterMessage struct {
+	Instance  string       `json:"instance"`
+	Kind      clusterKind  `json:"kind"`
+	OP        uint64       `json:"op"`
+	ID        uint64       `json:"id,omitempty"`
+	Spoilered bool         `json:"spoilered,omitempty"`
+	Post      *common.Post `json:"post,omitempty"`
+	Body      string       `json:"body,omitempty"`
+	Msg       []byte       `json:"msg,omitempty"`
+}
+
+// Start sharing feed updates with other instances connected to the same
+// database
+func initCluster() (err error) {
+	instanceID, err = auth.RandomID(12)
+	if err != nil {
+		return
+	}
+	go sendClusterMessages()
+	return db.ListenCluster(handleClusterMessages, resyncCluster)
+}
+
+// Send queued feed updates to other instances in batches
+func sendClusterMessages() {
+	var (
+		batch []clusterMessage
+		flush <-chan time.Time
+	)
+	for {
+		select {
+		case m := <-clusterQueue:
+			batch = append(batch, m)
+			if flush == nil {
+				flush = time.After(clusterBatchInterval)
+			}
+		case <-flush:
+			buf, err := json.Marshal(batch)
+			if err == nil {
+				err = db.NotifyCluster(buf)
+			}
+			if err != nil {
+				log.Errorf("cluster: sending feed updates: %s", err)
+			}
+			batch = batch[:0]
+			flush = nil
+		}
+	}
+}
+
+// Share a feed update applied by this instance with all other instances
+func relay(m clusterMessage) {
+	if !config.Clustered {
+		return
+	}
+	m.Instance = instanceID
+	select {
+	case clusterQueue <- m:
+	default:
+		log.Warnf("cluster: queue full: dropping feed update of thread %d",
+			m.OP)
+	}
+}
+
+// Apply a batch of feed updates received from another instance
+func handleClusterMessages(buf []byte) (err error) {
+	var batch []clusterMessage
+	err = json.Unmarshal(buf, &batch)
+	if err != nil {
+		return
+	}
+	for _, m := range batch {
+		err = handleClusterMessage(m)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Apply a feed update received from another instance
+func handleClusterMessage(m clusterMessage) (err error) {
+	if m.Instance == instanceID {
+		return
+	}
+
+	// Open post bodies are stored locally by each instance
+	switch {
+	case m.Kind == clusterSetOpenBody:
+		err = db.SetOpenBody(m.ID, []byte(m.Body))
+	case m.Kind == clusterInsertPost && m.Post != nil && m.Post.Editing:
+		err = db.SetOpenBody(m.ID, []byte(m.Post.Body))
+	}
+	if err != nil {
+		return
+	}
+
+	return sendIfExists(m.OP, func(f *Feed) error {
+		switch m.Kind {
+		case clusterInsertPost:
+			if m.Post != nil {
+				f.InsertPost(*m.Post, m.Msg)
+			}
+		case clusterInsertImage:
+			f.InsertImage(m.ID, m.Spoilered, m.Msg)
+		case clusterSpoilerImage:
+			f.SpoilerImage(m.ID, m.Msg)
+		case clusterClosePost:
+			f.ClosePost(m.ID, m.Msg)
+		case clusterPublishPost:
+			return insertStoredPost(f, m.ID)
+		case clusterSetOpenBody:
+			f.SetOpenBody(m.ID, m.Body, m.Msg)
+		case clusterSend:
+			f.Send(m.Msg)
+		}
+		return nil
+	})
+}
+
+// Updates sent by other instances, while the connection to the database was
+// lost, are missing from the local feeds. Disconnect all clients, so they
+// resynchronise to feeds freshly read from the database.
+func resyncCluster() error {
+	for _, c := range All() {
+		c.Close(nil)
+	}
+	return nil
+}
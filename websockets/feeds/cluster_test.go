@@ -0,0 +1,49 @@
+package feeds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bakape/meguca/db"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestHandleClusterMessage(t *testing.T) {
+	instanceID = "foo"
+	defer func() {
+		instanceID = ""
+	}()
+
+	cases := [...]struct {
+		name, instance, body string
+		id                   uint64
+	}{
+		{"other instance", "bar", "abc", 101},
+		{"own instance", "foo", "", 102},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			buf, err := json.Marshal([]clusterMessage{{
+				Instance: c.instance,
+				Kind:     clusterSetOpenBody,
+				ID:       c.id,
+				Body:     "abc",
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = handleClusterMessages(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := db.GetOpenBody(c.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertDeepEquals(t, body, c.body)
+		})
+	}
+}
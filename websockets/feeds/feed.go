@@ -1,6 +1,7 @@
 package feeds
 
 import (
+	"database/sql"
 	"github.com/bakape/meguca/common"
 	"time"
 
@@ -32,6 +33,14 @@ type moderationMessage struct {
 	entry common.ModerationEntry
 }
 
+// Client joining a feed with its last known position in the feed's log
+type joiningClient struct {
+	client common.Client
+	pos    LogPosition
+	// Number of last posts the client displays. 0 for all.
+	lastN int
+}
+
 type syncCount struct {
 	Active int `json:"active"`
 	Total  int `json:"total"`
@@ -49,6 +58,11 @@ type Feed struct {
 	messageBuffer
 	// Entire thread cached into memory
 	cache threadCache
+	// Add a client and resync it from its last known log position. Used
+	// instead of baseFeed.add.
+	join chan joiningClient
+	// Last log position sent to clients
+	sentPos uint64
 	// Propagates mesages to all listeners
 	send chan []byte
 	// Insert a new post into the thread and propagate to listeners
@@ -90,14 +104,17 @@ func (f *Feed) Start() (err error) {
 				f.cache.evict()
 
 			// Add client
-			case c := <-f.add:
-				f.addClient(c)
+			case j := <-f.join:
+				// Flush buffered messages first, so the client does not
+				// receive messages already accounted for in its sync message
+				f.flushToAll()
+				f.addClient(j.client)
 
-				msg, err := f.cache.getSyncMessage()
+				msg, err := f.cache.getResyncMessage(j.pos, j.lastN)
 				if err != nil {
 					log.Errorf("sync message: %s", err)
 				}
-				c.Send(msg)
+				j.client.Send(msg)
 
 				f.sendIPCount()
 
@@ -115,14 +132,15 @@ func (f *Feed) Start() (err error) {
 
 			// Send any buffered messages to any listening clients
 			case <-f.C:
-				if buf := f.flush(); buf == nil {
+				if !f.flushToAll() {
 					f.pause()
-				} else {
-					f.sendToAll(buf)
 				}
 
 			// Insert a new post, cache and propagate
 			case msg := <-f.insertPost:
+				if msg.id != f.id {
+					f.cache.addReply(msg.id)
+				}
 				f.modifyPost(msg.message, func(p *cachedPost) {
 					*p = msg.cachedPost
 				})
@@ -166,6 +184,9 @@ func (f *Feed) Start() (err error) {
 				})
 				f.cache.Moderation[msg.id] = append(f.cache.Moderation[msg.id],
 					msg.entry)
+				if msg.entry.Type == common.MergeThread && msg.id == f.id {
+					f.reloadCache()
+				}
 			}
 		}
 	}()
@@ -173,19 +194,51 @@ func (f *Feed) Start() (err error) {
 	return
 }
 
+// Reread the thread from the database, keeping the message log. Used after
+// posts of another thread were merged into this one.
+func (f *Feed) reloadCache() {
+	c, err := newThreadCache(f.id)
+	switch err {
+	case nil:
+		c.log = f.cache.log
+		f.cache = c
+	case sql.ErrNoRows:
+		// This thread was merged into another one and no longer exists
+	default:
+		log.Errorf("feed %d: reloading cache: %s", f.id, err)
+	}
+}
+
 func (f *Feed) modifyPost(msg message, fn func(*cachedPost)) {
 	f.startIfPaused()
 
 	p := f.cache.Recent[msg.id]
 	fn(&p)
-	f.cache.Recent[msg.id] = p
-
 	if msg.msg != nil {
+		p.pos = f.cache.log.append(msg.msg)
 		f.write(msg.msg)
 	}
+	f.cache.Recent[msg.id] = p
+
 	f.cache.clearMemoized()
 }
 
+// Send any buffered messages to all clients followed by the current log
+// position, if it changed. Returns false, if there was nothing to send.
+func (f *Feed) flushToAll() bool {
+	if pos := f.cache.log.pos; pos != f.sentPos {
+		f.sentPos = pos
+		msg, _ := common.EncodeMessage(common.MessageFeedPosition, pos)
+		f.write(msg)
+	}
+	buf := f.flush()
+	if buf == nil {
+		return false
+	}
+	f.sendToAll(buf)
+	return true
+}
+
 // Send a message to all listening clients
 func (f *Feed) Send(msg []byte) {
 	f.send <- msg
@@ -225,6 +278,13 @@ func (f *Feed) sendIPCount() {
 // InsertPost inserts a new post into the thread or reclaim an open post after disconnect
 // and propagate to listeners
 func (f *Feed) InsertPost(p common.Post, msg []byte) {
+	if p.Editing {
+		journalPost(journalEntry{
+			op:   f.id,
+			id:   p.ID,
+			body: p.Body,
+		})
+	}
 	f.insertPost <- postCreationMessage{
 		message: message{
 			id:  p.ID,
@@ -253,6 +313,11 @@ func (f *Feed) InsertImage(id uint64, spoilered bool, msg []byte) {
 
 // ClosePost closes a feed's post
 func (f *Feed) ClosePost(id uint64, msg []byte) {
+	journalPost(journalEntry{
+		closed: true,
+		op:     f.id,
+		id:     id,
+	})
 	f.closePost <- message{
 		id:  id,
 		msg: msg,
@@ -276,8 +341,15 @@ func (f *Feed) _moderatePost(id uint64, msg []byte,
 	}
 }
 
-// SetOpenBody sets the body of an open post and send update message to clients
+// SetOpenBody sets the body of an open post and send update message to clients.
+// If journaling is enabled, blocks until the new body is written to the
+// journal.
 func (f *Feed) SetOpenBody(id uint64, body string, msg []byte) {
+	journalPost(journalEntry{
+		op:   f.id,
+		id:   id,
+		body: body,
+	})
 	f.setOpenBody <- postBodyModMessage{
 		message: message{
 			id:  id,
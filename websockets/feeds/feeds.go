@@ -5,22 +5,33 @@ package feeds
 
 import (
 	"errors"
+	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/events"
 	"sync"
+	"sync/atomic"
 )
 
-// Contains and manages all active update feeds
-var feeds = feedMap{
-	// 64 len map to avoid some possible reallocation as the server starts
-	feeds:   make(map[uint64]*Feed, 64),
-	tvFeeds: make(map[string]*tvFeed, 64),
-}
+var (
+	// Contains and manages all active update feeds
+	feeds = feedMap{
+		// 64 len map to avoid some possible reallocation as the server starts
+		feeds:   make(map[uint64]*Feed, 64),
+		tvFeeds: make(map[string]*tvFeed, 64),
+	}
+
+	// ID of the last moderation log entry sent to the feeds. Moderation
+	// entries after it are resent, if notifications were missed.
+	lastModLogID uint64
+)
 
 // Export to avoid circular dependency
 func init() {
 	common.SendTo = SendTo
 	common.ClosePost = ClosePost
+	common.PublishPost = PublishPost
 }
 
 // Container for managing client<->update-feed assignment and interaction
@@ -32,7 +43,13 @@ type feedMap struct {
 
 // Add client to feed and send it the current status of the feed for
 // synchronization to the feed's internal state
-func addToFeed(id uint64, board string, c common.Client) (
+func addToFeed(
+	id uint64,
+	board string,
+	c common.Client,
+	pos LogPosition,
+	lastN int,
+) (
 	feed *Feed, err error,
 ) {
 	feeds.mu.Lock()
@@ -45,6 +62,7 @@ func addToFeed(id uint64, board string, c common.Client) (
 		if !ok {
 			feed = &Feed{
 				id:            id,
+				join:          make(chan joiningClient),
 				send:          make(chan []byte),
 				insertPost:    make(chan postCreationMessage),
 				closePost:     make(chan message),
@@ -62,7 +80,7 @@ func addToFeed(id uint64, board string, c common.Client) (
 				return
 			}
 		}
-		feed.add <- c
+		feed.join <- joiningClient{c, pos, lastN}
 	}
 
 	return
@@ -120,6 +138,11 @@ func SendTo(id uint64, msg []byte) {
 		f.Send(msg)
 		return nil
 	})
+	relay(clusterMessage{
+		Kind: clusterSend,
+		OP:   id,
+		Msg:  msg,
+	})
 }
 
 // Run a send function of a feed, if it exists
@@ -133,27 +156,81 @@ func sendIfExists(id uint64, fn func(*Feed) error) error {
 	return nil
 }
 
-// InsertPostInto inserts a post into a tread feed, if it exists. Only use for
-// already closed posts.
+// InsertPostInto inserts a post into a tread feed, if it exists. msg can be
+// nil to only update the feed's cache.
 func InsertPostInto(post common.StandalonePost, msg []byte) {
 	sendIfExists(post.OP, func(f *Feed) error {
 		f.InsertPost(post.Post, msg)
 		return nil
 	})
+	relay(clusterMessage{
+		Kind: clusterInsertPost,
+		OP:   post.OP,
+		ID:   post.ID,
+		Post: &post.Post,
+		Msg:  msg,
+	})
+}
+
+// InsertImage inserts an image into an open post of a thread feed, if it
+// exists
+func InsertImage(op, id uint64, spoilered bool, msg []byte) {
+	sendIfExists(op, func(f *Feed) error {
+		f.InsertImage(id, spoilered, msg)
+		return nil
+	})
+	relay(clusterMessage{
+		Kind:      clusterInsertImage,
+		OP:        op,
+		ID:        id,
+		Spoilered: spoilered,
+		Msg:       msg,
+	})
+}
+
+// SpoilerImage spoilers the image of a post in a thread feed, if it exists
+func SpoilerImage(op, id uint64, msg []byte) {
+	sendIfExists(op, func(f *Feed) error {
+		f.SpoilerImage(id, msg)
+		return nil
+	})
+	relay(clusterMessage{
+		Kind: clusterSpoilerImage,
+		OP:   op,
+		ID:   id,
+		Msg:  msg,
+	})
+}
+
+// SetOpenBody sets the body of an open post in a thread feed, if it exists
+func SetOpenBody(op, id uint64, body string, msg []byte) {
+	sendIfExists(op, func(f *Feed) error {
+		f.SetOpenBody(id, body, msg)
+		return nil
+	})
+	relay(clusterMessage{
+		Kind: clusterSetOpenBody,
+		OP:   op,
+		ID:   id,
+		Body: body,
+		Msg:  msg,
+	})
+}
+
+// ShareOpenBody stores the body of an open post hidden from feeds on all other
+// instances, so its author can reclaim it through any instance
+func ShareOpenBody(id uint64, body string) {
+	relay(clusterMessage{
+		Kind: clusterSetOpenBody,
+		ID:   id,
+		Body: body,
+	})
 }
 
 // ClosePost closes a post in a feed, if it exists
 func ClosePost(id, op uint64, links []common.Link, commands []common.Command,
 ) (err error) {
-	msg, err := common.EncodeMessage(common.MessageClosePost, struct {
-		ID       uint64           `json:"id"`
-		Links    []common.Link    `json:"links"`
-		Commands []common.Command `json:"commands"`
-	}{
-		ID:       id,
-		Links:    links,
-		Commands: commands,
-	})
+	msg, err := EncodeClosePost(id, links, commands)
 	if err != nil {
 		return
 	}
@@ -162,15 +239,86 @@ func ClosePost(id, op uint64, links []common.Link, commands []common.Command,
 		f.ClosePost(id, msg)
 		return nil
 	})
+	relay(clusterMessage{
+		Kind: clusterClosePost,
+		OP:   op,
+		ID:   id,
+		Msg:  msg,
+	})
+
+	return
+}
+
+// PublishPost inserts an already closed post into a thread feed, if it exists.
+// Used for posts not yet visible to the feed's clients.
+func PublishPost(id, op uint64) error {
+	relay(clusterMessage{
+		Kind: clusterPublishPost,
+		OP:   op,
+		ID:   id,
+	})
+	return sendIfExists(op, func(f *Feed) error {
+		return insertStoredPost(f, id)
+	})
+}
 
+// Read a post from the database and insert it into a feed
+func insertStoredPost(f *Feed, id uint64) (err error) {
+	p, err := db.GetPost(id)
+	if err != nil {
+		return
+	}
+	msg, err := common.EncodeMessage(common.MessageInsertPost, p.Post)
+	if err != nil {
+		return
+	}
+	f.InsertPost(p.Post, msg)
 	return
 }
 
+// EncodeClosePost encodes a message closing an open post
+func EncodeClosePost(id uint64, links []common.Link,
+	commands []common.Command,
+) ([]byte, error) {
+	return common.EncodeMessage(common.MessageClosePost, struct {
+		ID       uint64           `json:"id"`
+		Links    []common.Link    `json:"links"`
+		Commands []common.Command `json:"commands"`
+	}{
+		ID:       id,
+		Links:    links,
+		Commands: commands,
+	})
+}
+
 // Initialize internal runtime
 func Init() (err error) {
-	return db.Listen("post_moderated", func(msg string) (err error) {
+	if JournalPath != "" {
+		err = openJournal(JournalPath)
+		if err != nil {
+			return
+		}
+	}
+	if config.Clustered {
+		err = initCluster()
+		if err != nil {
+			return
+		}
+	}
+	go pollNowPlaying()
+	err = initAnnouncementBanners()
+	if err != nil {
+		return
+	}
+
+	id, err := db.GetLastModLogID()
+	if err != nil {
+		return
+	}
+	atomic.StoreUint64(&lastModLogID, id)
+	return db.ListenResync("post_moderated", func(msg string) (err error) {
 		return handlePostModeration(msg)
-	})
+	}, resyncPostModeration)
 }
 
 // Separate function for testing
@@ -179,13 +327,61 @@ func handlePostModeration(msg string) (err error) {
 	if err != nil {
 		return
 	}
-	op, logID := arr[0], arr[1]
-	return sendIfExists(op, func(f *Feed) (err error) {
-		e, err := db.GetModLogEntry(logID)
+	return sendModeration(arr[0], arr[1])
+}
+
+// Send moderation entries missed, while notifications were not being received
+func resyncPostModeration() (err error) {
+	mods, err := db.GetPostModerationsSince(atomic.LoadUint64(&lastModLogID))
+	if err != nil {
+		return
+	}
+	for _, m := range mods {
+		err = sendModeration(m.OP, m.LogID)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Send a moderation log entry to the feed of thread op, if any, and to staff
+// feed subscribers
+func sendModeration(op, logID uint64) (err error) {
+	for {
+		last := atomic.LoadUint64(&lastModLogID)
+		if logID <= last ||
+			atomic.CompareAndSwapUint64(&lastModLogID, last, logID) {
+			break
+		}
+	}
+
+	var (
+		e       auth.ModLogEntry
+		fetched bool
+	)
+	getEntry := func() (err error) {
+		if !fetched {
+			e, err = db.GetModLogEntry(logID)
+			fetched = err == nil
+		}
+		return
+	}
+
+	err = sendIfExists(op, func(f *Feed) (err error) {
+		err = getEntry()
 		if err != nil {
 			return
 		}
 
+		switch e.Type {
+		case common.ShadowBanPost:
+			return
+		case common.UnhidePost:
+			// Post was not part of the feed till now
+			return insertStoredPost(f, e.ID)
+		}
+
 		msg, err := common.EncodeMessage(common.MessageModeratePost, struct {
 			ID uint64 `json:"id"`
 			common.ModerationEntry
@@ -197,6 +393,40 @@ func handlePostModeration(msg string) (err error) {
 		f._moderatePost(e.ID, msg, e.ModerationEntry)
 		return
 	})
+	if err != nil {
+		return
+	}
+	err = getEntry()
+	if err != nil {
+		return
+	}
+	publish := !e.Type.IsStaffOnly()
+	if publish && config.Clustered {
+		// Notifications are received by all instances. Only one may publish.
+		publish, err = db.ClaimModLogEntry(logID)
+		if err != nil {
+			return
+		}
+	}
+	if publish {
+		events.Publish(events.Event{
+			Type:   events.Moderation,
+			Board:  e.Board,
+			Thread: op,
+			Post:   e.ID,
+			Data:   e.ModerationEntry,
+		})
+	}
+	if !HasStaffSubscribers() {
+		return
+	}
+	switch e.Type {
+	case common.DeletePost, common.DeleteImage, common.PurgePost,
+		common.ShadowBanPost, common.UnhidePost:
+		return sendModerationToStaff(e.ID, e.ModerationEntry)
+	default:
+		return
+	}
 }
 
 // Clear removes all existing feeds and clients. Used only in tests.
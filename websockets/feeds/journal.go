@@ -0,0 +1,340 @@
+package feeds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"github.com/bakape/meguca/db"
+	"os"
+	"sync"
+
+	"github.com/go-playground/log"
+)
+
+const (
+	// Length and checksum of each journal record
+	journalHeaderSize = 8
+
+	// OP, post ID and closed flag of each journal record
+	journalEntryHeaderSize = 17
+
+	// Journal is compacted to only the latest state of still open posts, once
+	// it grows past this size
+	journalCompactionSize = 16 << 20
+)
+
+var (
+	// JournalPath is the path to the write-ahead journal of feed messages.
+	// Journaling is disabled, if empty.
+	JournalPath string
+
+	// Active journal. Nil, if journaling is disabled.
+	journal *messageJournal
+
+	errCorruptJournal = errors.New("corrupt journal record")
+)
+
+// State of an open post carried by a feed message
+type journalEntry struct {
+	closed bool
+	op, id uint64
+	body   string
+}
+
+// Batch of journal records fsynced together. done is closed, once the batch
+// has been synced to disk.
+type journalBatch struct {
+	done chan struct{}
+	err  error
+}
+
+// Write-ahead journal of post body modifications sent through the feeds.
+// Records are fsynced in batches and callers block until their batch has
+// been written to disk, so any body modification accepted by a feed survives
+// a server crash.
+type messageJournal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	w    *bufio.Writer
+	size int64
+
+	// Records written since the last sync
+	pending *journalBatch
+	// Wakes up the syncing goroutine
+	wake chan struct{}
+
+	// Latest state of all journaled posts, that are still open. Used for
+	// compaction.
+	open map[uint64]journalEntry
+}
+
+// Replay any existing journal at path and open a new one for writing
+func openJournal(path string) (err error) {
+	entries, err := readJournal(path)
+	if err != nil {
+		return
+	}
+	err = replayJournal(entries)
+	if err != nil {
+		return
+	}
+
+	j := &messageJournal{
+		path: path,
+		wake: make(chan struct{}, 1),
+		open: make(map[uint64]journalEntry, 64),
+	}
+	err = j.openFile()
+	if err != nil {
+		return
+	}
+	journal = j
+	go j.syncLoop()
+	return
+}
+
+// Read all valid records from a journal file. A torn or corrupt tail left
+// behind by a crash mid-write is discarded.
+func readJournal(path string) (entries []journalEntry, err error) {
+	buf, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return
+	}
+
+	for len(buf) != 0 {
+		var (
+			e journalEntry
+			n int
+		)
+		e, n, err = decodeJournalRecord(buf)
+		if err != nil {
+			log.Warnf("journal: discarding %d byte tail: %s", len(buf), err)
+			return entries, nil
+		}
+		entries = append(entries, e)
+		buf = buf[n:]
+	}
+	return
+}
+
+// Write the latest body of each post left open by the previous server
+// instance to the open post body store
+func replayJournal(entries []journalEntry) (err error) {
+	latest := make(map[uint64]journalEntry, len(entries))
+	for _, e := range entries {
+		latest[e.id] = e
+	}
+
+	replayed := 0
+	for id, e := range latest {
+		if e.closed {
+			continue
+		}
+		var open bool
+		open, err = db.IsPostOpen(id)
+		if err != nil {
+			return
+		}
+		if !open {
+			continue
+		}
+		err = db.SetOpenBody(id, []byte(e.body))
+		if err != nil {
+			return
+		}
+		replayed++
+	}
+	if replayed != 0 {
+		log.Infof("journal: recovered %d open post bodies", replayed)
+	}
+	return
+}
+
+// Encode a journal record with its length and checksum header
+func encodeJournalRecord(e journalEntry) []byte {
+	l := journalEntryHeaderSize + len(e.body)
+	buf := make([]byte, journalHeaderSize+l)
+	payload := buf[journalHeaderSize:]
+
+	if e.closed {
+		payload[0] = 1
+	}
+	binary.LittleEndian.PutUint64(payload[1:], e.op)
+	binary.LittleEndian.PutUint64(payload[9:], e.id)
+	copy(payload[journalEntryHeaderSize:], e.body)
+
+	binary.LittleEndian.PutUint32(buf, uint32(l))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+	return buf
+}
+
+// Decode the first journal record in buf and return it together with the
+// number of bytes consumed
+func decodeJournalRecord(buf []byte) (e journalEntry, n int, err error) {
+	if len(buf) < journalHeaderSize {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	l := int(binary.LittleEndian.Uint32(buf))
+	n = journalHeaderSize + l
+	if l < journalEntryHeaderSize {
+		err = errCorruptJournal
+		return
+	}
+	if len(buf) < n {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	payload := buf[journalHeaderSize:n]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(buf[4:]) {
+		err = errCorruptJournal
+		return
+	}
+
+	e.closed = payload[0] == 1
+	e.op = binary.LittleEndian.Uint64(payload[1:])
+	e.id = binary.LittleEndian.Uint64(payload[9:])
+	e.body = string(payload[journalEntryHeaderSize:])
+	return
+}
+
+// Create a new empty journal file. Requires lock of j.mu, if the journal is
+// already in use.
+func (j *messageJournal) openFile() (err error) {
+	j.file, err = os.OpenFile(j.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		0600)
+	if err != nil {
+		return
+	}
+	j.w = bufio.NewWriter(j.file)
+	j.size = 0
+	return
+}
+
+// Append a record to the journal and block until it is synced to disk
+func (j *messageJournal) append(e journalEntry) error {
+	buf := encodeJournalRecord(e)
+
+	j.mu.Lock()
+	_, err := j.w.Write(buf)
+	if err != nil {
+		j.mu.Unlock()
+		return err
+	}
+	j.size += int64(len(buf))
+	if e.closed {
+		delete(j.open, e.id)
+	} else {
+		j.open[e.id] = e
+	}
+	if j.pending == nil {
+		j.pending = &journalBatch{
+			done: make(chan struct{}),
+		}
+	}
+	b := j.pending
+	j.mu.Unlock()
+
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+
+	<-b.done
+	return b.err
+}
+
+// Sync pending record batches to disk. Records appended during a sync are
+// accumulated and synced together in the next batch.
+func (j *messageJournal) syncLoop() {
+	for range j.wake {
+		j.mu.Lock()
+		b := j.pending
+		j.pending = nil
+		if b == nil {
+			j.mu.Unlock()
+			continue
+		}
+		err := j.w.Flush()
+		f := j.file
+		j.mu.Unlock()
+
+		if err == nil {
+			err = f.Sync()
+		}
+		b.err = err
+		close(b.done)
+
+		if err == nil {
+			err = j.compactIfNeeded()
+		}
+		if err != nil {
+			log.Errorf("journal: %s", err)
+		}
+	}
+}
+
+// Rewrite the journal with only the latest state of still open posts, if it
+// has grown too large
+func (j *messageJournal) compactIfNeeded() (err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.size < journalCompactionSize || j.pending != nil {
+		return
+	}
+
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	w := bufio.NewWriter(f)
+	var size int64
+	for _, e := range j.open {
+		buf := encodeJournalRecord(e)
+		_, err = w.Write(buf)
+		if err != nil {
+			f.Close()
+			return
+		}
+		size += int64(len(buf))
+	}
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		return
+	}
+	err = os.Rename(tmp, j.path)
+	if err != nil {
+		f.Close()
+		return
+	}
+
+	j.file.Close()
+	j.file = f
+	j.w = w
+	j.size = size
+	return
+}
+
+// Journal the state of an open post carried by a feed message, if journaling
+// is enabled
+func journalPost(e journalEntry) {
+	if journal == nil {
+		return
+	}
+	if err := journal.append(e); err != nil {
+		log.Errorf("journal: post %d: %s", e.id, err)
+	}
+}
@@ -0,0 +1,81 @@
+package feeds
+
+import (
+	"io/ioutil"
+	. "github.com/bakape/meguca/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRecordEncoding(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name  string
+		entry journalEntry
+	}{
+		{"open", journalEntry{op: 1, id: 2, body: "foo"}},
+		{"empty body", journalEntry{op: 1, id: 3}},
+		{"closed", journalEntry{closed: true, op: 1, id: 2}},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := encodeJournalRecord(c.entry)
+			e, n, err := decodeJournalRecord(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertDeepEquals(t, n, len(buf))
+			AssertDeepEquals(t, e, c.entry)
+		})
+	}
+}
+
+func TestReadTornJournal(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "meguca_journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	std := []journalEntry{
+		{op: 1, id: 2, body: "foo"},
+		{op: 1, id: 2, body: "foob"},
+	}
+	var buf []byte
+	for _, e := range std {
+		buf = append(buf, encodeJournalRecord(e)...)
+	}
+	torn := encodeJournalRecord(journalEntry{op: 1, id: 2, body: "fooba"})
+	buf = append(buf, torn[:len(torn)-2]...)
+
+	path := filepath.Join(dir, "journal")
+	err = ioutil.WriteFile(path, buf, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := readJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, res, std)
+}
+
+func TestCorruptJournalRecord(t *testing.T) {
+	t.Parallel()
+
+	buf := encodeJournalRecord(journalEntry{op: 1, id: 2, body: "foo"})
+	buf[len(buf)-1] = 'x'
+	_, _, err := decodeJournalRecord(buf)
+	if err != errCorruptJournal {
+		UnexpectedError(t, err)
+	}
+}
@@ -0,0 +1,81 @@
+package feeds
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"strconv"
+)
+
+const (
+	// Maximum number of most recent post modification messages retained in
+	// the feed's log for replaying to resyncing clients
+	logTailSize = 1 << 10
+
+	// Maximum number of post insertion messages included in delta snapshots
+	deltaTailSize = 64
+)
+
+// Log of post modification messages sent through a feed. Only a tail of the
+// most recent messages is retained.
+type messageLog struct {
+	// Unix microsecond time of the log's creation. Positions of different
+	// log instances are not comparable.
+	epoch int64
+	// Position of the last written message
+	pos uint64
+	// Most recent messages. The last message is at position pos.
+	tail []string
+}
+
+// Append a message to the log and return its position
+func (l *messageLog) append(msg []byte) uint64 {
+	if len(l.tail) == logTailSize {
+		copy(l.tail, l.tail[1:])
+		l.tail = l.tail[:logTailSize-1]
+	}
+	l.tail = append(l.tail, string(msg))
+	l.pos++
+	return l.pos
+}
+
+// Current position of the log
+func (l *messageLog) position() LogPosition {
+	return LogPosition{
+		Epoch:    l.epoch,
+		Position: l.pos,
+	}
+}
+
+// Returns, if the position pos of log instance epoch can be resynced from
+// the log
+func (l *messageLog) contains(epoch int64, pos uint64) bool {
+	return epoch == l.epoch && pos <= l.pos
+}
+
+// Return all messages after position pos, if they are still retained in the
+// log
+func (l *messageLog) since(pos uint64) ([]string, bool) {
+	first := l.pos - uint64(len(l.tail))
+	if pos < first || pos > l.pos {
+		return nil, false
+	}
+	return l.tail[pos-first:], true
+}
+
+// Returns the number of missed messages for a feed of postCount posts, up to
+// which missed messages are replayed instead of sending a delta snapshot
+func replayThreshold(postCount int) uint64 {
+	return uint64(config.Get().ResyncThreshold) * uint64(postCount)
+}
+
+// Extract the type of an encoded message
+func messageType(msg string) common.MessageType {
+	if len(msg) < 2 {
+		return common.MessageInvalid
+	}
+	t, err := strconv.ParseUint(msg[:2], 10, 8)
+	if err != nil {
+		return common.MessageInvalid
+	}
+	return common.MessageType(t)
+}
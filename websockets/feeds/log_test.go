@@ -0,0 +1,202 @@
+package feeds
+
+import (
+	"encoding/json"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	. "github.com/bakape/meguca/test"
+	"strconv"
+	"testing"
+)
+
+func TestMessageLog(t *testing.T) {
+	t.Parallel()
+
+	l := messageLog{epoch: 1}
+	for i := 0; i < logTailSize+10; i++ {
+		l.append([]byte(strconv.Itoa(i + 1)))
+	}
+
+	if l.contains(2, 1) {
+		t.Fatal("contains position of other epoch")
+	}
+	if l.contains(1, l.pos+1) {
+		t.Fatal("contains future position")
+	}
+
+	msgs, ok := l.since(l.pos - 2)
+	if !ok {
+		t.Fatal("recent position not retained")
+	}
+	AssertDeepEquals(t, msgs, []string{
+		strconv.Itoa(logTailSize + 9),
+		strconv.Itoa(logTailSize + 10),
+	})
+
+	if _, ok := l.since(5); ok {
+		t.Fatal("evicted position retained")
+	}
+}
+
+func TestResyncMessage(t *testing.T) {
+	conf := config.Defaults
+	conf.ResyncThreshold = 1
+	config.Set(conf)
+
+	encode := func(typ common.MessageType, id uint64) []byte {
+		msg, err := common.EncodeMessage(typ, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	c := threadCache{
+		syncMessage: syncMessage{
+			Recent:     make(map[uint64]cachedPost),
+			Moderation: make(map[uint64][]common.ModerationEntry),
+		},
+		replies: []uint64{1, 2},
+		log: messageLog{
+			epoch: 1,
+		},
+	}
+	modify := func(id uint64, typ common.MessageType) {
+		p := c.Recent[id]
+		p.Body += "a"
+		p.pos = c.log.append(encode(typ, id))
+		c.Recent[id] = p
+	}
+	modify(1, common.MessageInsertPost)
+	modify(1, common.MessageAppend)
+	modify(2, common.MessageInsertPost)
+
+	decode := func(buf []byte) (msg syncMessage) {
+		if typ := messageType(string(buf)); typ != common.MessageSynchronise {
+			t.Fatalf("unexpected message type: %d", typ)
+		}
+		err := json.Unmarshal(buf[2:], &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		buf, err := c.getResyncMessage(LogPosition{1, 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := decode(buf)
+		if !msg.Delta || len(msg.Recent) != 0 {
+			t.Fatal("not a replay")
+		}
+		AssertDeepEquals(t, msg.Log, []string{
+			string(encode(common.MessageAppend, 1)),
+			string(encode(common.MessageInsertPost, 2)),
+		})
+		AssertDeepEquals(t, msg.LogPosition, LogPosition{1, 3})
+	})
+
+	t.Run("delta snapshot", func(t *testing.T) {
+		modify(1, common.MessageAppend)
+		modify(1, common.MessageAppend)
+
+		buf, err := c.getResyncMessage(LogPosition{1, 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := decode(buf)
+		if !msg.Delta {
+			t.Fatal("not a delta")
+		}
+		AssertDeepEquals(t, msg.Log, []string{
+			string(encode(common.MessageInsertPost, 2)),
+		})
+		AssertDeepEquals(t, msg.Recent, map[uint64]cachedPost{
+			1: {Body: "aaaa"},
+			2: {Body: "a"},
+		})
+	})
+
+	t.Run("evicted post", func(t *testing.T) {
+		c := c
+		c.Recent = map[uint64]cachedPost{2: c.Recent[2]}
+		c.evictedPos = c.log.pos
+
+		buf, err := c.getResyncMessage(LogPosition{1, 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decode(buf).Delta {
+			t.Fatal("delta sync after eviction")
+		}
+	})
+
+	t.Run("other epoch", func(t *testing.T) {
+		buf, err := c.getResyncMessage(LogPosition{2, 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := decode(buf)
+		if msg.Delta {
+			t.Fatal("delta sync for other epoch")
+		}
+		if len(msg.Recent) != 2 {
+			t.Fatal("not a full sync")
+		}
+	})
+}
+
+func TestAbbreviatedSyncMessage(t *testing.T) {
+	c := threadCache{
+		syncMessage: syncMessage{
+			Recent: map[uint64]cachedPost{
+				2: {Body: "a"},
+				3: {Body: "b"},
+			},
+			Moderation: map[uint64][]common.ModerationEntry{
+				1: {{Type: common.LockThread}},
+				2: {{Type: common.DeletePost}},
+			},
+		},
+		log: messageLog{
+			epoch: 1,
+		},
+	}
+	for _, id := range [...]uint64{3, 2, 4} {
+		c.addReply(id)
+	}
+	AssertDeepEquals(t, c.replies, []uint64{2, 3, 4})
+
+	decode := func(lastN int) (msg syncMessage) {
+		t.Helper()
+		buf, err := c.getSyncMessage(lastN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = json.Unmarshal(buf[2:], &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	t.Run("full", func(t *testing.T) {
+		msg := decode(0)
+		AssertDeepEquals(t, msg.Omitted, 0)
+		AssertDeepEquals(t, len(msg.Recent), 2)
+		AssertDeepEquals(t, len(msg.Moderation), 2)
+	})
+
+	t.Run("last 2", func(t *testing.T) {
+		msg := decode(2)
+		AssertDeepEquals(t, msg.Omitted, 1)
+		AssertDeepEquals(t, msg.Recent, map[uint64]cachedPost{
+			3: {Body: "b"},
+		})
+		AssertDeepEquals(t, msg.Moderation, map[uint64][]common.ModerationEntry{
+			1: {{Type: common.LockThread}},
+		})
+	})
+}
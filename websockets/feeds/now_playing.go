@@ -0,0 +1,177 @@
+// Internet radio "now playing" banner
+
+package feeds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/go-playground/log"
+)
+
+const (
+	// Interval between polls of the radio API
+	nowPlayingInterval = 10 * time.Second
+
+	// Maximum interval between polls, while the radio API is down
+	nowPlayingMaxInterval = 5 * time.Minute
+)
+
+var (
+	nowPlayingClient = &http.Client{Timeout: 10 * time.Second}
+
+	// Song last received from the radio API. Zero value, if none or the API
+	// is down.
+	nowPlaying struct {
+		sync.RWMutex
+		song NowPlaying
+	}
+)
+
+// NowPlaying describes the song currently playing on the internet radio
+type NowPlaying struct {
+	Song      string `json:"np"`
+	DJ        string `json:"dj"`
+	Listeners uint   `json:"listeners"`
+
+	// Website of the radio
+	URL string `json:"url"`
+}
+
+// Poll the radio API set in the server configuration and send changes of the
+// playing song to clients of boards with the radio banner enabled. While the
+// API is down, the banner is hidden and the API is polled with exponential
+// backoff.
+func pollNowPlaying() {
+	var (
+		interval = nowPlayingInterval
+		down     bool
+	)
+	for {
+		api := config.Get().RadioAPI
+		if api == "" {
+			interval = nowPlayingInterval
+			down = false
+			setNowPlaying(NowPlaying{})
+		} else if np, err := fetchNowPlaying(api); err != nil {
+			if !down {
+				log.Warnf("now playing: radio API down: %s", err)
+				down = true
+			}
+			setNowPlaying(NowPlaying{})
+			interval *= 2
+			if interval > nowPlayingMaxInterval {
+				interval = nowPlayingMaxInterval
+			}
+		} else {
+			if down {
+				log.Info("now playing: radio API restored")
+				down = false
+			}
+			interval = nowPlayingInterval
+			setNowPlaying(np)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Fetch the currently playing song from an r/a/dio style API
+func fetchNowPlaying(api string) (np NowPlaying, err error) {
+	u, err := url.Parse(api)
+	if err != nil {
+		return
+	}
+	res, err := nowPlayingClient.Get(api)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		err = fmt.Errorf("unexpected status: %s", res.Status)
+		return
+	}
+
+	np, err = decodeNowPlaying(res.Body)
+	np.URL = u.Scheme + "://" + u.Host + "/"
+	return
+}
+
+// Decode an r/a/dio style API response
+func decodeNowPlaying(r io.Reader) (np NowPlaying, err error) {
+	var res struct {
+		Main struct {
+			NP        string `json:"np"`
+			Listeners uint   `json:"listeners"`
+			DJ        struct {
+				Name string `json:"djname"`
+			} `json:"dj"`
+		} `json:"main"`
+	}
+	err = json.NewDecoder(r).Decode(&res)
+	if err != nil {
+		return
+	}
+	np = NowPlaying{
+		Song:      res.Main.NP,
+		DJ:        res.Main.DJ.Name,
+		Listeners: res.Main.Listeners,
+	}
+	return
+}
+
+// Store the currently playing song and send it to all clients of boards with
+// the radio banner enabled, if changed
+func setNowPlaying(np NowPlaying) {
+	nowPlaying.Lock()
+	changed := np != nowPlaying.song
+	nowPlaying.song = np
+	nowPlaying.Unlock()
+	if !changed {
+		return
+	}
+
+	msg, err := common.EncodeMessage(common.MessageNowPlaying, np)
+	if err != nil {
+		log.Errorf("now playing: %s", err)
+		return
+	}
+
+	clients.RLock()
+	defer clients.RUnlock()
+
+	enabled := make(map[string]bool)
+	for cl, s := range clients.clients {
+		on, ok := enabled[s.board]
+		if !ok {
+			on = config.GetBoardConfigs(s.board).RadioBanner
+			enabled[s.board] = on
+		}
+		if on {
+			cl.Send(msg)
+		}
+	}
+}
+
+// NowPlayingMessage returns the message with the currently playing song to
+// send to a client synchronising to board or nil, if there is none or the
+// board does not have the radio banner enabled
+func NowPlayingMessage(board string) ([]byte, error) {
+	if !config.GetBoardConfigs(board).RadioBanner {
+		return nil, nil
+	}
+
+	nowPlaying.RLock()
+	np := nowPlaying.song
+	nowPlaying.RUnlock()
+	if np == (NowPlaying{}) {
+		return nil, nil
+	}
+	return common.EncodeMessage(common.MessageNowPlaying, np)
+}
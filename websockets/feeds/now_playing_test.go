@@ -0,0 +1,130 @@
+package feeds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	. "github.com/bakape/meguca/test"
+)
+
+const radioResponse = `{"main":{"np":"Girls, Be Ambitious","listeners":42,` +
+	`"dj":{"djname":"Hanyuu-sama"}}}`
+
+func TestDecodeNowPlaying(t *testing.T) {
+	t.Parallel()
+
+	np, err := decodeNowPlaying(strings.NewReader(radioResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, np, NowPlaying{
+		Song:      "Girls, Be Ambitious",
+		DJ:        "Hanyuu-sama",
+		Listeners: 42,
+	})
+}
+
+func TestFetchNowPlaying(t *testing.T) {
+	t.Parallel()
+
+	t.Run("up", func(t *testing.T) {
+		t.Parallel()
+
+		s := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(radioResponse))
+			},
+		))
+		defer s.Close()
+
+		np, err := fetchNowPlaying(s.URL + "/api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, np.Song, "Girls, Be Ambitious")
+		AssertDeepEquals(t, np.URL, s.URL+"/")
+	})
+
+	t.Run("down", func(t *testing.T) {
+		t.Parallel()
+
+		s := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(503)
+			},
+		))
+		defer s.Close()
+
+		if _, err := fetchNowPlaying(s.URL + "/api"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestSetNowPlaying(t *testing.T) {
+	for _, c := range [...]config.BoardConfigs{
+		{
+			ID: "radio",
+			BoardPublic: config.BoardPublic{
+				RadioBanner: true,
+			},
+		},
+		{ID: "quiet"},
+	} {
+		if _, err := config.SetBoardConfigs(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var enabled, disabled staffClient
+	clients.Lock()
+	clients.clients[&enabled] = syncID{board: "radio"}
+	clients.clients[&disabled] = syncID{board: "quiet"}
+	clients.Unlock()
+	defer func() {
+		clients.Lock()
+		delete(clients.clients, &enabled)
+		delete(clients.clients, &disabled)
+		clients.Unlock()
+		setNowPlaying(NowPlaying{})
+		config.RemoveBoard("radio")
+		config.RemoveBoard("quiet")
+	}()
+
+	np := NowPlaying{
+		Song:      "Super Special",
+		DJ:        "Hanyuu-sama",
+		Listeners: 7,
+		URL:       "https://r-a-d.io/",
+	}
+	std, err := common.EncodeMessage(common.MessageNowPlaying, np)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged songs are not resent
+	setNowPlaying(np)
+	setNowPlaying(np)
+	AssertDeepEquals(t, enabled.msgs, [][]byte{std})
+	AssertDeepEquals(t, len(disabled.msgs), 0)
+
+	t.Run("new client", func(t *testing.T) {
+		msg, err := NowPlayingMessage("radio")
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, msg, std)
+
+		msg, err = NowPlayingMessage("quiet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg != nil {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+	})
+}
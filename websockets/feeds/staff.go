@@ -0,0 +1,235 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"github.com/bakape/meguca/db"
+)
+
+// Types of staff feed events
+const (
+	StaffPost       = "post"
+	StaffReport     = "report"
+	StaffModeration = "moderation"
+)
+
+var (
+	// Clients subscribed to the staff feed
+	staff = struct {
+		sync.RWMutex
+		clients map[common.Client]staffSubscription
+	}{
+		clients: make(map[common.Client]staffSubscription),
+	}
+
+	errNoStaffBoards = errors.New("staff feed: no moderated boards selected")
+)
+
+// StaffFilter restricts the events sent to a staff feed subscriber
+type StaffFilter struct {
+	// Only send events from these boards. Empty means all boards the
+	// subscriber holds a staff position on.
+	Boards []string `json:"boards"`
+
+	// Only send events of posts with images
+	HasImage bool `json:"hasImage"`
+
+	// Only send events of posts with this IP hash
+	IPHash string `json:"ipHash"`
+}
+
+// StaffEvent is a new post, report or moderation action sent to staff feed
+// subscribers
+type StaffEvent struct {
+	Type     string `json:"type"`
+	Board    string `json:"board"`
+	ID       uint64 `json:"id"`
+	OP       uint64 `json:"op"`
+	HasImage bool   `json:"hasImage"`
+
+	// Anonymised identifier of the IP the post was created from
+	IPHash string `json:"ipHash,omitempty"`
+
+	// Set for new posts
+	Post *common.Post `json:"post,omitempty"`
+
+	// Set for reports
+	Reason string `json:"reason,omitempty"`
+
+	// Set for moderation actions
+	Moderation *common.ModerationEntry `json:"moderation,omitempty"`
+}
+
+type staffSubscription struct {
+	// Boards to send events from. Nil means all boards.
+	boards map[string]bool
+	StaffFilter
+}
+
+func (s staffSubscription) matches(e StaffEvent) bool {
+	switch {
+	case s.boards != nil && !s.boards[e.Board]:
+		return false
+	case s.HasImage && !e.HasImage:
+		return false
+	case s.IPHash != "" && s.IPHash != e.IPHash:
+		return false
+	default:
+		return true
+	}
+}
+
+// SubscribeToStaff subscribes a client to events of boards filtered by f.
+// staffBoards are the boards the client holds a staff position on with "all"
+// denoting all boards. Clients are automatically unsubscribed on disconnect.
+func SubscribeToStaff(c common.Client, staffBoards []string, f StaffFilter,
+) error {
+	var global bool
+	allowed := make(map[string]bool, len(staffBoards))
+	for _, b := range staffBoards {
+		if b == "all" {
+			global = true
+		}
+		allowed[b] = true
+	}
+
+	sub := staffSubscription{StaffFilter: f}
+	switch {
+	case len(f.Boards) != 0:
+		sub.boards = make(map[string]bool, len(f.Boards))
+		for _, b := range f.Boards {
+			if global || allowed[b] {
+				sub.boards[b] = true
+			}
+		}
+		if len(sub.boards) == 0 {
+			return errNoStaffBoards
+		}
+	case !global:
+		if len(allowed) == 0 {
+			return errNoStaffBoards
+		}
+		sub.boards = allowed
+	}
+
+	staff.Lock()
+	defer staff.Unlock()
+	staff.clients[c] = sub
+	return nil
+}
+
+// Unsubscribe a client from the staff feed, if subscribed
+func unsubscribeFromStaff(c common.Client) {
+	staff.Lock()
+	defer staff.Unlock()
+	delete(staff.clients, c)
+}
+
+// HasStaffSubscribers returns, if any clients are subscribed to the staff
+// feed. Used to skip building events no one would receive.
+func HasStaffSubscribers() bool {
+	staff.RLock()
+	defer staff.RUnlock()
+	return len(staff.clients) != 0
+}
+
+// SendToStaff sends an event to all matching staff feed subscribers
+func SendToStaff(e StaffEvent) (err error) {
+	staff.RLock()
+	defer staff.RUnlock()
+
+	var msg []byte
+	for c, sub := range staff.clients {
+		if !sub.matches(e) {
+			continue
+		}
+		if msg == nil {
+			msg, err = common.EncodeMessage(common.MessageStaffFeed, e)
+			if err != nil {
+				return
+			}
+		}
+		c.Send(msg)
+	}
+	return
+}
+
+// SendPostToStaff sends a newly created post to staff feed subscribers.
+// ip is the IP or IP hash as stored in the database.
+func SendPostToStaff(p common.StandalonePost, ip string) error {
+	if !HasStaffSubscribers() {
+		return nil
+	}
+	return SendToStaff(StaffEvent{
+		Type:     StaffPost,
+		Board:    p.Board,
+		ID:       p.ID,
+		OP:       p.OP,
+		HasImage: p.Image != nil,
+		IPHash:   StaffIPHash(ip),
+		Post:     &p.Post,
+	})
+}
+
+// SendReportToStaff sends a new report of a post to staff feed subscribers
+func SendReportToStaff(id uint64, reason string) (err error) {
+	if !HasStaffSubscribers() {
+		return
+	}
+	e, err := staffPostEvent(StaffReport, id)
+	if err != nil {
+		return
+	}
+	e.Reason = reason
+	return SendToStaff(e)
+}
+
+// Send a moderation action on a post to staff feed subscribers
+func sendModerationToStaff(id uint64, entry common.ModerationEntry,
+) (err error) {
+	e, err := staffPostEvent(StaffModeration, id)
+	if err != nil {
+		return
+	}
+	e.Moderation = &entry
+	return SendToStaff(e)
+}
+
+// Build an event of type typ concerning an existing post
+func staffPostEvent(typ string, id uint64) (e StaffEvent, err error) {
+	p, err := db.GetPost(id)
+	if err != nil {
+		return
+	}
+	ip, err := db.GetPostIP(id)
+	if err != nil {
+		return
+	}
+	e = StaffEvent{
+		Type:     typ,
+		Board:    p.Board,
+		ID:       id,
+		OP:       p.OP,
+		HasImage: p.Image != nil,
+		IPHash:   StaffIPHash(ip),
+	}
+	return
+}
+
+// StaffIPHash generates an identifier for correlating posts of the same IP,
+// that can not be reversed to the IP. ip is the IP or IP hash as stored in the
+// database.
+func StaffIPHash(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(config.Get().Salt))
+	h.Write([]byte(ip))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:9])
+}
@@ -0,0 +1,125 @@
+package feeds
+
+import (
+	"testing"
+
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+)
+
+// Records messages sent to a client
+type staffClient struct {
+	msgs [][]byte
+}
+
+func (c *staffClient) Send(msg []byte) { c.msgs = append(c.msgs, msg) }
+func (c *staffClient) Redirect(string) {}
+func (c *staffClient) IP() string      { return "::1" }
+func (c *staffClient) LastTime() int64 { return 0 }
+func (c *staffClient) Close(error)     {}
+
+func TestSubscribeToStaff(t *testing.T) {
+	cases := [...]struct {
+		name        string
+		staffBoards []string
+		filter      StaffFilter
+		err         error
+		boards      map[string]bool
+	}{
+		{
+			name:        "all staff boards",
+			staffBoards: []string{"a", "c"},
+			boards:      map[string]bool{"a": true, "c": true},
+		},
+		{
+			name:        "global staff",
+			staffBoards: []string{"all"},
+		},
+		{
+			name:        "filter boards",
+			staffBoards: []string{"a", "c"},
+			filter:      StaffFilter{Boards: []string{"a", "x"}},
+			boards:      map[string]bool{"a": true},
+		},
+		{
+			name:        "global staff filter boards",
+			staffBoards: []string{"all"},
+			filter:      StaffFilter{Boards: []string{"x"}},
+			boards:      map[string]bool{"x": true},
+		},
+		{
+			name:        "no moderated boards",
+			staffBoards: []string{"a"},
+			filter:      StaffFilter{Boards: []string{"c"}},
+			err:         errNoStaffBoards,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl := new(staffClient)
+			defer unsubscribeFromStaff(cl)
+			err := SubscribeToStaff(cl, c.staffBoards, c.filter)
+			if err != c.err {
+				UnexpectedError(t, err)
+			}
+			if err != nil {
+				return
+			}
+
+			staff.RLock()
+			sub := staff.clients[cl]
+			staff.RUnlock()
+			AssertDeepEquals(t, sub.boards, c.boards)
+		})
+	}
+}
+
+func TestSendToStaff(t *testing.T) {
+	all := new(staffClient)
+	images := new(staffClient)
+	byIP := new(staffClient)
+	clients := [...]struct {
+		cl     *staffClient
+		filter StaffFilter
+	}{
+		{all, StaffFilter{}},
+		{images, StaffFilter{HasImage: true}},
+		{byIP, StaffFilter{IPHash: "foo"}},
+	}
+	for _, c := range clients {
+		if err := SubscribeToStaff(c.cl, []string{"a"}, c.filter); err != nil {
+			t.Fatal(err)
+		}
+		defer unsubscribeFromStaff(c.cl)
+	}
+
+	events := [...]StaffEvent{
+		{Type: StaffPost, Board: "a", ID: 1, HasImage: true},
+		{Type: StaffReport, Board: "a", ID: 2, IPHash: "foo"},
+		{Type: StaffPost, Board: "c", ID: 3, HasImage: true},
+	}
+	for _, e := range events {
+		if err := SendToStaff(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	encode := func(e StaffEvent) []byte {
+		t.Helper()
+		msg, err := common.EncodeMessage(common.MessageStaffFeed, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	AssertDeepEquals(t, all.msgs, [][]byte{
+		encode(events[0]),
+		encode(events[1]),
+	})
+	AssertDeepEquals(t, images.msgs, [][]byte{encode(events[0])})
+	AssertDeepEquals(t, byIP.msgs, [][]byte{encode(events[1])})
+}
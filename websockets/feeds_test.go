@@ -1,8 +1,10 @@
 package websockets
 
 import (
+	. "github.com/bakape/meguca/test"
 	"github.com/bakape/meguca/test/test_db"
 	"github.com/bakape/meguca/websockets/feeds"
+	"regexp"
 	"testing"
 )
 
@@ -19,7 +21,14 @@ func TestStreamUpdates(t *testing.T) {
 	registerClient(t, cl, 1, "a")
 	go readListenErrors(t, cl, sv)
 
-	assertMessage(t, wcl, `30{"recent":{},"moderation":{}}`)
+	_, msg, err := wcl.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pattern = `^30{"epoch":\d+,"position":0,"recent":{},"moderation":{}}$`
+	if !regexp.MustCompile(pattern).Match(msg) {
+		LogUnexpected(t, pattern, string(msg))
+	}
 	assertMessage(t, wcl, "33[\"35{\\\"active\\\":0,\\\"total\\\":1}\"]")
 
 	// Send message
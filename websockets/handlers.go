@@ -8,6 +8,14 @@ import (
 	"github.com/bakape/meguca/websockets/feeds"
 )
 
+// Post modification message wrapped in an envelope identifying the thread of
+// the client's open post it targets
+type targetedMessage struct {
+	Thread uint64             `json:"thread"`
+	Type   common.MessageType `json:"type"`
+	Data   json.RawMessage    `json:"data"`
+}
+
 // Decode message JSON into the supplied type. Will augment, once we switch to
 // a binary message protocol.
 func decodeMessage(data []byte, dest interface{}) error {
@@ -22,6 +30,46 @@ func (c *Client) runHandler(typ common.MessageType, msg []byte) error {
 		return c.synchronise(data)
 	case common.MessageReclaim:
 		return c.reclaimPost(data)
+	case common.MessageInsertPost:
+		// Replies are always created in the synchronised thread
+		_, op, _ := feeds.GetSync(c)
+		c.selectPost(op)
+		return c.insertPost(data)
+	case common.MessageReservePost:
+		return c.reservePost()
+	case common.MessageTargetPost:
+		return c.runTargetedHandler(data)
+	case common.MessageNOOP:
+		// No operation message handler. Used as a one way pseudo-ping.
+		return nil
+	case common.MessageMeguTV:
+		return feeds.SubscribeToMeguTV(c)
+	case common.MessageStaffFeed:
+		return c.subscribeToStaff(data)
+	default:
+		// Messages without an envelope target the open post in the
+		// synchronised thread
+		_, op, _ := feeds.GetSync(c)
+		c.selectPost(op)
+		return c.runPostHandler(typ, data, msg)
+	}
+}
+
+// Run the handler of a post modification message wrapped in an envelope
+func (c *Client) runTargetedHandler(data []byte) error {
+	var msg targetedMessage
+	if err := decodeMessage(data, &msg); err != nil {
+		return err
+	}
+	c.selectPost(msg.Thread)
+	return c.runPostHandler(msg.Type, msg.Data, data)
+}
+
+// Run the appropriate handler for a message modifying the currently targeted
+// open post. msg is the entire message for error reporting.
+func (c *Client) runPostHandler(typ common.MessageType, data, msg []byte,
+) error {
+	switch typ {
 	case common.MessageAppend:
 		return c.appendRune(data)
 	case common.MessageBackspace:
@@ -30,17 +78,16 @@ func (c *Client) runHandler(typ common.MessageType, msg []byte) error {
 		return c.closePost()
 	case common.MessageSplice:
 		return c.spliceText(data)
-	case common.MessageInsertPost:
-		return c.insertPost(data)
+	case common.MessageUndo:
+		return c.undo()
+	case common.MessageRedo:
+		return c.redo()
 	case common.MessageInsertImage:
 		return c.insertImage(data)
-	case common.MessageNOOP:
-		// No operation message handler. Used as a one way pseudo-ping.
-		return nil
 	case common.MessageSpoiler:
 		return c.spoilerImage()
-	case common.MessageMeguTV:
-		return feeds.SubscribeToMeguTV(c)
+	case common.MessageDrawStroke:
+		return c.drawStroke(data)
 	default:
 		return errInvalidPayload(msg)
 	}
@@ -33,7 +33,5 @@ func TestDecodeMessage(t *testing.T) {
 	if err := decodeMessage(data, &msg); err != nil {
 		t.Fatal(err)
 	}
-	if msg != std {
-		LogUnexpected(t, std, msg)
-	}
+	AssertDeepEquals(t, msg, std)
 }
@@ -0,0 +1,79 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+)
+
+// Sent to clients in common.MessageHandshake
+type handshake struct {
+	Version            string   `json:"version"`
+	ProtocolVersion    uint     `json:"protocolVersion"`
+	MinProtocolVersion uint     `json:"minProtocolVersion"`
+	Features           []string `json:"features"`
+}
+
+// Send the server version, supported protocol versions and the features
+// negotiated for the client
+func (c *Client) sendHandshake(features []string) error {
+	return c.sendMessage(common.MessageHandshake, handshake{
+		Version:            common.Version,
+		ProtocolVersion:    common.ProtocolVersion,
+		MinProtocolVersion: common.MinProtocolVersion,
+		Features:           features,
+	})
+}
+
+// Returns the optional protocol features enabled on a board
+func enabledFeatures(board string) []string {
+	conf := config.Get()
+	boardConf := config.GetBoardConfigs(board)
+
+	features := make([]string, 0, 6)
+	features = append(features, common.FeatureBoardData)
+	if !boardConf.HideOpenPosts {
+		features = append(features, common.FeatureLiveUpdates)
+	}
+	if !boardConf.TextOnly {
+		features = append(features, common.FeatureOekaki)
+	}
+	if conf.EncryptedThreads {
+		features = append(features, common.FeatureEncryptedThreads)
+	}
+	if conf.ProofOfWork {
+		features = append(features, common.FeatureProofOfWork)
+	}
+	if conf.RadioAPI != "" && boardConf.RadioBanner {
+		features = append(features, common.FeatureRadio)
+	}
+	return features
+}
+
+// Restrict enabled features to the ones supported by the client. Clients not
+// specifying any are assumed to support all features of their protocol
+// version.
+func negotiateFeatures(enabled, requested []string) []string {
+	if requested == nil {
+		return enabled
+	}
+	features := make([]string, 0, len(enabled))
+	for _, f := range enabled {
+		for _, r := range requested {
+			if f == r {
+				features = append(features, f)
+				break
+			}
+		}
+	}
+	return features
+}
+
+// Returns, if a feature is in a list of features
+func hasFeature(features []string, name string) bool {
+	for _, f := range features {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
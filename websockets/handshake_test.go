@@ -0,0 +1,35 @@
+package websockets
+
+import (
+	"testing"
+
+	"github.com/bakape/meguca/common"
+	. "github.com/bakape/meguca/test"
+)
+
+func TestNegotiateFeatures(t *testing.T) {
+	t.Parallel()
+
+	enabled := []string{common.FeatureLiveUpdates, common.FeatureOekaki}
+	cases := [...]struct {
+		name                string
+		requested, features []string
+	}{
+		{"not specified", nil, enabled},
+		{"none", []string{}, []string{}},
+		{
+			"subset",
+			[]string{common.FeatureOekaki, common.FeatureRadio},
+			[]string{common.FeatureOekaki},
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			AssertDeepEquals(t, negotiateFeatures(enabled, c.requested),
+				c.features)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package websockets
 
 import (
 	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
 	"unicode/utf8"
 )
 
@@ -13,18 +14,84 @@ type openPost struct {
 	time                  int64
 	body                  []byte
 	board                 string
+
+	// Hidden by a shadow ban
+	shadowed bool
+	// Updates are only sent back to the author, because the post is hidden by
+	// a shadow ban or the board only publishes closed posts
+	private bool
+
+	// Edits of the body, that can be undone
+	history editHistory
+
+	// Strokes of an oekaki drawing to attach as the post's image on closing
+	strokes                      []common.Stroke
+	drawingPoints, drawingLength int
+}
+
+// Make the client's open post in thread op, if any, the target of post
+// modification messages. Any previously targeted open post is parked.
+func (c *Client) selectPost(op uint64) {
+	if c.post.op == op {
+		return
+	}
+	if c.post.id != 0 {
+		if c.parkedPosts == nil {
+			c.parkedPosts = make(map[uint64]openPost, maxOpenPosts)
+		}
+		c.parkedPosts[c.post.op] = c.post
+	}
+	c.post = c.parkedPosts[op]
+	delete(c.parkedPosts, op)
+}
+
+// Returns IDs of all posts open by the client
+func (c *Client) openPostIDs() []uint64 {
+	ids := make([]uint64, 0, len(c.parkedPosts)+1)
+	if c.post.id != 0 {
+		ids = append(ids, c.post.id)
+	}
+	for _, p := range c.parkedPosts {
+		ids = append(ids, p.id)
+	}
+	return ids
+}
+
+// Returns, if opening a post in the targeted thread would exceed
+// maxOpenPosts. Any post already open in the targeted thread is closed
+// first, so the new post and the posts open in other threads are counted.
+func (c *Client) atOpenPostLimit() bool {
+	return len(c.parkedPosts)+1 > maxOpenPosts
+}
+
+// Close all posts open by the client
+func (c *Client) closeAllPosts() (err error) {
+	err = c.closePreviousPost()
+	if err != nil {
+		return
+	}
+	for op := range c.parkedPosts {
+		c.selectPost(op)
+		err = c.closePost()
+		if err != nil {
+			return
+		}
+	}
+	return
 }
 
 // Initialize a new open post from a post struct
 func (o *openPost) init(p common.StandalonePost) {
 	*o = openPost{
-		id:    p.ID,
-		op:    p.OP,
-		time:  p.Time,
-		board: p.Board,
-		len:   utf8.RuneCountInString(p.Body),
-		body:  append(make([]byte, 0, 1<<10), p.Body...),
+		id:       p.ID,
+		op:       p.OP,
+		time:     p.Time,
+		board:    p.Board,
+		shadowed: p.Shadowed,
+		len:      utf8.RuneCountInString(p.Body),
+		body:     append(make([]byte, 0, 1<<10), p.Body...),
 	}
+	o.private = o.shadowed || config.GetBoardConfigs(o.board).HideOpenPosts
 	o.countLines()
 	if p.Image != nil {
 		o.hasImage = true
@@ -41,3 +108,83 @@ func (o *openPost) countLines() {
 		}
 	}
 }
+
+// Maximum number of posts a client can have open at once across all threads
+const maxOpenPosts = 4
+
+// Maximum number of edits of an open post, that can be undone
+const maxEditHistory = 64
+
+// Single modification of an open post's body. Equivalent to a splice
+// replacing removed with inserted at start.
+type bodyEdit struct {
+	start             int
+	removed, inserted []rune
+}
+
+// Merge a successive append or backspace into the edit. Returns, if merged.
+func (e *bodyEdit) extend(next bodyEdit) bool {
+	switch {
+	case len(e.removed) == 0 && len(next.removed) == 0 &&
+		next.start == e.start+len(e.inserted):
+		e.inserted = append(e.inserted, next.inserted...)
+	case len(e.inserted) == 0 && len(next.inserted) == 0 &&
+		next.start+len(next.removed) == e.start:
+		e.removed = append(next.removed, e.removed...)
+		e.start = next.start
+	default:
+		return false
+	}
+	return true
+}
+
+// Bounded ring of edits of an open post's body, that can be undone or redone.
+// Once full, the oldest edits are overwritten.
+type editHistory struct {
+	edits              [maxEditHistory]bodyEdit
+	first              int
+	undoable, redoable int
+}
+
+func (h *editHistory) at(i int) *bodyEdit {
+	return &h.edits[(h.first+i)%maxEditHistory]
+}
+
+// Record a new edit and discard any undone edits. If coalesce is true, the
+// edit is merged with the previous one, if possible. Used for grouping
+// individually typed characters.
+func (h *editHistory) push(e bodyEdit, coalesce bool) {
+	h.redoable = 0
+	if coalesce && h.undoable != 0 && h.at(h.undoable-1).extend(e) {
+		return
+	}
+	if h.undoable == maxEditHistory {
+		h.first = (h.first + 1) % maxEditHistory
+		h.undoable--
+	}
+	*h.at(h.undoable) = e
+	h.undoable++
+}
+
+// Returns the inverse of the last edit to apply for undoing it, if any
+func (h *editHistory) undo() (e bodyEdit, ok bool) {
+	if h.undoable == 0 {
+		return
+	}
+	h.undoable--
+	h.redoable++
+	e = *h.at(h.undoable)
+	e.removed, e.inserted = e.inserted, e.removed
+	return e, true
+}
+
+// Returns the last undone edit to reapply, if any
+func (h *editHistory) redo() (e bodyEdit, ok bool) {
+	if h.redoable == 0 {
+		return
+	}
+	e = *h.at(h.undoable)
+	h.undoable++
+	h.redoable--
+	return e, true
+}
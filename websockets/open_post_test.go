@@ -0,0 +1,121 @@
+package websockets
+
+import (
+	"testing"
+
+	. "github.com/bakape/meguca/test"
+)
+
+func TestEditHistory(t *testing.T) {
+	t.Parallel()
+
+	var h editHistory
+	for _, r := range "ab" {
+		h.push(bodyEdit{start: int(r - 'a'), inserted: []rune{r}}, true)
+	}
+	h.push(bodyEdit{start: 2, inserted: []rune{'\n'}}, false)
+	h.push(bodyEdit{start: 0, removed: []rune("ab"), inserted: []rune("c")},
+		false)
+
+	e, ok := h.undo()
+	if !ok {
+		t.Fatal("nothing to undo")
+	}
+	AssertDeepEquals(t, e, bodyEdit{
+		start:    0,
+		removed:  []rune("c"),
+		inserted: []rune("ab"),
+	})
+	h.undo()
+
+	e, _ = h.undo()
+	AssertDeepEquals(t, e, bodyEdit{
+		start:   0,
+		removed: []rune("ab"),
+	})
+	if _, ok := h.undo(); ok {
+		t.Fatal("undid past history start")
+	}
+
+	e, _ = h.redo()
+	AssertDeepEquals(t, e, bodyEdit{
+		start:    0,
+		inserted: []rune("ab"),
+	})
+
+	// New edits discard undone ones
+	h.push(bodyEdit{start: 2, inserted: []rune{'d'}}, true)
+	if _, ok := h.redo(); ok {
+		t.Fatal("redid discarded edit")
+	}
+	e, _ = h.undo()
+	AssertDeepEquals(t, e, bodyEdit{
+		start:   0,
+		removed: []rune("abd"),
+	})
+}
+
+func TestEditHistoryOverflow(t *testing.T) {
+	t.Parallel()
+
+	var h editHistory
+	for i := 0; i < maxEditHistory+10; i++ {
+		h.push(bodyEdit{start: i, inserted: []rune{'a'}}, false)
+	}
+
+	var undone int
+	for {
+		e, ok := h.undo()
+		if !ok {
+			break
+		}
+		undone++
+		if e.start < 10 {
+			t.Fatalf("overwritten edit undone: %d", e.start)
+		}
+	}
+	AssertDeepEquals(t, undone, maxEditHistory)
+}
+
+func TestSelectPost(t *testing.T) {
+	t.Parallel()
+
+	var c Client
+	c.post = openPost{id: 2, op: 1}
+
+	c.selectPost(3)
+	AssertDeepEquals(t, c.post, openPost{})
+	AssertDeepEquals(t, c.openPostIDs(), []uint64{2})
+
+	c.post = openPost{id: 4, op: 3}
+	c.selectPost(1)
+	AssertDeepEquals(t, c.post, openPost{id: 2, op: 1})
+	AssertDeepEquals(t, c.parkedPosts, map[uint64]openPost{
+		3: {id: 4, op: 3},
+	})
+	AssertDeepEquals(t, c.openPostIDs(), []uint64{2, 4})
+
+	// Already selected
+	c.selectPost(1)
+	AssertDeepEquals(t, c.post.id, uint64(2))
+	AssertDeepEquals(t, len(c.parkedPosts), 1)
+}
+
+func TestAtOpenPostLimit(t *testing.T) {
+	t.Parallel()
+
+	var c Client
+	c.post = openPost{id: 1, op: 1}
+	for i := uint64(2); i <= maxOpenPosts; i++ {
+		c.selectPost(i)
+		AssertDeepEquals(t, c.atOpenPostLimit(), false)
+		c.post = openPost{id: i, op: i}
+	}
+	AssertDeepEquals(t, len(c.openPostIDs()), maxOpenPosts)
+
+	// Replacing the post in the targeted thread keeps the count
+	AssertDeepEquals(t, c.atOpenPostLimit(), false)
+
+	c.selectPost(maxOpenPosts + 1)
+	AssertDeepEquals(t, c.atOpenPostLimit(), true)
+}
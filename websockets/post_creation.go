@@ -1,40 +1,66 @@
 package websockets
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/dnsbl"
+	"github.com/bakape/meguca/events"
 	"github.com/bakape/meguca/geoip"
+	mlog "github.com/bakape/meguca/log"
 	"github.com/bakape/meguca/parser"
+	"github.com/bakape/meguca/topics"
 	"github.com/bakape/meguca/websockets/feeds"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
 var (
-	errReadOnly          = common.ErrInvalidInput("read only board")
-	errInvalidImageToken = common.ErrInvalidInput("image token")
-	errImageNameTooLong  = common.ErrTooLong("image name")
-	errNoTextOrImage     = common.ErrInvalidInput("no text or image")
+	errReadOnly           = common.ErrInvalidInput("read only board")
+	errInvalidImageToken  = common.ErrInvalidInput("image token")
+	errImageNameTooLong   = common.ErrTooLong("image name")
+	errNoTextOrImage      = common.ErrInvalidInput("no text or image")
+	errNotInThread        = common.ErrInvalidInput("not synced to a thread")
+	errTooManyOpenPosts   = common.ErrInvalidInput("too many open posts")
+	errEncryptionDisabled = common.ErrInvalidInput(
+		"encrypted threads disabled")
+	errInvalidEncryptedBody = common.ErrInvalidInput("encrypted body")
+	errProxyBlocked         = common.StatusError{
+		Err:  errors.New("posting through proxies is not allowed"),
+		Code: 403,
+	}
+	errProxyNeedsCaptcha = common.StatusError{
+		Err:  errors.New("captcha required for posting through proxies"),
+		Code: 403,
+	}
 )
 
 // ThreadCreationRequest contains data for creating a new thread
+// Encrypted specifies, if the thread's post bodies are end-to-end encrypted.
 type ThreadCreationRequest struct {
 	ReplyCreationRequest
+	Encrypted      bool
 	Subject, Board string
 }
 
 // ReplyCreationRequest contains common fields for both thread and reply
-// creation
+// creation.
+// Reservation optionally specifies the claim token of a previously reserved
+// post ID to commit the reply under.
+// Challenge is a proof of work challenge issued to the client and Nonce its
+// solution, if proof of work is enabled.
 type ReplyCreationRequest struct {
 	Sage, Open bool
 	Image      ImageRequest
 	auth.SessionCreds
-	Name, Password, Body string
+	Name, Password, Body, Reservation, Challenge, Nonce string
 }
 
 // ImageRequest contains data for allocating an image
@@ -45,7 +71,8 @@ type ImageRequest struct {
 
 // CreateThread creates a new tread and writes it to the database.
 // open specifies, if the thread OP should stay open after creation.
-func CreateThread(req ThreadCreationRequest, ip string) (
+// ctx carries the request or connection IDs errors are logged with.
+func CreateThread(ctx context.Context, req ThreadCreationRequest, ip string) (
 	post db.Post, err error,
 ) {
 	if !auth.IsNonMetaBoard(req.Board) {
@@ -56,11 +83,19 @@ func CreateThread(req ThreadCreationRequest, ip string) (
 	if err != nil {
 		return
 	}
+	err = consumePow(ip, req.Challenge, req.Nonce)
+	if err != nil {
+		return
+	}
 	conf, err := getBoardConfig(req.Board)
 	if err != nil {
 		return
 	}
-	post, err = constructPost(req.ReplyCreationRequest, conf, ip)
+	if req.Encrypted && !config.Get().EncryptedThreads {
+		err = errEncryptionDisabled
+		return
+	}
+	post, err = constructPost(req.ReplyCreationRequest, conf, ip, req.Encrypted)
 	if err != nil {
 		return
 	}
@@ -68,30 +103,86 @@ func CreateThread(req ThreadCreationRequest, ip string) (
 	if err != nil {
 		return
 	}
+	var flag bool
+	if !post.Editing && !req.Encrypted {
+		flag, err = checkDuplicate(conf, post.Body)
+		if err != nil {
+			return
+		}
+	}
 
 	// Must ensure image token usage is done atomically, as not to cause
 	// possible data races with unused image cleanup
 	err = db.InTransaction(false, func(tx *sql.Tx) (err error) {
-		err = db.InsertThread(tx, subject, &post)
+		err = db.InsertThread(tx, subject, req.Encrypted, &post)
 		if err != nil {
 			return
 		}
 
 		if !conf.TextOnly && req.Image.Token != "" &&
 			req.Image.Name != "" {
-			err = insertImage(tx, req.Image, &post)
+			err = insertImage(tx, conf, req.Image, &post)
 			if err != nil {
 				return
 			}
 		}
 		return
 	})
+	if err != nil {
+		return
+	}
+	sendPostToStaff(ctx, post, ip)
+	publishPostCreation(post, true)
+	if !post.Editing && !req.Encrypted && !post.Shadowed {
+		topics.Add(post.Board, post.Body)
+		if flag {
+			flagDuplicate(ctx, post.ID, post.Board, ip)
+		}
+	}
 
 	return
 }
 
+// Send a newly created post to the staff feed
+func sendPostToStaff(ctx context.Context, post db.Post, ip string) {
+	err := feeds.SendPostToStaff(post.StandalonePost, db.HashIP(ip))
+	if err != nil {
+		mlog.FromContext(ctx, "websockets").Errorf("staff feed: %s", err)
+	}
+}
+
+// Publish the creation of a post or thread and any image attached with it
+func publishPostCreation(post db.Post, thread bool) {
+	if post.Shadowed {
+		return
+	}
+	e := events.Event{
+		Type:   events.PostCreated,
+		Board:  post.Board,
+		Thread: post.OP,
+		Post:   post.ID,
+		Data:   post.Post,
+	}
+	if thread {
+		e.Type = events.ThreadCreated
+	}
+	events.Publish(e)
+	if post.Image != nil {
+		e.Type = events.ImageUploaded
+		e.Data = post.Image
+		events.Publish(e)
+	}
+}
+
 // Insert image into a post on post creation
-func insertImage(tx *sql.Tx, req ImageRequest, p *db.Post) (err error) {
+func insertImage(
+	tx *sql.Tx,
+	conf config.BoardConfigs,
+	req ImageRequest,
+	p *db.Post,
+) (
+	err error,
+) {
 	err = formatImageName(&req.Name)
 	if err != nil {
 		return
@@ -107,6 +198,10 @@ func insertImage(tx *sql.Tx, req ImageRequest, p *db.Post) (err error) {
 	if err != nil {
 		return
 	}
+	err = checkFileType(conf, p.Image.FileType)
+	if err != nil {
+		return
+	}
 
 	p.Image.Name = req.Name
 	p.Image.Spoiler = req.Spoiler
@@ -115,7 +210,9 @@ func insertImage(tx *sql.Tx, req ImageRequest, p *db.Post) (err error) {
 
 // CreatePost creates a new post and writes it to the database.
 // open specifies, if the post should stay open after creation.
+// ctx carries the request or connection IDs errors are logged with.
 func CreatePost(
+	ctx context.Context,
 	op uint64,
 	board, ip string,
 	req ReplyCreationRequest,
@@ -126,6 +223,10 @@ func CreatePost(
 	if err != nil {
 		return
 	}
+	err = consumePow(ip, req.Challenge, req.Nonce)
+	if err != nil {
+		return
+	}
 
 	conf, err := getBoardConfig(board)
 	if err != nil {
@@ -148,24 +249,42 @@ func CreatePost(
 		err = common.StatusError{errors.New("thread is locked"), 400}
 		return
 	}
+	encrypted, err := db.CheckThreadEncrypted(op)
+	if err != nil {
+		return
+	}
 
-	post, err = constructPost(req, conf, ip)
+	post, err = constructPost(req, conf, ip, encrypted)
 	if err != nil {
 		return
 	}
 
 	post.OP = op
+	var flag bool
+	if !post.Editing && !encrypted {
+		flag, err = checkDuplicate(conf, post.Body)
+		if err != nil {
+			return
+		}
+	}
 
 	// Must ensure image token usage is done atomically, as not to cause
 	// possible data races with unused image cleanup
 	err = db.InTransaction(false, func(tx *sql.Tx) (err error) {
+		if req.Reservation != "" {
+			post.ID, err = db.ClaimPostReservation(tx, op, ip, req.Reservation)
+			if err != nil {
+				return
+			}
+		}
+
 		err = db.InsertPost(tx, &post)
 		if err != nil {
 			return
 		}
 
 		if hasImage {
-			err = insertImage(tx, req.Image, &post)
+			err = insertImage(tx, conf, req.Image, &post)
 			if err != nil {
 				return
 			}
@@ -173,6 +292,17 @@ func CreatePost(
 
 		return
 	})
+	if err != nil {
+		return
+	}
+	sendPostToStaff(ctx, post, ip)
+	publishPostCreation(post, false)
+	if !post.Editing && !encrypted && !post.Shadowed {
+		topics.Add(board, post.Body)
+		if flag {
+			flagDuplicate(ctx, post.ID, board, ip)
+		}
+	}
 
 	msg, err = common.EncodeMessage(common.MessageInsertPost, post.Post)
 	return
@@ -180,12 +310,16 @@ func CreatePost(
 
 // Insert a new post into the database
 func (c *Client) insertPost(data []byte) (err error) {
+	if c.atOpenPostLimit() {
+		return errTooManyOpenPosts
+	}
 	err = c.closePreviousPost()
 	if err != nil {
 		return
 	}
 
-	needCaptcha, err := db.NeedCaptcha(c.ip)
+	_, op, board := feeds.GetSync(c)
+	needCaptcha, err := c.needCaptcha(board)
 	if err != nil {
 		return
 	}
@@ -201,9 +335,17 @@ func (c *Client) insertPost(data []byte) (err error) {
 	// Replies created through websockets can only be open
 	req.Open = true
 
-	_, op, board := feeds.GetSync(c)
-	post, msg, err := CreatePost(op, board, c.ip, req)
-	if err != nil {
+	post, msg, err := CreatePost(c.ctx, op, board, c.ip, req)
+
+	// Any proof of work solution is consumed by the creation attempt
+	if powErr := c.sendPowChallenge(); powErr != nil {
+		return powErr
+	}
+	switch err {
+	case nil:
+	case errProxyNeedsCaptcha:
+		return c.sendMessage(common.MessageCaptcha, 0)
+	default:
 		return
 	}
 
@@ -221,19 +363,76 @@ func (c *Client) insertPost(data []byte) (err error) {
 		}
 		c.post.init(post.StandalonePost)
 	}
-	c.feed.InsertPost(post.StandalonePost.Post, msg)
+	if post.Shadowed ||
+		post.Editing && config.GetBoardConfigs(board).HideOpenPosts {
+		// Only echo back posts hidden from other clients
+		c.Send(msg)
+		if post.Editing {
+			feeds.ShareOpenBody(post.ID, post.Body)
+		}
+	} else {
+		feeds.InsertPostInto(post.StandalonePost, msg)
+	}
 	err = CheckRouletteBan(post.Commands, post.Board, post.OP, post.ID)
 	if err != nil {
 		return
 	}
 
 	conf := config.Get()
-	c.incrementSpamScore(conf.PostCreationScore +
-		conf.CharScore*uint(c.post.len))
+	score := conf.CharScore * uint(c.post.len)
+	if req.Reservation == "" {
+		score += conf.PostCreationScore
+	}
+	c.incrementSpamScore(score)
 	c.setLastTime()
 	return
 }
 
+// Reserve a post ID in the synchronised thread without creating a publically
+// visible post. The client commits the post later with insertPost by passing
+// the returned claim token.
+func (c *Client) reservePost() (err error) {
+	_, op, board := feeds.GetSync(c)
+	needCaptcha, err := c.needCaptcha(board)
+	if err != nil {
+		return
+	}
+	if needCaptcha {
+		return c.sendMessage(common.MessageCaptcha, 0)
+	}
+
+	if op == 0 {
+		return errNotInThread
+	}
+	err = db.IsBanned(board, c.ip)
+	if err != nil {
+		return
+	}
+	conf, err := getBoardConfig(board)
+	if err != nil {
+		return
+	}
+	err = checkCooldown(conf, c.ip)
+	if err != nil {
+		return
+	}
+
+	var msg struct {
+		ID    uint64 `json:"id"`
+		Token string `json:"token"`
+	}
+	msg.ID, msg.Token, err = db.ReservePost(op, c.ip)
+	if err != nil {
+		return
+	}
+
+	// Charged like post creation, which is not charged again on committing
+	// the reservation
+	c.incrementSpamScore(config.Get().PostCreationScore)
+	c.setLastTime()
+	return c.sendMessage(common.MessageReservePost, msg)
+}
+
 // If the client has a previous post, close it silently
 func (c *Client) closePreviousPost() error {
 	if c.post.id != 0 {
@@ -251,11 +450,14 @@ func getBoardConfig(board string) (conf config.BoardConfigs, err error) {
 	return
 }
 
-// Construct the common parts of the new post for both threads and replies
+// Construct the common parts of the new post for both threads and replies.
+// encrypted specifies, if the post body is an end-to-end encrypted blob, that
+// is to be stored and relayed as is.
 func constructPost(
 	req ReplyCreationRequest,
 	conf config.BoardConfigs,
 	ip string,
+	encrypted bool,
 ) (
 	post db.Post, err error,
 ) {
@@ -269,6 +471,10 @@ func constructPost(
 		},
 		IP: ip,
 	}
+	post.Shadowed, err = db.IsShadowBanned(conf.ID, ip)
+	if err != nil {
+		return
+	}
 
 	if !conf.ForcedAnon {
 		post.Name, post.Trip, err = parser.ParseName(req.Name)
@@ -281,20 +487,36 @@ func constructPost(
 		post.Flag = geoip.LookUp(ip)
 	}
 
-	if utf8.RuneCountInString(req.Body) > common.MaxLenBody {
-		err = common.ErrBodyTooLong
+	post.Proxy, err = checkProxy(conf.ProxyPolicy, ip)
+	if err != nil {
 		return
 	}
+	err = checkCooldown(conf, ip)
+	if err != nil {
+		return
+	}
+
+	if encrypted {
+		err = validateEncryptedBody(req.Body)
+		if err != nil {
+			return
+		}
+	} else {
+		if utf8.RuneCountInString(req.Body) > maxBodyLen(conf) {
+			err = common.ErrBodyTooLong
+			return
+		}
 
-	lines := 0
-	for _, r := range req.Body {
-		if r == '\n' {
-			lines++
+		lines := 0
+		for _, r := range req.Body {
+			if r == '\n' {
+				lines++
+			}
+		}
+		if lines > common.MaxLinesBody {
+			err = errTooManyLines
+			return
 		}
-	}
-	if lines > common.MaxLinesBody {
-		err = errTooManyLines
-		return
 	}
 
 	// Attach staff position title after validations
@@ -317,11 +539,9 @@ func constructPost(
 		}
 	}
 
-	if req.Open {
-		post.Editing = true
-
-		// Posts that are committed in one action need not a password, as they
-		// are closed on commit and can not be reclaimed
+	// Open posts require a password for reclaiming them. For posts committed
+	// in one action it is optional and only used for deletion by the author.
+	if req.Open && !encrypted || req.Password != "" {
 		err = parser.VerifyPostPassword(req.Password)
 		if err != nil {
 			return
@@ -330,7 +550,15 @@ func constructPost(
 		if err != nil {
 			return
 		}
-	} else {
+	}
+
+	switch {
+	case encrypted:
+		// Ciphertext can neither be edited incrementally nor parsed, so
+		// encrypted posts are always committed in one action
+	case req.Open:
+		post.Editing = true
+	default:
 		// TODO: Move DB checks out of the parser. The parser should just parse.
 		// Return slices of pointers to links and commands that need to be
 		// validated.
@@ -350,6 +578,48 @@ func constructPost(
 	return
 }
 
+// Validate an end-to-end encrypted post body. The server can only enforce the
+// body is a base64-encoded blob within size limits.
+func validateEncryptedBody(body string) error {
+	if len(body) > common.MaxLenEncryptedBody {
+		return common.ErrBodyTooLong
+	}
+	if _, err := base64.StdEncoding.DecodeString(body); err != nil {
+		return errInvalidEncryptedBody
+	}
+	return nil
+}
+
+// Apply the board's proxy policy to a poster. Returns, if the post is to be
+// tagged as posted through a proxy.
+func checkProxy(policy, ip string) (tag bool, err error) {
+	switch policy {
+	case "", common.ProxyPolicyNone:
+		return
+	}
+	if !dnsbl.Enabled() || !dnsbl.IsProxy(ip) {
+		return
+	}
+
+	switch policy {
+	case common.ProxyPolicyTag:
+		tag = true
+	case common.ProxyPolicyCaptcha:
+		var solved bool
+		solved, err = db.SolvedCaptchaRecently(ip, time.Minute*10)
+		switch {
+		case err != nil:
+		case !solved:
+			err = errProxyNeedsCaptcha
+		default:
+			tag = true
+		}
+	case common.ProxyPolicyBlock:
+		err = errProxyBlocked
+	}
+	return
+}
+
 // Trim on the last dot in the file name, but also strip for .tar.gz and
 // .tar.xz as special cases.
 func formatImageName(name *string) (err error) {
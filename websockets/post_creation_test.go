@@ -1,6 +1,7 @@
 package websockets
 
 import (
+	"context"
 	"database/sql"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
@@ -9,6 +10,7 @@ import (
 	"github.com/bakape/meguca/test/test_db"
 	"github.com/bakape/meguca/websockets/feeds"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -80,7 +82,7 @@ func TestInsertThread(t *testing.T) {
 			req := ThreadCreationRequest{
 				Board: c.board,
 			}
-			_, err := CreateThread(req, "")
+			_, err := CreateThread(context.Background(), req, "")
 			AssertDeepEquals(t, c.err, err)
 		})
 	}
@@ -129,7 +131,7 @@ func testCreateThread(t *testing.T) {
 		Subject: "subject",
 		Board:   "c",
 	}
-	p, err := CreateThread(req, "::1")
+	p, err := CreateThread(context.Background(), req, "::1")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -154,7 +156,7 @@ func testCreateThread(t *testing.T) {
 }
 
 func testCreateThreadTextOnly(t *testing.T) {
-	post, err := CreateThread(ThreadCreationRequest{
+	post, err := CreateThread(context.Background(), ThreadCreationRequest{
 		ReplyCreationRequest: ReplyCreationRequest{
 			Name:     "name",
 			Password: "123",
@@ -362,7 +364,7 @@ func registerClient(t testing.TB, cl *Client, id uint64, board string) {
 	t.Helper()
 
 	var err error
-	cl.feed, err = feeds.SyncClient(cl, id, board)
+	cl.feed, err = feeds.SyncClient(cl, id, board, feeds.LogPosition{}, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -481,3 +483,30 @@ func TestPostCreationForcedAnon(t *testing.T) {
 		t.Fatal("not anonymous")
 	}
 }
+
+func TestValidateEncryptedBody(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name, body string
+		err        error
+	}{
+		{"valid", "c2VjcmV0IG1lc3NhZ2U=", nil},
+		{"not base64", ">>1 hello", errInvalidEncryptedBody},
+		{
+			"too long",
+			strings.Repeat("A", common.MaxLenEncryptedBody+4),
+			common.ErrBodyTooLong,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if err := validateEncryptedBody(c.body); err != c.err {
+				UnexpectedError(t, err)
+			}
+		})
+	}
+}
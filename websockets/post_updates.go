@@ -8,8 +8,11 @@ import (
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/config"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/events"
 	"github.com/bakape/meguca/parser"
+	"github.com/bakape/meguca/topics"
 	"github.com/bakape/meguca/util"
+	"github.com/bakape/meguca/websockets/feeds"
 	"time"
 	"unicode/utf8"
 )
@@ -20,6 +23,7 @@ var (
 	errTooManyLines  = errors.New("too many lines in post body")
 	errSpliceTooLong = errors.New("splice text too long")
 	errSpliceNOOP    = errors.New("splice NOOP")
+	errHistoryDesync = errors.New("edit history out of sync with post body")
 	errTextOnly      = errors.New("text only board")
 	errHasImage      = errors.New("post already has image")
 )
@@ -89,7 +93,7 @@ func (c *Client) appendRune(data []byte) (err error) {
 		return
 	case !has:
 		return
-	case c.post.len+1 > common.MaxLenBody:
+	case c.post.len+1 > c.post.maxLen():
 		return common.ErrBodyTooLong
 	}
 
@@ -119,6 +123,10 @@ func (c *Client) appendRune(data []byte) (err error) {
 		return
 	}
 
+	c.post.history.push(bodyEdit{
+		start:    c.post.len,
+		inserted: []rune{char},
+	}, char != '\n')
 	c.post.body = append(c.post.body, string(char)...)
 	c.post.len++
 	return c.updateBody(msg, 1)
@@ -128,7 +136,13 @@ func (c *Client) appendRune(data []byte) (err error) {
 // embedded database. Requires locking of c.openPost.
 // n specifies the number of characters updated.
 func (c *Client) updateBody(msg []byte, n int) error {
-	c.feed.SetOpenBody(c.post.id, string(c.post.body), msg)
+	body := string(c.post.body)
+	if c.post.private {
+		c.Send(msg)
+		feeds.ShareOpenBody(c.post.id, body)
+	} else {
+		feeds.SetOpenBody(c.post.op, c.post.id, body, msg)
+	}
 	c.incrementSpamScore(uint(n) * config.Get().CharScore)
 	return db.SetOpenBody(c.post.id, c.post.body)
 }
@@ -162,6 +176,10 @@ func (c *Client) backspace() error {
 		c.post.lines--
 	}
 	c.post.len--
+	c.post.history.push(bodyEdit{
+		start:   c.post.len,
+		removed: []rune{r},
+	}, true)
 
 	return c.updateBody(msg, 1)
 }
@@ -171,6 +189,10 @@ func (c *Client) closePost() (err error) {
 	if c.post.id == 0 {
 		return errNoPostOpen
 	}
+	err = c.attachDrawing()
+	if err != nil {
+		return
+	}
 
 	var (
 		links []common.Link
@@ -188,6 +210,27 @@ func (c *Client) closePost() (err error) {
 	if err != nil {
 		return
 	}
+	if c.post.private {
+		// Hidden from the feed, so the author must be notified directly
+		var msg []byte
+		msg, err = feeds.EncodeClosePost(c.post.id, links, com)
+		if err != nil {
+			return
+		}
+		c.Send(msg)
+	}
+	if c.post.shadowed {
+		c.post = openPost{}
+		return
+	}
+	topics.Add(c.post.board, string(c.post.body))
+
+	// Open posts are already public, so they can only be flagged
+	conf := config.GetBoardConfigs(c.post.board).BoardConfigs
+	flag, err := checkDuplicate(conf, string(c.post.body))
+	if flag || err == errDuplicatePost {
+		flagDuplicate(c.ctx, c.post.id, c.post.board, c.ip)
+	}
 
 	err = CheckRouletteBan(com, c.post.board, c.post.op, c.post.id)
 	c.post = openPost{}
@@ -231,13 +274,14 @@ func (c *Client) spliceText(data []byte) error {
 	if err != nil {
 		return err
 	}
+	maxLen := c.post.maxLen()
 
 	// Validate
 	switch {
 	case err != nil:
 		return err
-	case req.Start > common.MaxLenBody,
-		req.Len > common.MaxLenBody,
+	case req.Start > uint(maxLen),
+		req.Len > uint(maxLen),
 		int(req.Start+req.Len) > c.post.len:
 		return &errInvalidSpliceCoords{
 			body: string(c.post.body),
@@ -251,7 +295,7 @@ func (c *Client) spliceText(data []byte) error {
 		}
 	case req.Len == 0 && len(req.Text) == 0:
 		return errSpliceNOOP // This does nothing. Client-side error.
-	case len(req.Text) > common.MaxLenBody:
+	case len(req.Text) > maxLen:
 		return errSpliceTooLong // Nice try, kid
 	}
 
@@ -264,6 +308,8 @@ func (c *Client) spliceText(data []byte) error {
 	var (
 		old = []rune(string(c.post.body))
 		end = append(req.Text, old[req.Start+req.Len:]...)
+		// Text actually inserted, for recording in the edit history
+		inserted = req.Text
 	)
 	c.post.len += -int(req.Len) + len(req.Text)
 	res := spliceMessage{
@@ -275,12 +321,13 @@ func (c *Client) spliceText(data []byte) error {
 	}
 
 	// If it goes over the max post length, trim the end
-	exceeding := c.post.len - common.MaxLenBody
+	exceeding := c.post.len - maxLen
 	if exceeding > 0 {
 		end = end[:len(end)-exceeding]
 		res.Len = uint(len(old[int(req.Start):]))
 		res.Text = string(end)
-		c.post.len = common.MaxLenBody
+		inserted = end
+		c.post.len = maxLen
 	}
 
 	msg, err := common.EncodeMessage(common.MessageSplice, res)
@@ -297,6 +344,11 @@ func (c *Client) spliceText(data []byte) error {
 		byteStartPos += utf8.RuneLen(r)
 	}
 	c.post.body = append(c.post.body[:byteStartPos], string(end)...)
+	c.post.history.push(bodyEdit{
+		start:    int(req.Start),
+		removed:  old[req.Start : req.Start+res.Len],
+		inserted: inserted,
+	}, false)
 
 	c.post.countLines()
 	if c.post.lines > common.MaxLinesBody {
@@ -307,6 +359,62 @@ func (c *Client) spliceText(data []byte) error {
 	return c.updateBody(msg, len(res.Text)+1)
 }
 
+// Revert the last edit of the open post's body
+func (c *Client) undo() error {
+	return c.replayEdit(c.post.history.undo)
+}
+
+// Reapply the last undone edit of the open post's body
+func (c *Client) redo() error {
+	return c.replayEdit(c.post.history.redo)
+}
+
+// Apply an edit retrieved from the open post's edit history and send the
+// resulting splice to listening clients
+func (c *Client) replayEdit(next func() (bodyEdit, bool)) error {
+	if has, err := c.hasPost(); err != nil {
+		return err
+	} else if !has {
+		return nil
+	}
+
+	e, ok := next()
+	if !ok {
+		return nil // Nothing to undo or redo
+	}
+	old := []rune(string(c.post.body))
+	if e.start+len(e.removed) > len(old) {
+		return errHistoryDesync
+	}
+
+	body := make([]rune, 0, len(old)-len(e.removed)+len(e.inserted))
+	body = append(body, old[:e.start]...)
+	body = append(body, e.inserted...)
+	body = append(body, old[e.start+len(e.removed):]...)
+
+	msg, err := common.EncodeMessage(common.MessageSplice, spliceMessage{
+		ID: c.post.id,
+		spliceRequestString: spliceRequestString{
+			spliceCoords: spliceCoords{
+				Start: uint(e.start),
+				Len:   uint(len(e.removed)),
+			},
+			Text: string(e.inserted),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Allocates a new slice, so concurrent reads in the update feed are not
+	// affected
+	c.post.body = []byte(string(body))
+	c.post.len = len(body)
+	c.post.countLines()
+
+	return c.updateBody(msg, len(e.inserted)+1)
+}
+
 // Insert and image into an existing open post
 // Note: Spam score is now incremented on image thumbnailing, not assignment to
 // post.
@@ -327,7 +435,8 @@ func (c *Client) insertImage(data []byte) (err error) {
 		return
 	}
 
-	if config.GetBoardConfigs(c.post.board).TextOnly {
+	conf := config.GetBoardConfigs(c.post.board).BoardConfigs
+	if conf.TextOnly {
 		return errTextOnly
 	}
 
@@ -335,19 +444,47 @@ func (c *Client) insertImage(data []byte) (err error) {
 	if err != nil {
 		return
 	}
+	return c.attachImage(conf, req)
+}
+
+// Attach an allocated image to the open post and propagate it to listening
+// clients
+func (c *Client) attachImage(conf config.BoardConfigs, req ImageRequest,
+) (err error) {
 	var msg []byte
 	err = db.InTransaction(false, func(tx *sql.Tx) (err error) {
 		msg, err = db.InsertImage(tx, c.post.id, req.Token, req.Name,
 			req.Spoiler)
-		return
+		if err != nil || len(conf.FileTypes) == 0 {
+			return
+		}
+		var img common.ImageCommon
+		err = json.Unmarshal(msg, &img)
+		if err != nil {
+			return
+		}
+		return checkFileType(conf, img.FileType)
 	})
 	if err != nil {
 		return
 	}
 	c.post.hasImage = true
 	c.post.isSpoilered = req.Spoiler
-	c.feed.InsertImage(c.post.id, req.Spoiler,
-		common.PrependMessageType(common.MessageInsertImage, msg))
+	if !c.post.shadowed {
+		events.Publish(events.Event{
+			Type:   events.ImageUploaded,
+			Board:  c.post.board,
+			Thread: c.post.op,
+			Post:   c.post.id,
+			Data:   json.RawMessage(msg),
+		})
+	}
+	msg = common.PrependMessageType(common.MessageInsertImage, msg)
+	if c.post.private {
+		c.Send(msg)
+	} else {
+		feeds.InsertImage(c.post.op, c.post.id, req.Spoiler, msg)
+	}
 
 	return
 }
@@ -374,7 +511,11 @@ func (c *Client) spoilerImage() (err error) {
 	if err != nil {
 		return
 	}
-	c.feed.SpoilerImage(c.post.id, msg)
+	if c.post.private {
+		c.Send(msg)
+	} else {
+		feeds.SpoilerImage(c.post.op, c.post.id, msg)
+	}
 
 	return
 }
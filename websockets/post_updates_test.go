@@ -579,6 +579,69 @@ func TestSplice(t *testing.T) {
 	}
 }
 
+func TestUndoRedo(t *testing.T) {
+	feeds.Clear()
+	test_db.ClearTables(t, "boards")
+	test_db.WriteSampleBoard(t)
+	test_db.WriteSampleThread(t)
+	writeSamplePost(t)
+
+	sv := newWSServer(t)
+	defer sv.Close()
+	cl, _ := sv.NewClient()
+	registerClient(t, cl, 1, "a")
+	cl.post = openPost{
+		id:    2,
+		op:    1,
+		len:   3,
+		board: "a",
+		time:  time.Now().Unix(),
+		body:  []byte("abc"),
+	}
+
+	for _, r := range [...]string{"100", "101"} {
+		if err := cl.appendRune([]byte(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := spliceRequest{
+		spliceCoords: spliceCoords{
+			Start: 1,
+			Len:   2,
+		},
+		Text: []rune("ぁ"),
+	}
+	if err := cl.spliceText(marshalJSON(t, req)); err != nil {
+		t.Fatal(err)
+	}
+	assertOpenPost(t, cl, 4, "aぁde")
+
+	steps := [...]struct {
+		redo bool
+		len  int
+		body string
+	}{
+		{false, 5, "abcde"},
+		{false, 3, "abc"},
+		{false, 3, "abc"},
+		{true, 5, "abcde"},
+		{true, 4, "aぁde"},
+	}
+	for _, s := range steps {
+		fn := cl.undo
+		if s.redo {
+			fn = cl.redo
+		}
+		if err := fn(); err != nil {
+			t.Fatal(err)
+		}
+		assertOpenPost(t, cl, s.len, s.body)
+	}
+
+	awaitFlush()
+	assertBody(t, 2, "aぁde")
+}
+
 func TestCloseOldOpenPost(t *testing.T) {
 	feeds.Clear()
 	test_db.ClearTables(t, "boards")
@@ -0,0 +1,234 @@
+package websockets
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/config"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+const (
+	// Minimum number of leading zero bits of a valid solution hash
+	powBaseDifficulty = 16
+
+	// Maximum number of leading zero bits of a valid solution hash
+	powMaxDifficulty = 24
+
+	// Window of time to count posts by an IP within for scaling difficulty
+	powRateWindow = time.Minute * 10
+
+	// Number of posts within powRateWindow, that increase the difficulty by
+	// one bit
+	powPostsPerStep = 5
+
+	// Time an issued challenge can be solved within
+	powChallengeTTL = time.Minute * 10
+
+	// Maximum accepted length of a solution nonce
+	maxLenPowNonce = 64
+)
+
+var (
+	// Recent post creation times by IP
+	powRates = postRateStore{
+		posts: make(map[string][]time.Time, 1<<10),
+	}
+
+	// Issued unsolved challenges
+	powChallenges = challengeStore{
+		issued: make(map[string]issuedChallenge, 1<<10),
+	}
+
+	errInvalidPow = common.StatusError{
+		Err:  errors.New("invalid proof of work"),
+		Code: 403,
+	}
+)
+
+func init() {
+	go func() {
+		for range time.Tick(time.Minute) {
+			now := time.Now()
+			powRates.clean(now)
+			powChallenges.clean(now)
+		}
+	}()
+}
+
+// PowChallenge is a proof of work challenge. To solve it the client must find
+// a nonce, such that the SHA-256 hash of the challenge concatenated with the
+// nonce has at least Difficulty leading zero bits.
+type PowChallenge struct {
+	Challenge  string `json:"challenge"`
+	Difficulty uint   `json:"difficulty"`
+}
+
+// Challenge issued to an IP
+type issuedChallenge struct {
+	difficulty uint
+	ip         string
+	expires    time.Time
+}
+
+// Stores challenges issued to clients until they are solved or expire
+type challengeStore struct {
+	mu     sync.Mutex
+	issued map[string]issuedChallenge
+}
+
+// Store a challenge issued to an IP
+func (s *challengeStore) add(ch PowChallenge, ip string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issued[ch.Challenge] = issuedChallenge{
+		difficulty: ch.Difficulty,
+		ip:         ip,
+		expires:    now.Add(powChallengeTTL),
+	}
+}
+
+// Remove and return a challenge, if it was issued to the IP and has not
+// expired
+func (s *challengeStore) take(challenge, ip string, now time.Time) (
+	ch PowChallenge, ok bool,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iss, ok := s.issued[challenge]
+	if !ok {
+		return
+	}
+	delete(s.issued, challenge)
+	if iss.ip != ip || !iss.expires.After(now) {
+		return ch, false
+	}
+	return PowChallenge{challenge, iss.difficulty}, true
+}
+
+// Remove expired challenges
+func (s *challengeStore) clean(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, iss := range s.issued {
+		if !iss.expires.After(now) {
+			delete(s.issued, k)
+		}
+	}
+}
+
+// Stores recent post creation times of IPs
+type postRateStore struct {
+	mu    sync.Mutex
+	posts map[string][]time.Time
+}
+
+// Record a post by an IP
+func (s *postRateStore) add(ip string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts[ip] = append(s.posts[ip], now)
+}
+
+// Return the number of posts by an IP within powRateWindow
+func (s *postRateStore) count(ip string, now time.Time) (n uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	min := now.Add(-powRateWindow)
+	for _, t := range s.posts[ip] {
+		if t.After(min) {
+			n++
+		}
+	}
+	return
+}
+
+// Remove posts outside of powRateWindow
+func (s *postRateStore) clean(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	min := now.Add(-powRateWindow)
+	for ip, times := range s.posts {
+		i := 0
+		for i < len(times) && !times[i].After(min) {
+			i++
+		}
+		if i == len(times) {
+			delete(s.posts, ip)
+		} else if i != 0 {
+			s.posts[ip] = append(times[:0], times[i:]...)
+		}
+	}
+}
+
+// Compute the challenge difficulty for an IP from its recent posting rate
+func powDifficulty(ip string, now time.Time) uint {
+	d := powBaseDifficulty + powRates.count(ip, now)/powPostsPerStep
+	if d > powMaxDifficulty {
+		d = powMaxDifficulty
+	}
+	return d
+}
+
+// Returns, if nonce is a valid solution to the challenge
+func verifyPow(ch PowChallenge, nonce string) bool {
+	if ch.Challenge == "" || nonce == "" || len(nonce) > maxLenPowNonce {
+		return false
+	}
+	sum := sha256.Sum256([]byte(ch.Challenge + nonce))
+	var zeros uint
+	for _, b := range sum {
+		if b != 0 {
+			zeros += uint(bits.LeadingZeros8(b))
+			break
+		}
+		zeros += 8
+	}
+	return zeros >= ch.Difficulty
+}
+
+// IssuePowChallenge creates a new proof of work challenge for an IP to solve
+// before creating a post
+func IssuePowChallenge(ip string) (ch PowChallenge, err error) {
+	ch.Challenge, err = auth.RandomID(16)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	ch.Difficulty = powDifficulty(ip, now)
+	powChallenges.add(ch, ip, now)
+	return
+}
+
+// Verify and consume a solution to a proof of work challenge issued to an IP,
+// if enabled
+func consumePow(ip, challenge, nonce string) error {
+	if !config.Get().ProofOfWork {
+		return nil
+	}
+	now := time.Now()
+	ch, ok := powChallenges.take(challenge, ip, now)
+	if !ok || !verifyPow(ch, nonce) {
+		return errInvalidPow
+	}
+	powRates.add(ip, now)
+	return nil
+}
+
+// Issue a new proof of work challenge to the client, if enabled
+func (c *Client) sendPowChallenge() (err error) {
+	if !config.Get().ProofOfWork {
+		return
+	}
+	ch, err := IssuePowChallenge(c.ip)
+	if err != nil {
+		return
+	}
+	return c.sendMessage(common.MessagePowChallenge, ch)
+}
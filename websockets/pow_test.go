@@ -0,0 +1,128 @@
+package websockets
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bakape/meguca/config"
+)
+
+// Find a nonce solving the challenge
+func solvePow(ch PowChallenge) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		if verifyPow(ch, nonce) {
+			return nonce
+		}
+	}
+}
+
+func TestVerifyPow(t *testing.T) {
+	t.Parallel()
+
+	ch := PowChallenge{
+		Challenge:  "foo",
+		Difficulty: 8,
+	}
+	nonce := solvePow(ch)
+
+	ch.Difficulty = 256
+	if verifyPow(ch, nonce) {
+		t.Fatal("impossible difficulty solved")
+	}
+	if verifyPow(PowChallenge{}, nonce) {
+		t.Fatal("empty challenge solved")
+	}
+}
+
+func TestPowDifficulty(t *testing.T) {
+	powRates = postRateStore{
+		posts: make(map[string][]time.Time),
+	}
+	now := time.Now()
+	const ip = "::1"
+
+	if d := powDifficulty(ip, now); d != powBaseDifficulty {
+		t.Fatalf("unexpected difficulty: %d", d)
+	}
+	for i := 0; i < powPostsPerStep*2; i++ {
+		powRates.add(ip, now)
+	}
+	if d := powDifficulty(ip, now); d != powBaseDifficulty+2 {
+		t.Fatalf("unexpected difficulty: %d", d)
+	}
+	for i := 0; i < powPostsPerStep*100; i++ {
+		powRates.add(ip, now)
+	}
+	if d := powDifficulty(ip, now); d != powMaxDifficulty {
+		t.Fatalf("unexpected difficulty: %d", d)
+	}
+
+	powRates.clean(now.Add(powRateWindow))
+	if len(powRates.posts) != 0 {
+		t.Fatal("post rates not cleaned")
+	}
+}
+
+func TestConsumePow(t *testing.T) {
+	config.Set(config.Configs{
+		Public: config.Public{
+			ProofOfWork: true,
+		},
+	})
+	defer config.Set(config.Configs{})
+	powRates = postRateStore{
+		posts: make(map[string][]time.Time),
+	}
+	powChallenges = challengeStore{
+		issued: make(map[string]issuedChallenge),
+	}
+	const ip = "::1"
+
+	ch, err := IssuePowChallenge(ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := solvePow(ch)
+
+	cases := [...]struct {
+		name, ip, challenge, nonce string
+		err                        error
+	}{
+		{"no solution", ip, "", "", errInvalidPow},
+		{"unknown challenge", ip, "foo", nonce, errInvalidPow},
+		{"valid", ip, ch.Challenge, nonce, nil},
+		{"reused", ip, ch.Challenge, nonce, errInvalidPow},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := consumePow(c.ip, c.challenge, c.nonce); err != c.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("other IP", func(t *testing.T) {
+		ch, err := IssuePowChallenge(ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = consumePow("::2", ch.Challenge, solvePow(ch))
+		if err != errInvalidPow {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ch, err := IssuePowChallenge(ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		powChallenges.clean(time.Now().Add(powChallengeTTL))
+		err = consumePow(ip, ch.Challenge, solvePow(ch))
+		if err != errInvalidPow {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package websockets
+
+import (
+	"github.com/bakape/meguca/auth"
+	"github.com/bakape/meguca/common"
+	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/websockets/feeds"
+)
+
+var errNotStaff = common.ErrAccessDenied("not staff")
+
+// Subscribe a logged in staff member to the activity feed of all boards they
+// hold a staff position on
+func (c *Client) subscribeToStaff(data []byte) (err error) {
+	var f feeds.StaffFilter
+	err = decodeMessage(data, &f)
+	if err != nil {
+		return
+	}
+
+	user, err := c.staffAccount()
+	if err != nil {
+		return
+	}
+	boards, err := db.GetStaffBoards(user)
+	if err != nil {
+		return
+	}
+	if len(boards) == 0 {
+		return errNotStaff
+	}
+	return feeds.SubscribeToStaff(c, boards, f)
+}
+
+// Return the account the client is authenticated as for staff actions.
+// Clients authenticated with an API token act as the token owner, if the
+// token has the moderate scope.
+func (c *Client) staffAccount() (user string, err error) {
+	if c.token.ID != 0 {
+		if !c.token.HasScope(auth.ScopeModerate) {
+			return "", errNotStaff
+		}
+		return c.token.Owner, nil
+	}
+
+	if c.creds.UserID == "" || c.creds.Session == "" {
+		return "", errNotStaff
+	}
+	ok, err := db.IsLoggedIn(c.creds.UserID, c.creds.Session)
+	switch {
+	case err != nil:
+		return
+	case !ok:
+		return "", errNotStaff
+	}
+	return c.creds.UserID, nil
+}
+
+// Returns, if the client is authenticated as staff of a board. Errors are
+// treated as having no access rights.
+func (c *Client) isBoardStaff(board string) bool {
+	user, err := c.staffAccount()
+	if err != nil {
+		return false
+	}
+	can, err := db.CanPerform(user, board, auth.Janitor)
+	return err == nil && can
+}
@@ -3,6 +3,7 @@
 package websockets
 
 import (
+	"errors"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/cache"
 	"github.com/bakape/meguca/common"
@@ -13,11 +14,39 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	errClientOutdated = common.StatusError{
+		Err:  errors.New("client outdated"),
+		Code: 426,
+	}
+	errClientTooNew = common.StatusError{
+		Err:  errors.New("client newer than server"),
+		Code: 426,
+	}
+	errInvalidLastN = common.ErrInvalidInput("invalid number of last posts")
+)
+
 type syncRequest struct {
+	// Last known position in the thread feed's message log, if resyncing
+	feeds.LogPosition
 	Last100, Catalog      bool
 	Page, ProtocolVersion uint
 	Thread                uint64
 	Board                 string
+
+	// Only synchronise the last N posts of the thread. Supersedes Last100.
+	Last int
+
+	// Keep open posts in other threads open, instead of closing them
+	KeepOpenPosts bool
+
+	// Optional protocol features supported by the client. nil, if all
+	// features of the client's protocol version are supported.
+	Features []string
+
+	// Send board configurations and board page JSON. Negotiated from
+	// ProtocolVersion and Features.
+	boardData bool
 }
 
 type reclaimRequest struct {
@@ -33,8 +62,18 @@ func (c *Client) synchronise(data []byte) error {
 	switch {
 	case err != nil:
 		return err
+	case msg.ProtocolVersion != 0 &&
+		(msg.ProtocolVersion < common.MinProtocolVersion ||
+			msg.ProtocolVersion > common.ProtocolVersion):
+		// Clients not specifying any version only receive the board JSON
+		return c.rejectIncompatible(msg.ProtocolVersion)
 	case !auth.IsBoard(msg.Board):
 		return common.ErrInvalidBoard(msg.Board)
+	case config.IsStaffOnly(msg.Board) && !c.isBoardStaff(msg.Board):
+		// Do not disclose the existence of staff-only boards
+		return common.ErrInvalidBoard(msg.Board)
+	case msg.Last != 0 && !common.IsLastN(msg.Last):
+		return errInvalidLastN
 	case msg.Thread != 0:
 		valid, err := db.ValidateOP(msg.Thread, msg.Board)
 		switch {
@@ -50,35 +89,100 @@ func (c *Client) synchronise(data []byte) error {
 		return err
 	}
 
-	if msg.ProtocolVersion == common.ProtocolVersion {
-		buf, err := common.EncodeMessage(common.MessageConfigs,
-			config.GetBoardConfigs(msg.Board).BoardConfigs)
+	// MessageHandshake was added in protocol version 2. Clients of previous
+	// versions, that specify one, always receive board data.
+	switch {
+	case msg.ProtocolVersion >= 2:
+		features := negotiateFeatures(enabledFeatures(msg.Board),
+			msg.Features)
+		msg.boardData = hasFeature(features, common.FeatureBoardData)
+		err = c.sendHandshake(features)
 		if err != nil {
 			return err
 		}
-		err = c.send(buf)
+	case msg.ProtocolVersion != 0:
+		msg.boardData = true
+	}
+	if msg.boardData {
+		// Only send the public subset. The full configs contain secrets.
+		err = c.send(common.PrependMessageType(common.MessageConfigs,
+			config.GetBoardConfigs(msg.Board).JSON))
 		if err != nil {
 			return err
 		}
 	}
 
+	np, err := feeds.NowPlayingMessage(msg.Board)
+	if err != nil {
+		return err
+	}
+	if np != nil {
+		err = c.send(np)
+		if err != nil {
+			return err
+		}
+	}
+
+	banners, err := feeds.AnnouncementBannersMessage(msg.Board)
+	if err != nil {
+		return err
+	}
+	err = c.send(banners)
+	if err != nil {
+		return err
+	}
+
+	err = c.sendPowChallenge()
+	if err != nil {
+		return err
+	}
+
 	return c.registerSync(msg)
 }
 
+// Ask a client with an incompatible protocol version to refresh and close
+// the connection. Reloading the page loads the client version served by this
+// server.
+func (c *Client) rejectIncompatible(version uint) error {
+	err := c.sendMessage(common.MessageRefresh, struct {
+		Version            string `json:"version"`
+		ProtocolVersion    uint   `json:"protocolVersion"`
+		MinProtocolVersion uint   `json:"minProtocolVersion"`
+		MaxProtocolVersion uint   `json:"maxProtocolVersion"`
+	}{
+		Version:            common.Version,
+		ProtocolVersion:    version,
+		MinProtocolVersion: common.MinProtocolVersion,
+		MaxProtocolVersion: common.ProtocolVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if version > common.ProtocolVersion {
+		return errClientTooNew
+	}
+	return errClientOutdated
+}
+
 // Register fresh client sync or change from previous sync
 func (c *Client) registerSync(req syncRequest) (err error) {
-	if c.post.id != 0 {
-		err = c.closePreviousPost()
+	if !req.KeepOpenPosts {
+		err = c.closeAllPosts()
 		if err != nil {
 			return
 		}
 	}
 
-	c.feed, err = feeds.SyncClient(c, req.Thread, req.Board)
+	lastN := req.Last
+	if lastN == 0 && req.Last100 {
+		lastN = 100
+	}
+	c.feed, err = feeds.SyncClient(c, req.Thread, req.Board, req.LogPosition,
+		lastN)
 	if err != nil || req.Thread != 0 {
 		return
 	}
-	if req.ProtocolVersion != common.ProtocolVersion {
+	if !req.boardData {
 		return c.sendMessage(common.MessageSynchronise, nil)
 	}
 
@@ -100,10 +204,6 @@ func (c *Client) registerSync(req syncRequest) (err error) {
 // by multiple clients. This opens us up to some exploits, but nothing severe.
 // Still need to think of a solution.
 func (c *Client) reclaimPost(data []byte) error {
-	if err := c.closePreviousPost(); err != nil {
-		return err
-	}
-
 	var req reclaimRequest
 	if err := decodeMessage(data, &req); err != nil {
 		return err
@@ -126,15 +226,34 @@ func (c *Client) reclaimPost(data []byte) error {
 	}
 
 	post, err := db.GetPost(req.ID)
+	if err != nil {
+		return err
+	}
+	c.selectPost(post.OP)
+	if c.post.id != post.ID && c.atOpenPostLimit() {
+		return errTooManyOpenPosts
+	}
+
+	ok, err := db.ReclaimOpenPost(req.ID)
 	switch {
 	case err != nil:
 		return err
-	case !post.Editing:
+	case !ok:
+		// Already closed or not reclaimed in time
 		return c.sendMessage(common.MessageReclaim, 1)
 	}
 
+	// Only one post per thread can be open
+	if c.post.id != post.ID {
+		if err := c.closePreviousPost(); err != nil {
+			return err
+		}
+	}
+
 	c.post.init(post)
-	c.feed.InsertPost(post.Post, nil)
+	if !c.post.private {
+		feeds.InsertPostInto(post, nil)
+	}
 
 	return c.sendMessage(common.MessageReclaim, 0)
 }
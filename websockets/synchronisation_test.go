@@ -60,6 +60,8 @@ func TestSyncToBoard(t *testing.T) {
 	if err := cl.synchronise(marshalJSON(t, msg)); err != nil {
 		t.Fatal(err)
 	}
+	assertMessage(t, wcl,
+		encodeMessageType(common.MessageAnnouncementBanners)+"[]")
 	assertMessage(t, wcl, "30null")
 }
 
@@ -134,6 +136,27 @@ func TestInvalidThreadSync(t *testing.T) {
 		cl.synchronise(data).Error())
 }
 
+func TestIncompatibleProtocolSync(t *testing.T) {
+	feeds.Clear()
+	setBoardConfigs(t, false)
+
+	sv := newWSServer(t)
+	defer sv.Close()
+	cl, wcl := sv.NewClient()
+
+	version := uint(common.ProtocolVersion + 1)
+	data := marshalJSON(t, syncRequest{
+		Board:           "a",
+		ProtocolVersion: version,
+	})
+	AssertDeepEquals(t, cl.synchronise(data), errClientTooNew)
+	assertMessage(t, wcl, encodeMessageType(common.MessageRefresh)+
+		`{"version":"`+common.Version+`","protocolVersion":`+
+		strconv.Itoa(int(version))+`,"minProtocolVersion":`+
+		strconv.Itoa(common.MinProtocolVersion)+`,"maxProtocolVersion":`+
+		strconv.Itoa(common.ProtocolVersion)+`}`)
+}
+
 func TestSyncToThread(t *testing.T) {
 	feeds.Clear()
 	test_db.ClearTables(t, "boards")
@@ -151,6 +174,8 @@ func TestSyncToThread(t *testing.T) {
 		Thread: 1,
 	})
 
+	assertMessage(t, wcl,
+		encodeMessageType(common.MessageAnnouncementBanners)+"[]")
 	skipMessage(t, wcl)
 	skipMessage(t, wcl)
 	assertMessage(t, wcl, "33[\"35{\\\"active\\\":0,\\\"total\\\":1}\"]")
@@ -3,11 +3,14 @@
 package websockets
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/bakape/meguca/auth"
 	"github.com/bakape/meguca/common"
 	"github.com/bakape/meguca/db"
+	"github.com/bakape/meguca/lang"
+	mlog "github.com/bakape/meguca/log"
 	"github.com/bakape/meguca/util"
 	"github.com/bakape/meguca/websockets/feeds"
 	"net/http"
@@ -15,7 +18,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-playground/log"
 	"github.com/gorilla/websocket"
 )
 
@@ -51,12 +53,12 @@ func (e errInvalidFrame) Error() string {
 // Client stores and manages a websocket-connected remote client and its
 // interaction with the server and database
 type Client struct {
-	// Client is requesting only the last 100 posts
-	last100 bool
 	// Have received first message, which must be a common.MessageSynchronise
 	gotFirstMessage bool
-	// Post currently open by the client
+	// Open post currently targeted by post modification messages
 	post openPost
+	// Other open posts of the client, keyed by thread
+	parkedPosts map[uint64]openPost
 	// Protects checking and setting interface properties through the
 	// common.Client interface
 	mu sync.RWMutex
@@ -64,8 +66,22 @@ type Client struct {
 	feed *feeds.Feed
 	// Underlying websocket connection
 	conn *websocket.Conn
+	// Carries the IDs of the connection and its upgrade request, that errors
+	// are logged with
+	ctx context.Context
 	// Client IP
 	ip string
+	// Login credentials sent with the upgrade request, if any
+	creds auth.SessionCreds
+	// API token the upgrade request was authenticated with, if any
+	token auth.APIToken
+	// Anonymised client identifier used in audit samples
+	fingerprint string
+	// Accept-Language header of the upgrade request. Used to translate error
+	// messages sent to the client.
+	acceptLanguage string
+	// Time of the last message received from the client
+	lastMessage time.Time
 	// Client last post time
 	lastTime int64
 	// Internal message receiver channel
@@ -119,11 +135,17 @@ func newClient(conn *websocket.Conn, req *http.Request, ip string,
 ) (
 	*Client, error,
 ) {
+	token, _ := auth.APITokenFromContext(req.Context())
 	return &Client{
-		ip:       ip,
-		close:    make(chan error, 2),
-		receive:  make(chan receivedMessage),
-		redirect: make(chan string),
+		ctx:            mlog.ContextWithConnID(req.Context(), mlog.NewID()),
+		ip:             ip,
+		creds:          auth.ExtractLoginCreds(req),
+		token:          token,
+		fingerprint:    clientFingerprint(ip, req.UserAgent()),
+		acceptLanguage: req.Header.Get("Accept-Language"),
+		close:          make(chan error, 2),
+		receive:        make(chan receivedMessage),
+		redirect:       make(chan string),
 		// Allows for ~60 seconds of messages, until the buffer overflows.
 		// A larger gap is more acceptable to shitty connections and mobile
 		// phones, especially while uploading.
@@ -134,12 +156,23 @@ func newClient(conn *websocket.Conn, req *http.Request, ip string,
 
 // Listen listens for incoming messages on the channels and processes them
 func (c *Client) listen() error {
+	if c.token.ID != 0 {
+		registerTokenClient(c)
+		defer unregisterTokenClient(c)
+	}
 	go c.receiverLoop()
 
 	// Clean up, when loop exits
 	err := c.listenerLoop()
+	_, _, board := feeds.GetSync(c)
 	feeds.RemoveClient(c)
-	return c.closeConnections(err)
+	for _, id := range c.openPostIDs() {
+		// Allow the author to reclaim the post after reconnecting
+		if dErr := db.SetOpenPostDisconnected(id); dErr != nil {
+			err = util.WrapError(dErr.Error(), err)
+		}
+	}
+	return c.closeConnections(err, board)
 }
 
 // Separate function to ease error handling of the internal client loop
@@ -180,8 +213,9 @@ func (c *Client) listenerLoop() error {
 	}
 }
 
-// Close all connections an goroutines associated with the Client
-func (c *Client) closeConnections(err error) error {
+// Close all connections an goroutines associated with the Client. board is the
+// board the client was last synchronised to, if any.
+func (c *Client) closeConnections(err error, board string) error {
 	// Close receiver loop
 	c.Close(nil)
 
@@ -199,7 +233,8 @@ func (c *Client) closeConnections(err error) error {
 	case nil:
 		closeType = websocket.CloseNormalClosure
 	default:
-		c.sendMessage(common.MessageInvalid, err.Error())
+		ln := lang.GetByID(lang.Negotiate(c.acceptLanguage, board))
+		c.sendMessage(common.MessageInvalid, ln.Error(err))
 		closeType = websocket.CloseInvalidFramePayloadData
 	}
 
@@ -281,6 +316,7 @@ func (c *Client) handleMessage(msgType int, msg []byte) error {
 		return errInvalidPayload(msg)
 	}
 	typ := common.MessageType(uncast)
+	c.sampleMessage(typ, len(msg), time.Now())
 	if !c.gotFirstMessage {
 		if typ != common.MessageSynchronise {
 			return errInvalidPayload(msg)
@@ -302,7 +338,8 @@ func (c *Client) logError(err error) {
 	if common.CanIgnoreClientError(err) {
 		return
 	}
-	log.Errorf("websockets: by %s: %s: %#v", c.ip, err, err)
+	mlog.FromContext(c.ctx, "websockets").
+		Errorf("websockets: by %s: %s: %#v", c.ip, err, err)
 }
 
 // Close closes a websocket connection with the provided status code and